@@ -0,0 +1,69 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/metaform/connector-fabric-manager/common/types"
+)
+
+// Machine-readable error codes returned in APIError.
+const (
+	ErrorCodeNotFound     = "NOT_FOUND"
+	ErrorCodeConflict     = "CONFLICT"
+	ErrorCodeInvalidInput = "INVALID_INPUT"
+	ErrorCodeInternal     = "INTERNAL_ERROR"
+)
+
+// APIError is the machine-readable error body returned by read APIs.
+type APIError struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// ToAPIError maps an error to an HTTP status and error body. Errors that are not caused by the request are reported
+// as internal errors without disclosing their cause.
+func ToAPIError(err error) (int, APIError) {
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		return http.StatusNotFound, APIError{Code: ErrorCodeNotFound, Message: "Not found"}
+	case errors.Is(err, types.ErrConflict):
+		return http.StatusConflict, APIError{Code: ErrorCodeConflict, Message: "Conflict"}
+	case errors.As(err, &validationErrs):
+		details := make([]string, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			details = append(details, fmt.Sprintf("%s failed validation: %s", fieldErr.Namespace(), fieldErr.Tag()))
+		}
+		return http.StatusBadRequest, APIError{Code: ErrorCodeInvalidInput, Message: "Validation failed", Details: details}
+	case errors.Is(err, types.ErrInvalidInput) || types.IsClientError(err):
+		return http.StatusBadRequest, APIError{Code: ErrorCodeInvalidInput, Message: "Invalid input", Details: []string{err.Error()}}
+	default:
+		return http.StatusInternalServerError, APIError{Code: ErrorCodeInternal, Message: "Internal server error"}
+	}
+}
+
+// WriteError writes the APIError for err with the mapped HTTP status.
+func WriteError(w http.ResponseWriter, err error) {
+	status, body := ToAPIError(err)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	// The status is already written, an encoding failure cannot be reported to the client
+	_ = json.NewEncoder(w).Encode(body)
+}
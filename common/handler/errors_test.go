@@ -0,0 +1,75 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError_SentinelErrors(t *testing.T) {
+	type validated struct {
+		Name string `validate:"required"`
+	}
+	validationErr := model.Validator.Struct(validated{})
+	require.Error(t, validationErr)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		hasDetails     bool
+	}{
+		{"not found", types.ErrNotFound, http.StatusNotFound, ErrorCodeNotFound, false},
+		{"wrapped not found", fmt.Errorf("orchestration 123: %w", types.ErrNotFound), http.StatusNotFound, ErrorCodeNotFound, false},
+		{"conflict", types.ErrConflict, http.StatusConflict, ErrorCodeConflict, false},
+		{"invalid input", fmt.Errorf("%w: malformed cursor", types.ErrInvalidInput), http.StatusBadRequest, ErrorCodeInvalidInput, true},
+		{"bad request", types.BadRequestError{Message: "missing id"}, http.StatusBadRequest, ErrorCodeInvalidInput, true},
+		{"validation", validationErr, http.StatusBadRequest, ErrorCodeInvalidInput, true},
+		{"internal", errors.New("connection refused"), http.StatusInternalServerError, ErrorCodeInternal, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newMockResponseWriter()
+
+			WriteError(w, tt.err)
+
+			assert.Equal(t, tt.expectedStatus, w.statusCode)
+			assert.Equal(t, "application/json", w.headers.Get("Content-Type"))
+
+			var body APIError
+			require.NoError(t, json.Unmarshal(w.body.Bytes(), &body))
+			assert.Equal(t, tt.expectedCode, body.Code)
+			assert.NotEmpty(t, body.Message)
+			assert.Equal(t, tt.hasDetails, len(body.Details) > 0)
+		})
+	}
+}
+
+func TestWriteError_InternalErrorNotDisclosed(t *testing.T) {
+	w := newMockResponseWriter()
+
+	WriteError(w, errors.New("password authentication failed for user cfm"))
+
+	assert.NotContains(t, w.body.String(), "password")
+}
@@ -0,0 +1,131 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+	store2 "github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timestampedEntity struct {
+	ID        string
+	Group     string
+	Timestamp time.Time
+	Version   int64
+}
+
+func (t *timestampedEntity) GetID() string {
+	return t.ID
+}
+
+func (t *timestampedEntity) GetVersion() int64 {
+	return t.Version
+}
+
+func (t *timestampedEntity) IncrementVersion() {
+	t.Version++
+}
+
+func timestampedCursor(entity *timestampedEntity) store2.Cursor {
+	return store2.Cursor{Timestamp: entity.Timestamp, ID: entity.ID}
+}
+
+func TestInMemoryEntityStore_ListByCursor_NoGapsOrDuplicates(t *testing.T) {
+	store := NewInMemoryEntityStore[*timestampedEntity]()
+	ctx := context.Background()
+
+	// Seed entities sharing timestamps so ordering must fall back to the ID
+	base := time.Now().UTC()
+	const total = 103
+	for i := 0; i < total; i++ {
+		_, err := store.Create(ctx, &timestampedEntity{
+			ID:        fmt.Sprintf("entity-%03d", i),
+			Group:     []string{"a", "b"}[i%2],
+			Timestamp: base.Add(time.Duration(i/4) * time.Second),
+		})
+		require.NoError(t, err)
+	}
+
+	seen := make(map[string]struct{})
+	var last *timestampedEntity
+	cursor := ""
+	pages := 0
+	for {
+		items, next, err := store.ListByCursor(ctx, nil, cursor, 10, timestampedCursor)
+		require.NoError(t, err)
+		pages++
+		for _, item := range items {
+			_, duplicate := seen[item.ID]
+			require.False(t, duplicate, "duplicate entity %s", item.ID)
+			seen[item.ID] = struct{}{}
+			if last != nil {
+				require.True(t, timestampedCursor(last).After(item.Timestamp, item.ID), "entities out of order")
+			}
+			last = item
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, total, len(seen))
+	assert.Equal(t, 11, pages)
+}
+
+func TestInMemoryEntityStore_ListByCursor_WithPredicate(t *testing.T) {
+	store := NewInMemoryEntityStore[*timestampedEntity]()
+	ctx := context.Background()
+
+	base := time.Now().UTC()
+	for i := 0; i < 10; i++ {
+		_, err := store.Create(ctx, &timestampedEntity{
+			ID:        fmt.Sprintf("entity-%02d", i),
+			Group:     []string{"a", "b"}[i%2],
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+		require.NoError(t, err)
+	}
+
+	first, next, err := store.ListByCursor(ctx, query.Eq("Group", "a"), "", 3, timestampedCursor)
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	assert.NotEmpty(t, next)
+
+	second, next, err := store.ListByCursor(ctx, query.Eq("Group", "a"), next, 3, timestampedCursor)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	assert.Empty(t, next)
+	for _, item := range append(first, second...) {
+		assert.Equal(t, "a", item.Group)
+	}
+}
+
+func TestInMemoryEntityStore_ListByCursor_InvalidInput(t *testing.T) {
+	store := NewInMemoryEntityStore[*timestampedEntity]()
+	ctx := context.Background()
+
+	_, _, err := store.ListByCursor(ctx, nil, "", 0, timestampedCursor)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	_, _, err = store.ListByCursor(ctx, nil, "garbage!", 10, timestampedCursor)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
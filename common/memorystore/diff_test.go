@@ -0,0 +1,108 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	store2 "github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffStores(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEntityStore[*testEntity]()
+	replica := NewInMemoryEntityStore[*testEntity]()
+
+	for _, entity := range []*testEntity{
+		{ID: "same", Version: 2},
+		{ID: "diverged", Version: 3},
+		{ID: "primary-only", Version: 1},
+	} {
+		_, err := primary.Create(ctx, entity)
+		require.NoError(t, err)
+	}
+	for _, entity := range []*testEntity{
+		{ID: "same", Version: 2},
+		{ID: "diverged", Version: 2},
+		{ID: "replica-only", Version: 1},
+	} {
+		_, err := replica.Create(ctx, entity)
+		require.NoError(t, err)
+	}
+
+	onlyInA, onlyInB, conflicting, err := store2.DiffStores[*testEntity](ctx, primary.PageByID, replica.PageByID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"primary-only"}, onlyInA)
+	assert.Equal(t, []string{"replica-only"}, onlyInB)
+	assert.Equal(t, []string{"diverged"}, conflicting)
+}
+
+func TestDiffStores_Identical(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEntityStore[*testEntity]()
+	replica := NewInMemoryEntityStore[*testEntity]()
+	for _, s := range []*InMemoryEntityStore[*testEntity]{primary, replica} {
+		_, err := s.Create(ctx, &testEntity{ID: "entity-1", Version: 1})
+		require.NoError(t, err)
+	}
+
+	onlyInA, onlyInB, conflicting, err := store2.DiffStores[*testEntity](ctx, primary.PageByID, replica.PageByID)
+
+	require.NoError(t, err)
+	assert.Empty(t, onlyInA)
+	assert.Empty(t, onlyInB)
+	assert.Empty(t, conflicting)
+}
+
+func TestDiffStores_Paged(t *testing.T) {
+	ctx := context.Background()
+	primary := NewInMemoryEntityStore[*testEntity]()
+	replica := NewInMemoryEntityStore[*testEntity]()
+	expectedOnlyInA := make([]string, 0)
+	for i := range 250 {
+		id := fmt.Sprintf("entity-%03d", i)
+		_, err := primary.Create(ctx, &testEntity{ID: id, Version: 1})
+		require.NoError(t, err)
+		if i%3 == 0 {
+			expectedOnlyInA = append(expectedOnlyInA, id)
+			continue
+		}
+		_, err = replica.Create(ctx, &testEntity{ID: id, Version: 1})
+		require.NoError(t, err)
+	}
+
+	onlyInA, onlyInB, conflicting, err := store2.DiffStores[*testEntity](ctx, primary.PageByID, replica.PageByID)
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedOnlyInA, onlyInA)
+	assert.Empty(t, onlyInB)
+	assert.Empty(t, conflicting)
+}
+
+func TestDiffStores_ReadError(t *testing.T) {
+	ctx := context.Background()
+	readErr := errors.New("connection lost")
+	failingPage := func(context.Context, string, int) ([]*testEntity, error) {
+		return nil, readErr
+	}
+
+	_, _, _, err := store2.DiffStores[*testEntity](ctx, failingPage, NewInMemoryEntityStore[*testEntity]().PageByID)
+
+	require.ErrorIs(t, err, readErr)
+}
@@ -16,8 +16,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"iter"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/query"
 	"github.com/metaform/connector-fabric-manager/common/store"
@@ -28,6 +32,7 @@ func NewInMemoryEntityStore[T store.EntityType]() *InMemoryEntityStore[T] {
 	estore := &InMemoryEntityStore[T]{
 		cache:   make(map[string]T),
 		matcher: &query.DefaultFieldMatcher{},
+		locks:   make(map[string]*memoryLock),
 	}
 	return estore
 }
@@ -36,6 +41,12 @@ type InMemoryEntityStore[T store.EntityType] struct {
 	cache   map[string]T
 	mu      sync.RWMutex
 	matcher query.FieldMatcher
+	locks   map[string]*memoryLock
+}
+
+// memoryLock is a lock held until it is released or expires.
+type memoryLock struct {
+	expires time.Time
 }
 
 func (s *InMemoryEntityStore[T]) FindByID(_ context.Context, id string) (T, error) {
@@ -267,6 +278,133 @@ func (s *InMemoryEntityStore[T]) DeleteByPredicate(_ context.Context, predicate
 	return nil
 }
 
+// ListByCursor returns up to limit entities matching the predicate (or all if predicate is nil) ordered by the key
+// returned from keyFn, starting after the given cursor. The returned cursor is empty when no further entities remain.
+func (s *InMemoryEntityStore[T]) ListByCursor(
+	ctx context.Context,
+	predicate query.Predicate,
+	cursor string,
+	limit int,
+	keyFn store.CursorKeyFunc[T]) ([]T, string, error) {
+
+	if limit <= 0 {
+		return nil, "", types.ErrInvalidInput
+	}
+	after, err := store.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var filtered []T
+	for _, entity := range s.cache {
+		if predicate != nil && !predicate.Matches(entity, s.matcher) {
+			continue
+		}
+		key := keyFn(entity)
+		if after != nil && !after.After(key.Timestamp, key.ID) {
+			continue
+		}
+		filtered = append(filtered, entity)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	slices.SortFunc(filtered, func(a, b T) int {
+		ka, kb := keyFn(a), keyFn(b)
+		if c := ka.Timestamp.Compare(kb.Timestamp); c != 0 {
+			return c
+		}
+		return strings.Compare(ka.ID, kb.ID)
+	})
+
+	next := ""
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+		next = store.EncodeCursor(keyFn(filtered[limit-1]))
+	}
+
+	results := make([]T, 0, len(filtered))
+	for _, entity := range filtered {
+		copied, err := copyEntity(entity)
+		if err != nil {
+			return nil, "", err
+		}
+		results = append(results, copied)
+	}
+	return results, next, nil
+}
+
+// TryLock acquires the named lock unless it is held and has not expired.
+func (s *InMemoryEntityStore[T]) TryLock(_ context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	if name == "" || ttl <= 0 {
+		return false, func() {}, types.ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if held, exists := s.locks[name]; exists && now.Before(held.expires) {
+		return false, func() {}, nil
+	}
+	lock := &memoryLock{expires: now.Add(ttl)}
+	s.locks[name] = lock
+
+	release := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The lock may have expired and been acquired by another holder
+		if s.locks[name] == lock {
+			delete(s.locks, name)
+		}
+	}
+	return true, release, nil
+}
+
+// Export writes all entities to w as JSON Lines ordered by ID.
+func (s *InMemoryEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return store.ExportJSONLines(ctx, w, s.PageByID)
+}
+
+// Import creates the entities read from r, failing with types.ErrConflict if an entity already exists.
+func (s *InMemoryEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return store.ImportJSONLines(ctx, r, func(ctx context.Context, entity T) error {
+		_, err := s.Create(ctx, entity)
+		return err
+	})
+}
+
+// PageByID returns copies of up to limit entities ordered by ID, starting after afterID.
+func (s *InMemoryEntityStore[T]) PageByID(_ context.Context, afterID string, limit int) ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.cache))
+	for id := range s.cache {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	page := make([]T, 0, len(ids))
+	for _, id := range ids {
+		copied, err := copyEntity(s.cache[id])
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, copied)
+	}
+	return page, nil
+}
+
 // copyEntity creates a copy of a pointer entity by dereferencing, copying, and re-addressing
 func copyEntity[T store.EntityType](entity T) (T, error) {
 	// Marshal to JSON
@@ -13,9 +13,12 @@
 package memorystore
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/collection"
 	"github.com/metaform/connector-fabric-manager/common/query"
@@ -612,3 +615,91 @@ func TestInMemoryEntityStore_GetAllCount(t *testing.T) {
 		t.Errorf("expected count to be 2 after delete, got %d", count)
 	}
 }
+
+func TestInMemoryEntityStore_TryLock(t *testing.T) {
+	store := NewInMemoryEntityStore[*testEntity]()
+	ctx := context.Background()
+
+	t.Run("second lock fails while held", func(t *testing.T) {
+		acquired, release, err := store.TryLock(ctx, "sweeper", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, _, err = store.TryLock(ctx, "sweeper", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+
+		acquired, otherRelease, err := store.TryLock(ctx, "reconciler", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired, "locks with other names are independent")
+		otherRelease()
+
+		release()
+		acquired, release, err = store.TryLock(ctx, "sweeper", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired)
+		release()
+	})
+
+	t.Run("expired lock is acquired", func(t *testing.T) {
+		acquired, staleRelease, err := store.TryLock(ctx, "compaction", time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		time.Sleep(5 * time.Millisecond)
+
+		acquired, release, err := store.TryLock(ctx, "compaction", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		// Releasing the expired lock does not free the lock of the new holder
+		staleRelease()
+		acquired, _, err = store.TryLock(ctx, "compaction", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+		release()
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, _, err := store.TryLock(ctx, "", time.Minute)
+		assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+		_, _, err = store.TryLock(ctx, "sweeper", 0)
+		assert.ErrorIs(t, err, types.ErrInvalidInput)
+	})
+}
+
+func TestInMemoryEntityStore_ExportImport(t *testing.T) {
+	source := NewInMemoryEntityStore[*testEntity]()
+	ctx := context.Background()
+
+	// Span several export pages
+	for i := range 250 {
+		_, err := source.Create(ctx, &testEntity{ID: fmt.Sprintf("entity-%03d", i), Value: fmt.Sprintf("value-%d", i), Version: int64(i % 3)})
+		require.NoError(t, err)
+	}
+
+	var exported bytes.Buffer
+	require.NoError(t, source.Export(ctx, &exported))
+	lines := strings.Split(strings.TrimSuffix(exported.String(), "\n"), "\n")
+	require.Len(t, lines, 250)
+	assert.JSONEq(t, `{"ID":"entity-000","Value":"value-0","Version":0}`, lines[0])
+
+	target := NewInMemoryEntityStore[*testEntity]()
+	require.NoError(t, target.Import(ctx, &exported))
+
+	expected, err := collection.CollectAll(source.GetAll(ctx))
+	require.NoError(t, err)
+	imported, err := collection.CollectAll(target.GetAll(ctx))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, imported)
+
+	t.Run("existing entity conflicts", func(t *testing.T) {
+		err := target.Import(ctx, strings.NewReader(`{"ID":"entity-000","Value":"other"}`+"\n"))
+		assert.ErrorIs(t, err, types.ErrConflict)
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		err := NewInMemoryEntityStore[*testEntity]().Import(ctx, strings.NewReader(`{"ID":"entity-1"}`+"\nnot json\n"))
+		assert.ErrorIs(t, err, types.ErrInvalidInput)
+	})
+}
@@ -14,6 +14,7 @@ package model
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -45,6 +46,8 @@ type OrchestrationManifest struct {
 	CorrelationID     string            `json:"correlationId" validate:"required"`
 	OrchestrationType OrchestrationType `json:"orchestrationType" validate:"required"`
 	Payload           map[string]any    `json:"payload omitempty"`
+	Deadline          time.Time         `json:"deadline,omitzero"`
+	Labels            map[string]string `json:"labels,omitempty"`
 }
 
 // OrchestrationResponse returned when a system deployment completes.
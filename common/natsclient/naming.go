@@ -0,0 +1,67 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsclient
+
+import "strings"
+
+// NamingStrategy determines the stream, subject and durable consumer names used for component messaging. Deployments
+// sharing a NATS server can use different strategies to avoid collisions. Publishers and consumers exchanging messages,
+// e.g. the provision manager and activity agents, must use the same strategy.
+type NamingStrategy interface {
+	// StreamName returns the name of the stream component messages are published to.
+	StreamName() string
+
+	// Subject returns the subject messages of the given type are published to, e.g. an activity type or
+	// CFMOrchestrationResponse.
+	Subject(orchType string) string
+
+	// TerminalSubject returns the subject terminal state changes of orchestrations of the given type are published to,
+	// so that consumers interested in completions do not need to filter state changes.
+	TerminalSubject(orchType string) string
+
+	// DLQSubject returns the subject unprocessable messages are published to.
+	DLQSubject() string
+
+	// DurableName returns the durable name of the given consumer.
+	DurableName(consumer string) string
+}
+
+// DefaultNamingStrategy publishes messages to subjects prefixed with CFMSubjectPrefix on the configured stream. Periods
+// in message types are replaced with dashes since NATS uses them to denote subject hierarchies.
+type DefaultNamingStrategy struct {
+	Stream string
+}
+
+func (s DefaultNamingStrategy) StreamName() string {
+	return s.Stream
+}
+
+func (s DefaultNamingStrategy) Subject(orchType string) string {
+	return CFMSubjectPrefix + "." + sanitizeName(orchType)
+}
+
+func (s DefaultNamingStrategy) TerminalSubject(orchType string) string {
+	return CFMTerminalSubjectPrefix + "." + sanitizeName(orchType)
+}
+
+func (s DefaultNamingStrategy) DLQSubject() string {
+	return s.Subject(CFMDeadLetter)
+}
+
+func (s DefaultNamingStrategy) DurableName(consumer string) string {
+	return sanitizeName(consumer)
+}
+
+func sanitizeName(name string) string {
+	return strings.ReplaceAll(name, ".", "-")
+}
@@ -0,0 +1,38 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultNamingStrategy(t *testing.T) {
+	naming := DefaultNamingStrategy{Stream: "cfm-stream"}
+
+	assert.Equal(t, "cfm-stream", naming.StreamName())
+	assert.Equal(t, CFMOrchestrationSubject, naming.Subject(CFMOrchestration))
+	assert.Equal(t, CFMOrchestrationResponseSubject, naming.Subject(CFMOrchestrationResponse))
+	assert.Equal(t, CFMOrchestrationCompensationSubject, naming.Subject(CFMOrchestrationCompensation))
+	assert.Equal(t, "event.cfm-dead-letter", naming.DLQSubject())
+	assert.Equal(t, "event.terminal.cfm-orchestration-vpa-deploy", naming.TerminalSubject("cfm.orchestration.vpa.deploy"))
+}
+
+func TestDefaultNamingStrategy_SanitizesPeriods(t *testing.T) {
+	naming := DefaultNamingStrategy{}
+
+	assert.Equal(t, "event.edc-test-activity", naming.Subject("edc.test.activity"))
+	assert.Equal(t, "edc-test-activity", naming.DurableName("edc.test.activity"))
+	assert.Equal(t, "orchestration-index", naming.DurableName("orchestration-index"))
+}
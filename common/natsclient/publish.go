@@ -0,0 +1,43 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// PublishExpectingSeq publishes the payload to the subject only if the last message on the subject has the given
+// stream sequence, implementing compare-and-set at the stream level. A sequence of zero expects the subject to have no
+// messages. Returns types.ErrVersionConflict if another message was published to the subject since, e.g. by a
+// concurrent producer.
+func PublishExpectingSeq(
+	ctx context.Context,
+	client MsgClient,
+	subject string,
+	data []byte,
+	lastSeq uint64) (*jetstream.PubAck, error) {
+	ack, err := client.Publish(ctx, subject, data, jetstream.WithExpectLastSequencePerSubject(lastSeq))
+	if err != nil {
+		var apiErr *jetstream.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence {
+			return nil, fmt.Errorf("%w: %s expected at sequence %d: %v", types.ErrVersionConflict, subject, lastSeq, err)
+		}
+		return nil, err
+	}
+	return ack, nil
+}
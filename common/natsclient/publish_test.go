@@ -0,0 +1,70 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpectingSeq(t *testing.T) {
+	client := &publishClient{ack: &jetstream.PubAck{Sequence: 8}}
+
+	ack, err := PublishExpectingSeq(context.Background(), client, "event.orch-1", []byte("data"), 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), ack.Sequence)
+	assert.Equal(t, "event.orch-1", client.subject)
+}
+
+func TestPublishExpectingSeq_StaleSequenceConflict(t *testing.T) {
+	client := &publishClient{err: &jetstream.APIError{
+		Code:        400,
+		ErrorCode:   jetstream.JSErrCodeStreamWrongLastSequence,
+		Description: "wrong last sequence: 8",
+	}}
+
+	_, err := PublishExpectingSeq(context.Background(), client, "event.orch-1", []byte("data"), 7)
+
+	require.ErrorIs(t, err, types.ErrVersionConflict)
+	assert.ErrorIs(t, err, types.ErrConflict)
+}
+
+func TestPublishExpectingSeq_OtherErrorsReturned(t *testing.T) {
+	publishErr := errors.New("timeout")
+	client := &publishClient{err: publishErr}
+
+	_, err := PublishExpectingSeq(context.Background(), client, "event.orch-1", []byte("data"), 7)
+
+	require.ErrorIs(t, err, publishErr)
+	assert.NotErrorIs(t, err, types.ErrConflict)
+}
+
+// publishClient records the subject published to and returns the given ack or error.
+type publishClient struct {
+	MsgClient
+	ack     *jetstream.PubAck
+	err     error
+	subject string
+}
+
+func (c *publishClient) Publish(_ context.Context, subject string, _ []byte, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	c.subject = subject
+	return c.ack, c.err
+}
@@ -16,6 +16,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/nats-io/nats.go/jetstream"
@@ -26,11 +27,40 @@ const CFMOrchestration = "cfm-orchestration"
 const CFMOrchestrationSubject = CFMSubjectPrefix + "." + CFMOrchestration
 const CFMOrchestrationResponse = "cfm-orchestration-response"
 const CFMOrchestrationResponseSubject = CFMSubjectPrefix + "." + CFMOrchestrationResponse
+const CFMOrchestrationCompensation = "cfm-orchestration-compensation"
+const CFMOrchestrationCompensationSubject = CFMSubjectPrefix + "." + CFMOrchestrationCompensation
+const CFMDeadLetter = "cfm-dead-letter"
+const CFMOrchestrationStateChange = "cfm-orchestration-state-change"
+const CFMOrchestrationAudit = "cfm-orchestration-audit"
+
+// CFMTerminalSubjectPrefix prefixes the subjects terminal orchestration state changes are published to.
+const CFMTerminalSubjectPrefix = CFMSubjectPrefix + ".terminal"
+
+// streamSubjects are the subjects a stream used for component messaging must capture.
+var streamSubjects = []string{CFMSubjectPrefix + ".*", CFMTerminalSubjectPrefix + ".*"}
 
 // SetupStream configures a JetStream stream used for component messaging. If the stream does not exist, it is created.
+// An existing stream is updated to capture subjects added in later versions, keeping any subjects configured in
+// addition.
 func SetupStream(ctx context.Context, client *NatsClient, streamName string) (jetstream.Stream, error) {
 	stream, err := client.JetStream.Stream(ctx, streamName)
 	if err == nil {
+		cfg := stream.CachedInfo().Config
+		cfg.Subjects = slices.Clone(cfg.Subjects)
+		missing := false
+		for _, subject := range streamSubjects {
+			if !slices.ContainsFunc(cfg.Subjects, func(existing string) bool { return subjectCovers(existing, subject) }) {
+				cfg.Subjects = append(cfg.Subjects, subject)
+				missing = true
+			}
+		}
+		if !missing {
+			return stream, nil
+		}
+		stream, err = client.JetStream.UpdateStream(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to update subjects of NATS stream: %w", err)
+		}
 		return stream, nil
 	}
 
@@ -39,7 +69,7 @@ func SetupStream(ctx context.Context, client *NatsClient, streamName string) (je
 		cfg := jetstream.StreamConfig{
 			Name:      streamName,
 			Retention: jetstream.WorkQueuePolicy,
-			Subjects:  []string{CFMSubjectPrefix + ".*"},
+			Subjects:  slices.Clone(streamSubjects),
 		}
 		return client.JetStream.CreateOrUpdateStream(ctx, cfg)
 	}
@@ -47,12 +77,36 @@ func SetupStream(ctx context.Context, client *NatsClient, streamName string) (je
 	return nil, fmt.Errorf("unable to access NATS stream: %w", err)
 }
 
+// subjectCovers returns true if all subjects matching subject also match pattern. Tokens are compared one by one, a
+// '*' in the pattern covers any single token and a trailing '>' covers the remaining tokens.
+func subjectCovers(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i < len(subjectTokens)
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
 // SetupConsumer creates or updates a NATS JetStream consumer for an activity processor.
 func SetupConsumer(ctx context.Context, stream jetstream.Stream, subject string) (jetstream.Consumer, error) {
-	sanitizedSubject := strings.ReplaceAll(subject, ".", "-") // convert to `-` because NATs uses dot-notation to denote subject hierarchies
+	return SetupNamedConsumer(ctx, stream, DefaultNamingStrategy{}, subject)
+}
+
+// SetupNamedConsumer creates or updates a NATS JetStream consumer for the given message type using the naming strategy.
+func SetupNamedConsumer(
+	ctx context.Context,
+	stream jetstream.Stream,
+	naming NamingStrategy,
+	messageType string) (jetstream.Consumer, error) {
 	return stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
-		Durable:       sanitizedSubject,
+		Durable:       naming.DurableName(messageType),
 		AckPolicy:     jetstream.AckExplicitPolicy,
-		FilterSubject: CFMSubjectPrefix + "." + sanitizedSubject,
+		FilterSubject: naming.Subject(messageType),
 	})
 }
@@ -0,0 +1,81 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupStream_UpdatesMissingSubjects(t *testing.T) {
+	js := &streamJetStream{stream: &infoStream{info: &jetstream.StreamInfo{Config: jetstream.StreamConfig{
+		Name:     "cfm-stream",
+		Subjects: []string{"event.*", "custom.subject"},
+	}}}}
+
+	_, err := SetupStream(context.Background(), &NatsClient{JetStream: js}, "cfm-stream")
+
+	require.NoError(t, err)
+	require.NotNil(t, js.updated, "streams created by earlier versions must capture terminal subjects")
+	assert.Equal(t, []string{"event.*", "custom.subject", "event.terminal.*"}, js.updated.Subjects)
+}
+
+func TestSetupStream_SubjectsCovered(t *testing.T) {
+	js := &streamJetStream{stream: &infoStream{info: &jetstream.StreamInfo{Config: jetstream.StreamConfig{
+		Name:     "cfm-stream",
+		Subjects: []string{"event.>"},
+	}}}}
+
+	_, err := SetupStream(context.Background(), &NatsClient{JetStream: js}, "cfm-stream")
+
+	require.NoError(t, err)
+	assert.Nil(t, js.updated)
+}
+
+func TestSubjectCovers(t *testing.T) {
+	assert.True(t, subjectCovers("event.*", "event.*"))
+	assert.True(t, subjectCovers("event.>", "event.terminal.*"))
+	assert.True(t, subjectCovers("*.terminal.*", "event.terminal.*"))
+	assert.False(t, subjectCovers("event.*", "event.terminal.*"))
+	assert.False(t, subjectCovers("event.terminal.*", "event.*"))
+	assert.False(t, subjectCovers("event.>", "event"))
+}
+
+// streamJetStream serves a single existing stream and records its updated configuration.
+type streamJetStream struct {
+	jetstream.JetStream
+	stream  *infoStream
+	updated *jetstream.StreamConfig
+}
+
+func (j *streamJetStream) Stream(context.Context, string) (jetstream.Stream, error) {
+	return j.stream, nil
+}
+
+func (j *streamJetStream) UpdateStream(_ context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error) {
+	j.updated = &cfg
+	return &infoStream{info: &jetstream.StreamInfo{Config: cfg}}, nil
+}
+
+type infoStream struct {
+	jetstream.Stream
+	info *jetstream.StreamInfo
+}
+
+func (s *infoStream) CachedInfo() *jetstream.StreamInfo {
+	return s.info
+}
@@ -17,9 +17,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/metaform/connector-fabric-manager/common/query"
 	"github.com/metaform/connector-fabric-manager/common/store"
 	"github.com/metaform/connector-fabric-manager/common/types"
@@ -387,6 +390,199 @@ func (p *PostgresEntityStore[T]) DeleteByPredicate(ctx context.Context, predicat
 	return nil
 }
 
+// LocksTable holds the leases of the locks acquired by TryLock, see CreateLocksTable.
+const LocksTable = "cfm_locks"
+
+// CreateLocksTable creates the table holding the leases of the locks acquired by TryLock.
+func CreateLocksTable(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			name VARCHAR(255) PRIMARY KEY,
+			holder VARCHAR(255) NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)`, LocksTable))
+	return err
+}
+
+// TryLock acquires the named lock by writing a lease row expiring after ttl in the transaction of the context. The lease
+// is held until it is released or expires, also after the transaction commits. It is discarded if the transaction rolls
+// back, e.g. because the holder crashed. The release function deletes the lease in the same transaction and must
+// therefore be called before the transaction ends, a lease committed without release is held until it expires.
+//
+// A transaction-scoped advisory lock serializes acquisition so that concurrent transactions fail fast instead of
+// waiting for each other. A holder that stays idle in the transaction for longer than ttl has its session terminated,
+// which frees the advisory lock and discards the uncommitted lease.
+func (p *PostgresEntityStore[T]) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	noop := func() {}
+	if name == "" || ttl <= 0 {
+		return false, noop, types.ErrInvalidInput
+	}
+
+	tx := getTxFromContext(ctx)
+	_, err := tx.ExecContext(ctx,
+		fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", max(ttl.Milliseconds(), 1)))
+	if err != nil {
+		return false, noop, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+
+	var acquired bool
+	err = tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock(hashtextextended($1, 0))", name).Scan(&acquired)
+	if err != nil {
+		return false, noop, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		return false, noop, nil
+	}
+
+	// The lease is taken over if it is absent or expired
+	holder := uuid.NewString()
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s (name, holder, expires_at)
+		VALUES ($1, $2, clock_timestamp() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE %[1]s.expires_at <= clock_timestamp()
+		RETURNING holder`, LocksTable),
+		name, holder, max(ttl.Milliseconds(), 1)).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, noop, nil
+	}
+	if err != nil {
+		return false, noop, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	release := func() {
+		// Only the own lease is deleted, it may have expired and been acquired by another holder. Errors are ignored
+		// since the lease expires anyway.
+		_, _ = tx.ExecContext(context.WithoutCancel(ctx),
+			fmt.Sprintf("DELETE FROM %s WHERE name = $1 AND holder = $2", LocksTable), name, holder)
+	}
+	return true, release, nil
+}
+
+// Export writes all entities to w as JSON Lines ordered by ID. Entities are read in pages within the transaction of the
+// context.
+func (p *PostgresEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return store.ExportJSONLines(ctx, w, p.PageByID)
+}
+
+// Import creates the entities read from r within the transaction of the context.
+func (p *PostgresEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return store.ImportJSONLines(ctx, r, func(ctx context.Context, entity T) error {
+		_, err := p.Create(ctx, entity)
+		return err
+	})
+}
+
+// PageByID returns up to limit entities ordered by ID, starting after afterID. IDs are compared byte-wise using the C
+// collation, consistent with the other stores, so that pages of different stores can be merged by ID.
+func (p *PostgresEntityStore[T]) PageByID(ctx context.Context, afterID string, limit int) ([]T, error) {
+	tx := getTxFromContext(ctx)
+	rows, err := tx.QueryContext(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s WHERE id COLLATE "C" > $1 ORDER BY id COLLATE "C" LIMIT $2`, strings.Join(p.columnNames, ", "), p.tableName),
+		afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]T, 0, limit)
+	for rows.Next() {
+		scanValues := make([]any, len(p.columnNames))
+		for i := range scanValues {
+			scanValues[i] = new(any)
+		}
+		if err := rows.Scan(scanValues...); err != nil {
+			return nil, fmt.Errorf("failed to scan entity: %w", err)
+		}
+		record := p.buildRecordFromScan(scanValues)
+		entity, err := p.recordToEntity(tx, &record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert record to entity: %w", err)
+		}
+		results = append(results, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return results, nil
+}
+
+// ListByCursor returns up to limit entities matching the predicate (or all if predicate is nil) ordered by
+// (timestampColumn, id), starting after the given cursor. The returned cursor is empty when no further entities remain.
+// An index on (timestampColumn, id) allows the query to seek directly to the cursor position.
+func (p *PostgresEntityStore[T]) ListByCursor(
+	ctx context.Context,
+	predicate query.Predicate,
+	cursor string,
+	limit int,
+	timestampColumn string,
+	keyFn store.CursorKeyFunc[T]) ([]T, string, error) {
+
+	if limit <= 0 {
+		return nil, "", types.ErrInvalidInput
+	}
+	after, err := store.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var conditions []string
+	var args []any
+	if predicate != nil {
+		whereClause, predicateArgs := p.builder.BuildSQL(predicate)
+		if whereClause != "" {
+			conditions = append(conditions, "("+whereClause+")")
+			args = append(args, predicateArgs...)
+		}
+	}
+	if after != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) > ($%d, $%d)", timestampColumn, len(args)+1, len(args)+2))
+		args = append(args, after.Timestamp, after.ID)
+	}
+
+	queryStr := fmt.Sprintf("SELECT %s FROM %s", strings.Join(p.columnNames, ", "), p.tableName)
+	if len(conditions) > 0 {
+		queryStr = fmt.Sprintf("%s WHERE %s", queryStr, strings.Join(conditions, " AND "))
+	}
+	// Fetch one extra row to determine if another page exists
+	queryStr = fmt.Sprintf("%s ORDER BY %s, id LIMIT $%d", queryStr, timestampColumn, len(args)+1)
+	args = append(args, limit+1)
+
+	tx := getTxFromContext(ctx)
+	rows, err := tx.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query entities: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]T, 0, limit)
+	for rows.Next() {
+		scanValues := make([]any, len(p.columnNames))
+		for i := range scanValues {
+			scanValues[i] = new(any)
+		}
+		if err := rows.Scan(scanValues...); err != nil {
+			return nil, "", fmt.Errorf("failed to scan entity: %w", err)
+		}
+		record := p.buildRecordFromScan(scanValues)
+		entity, err := p.recordToEntity(tx, &record)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to convert record to entity: %w", err)
+		}
+		results = append(results, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("iteration error: %w", err)
+	}
+
+	next := ""
+	if len(results) > limit {
+		results = results[:limit]
+		next = store.EncodeCursor(keyFn(results[limit-1]))
+	}
+	return results, next, nil
+}
+
 // queryEntities queries entities with optional filtering and pagination
 func (p *PostgresEntityStore[T]) queryEntities(
 	ctx context.Context,
@@ -13,14 +13,18 @@
 package sqlstore
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/metaform/connector-fabric-manager/common/collection"
 	"github.com/metaform/connector-fabric-manager/common/store"
 	"github.com/metaform/connector-fabric-manager/common/types"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +70,12 @@ func setupEntityTable(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func setupLocksTable(t *testing.T) {
+	_, err := testDB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", LocksTable))
+	require.NoError(t, err)
+	require.NoError(t, CreateLocksTable(testDB))
+}
+
 // recordToEntity converts DatabaseRecord to testEntity
 // - id, value, version, created_at come from regular columns
 // - metadata comes from JSONB column
@@ -583,6 +593,103 @@ func TestNewPostgresEntityStore_VersionIncrement(t *testing.T) {
 	assert.Equal(t, int64(2), retrieved.Version)
 }
 
+// TestNewPostgresEntityStore_TryLock tests that a lock is exclusive until it is released
+func TestNewPostgresEntityStore_TryLock(t *testing.T) {
+	setupLocksTable(t)
+	columnNames := []string{"id", "value", "version", "created_at", "metadata"}
+	estore := NewPostgresEntityStore("test_entities", columnNames, recordToEntity, entityToRecord, *createBuilder())
+	ctx := context.Background()
+
+	tx1, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx1.Rollback()
+	tx2, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx2.Rollback()
+
+	acquired, release, err := estore.TryLock(context.WithValue(ctx, SQLTransactionKey, tx1), "sweeper", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	tx2Ctx := context.WithValue(ctx, SQLTransactionKey, tx2)
+	acquired, _, err = estore.TryLock(tx2Ctx, "sweeper", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "lock must not be acquired while held")
+
+	acquired, _, err = estore.TryLock(tx2Ctx, "reconciler", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "locks with other names are independent")
+
+	release()
+	require.NoError(t, tx1.Commit())
+	acquired, _, err = estore.TryLock(tx2Ctx, "sweeper", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "lock must be acquired once released")
+}
+
+// TestNewPostgresEntityStore_TryLock_LeaseExpires tests that a lock committed without release is held until its lease
+// expires
+func TestNewPostgresEntityStore_TryLock_LeaseExpires(t *testing.T) {
+	setupLocksTable(t)
+	columnNames := []string{"id", "value", "version", "created_at", "metadata"}
+	estore := NewPostgresEntityStore("test_entities", columnNames, recordToEntity, entityToRecord, *createBuilder())
+	ctx := context.Background()
+	tryLock := func(ttl time.Duration) bool {
+		tx, err := testDB.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		acquired, _, err := estore.TryLock(context.WithValue(ctx, SQLTransactionKey, tx), "sweeper", ttl)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+		return acquired
+	}
+
+	require.True(t, tryLock(500*time.Millisecond))
+	assert.False(t, tryLock(time.Minute), "the lease must outlive the transaction acquiring it")
+
+	time.Sleep(600 * time.Millisecond)
+	assert.True(t, tryLock(time.Minute), "lock must be acquired once the lease expired")
+}
+
+// TestNewPostgresEntityStore_ExportImport tests that exported entities are imported into an empty table unchanged
+func TestNewPostgresEntityStore_ExportImport(t *testing.T) {
+	setupEntityTable(t)
+
+	columnNames := []string{"id", "value", "version", "created_at", "metadata"}
+	estore := NewPostgresEntityStore("test_entities", columnNames, recordToEntity, entityToRecord, *createBuilder())
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txCtx := context.WithValue(ctx, SQLTransactionKey, tx)
+
+	// Span several export pages
+	for i := range 150 {
+		_, err := estore.Create(txCtx, &testEntity{
+			ID:        fmt.Sprintf("entity-%03d", i),
+			Value:     fmt.Sprintf("value-%d", i),
+			Version:   int64(i%3 + 1),
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			Metadata:  map[string]any{"index": float64(i)},
+		})
+		require.NoError(t, err)
+	}
+	expected, err := collection.CollectAll(estore.GetAll(txCtx))
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	require.NoError(t, estore.Export(txCtx, &exported))
+	assert.Equal(t, 150, strings.Count(exported.String(), "\n"))
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM test_entities")
+	require.NoError(t, err)
+	require.NoError(t, estore.Import(txCtx, &exported))
+
+	imported, err := collection.CollectAll(estore.GetAll(txCtx))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, imported)
+}
+
 func createBuilder() *JSONBSQLBuilder {
 	builder := NewPostgresJSONBBuilder().WithJSONBFieldTypes(map[string]JSONBFieldType{
 		"metadata": JSONBFieldTypeScalar,
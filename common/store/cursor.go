@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+)
+
+// Cursor marks the position of the last item returned by a keyset-paginated listing. Items are ordered by
+// (Timestamp, ID), which allows a store to resume after the cursor without scanning skipped rows.
+type Cursor struct {
+	Timestamp time.Time `json:"ts"`
+	ID        string    `json:"id"`
+}
+
+// CursorKeyFunc returns the cursor position of an entity.
+type CursorKeyFunc[T EntityType] func(entity T) Cursor
+
+// After returns true if the given position sorts after the cursor.
+func (c Cursor) After(timestamp time.Time, id string) bool {
+	if timestamp.Equal(c.Timestamp) {
+		return id > c.ID
+	}
+	return timestamp.After(c.Timestamp)
+}
+
+// EncodeCursor serializes the cursor to an opaque, URL-safe string.
+func EncodeCursor(cursor Cursor) string {
+	// Cursor only contains a timestamp and a string, marshalling cannot fail
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously returned by EncodeCursor. An empty string denotes the start of the listing
+// and returns nil. Cursors that are not in the format written by EncodeCursor return types.ErrInvalidInput. Zero
+// timestamps are valid positions, e.g. of entities without a timestamp.
+func DecodeCursor(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor encoding", types.ErrInvalidInput)
+	}
+	var fields struct {
+		Timestamp *time.Time `json:"ts"`
+		ID        *string    `json:"id"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", types.ErrInvalidInput)
+	}
+	if fields.Timestamp == nil || fields.ID == nil {
+		return nil, fmt.Errorf("%w: incomplete cursor", types.ErrInvalidInput)
+	}
+	return &Cursor{Timestamp: *fields.Timestamp, ID: *fields.ID}, nil
+}
@@ -0,0 +1,82 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_EncodeDecodeRoundTrip(t *testing.T) {
+	cursor := Cursor{Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC), ID: "entry-1"}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+
+	require.NoError(t, err)
+	require.NotNil(t, decoded)
+	assert.True(t, cursor.Timestamp.Equal(decoded.Timestamp))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestCursor_EncodeDecodeZeroTimestamp(t *testing.T) {
+	decoded, err := DecodeCursor(EncodeCursor(Cursor{ID: "entry-1"}))
+
+	require.NoError(t, err)
+	require.NotNil(t, decoded)
+	assert.True(t, decoded.Timestamp.IsZero())
+	assert.Equal(t, "entry-1", decoded.ID)
+}
+
+func TestCursor_DecodeEmpty(t *testing.T) {
+	decoded, err := DecodeCursor("")
+
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestCursor_DecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!not-base64!!"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("not json"))},
+		{"missing id", base64.RawURLEncoding.EncodeToString([]byte(`{"ts":"2025-01-02T03:04:05Z"}`))},
+		{"missing timestamp", base64.RawURLEncoding.EncodeToString([]byte(`{"id":"entry-1"}`))},
+		{"invalid timestamp", base64.RawURLEncoding.EncodeToString([]byte(`{"ts":"yesterday","id":"entry-1"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(tt.cursor)
+
+			require.ErrorIs(t, err, types.ErrInvalidInput)
+			assert.Nil(t, decoded)
+		})
+	}
+}
+
+func TestCursor_After(t *testing.T) {
+	now := time.Now()
+	cursor := Cursor{Timestamp: now, ID: "b"}
+
+	assert.True(t, cursor.After(now.Add(time.Millisecond), "a"))
+	assert.True(t, cursor.After(now, "c"))
+	assert.False(t, cursor.After(now, "b"))
+	assert.False(t, cursor.After(now, "a"))
+	assert.False(t, cursor.After(now.Add(-time.Millisecond), "z"))
+}
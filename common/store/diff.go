@@ -0,0 +1,89 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiffStores compares the entities returned by two page functions by ID and version, e.g. to detect drift between a
+// primary and a replica or to verify a migration. It returns the IDs of entities only present in a, only present in b
+// and present in both with different versions. Both sides are paged by ID and merged, so that memory use is bounded by
+// the page size and the number of differences rather than the size of the stores, and no query is issued while the
+// results of another are being read. Stores provide page functions through their PageByID method.
+func DiffStores[T EntityType](ctx context.Context, a, b PageFunc[T]) (onlyInA, onlyInB, conflicting []string, err error) {
+	nextA := pageIterator(a)
+	nextB := pageIterator(b)
+	entityA, okA, err := nextA(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	entityB, okB, err := nextB(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for okA || okB {
+		switch {
+		case !okB || (okA && entityA.GetID() < entityB.GetID()):
+			onlyInA = append(onlyInA, entityA.GetID())
+			entityA, okA, err = nextA(ctx)
+		case !okA || entityB.GetID() < entityA.GetID():
+			onlyInB = append(onlyInB, entityB.GetID())
+			entityB, okB, err = nextB(ctx)
+		default:
+			if entityA.GetVersion() != entityB.GetVersion() {
+				conflicting = append(conflicting, entityA.GetID())
+			}
+			entityA, okA, err = nextA(ctx)
+			if err == nil {
+				entityB, okB, err = nextB(ctx)
+			}
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return onlyInA, onlyInB, conflicting, nil
+}
+
+// pageIterator returns a function yielding the entities of page one at a time in ID order. It returns false once all
+// entities have been returned.
+func pageIterator[T EntityType](page PageFunc[T]) func(ctx context.Context) (T, bool, error) {
+	var buffer []T
+	afterID := ""
+	done := false
+	return func(ctx context.Context) (T, bool, error) {
+		var zero T
+		if len(buffer) == 0 {
+			if done {
+				return zero, false, nil
+			}
+			entities, err := page(ctx, afterID, exportPageSize)
+			if err != nil {
+				return zero, false, fmt.Errorf("failed to read entities: %w", err)
+			}
+			if len(entities) < exportPageSize {
+				done = true
+			}
+			if len(entities) == 0 {
+				return zero, false, nil
+			}
+			afterID = entities[len(entities)-1].GetID()
+			buffer = entities
+		}
+		entity := buffer[0]
+		buffer = buffer[1:]
+		return entity, true, nil
+	}
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+)
+
+// exportPageSize is the number of entities loaded per page when exporting.
+const exportPageSize = 100
+
+// PageFunc returns up to limit entities ordered by ID, starting after the given ID. An empty ID denotes the start.
+type PageFunc[T EntityType] func(ctx context.Context, afterID string, limit int) ([]T, error)
+
+// ExportJSONLines writes all entities returned by page to w as JSON Lines, one entity per line. Entities are loaded one
+// page at a time using keyset pagination on the ID so that memory use does not grow with the number of entities.
+func ExportJSONLines[T EntityType](ctx context.Context, w io.Writer, page PageFunc[T]) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entities, err := page(ctx, afterID, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("error loading entities to export: %w", err)
+		}
+		for _, entity := range entities {
+			// Encode terminates each entity with a newline
+			if err := encoder.Encode(entity); err != nil {
+				return fmt.Errorf("error exporting entity %s: %w", entity.GetID(), err)
+			}
+		}
+		if len(entities) < exportPageSize {
+			return nil
+		}
+		afterID = entities[len(entities)-1].GetID()
+	}
+}
+
+// ImportJSONLines reads entities written by ExportJSONLines from r and passes each to create, stopping at the first
+// error. Malformed lines return types.ErrInvalidInput.
+func ImportJSONLines[T EntityType](ctx context.Context, r io.Reader, create func(context.Context, T) error) error {
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var entity T
+		if err := decoder.Decode(&entity); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: malformed entity on line %d: %v", types.ErrInvalidInput, line, err)
+		}
+		if err := create(ctx, entity); err != nil {
+			return fmt.Errorf("error importing entity %s: %w", entity.GetID(), err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+)
+
+// InstrumentedEntityStore decorates an EntityStore and records the latency and outcome of each call, keeping
+// observability out of the store implementations. The latency of calls returning an iterator is measured until the
+// iteration completes.
+type InstrumentedEntityStore[T EntityType] struct {
+	delegate EntityStore[T]
+	metrics  StoreMetrics
+}
+
+func NewInstrumentedEntityStore[T EntityType](delegate EntityStore[T], metrics StoreMetrics) *InstrumentedEntityStore[T] {
+	return &InstrumentedEntityStore[T]{delegate: delegate, metrics: metrics}
+}
+
+func (s *InstrumentedEntityStore[T]) FindByID(ctx context.Context, id string) (T, error) {
+	start := time.Now()
+	entity, err := s.delegate.FindByID(ctx, id)
+	s.metrics.RecordCall("FindByID", time.Since(start), err)
+	return entity, err
+}
+
+func (s *InstrumentedEntityStore[T]) Exists(ctx context.Context, id string) (bool, error) {
+	start := time.Now()
+	exists, err := s.delegate.Exists(ctx, id)
+	s.metrics.RecordCall("Exists", time.Since(start), err)
+	return exists, err
+}
+
+func (s *InstrumentedEntityStore[T]) Create(ctx context.Context, entity T) (T, error) {
+	start := time.Now()
+	created, err := s.delegate.Create(ctx, entity)
+	s.metrics.RecordCall("Create", time.Since(start), err)
+	return created, err
+}
+
+func (s *InstrumentedEntityStore[T]) Update(ctx context.Context, entity T) error {
+	start := time.Now()
+	err := s.delegate.Update(ctx, entity)
+	s.metrics.RecordCall("Update", time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedEntityStore[T]) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.delegate.Delete(ctx, id)
+	s.metrics.RecordCall("Delete", time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedEntityStore[T]) GetAll(ctx context.Context) iter.Seq2[T, error] {
+	return s.instrumentSeq("GetAll", s.delegate.GetAll(ctx))
+}
+
+func (s *InstrumentedEntityStore[T]) GetAllCount(ctx context.Context) (int64, error) {
+	start := time.Now()
+	count, err := s.delegate.GetAllCount(ctx)
+	s.metrics.RecordCall("GetAllCount", time.Since(start), err)
+	return count, err
+}
+
+func (s *InstrumentedEntityStore[T]) GetAllPaginated(ctx context.Context, opts PaginationOptions) iter.Seq2[T, error] {
+	return s.instrumentSeq("GetAllPaginated", s.delegate.GetAllPaginated(ctx, opts))
+}
+
+func (s *InstrumentedEntityStore[T]) FindByPredicate(ctx context.Context, predicate query.Predicate) iter.Seq2[T, error] {
+	return s.instrumentSeq("FindByPredicate", s.delegate.FindByPredicate(ctx, predicate))
+}
+
+func (s *InstrumentedEntityStore[T]) FindByPredicatePaginated(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts PaginationOptions) iter.Seq2[T, error] {
+	return s.instrumentSeq("FindByPredicatePaginated", s.delegate.FindByPredicatePaginated(ctx, predicate, opts))
+}
+
+func (s *InstrumentedEntityStore[T]) FindFirstByPredicate(ctx context.Context, predicate query.Predicate) (T, error) {
+	start := time.Now()
+	entity, err := s.delegate.FindFirstByPredicate(ctx, predicate)
+	s.metrics.RecordCall("FindFirstByPredicate", time.Since(start), err)
+	return entity, err
+}
+
+func (s *InstrumentedEntityStore[T]) CountByPredicate(ctx context.Context, predicate query.Predicate) (int64, error) {
+	start := time.Now()
+	count, err := s.delegate.CountByPredicate(ctx, predicate)
+	s.metrics.RecordCall("CountByPredicate", time.Since(start), err)
+	return count, err
+}
+
+func (s *InstrumentedEntityStore[T]) DeleteByPredicate(ctx context.Context, predicate query.Predicate) error {
+	start := time.Now()
+	err := s.delegate.DeleteByPredicate(ctx, predicate)
+	s.metrics.RecordCall("DeleteByPredicate", time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedEntityStore[T]) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	start := time.Now()
+	acquired, release, err := s.delegate.TryLock(ctx, name, ttl)
+	s.metrics.RecordCall("TryLock", time.Since(start), err)
+	return acquired, release, err
+}
+
+func (s *InstrumentedEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	start := time.Now()
+	err := s.delegate.Export(ctx, w)
+	s.metrics.RecordCall("Export", time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	start := time.Now()
+	err := s.delegate.Import(ctx, r)
+	s.metrics.RecordCall("Import", time.Since(start), err)
+	return err
+}
+
+// instrumentSeq records the call once the iteration completes or is stopped. The first error yielded is recorded.
+func (s *InstrumentedEntityStore[T]) instrumentSeq(method string, seq iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		start := time.Now()
+		var seqErr error
+		defer func() {
+			s.metrics.RecordCall(method, time.Since(start), seqErr)
+		}()
+		for entity, err := range seq {
+			if err != nil && seqErr == nil {
+				seqErr = err
+			}
+			if !yield(entity, err) {
+				return
+			}
+		}
+	}
+}
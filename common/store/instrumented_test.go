@@ -0,0 +1,116 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedEntityStore_FindByID(t *testing.T) {
+	recorder := &StoreCallRecorder{}
+	delegate := &stubStore{entity: &versionedEntity{ID: "entity-1", Version: 3}, delay: 5 * time.Millisecond}
+	instrumented := NewInstrumentedEntityStore[*versionedEntity](delegate, recorder)
+
+	entity, err := instrumented.FindByID(context.Background(), "entity-1")
+
+	require.NoError(t, err)
+	assert.Same(t, delegate.entity, entity)
+	assert.Equal(t, []string{"entity-1"}, delegate.requested)
+	stats := recorder.Stats("FindByID")
+	assert.Equal(t, int64(1), stats.Calls)
+	assert.Equal(t, int64(0), stats.Errors)
+	assert.GreaterOrEqual(t, stats.TotalDuration, delegate.delay)
+}
+
+func TestInstrumentedEntityStore_FindByID_Error(t *testing.T) {
+	recorder := &StoreCallRecorder{}
+	instrumented := NewInstrumentedEntityStore[*versionedEntity](&stubStore{}, recorder)
+
+	entity, err := instrumented.FindByID(context.Background(), "entity-1")
+
+	require.ErrorIs(t, err, types.ErrNotFound)
+	assert.Nil(t, entity)
+	assert.Equal(t, int64(1), recorder.Stats("FindByID").Errors)
+}
+
+func TestInstrumentedEntityStore_GetAll_RecordedOnCompletion(t *testing.T) {
+	recorder := &StoreCallRecorder{}
+	readErr := errors.New("connection lost")
+	delegate := &stubStore{all: []*versionedEntity{{ID: "entity-1"}, nil}, allErr: readErr}
+	instrumented := NewInstrumentedEntityStore[*versionedEntity](delegate, recorder)
+
+	seq := instrumented.GetAll(context.Background())
+	assert.Equal(t, int64(0), recorder.Stats("GetAll").Calls, "the call is recorded once iterated")
+
+	var errs []error
+	for _, err := range seq {
+		errs = append(errs, err)
+	}
+
+	assert.Equal(t, []error{nil, readErr}, errs)
+	stats := recorder.Stats("GetAll")
+	assert.Equal(t, int64(1), stats.Calls)
+	assert.Equal(t, int64(1), stats.Errors)
+}
+
+// versionedEntity is a minimal EntityType.
+type versionedEntity struct {
+	ID      string
+	Version int64
+}
+
+func (e *versionedEntity) GetID() string     { return e.ID }
+func (e *versionedEntity) GetVersion() int64 { return e.Version }
+func (e *versionedEntity) IncrementVersion() { e.Version++ }
+
+// stubStore returns entity from FindByID after delay or ErrNotFound if entity is nil. GetAll yields all, the last
+// element with allErr. Other methods are not implemented.
+type stubStore struct {
+	EntityStore[*versionedEntity]
+	entity    *versionedEntity
+	delay     time.Duration
+	requested []string
+	all       []*versionedEntity
+	allErr    error
+}
+
+func (s *stubStore) FindByID(_ context.Context, id string) (*versionedEntity, error) {
+	s.requested = append(s.requested, id)
+	time.Sleep(s.delay)
+	if s.entity == nil {
+		return nil, types.ErrNotFound
+	}
+	return s.entity, nil
+}
+
+func (s *stubStore) GetAll(context.Context) iter.Seq2[*versionedEntity, error] {
+	return func(yield func(*versionedEntity, error) bool) {
+		for i, entity := range s.all {
+			var err error
+			if i == len(s.all)-1 {
+				err = s.allErr
+			}
+			if !yield(entity, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/system"
+)
+
+const (
+	TransactionMetricsKey system.ServiceType = "store:TransactionMetrics"
+)
+
+// TransactionMetrics records the outcome of transactions. A high rollback rate signals contention or bugs.
+type TransactionMetrics interface {
+	RecordCommit()
+	RecordRollback()
+}
+
+// TransactionCounter is a TransactionMetrics implementation that counts commits and rollbacks.
+type TransactionCounter struct {
+	commits   atomic.Int64
+	rollbacks atomic.Int64
+}
+
+func (c *TransactionCounter) RecordCommit() {
+	c.commits.Add(1)
+}
+
+func (c *TransactionCounter) RecordRollback() {
+	c.rollbacks.Add(1)
+}
+
+// Commits returns the number of committed transactions.
+func (c *TransactionCounter) Commits() int64 {
+	return c.commits.Load()
+}
+
+// Rollbacks returns the number of rolled back transactions.
+func (c *TransactionCounter) Rollbacks() int64 {
+	return c.rollbacks.Load()
+}
+
+// RollbackRatio returns the fraction of transactions that were rolled back or 0 if no transactions were recorded.
+func (c *TransactionCounter) RollbackRatio() float64 {
+	rollbacks := c.rollbacks.Load()
+	total := c.commits.Load() + rollbacks
+	if total == 0 {
+		return 0
+	}
+	return float64(rollbacks) / float64(total)
+}
+
+// MeteredTransactionContext decorates a TransactionContext and records the outcome of each transaction. A transaction
+// is recorded as a rollback if the callback or the commit fails, or the callback panics.
+type MeteredTransactionContext struct {
+	delegate TransactionContext
+	metrics  TransactionMetrics
+}
+
+func NewMeteredTransactionContext(delegate TransactionContext, metrics TransactionMetrics) *MeteredTransactionContext {
+	return &MeteredTransactionContext{delegate: delegate, metrics: metrics}
+}
+
+func (m *MeteredTransactionContext) Execute(ctx context.Context, callback func(ctx context.Context) error) error {
+	completed := false
+	defer func() {
+		if !completed {
+			m.metrics.RecordRollback() // panic
+		}
+	}()
+
+	err := m.delegate.Execute(ctx, callback)
+	completed = true
+	if err != nil {
+		m.metrics.RecordRollback()
+		return err
+	}
+	m.metrics.RecordCommit()
+	return nil
+}
+
+// StoreMetrics records the latency and outcome of store calls, see InstrumentedEntityStore.
+type StoreMetrics interface {
+	// RecordCall records a call of the store method that took duration and returned err, nil if it succeeded.
+	RecordCall(method string, duration time.Duration, err error)
+}
+
+// CallStats holds the statistics of a store method recorded by a StoreCallRecorder.
+type CallStats struct {
+	Calls         int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// AverageDuration returns the average duration of the calls or 0 if no calls were recorded.
+func (s CallStats) AverageDuration() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Calls)
+}
+
+// StoreCallRecorder is a StoreMetrics implementation aggregating calls per store method. The zero value is ready to
+// use.
+type StoreCallRecorder struct {
+	mu    sync.RWMutex
+	stats map[string]CallStats
+}
+
+func (r *StoreCallRecorder) RecordCall(method string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stats == nil {
+		r.stats = make(map[string]CallStats)
+	}
+	stats := r.stats[method]
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.TotalDuration += duration
+	stats.MaxDuration = max(stats.MaxDuration, duration)
+	r.stats[method] = stats
+}
+
+// Stats returns the statistics recorded for the store method.
+func (r *StoreCallRecorder) Stats(method string) CallStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stats[method]
+}
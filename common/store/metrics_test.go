@@ -0,0 +1,75 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeteredTransactionContext_Commit(t *testing.T) {
+	counter := &TransactionCounter{}
+	trxContext := NewMeteredTransactionContext(NoOpTransactionContext{}, counter)
+
+	err := trxContext.Execute(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counter.Commits())
+	assert.Equal(t, int64(0), counter.Rollbacks())
+}
+
+func TestMeteredTransactionContext_ForcedErrorRollsBackOnce(t *testing.T) {
+	counter := &TransactionCounter{}
+	trxContext := NewMeteredTransactionContext(NoOpTransactionContext{}, counter)
+	forcedErr := errors.New("forced error")
+
+	err := trxContext.Execute(context.Background(), func(ctx context.Context) error {
+		return forcedErr
+	})
+
+	require.ErrorIs(t, err, forcedErr)
+	assert.Equal(t, int64(0), counter.Commits())
+	assert.Equal(t, int64(1), counter.Rollbacks())
+}
+
+func TestMeteredTransactionContext_PanicRollsBack(t *testing.T) {
+	counter := &TransactionCounter{}
+	trxContext := NewMeteredTransactionContext(NoOpTransactionContext{}, counter)
+
+	assert.Panics(t, func() {
+		_ = trxContext.Execute(context.Background(), func(ctx context.Context) error {
+			panic("forced panic")
+		})
+	})
+
+	assert.Equal(t, int64(0), counter.Commits())
+	assert.Equal(t, int64(1), counter.Rollbacks())
+}
+
+func TestTransactionCounter_RollbackRatio(t *testing.T) {
+	counter := &TransactionCounter{}
+	assert.Equal(t, 0.0, counter.RollbackRatio())
+
+	counter.RecordCommit()
+	counter.RecordCommit()
+	counter.RecordCommit()
+	counter.RecordRollback()
+
+	assert.Equal(t, 0.25, counter.RollbackRatio())
+}
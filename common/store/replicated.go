@@ -0,0 +1,138 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+)
+
+// ReplicatedEntityStore routes writes to the primary store and reads to read replicas, offloading read queries from the
+// primary. Since replicas lag behind the primary, reads within the read-after-write window following a write are routed
+// to the primary so that callers observe their own writes. Reads are distributed round-robin across the replicas.
+//
+// Replicas must be usable with the contexts passed by callers, e.g. by resolving their own connection rather than the
+// transaction of the primary.
+type ReplicatedEntityStore[T EntityType] struct {
+	writer    EntityStore[T]
+	readers   []EntityStore[T]
+	window    time.Duration
+	now       func() time.Time
+	lastWrite atomic.Int64
+	next      atomic.Uint64
+}
+
+// NewReplicatedEntityStore creates a store writing to writer and reading from readers. Reads go to the writer if no
+// readers are given.
+func NewReplicatedEntityStore[T EntityType](
+	writer EntityStore[T],
+	readers []EntityStore[T],
+	readAfterWriteWindow time.Duration) *ReplicatedEntityStore[T] {
+	return &ReplicatedEntityStore[T]{writer: writer, readers: readers, window: readAfterWriteWindow, now: time.Now}
+}
+
+// reader returns the store serving the next read.
+func (s *ReplicatedEntityStore[T]) reader() EntityStore[T] {
+	if len(s.readers) == 0 {
+		return s.writer
+	}
+	if s.window > 0 {
+		if lastWrite := s.lastWrite.Load(); lastWrite != 0 && s.now().Sub(time.Unix(0, lastWrite)) < s.window {
+			return s.writer
+		}
+	}
+	return s.readers[(s.next.Add(1)-1)%uint64(len(s.readers))]
+}
+
+// written starts the read-after-write window.
+func (s *ReplicatedEntityStore[T]) written() {
+	s.lastWrite.Store(s.now().UnixNano())
+}
+
+func (s *ReplicatedEntityStore[T]) FindByID(ctx context.Context, id string) (T, error) {
+	return s.reader().FindByID(ctx, id)
+}
+
+func (s *ReplicatedEntityStore[T]) Exists(ctx context.Context, id string) (bool, error) {
+	return s.reader().Exists(ctx, id)
+}
+
+func (s *ReplicatedEntityStore[T]) Create(ctx context.Context, entity T) (T, error) {
+	defer s.written()
+	return s.writer.Create(ctx, entity)
+}
+
+func (s *ReplicatedEntityStore[T]) Update(ctx context.Context, entity T) error {
+	defer s.written()
+	return s.writer.Update(ctx, entity)
+}
+
+func (s *ReplicatedEntityStore[T]) Delete(ctx context.Context, id string) error {
+	defer s.written()
+	return s.writer.Delete(ctx, id)
+}
+
+func (s *ReplicatedEntityStore[T]) GetAll(ctx context.Context) iter.Seq2[T, error] {
+	return s.reader().GetAll(ctx)
+}
+
+func (s *ReplicatedEntityStore[T]) GetAllCount(ctx context.Context) (int64, error) {
+	return s.reader().GetAllCount(ctx)
+}
+
+func (s *ReplicatedEntityStore[T]) GetAllPaginated(ctx context.Context, opts PaginationOptions) iter.Seq2[T, error] {
+	return s.reader().GetAllPaginated(ctx, opts)
+}
+
+func (s *ReplicatedEntityStore[T]) FindByPredicate(ctx context.Context, predicate query.Predicate) iter.Seq2[T, error] {
+	return s.reader().FindByPredicate(ctx, predicate)
+}
+
+func (s *ReplicatedEntityStore[T]) FindByPredicatePaginated(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts PaginationOptions) iter.Seq2[T, error] {
+	return s.reader().FindByPredicatePaginated(ctx, predicate, opts)
+}
+
+func (s *ReplicatedEntityStore[T]) FindFirstByPredicate(ctx context.Context, predicate query.Predicate) (T, error) {
+	return s.reader().FindFirstByPredicate(ctx, predicate)
+}
+
+func (s *ReplicatedEntityStore[T]) CountByPredicate(ctx context.Context, predicate query.Predicate) (int64, error) {
+	return s.reader().CountByPredicate(ctx, predicate)
+}
+
+func (s *ReplicatedEntityStore[T]) DeleteByPredicate(ctx context.Context, predicate query.Predicate) error {
+	defer s.written()
+	return s.writer.DeleteByPredicate(ctx, predicate)
+}
+
+// TryLock acquires locks on the primary so that all holders contend on the same state.
+func (s *ReplicatedEntityStore[T]) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	return s.writer.TryLock(ctx, name, ttl)
+}
+
+func (s *ReplicatedEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return s.reader().Export(ctx, w)
+}
+
+func (s *ReplicatedEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	defer s.written()
+	return s.writer.Import(ctx, r)
+}
@@ -0,0 +1,73 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicatedEntityStore_ReadsRoutedToReplicasOutsideReadAfterWriteWindow(t *testing.T) {
+	primary := &routedStore{name: "primary"}
+	replicas := []EntityStore[*versionedEntity]{&routedStore{name: "replica-1"}, &routedStore{name: "replica-2"}}
+	replicated := NewReplicatedEntityStore[*versionedEntity](primary, replicas, 5*time.Second)
+	now := time.Now()
+	replicated.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.Equal(t, "replica-1", readFrom(t, replicated))
+	assert.Equal(t, "replica-2", readFrom(t, replicated), "reads are distributed across the replicas")
+
+	require.NoError(t, replicated.Update(ctx, &versionedEntity{ID: "entity-1"}))
+	assert.Equal(t, []string{"entity-1"}, primary.updated)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, "primary", readFrom(t, replicated), "reads within the window must see the write")
+
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, "replica-1", readFrom(t, replicated))
+}
+
+func TestReplicatedEntityStore_NoReplicas_ReadsFromPrimary(t *testing.T) {
+	replicated := NewReplicatedEntityStore[*versionedEntity](&routedStore{name: "primary"}, nil, 0)
+
+	assert.Equal(t, "primary", readFrom(t, replicated))
+}
+
+func readFrom(t *testing.T, replicated *ReplicatedEntityStore[*versionedEntity]) string {
+	t.Helper()
+	entity, err := replicated.FindByID(context.Background(), "entity-1")
+	require.NoError(t, err)
+	return entity.ID
+}
+
+// routedStore returns an entity whose ID is the name of the store from FindByID and records updated IDs. Other methods
+// are not implemented.
+type routedStore struct {
+	EntityStore[*versionedEntity]
+	name    string
+	updated []string
+}
+
+func (s *routedStore) FindByID(context.Context, string) (*versionedEntity, error) {
+	return &versionedEntity{ID: s.name}, nil
+}
+
+func (s *routedStore) Update(_ context.Context, entity *versionedEntity) error {
+	s.updated = append(s.updated, entity.ID)
+	return nil
+}
@@ -14,7 +14,9 @@ package store
 
 import (
 	"context"
+	"io"
 	"iter"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/query"
 	"github.com/metaform/connector-fabric-manager/common/system"
@@ -116,6 +118,14 @@ type EntityStore[T EntityType] interface {
 	FindFirstByPredicate(ctx context.Context, predicate query.Predicate) (T, error)
 	CountByPredicate(ctx context.Context, predicate query.Predicate) (int64, error)
 	DeleteByPredicate(ctx context.Context, predicate query.Predicate) error
+	// TryLock acquires the named lock if no other holder has it, e.g. so that only one replica runs a background job.
+	// The lock expires after ttl so that it is freed if the holder crashes. release frees the lock and must be called
+	// when acquired is true.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (acquired bool, release func(), err error)
+	// Export streams all entities to w as JSON Lines, one entity per line, without loading all entities into memory.
+	Export(ctx context.Context, w io.Writer) error
+	// Import creates the entities read from r in the format written by Export.
+	Import(ctx context.Context, r io.Reader) error
 }
 
 // EntityType defines a versionable entity.
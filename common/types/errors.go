@@ -15,11 +15,18 @@ package types
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
 	// ErrConflict indicates an object conflict, e.g. when creating an object that already exists
 	ErrConflict = NewRecoverableError("conflict")
+	// ErrVersionConflict indicates an object was modified concurrently, i.e. it did not have the expected version. It
+	// wraps ErrConflict.
+	ErrVersionConflict = GeneralRecoverableError{Message: "version conflict", Cause: ErrConflict}
+	// ErrAlreadyExists indicates that an object is not created since an equivalent object exists. Unlike ErrConflict,
+	// the operation does not succeed when retried.
+	ErrAlreadyExists = NewRecoverableError("already exists")
 	// ErrNotFound indicates that a certain object does not exist
 	ErrNotFound = NewRecoverableError("not found")
 	// ErrInvalidInput Sentinel error to indicate a wrong input, e.g., a string when a number was expected, or an empty string
@@ -41,6 +48,13 @@ type FatalError interface {
 	IsFatal() bool
 }
 
+// RetryAfterError is implemented by errors that know when the failed operation can be retried, e.g. a backend
+// responding with a rate limit.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
 type GeneralRecoverableError struct {
 	Message string
 	Cause   error
@@ -50,6 +64,18 @@ func (e GeneralRecoverableError) Error() string       { return e.Message }
 func (e GeneralRecoverableError) Unwrap() error       { return e.Cause }
 func (e GeneralRecoverableError) IsRecoverable() bool { return true }
 
+// RetryableError is a recoverable error carrying a retry delay.
+type RetryableError struct {
+	Message string
+	Cause   error
+	Delay   time.Duration
+}
+
+func (e RetryableError) Error() string             { return e.Message }
+func (e RetryableError) Unwrap() error             { return e.Cause }
+func (e RetryableError) IsRecoverable() bool       { return true }
+func (e RetryableError) RetryAfter() time.Duration { return e.Delay }
+
 type BadRequestError struct {
 	Message string
 	Cause   error
@@ -72,6 +98,10 @@ func NewRecoverableError(message string, args ...any) error {
 	return GeneralRecoverableError{Message: fmt.Sprintf(message, args...)}
 }
 
+func NewRetryAfterError(delay time.Duration, message string, args ...any) error {
+	return RetryableError{Message: fmt.Sprintf(message, args...), Delay: delay}
+}
+
 func NewClientError(message string, args ...any) error {
 	return BadRequestError{Message: fmt.Sprintf(message, args...)}
 }
@@ -127,3 +157,13 @@ func IsFatal(err error) bool {
 	var fatalErr FatalError
 	return errors.As(err, &fatalErr) && fatalErr.IsFatal()
 }
+
+// RetryAfter returns the retry delay of the first error in the chain implementing RetryAfterError. The second return
+// value is false if no error carries a positive delay.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryErr RetryAfterError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter() <= 0 {
+		return 0, false
+	}
+	return retryErr.RetryAfter(), true
+}
@@ -16,6 +16,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestNewRecoverableWrappedError(t *testing.T) {
@@ -212,3 +213,26 @@ func TestWrappedErrorsWithErrorsIs(t *testing.T) {
 		t.Error("errors.Is should find the original error in fatal wrapped error")
 	}
 }
+
+func TestRetryAfter(t *testing.T) {
+	retryErr := NewRetryAfterError(5*time.Second, "rate limited by %s", "backend")
+
+	if retryErr.Error() != "rate limited by backend" {
+		t.Errorf("Error() = %q, want %q", retryErr.Error(), "rate limited by backend")
+	}
+	if !IsRecoverable(retryErr) {
+		t.Error("retry after error should be recoverable")
+	}
+
+	delay, ok := RetryAfter(fmt.Errorf("update failed: %w", retryErr))
+	if !ok || delay != 5*time.Second {
+		t.Errorf("RetryAfter() = (%v, %v), want (5s, true)", delay, ok)
+	}
+
+	if _, ok := RetryAfter(errors.New("no hint")); ok {
+		t.Error("RetryAfter should not report a delay for errors without a hint")
+	}
+	if _, ok := RetryAfter(NewRetryAfterError(0, "no delay")); ok {
+		t.Error("RetryAfter should not report a zero delay")
+	}
+}
@@ -0,0 +1,41 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import "context"
+
+type orchestrationKeyType struct{}
+
+// orchestrationKey defines the key for obtaining the current orchestration entry from the context.
+var orchestrationKey = orchestrationKeyType{}
+
+// WithOrchestration returns a copy of the context carrying the orchestration entry being processed.
+func WithOrchestration(ctx context.Context, entry *OrchestrationEntry) context.Context {
+	return context.WithValue(ctx, orchestrationKey, entry)
+}
+
+// OrchestrationFromContext returns the orchestration entry being processed or false if the context does not carry one.
+func OrchestrationFromContext(ctx context.Context) (*OrchestrationEntry, bool) {
+	entry, ok := ctx.Value(orchestrationKey).(*OrchestrationEntry)
+	return entry, ok && entry != nil
+}
+
+// CorrelationIDFromContext returns the correlation ID of the orchestration being processed or false if the context
+// does not carry an orchestration with a correlation ID.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	entry, ok := OrchestrationFromContext(ctx)
+	if !ok || entry.CorrelationID == "" {
+		return "", false
+	}
+	return entry.CorrelationID, true
+}
@@ -0,0 +1,44 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestrationFromContext(t *testing.T) {
+	entry := &OrchestrationEntry{ID: "orch-1", CorrelationID: "corr-1"}
+	ctx := WithOrchestration(context.Background(), entry)
+
+	found, ok := OrchestrationFromContext(ctx)
+	require.True(t, ok)
+	assert.Same(t, entry, found)
+
+	correlationID, ok := CorrelationIDFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "corr-1", correlationID)
+}
+
+func TestOrchestrationFromContext_NotSet(t *testing.T) {
+	_, ok := OrchestrationFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = CorrelationIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = CorrelationIDFromContext(WithOrchestration(context.Background(), &OrchestrationEntry{ID: "orch-1"}))
+	assert.False(t, ok, "entries without a correlation ID")
+}
@@ -0,0 +1,74 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+)
+
+// EntryHandler processes orchestration entries recorded in the index.
+type EntryHandler interface {
+	// Handle processes the recorded entry. Entries are retried until they are handled, so implementations must be
+	// idempotent.
+	Handle(ctx context.Context, entry *OrchestrationEntry) error
+}
+
+type handlerKey struct {
+	orchestrationType model.OrchestrationType
+	mode              string
+}
+
+// HandlerRegistry dispatches recorded entries to the handler registered for their orchestration type and mode. This
+// allows new processing logic to be rolled out gradually by setting the mode of individual orchestrations. Entries with
+// a mode no handler is registered for are dispatched to the default handler of their type, registered with an empty
+// mode.
+type HandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[handlerKey]EntryHandler
+}
+
+func NewHandlerRegistry() *HandlerRegistry {
+	return &HandlerRegistry{handlers: make(map[handlerKey]EntryHandler)}
+}
+
+// Register registers the handler for the orchestration type and mode, replacing a handler registered before. An empty
+// mode registers the default handler of the type.
+func (r *HandlerRegistry) Register(orchestrationType model.OrchestrationType, mode string, handler EntryHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[handlerKey{orchestrationType: orchestrationType, mode: mode}] = handler
+}
+
+// Resolve returns the handler for the orchestration type and mode, falling back to the default handler of the type.
+func (r *HandlerRegistry) Resolve(orchestrationType model.OrchestrationType, mode string) (EntryHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if handler, found := r.handlers[handlerKey{orchestrationType: orchestrationType, mode: mode}]; found {
+		return handler, true
+	}
+	handler, found := r.handlers[handlerKey{orchestrationType: orchestrationType}]
+	return handler, found
+}
+
+// Project dispatches the entry to its handler so that the registry can be registered as a Projector. Entries of types
+// without a handler are skipped.
+func (r *HandlerRegistry) Project(ctx context.Context, entry *OrchestrationEntry) error {
+	handler, found := r.Resolve(entry.OrchestrationType, entry.Mode)
+	if !found {
+		return nil
+	}
+	return handler.Handle(ctx, entry)
+}
@@ -0,0 +1,70 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerRegistry_DispatchesByMode(t *testing.T) {
+	stable := &recordingHandler{}
+	experimental := &recordingHandler{}
+	registry := NewHandlerRegistry()
+	registry.Register("cfm.provision", "", stable)
+	registry.Register("cfm.provision", "experimental", experimental)
+
+	ctx := context.Background()
+	require.NoError(t, registry.Project(ctx, &OrchestrationEntry{ID: "orch-1", OrchestrationType: "cfm.provision"}))
+	require.NoError(t, registry.Project(ctx, &OrchestrationEntry{ID: "orch-2", OrchestrationType: "cfm.provision", Mode: "experimental"}))
+
+	assert.Equal(t, []string{"orch-1"}, stable.handled)
+	assert.Equal(t, []string{"orch-2"}, experimental.handled)
+}
+
+func TestHandlerRegistry_UnknownModeFallsBackToDefault(t *testing.T) {
+	stable := &recordingHandler{}
+	registry := NewHandlerRegistry()
+	registry.Register("cfm.provision", "", stable)
+
+	require.NoError(t, registry.Project(context.Background(),
+		&OrchestrationEntry{ID: "orch-1", OrchestrationType: "cfm.provision", Mode: "canary"}))
+
+	assert.Equal(t, []string{"orch-1"}, stable.handled)
+}
+
+func TestHandlerRegistry_UnknownTypeSkipped(t *testing.T) {
+	experimental := &recordingHandler{}
+	registry := NewHandlerRegistry()
+	registry.Register("cfm.provision", "experimental", experimental)
+
+	require.NoError(t, registry.Project(context.Background(),
+		&OrchestrationEntry{ID: "orch-1", OrchestrationType: "cfm.deprovision", Mode: "experimental"}))
+
+	_, found := registry.Resolve("cfm.provision", "")
+	assert.False(t, found, "modes do not serve as default handlers")
+	assert.Empty(t, experimental.handled)
+}
+
+// recordingHandler records the IDs of the handled entries.
+type recordingHandler struct {
+	handled []string
+}
+
+func (h *recordingHandler) Handle(_ context.Context, entry *OrchestrationEntry) error {
+	h.handled = append(h.handled, entry.ID)
+	return nil
+}
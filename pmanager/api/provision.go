@@ -17,6 +17,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"iter"
 	"reflect"
 
@@ -33,8 +34,189 @@ const (
 	DefinitionStoreKey   system.ServiceType = "pmapi:DefinitionStore"
 	OrchestratorKey      system.ServiceType = "pmapi:Orchestrator"
 	DefinitionManagerKey system.ServiceType = "pmapi:DefinitionManager"
+	HealthCheckKey       system.ServiceType = "pmapi:HealthCheck"
+	DeadLetterQueueKey   system.ServiceType = "pmapi:DeadLetterQueue"
+	StateForwarderKey    system.ServiceType = "pmapi:StateForwarder"
+	DeliveryMetricsKey   system.ServiceType = "pmapi:DeliveryMetrics"
+	IndexRebuilderKey    system.ServiceType = "pmapi:IndexRebuilder"
+	RetrierKey           system.ServiceType = "pmapi:Retrier"
+	EntryValidatorKey    system.ServiceType = "pmapi:EntryValidator"
+	BulkTransitionerKey  system.ServiceType = "pmapi:BulkTransitioner"
+	TemplateRegistryKey  system.ServiceType = "pmapi:TemplateRegistry"
+	EntryNotifierKey     system.ServiceType = "pmapi:EntryNotifier"
+	MaintenanceKey       system.ServiceType = "pmapi:Maintenance"
+	TypePauserKey        system.ServiceType = "pmapi:TypePauser"
 )
 
+// ErrAlreadyTerminal is returned when an operation requires an orchestration that has not reached a terminal state.
+var ErrAlreadyTerminal = errors.New("orchestration already terminal")
+
+// HealthCheck reports whether a runtime component is able to perform its work.
+type HealthCheck interface {
+	// CheckHealth returns an error describing the failure if the component is unhealthy.
+	CheckHealth(ctx context.Context) error
+}
+
+// StateForwarder mirrors orchestration state changes to an external system.
+type StateForwarder interface {
+	// Forward publishes the state of the orchestration entry. Implementations must preserve the order of changes for
+	// the same orchestration ID.
+	Forward(ctx context.Context, entry *OrchestrationEntry) error
+}
+
+// Projector maintains a read model derived from the orchestration entries recorded in the index, e.g. the number of
+// orchestrations per customer.
+type Projector interface {
+	// Project applies the recorded entry to the read model. Entries are projected in the order they are recorded and
+	// retried until they are projected, so implementations must be idempotent.
+	Project(ctx context.Context, entry *OrchestrationEntry) error
+}
+
+// DeliveryMetrics records how many delivery attempts orchestration messages needed until they were processed. A
+// right-skewed distribution signals flaky downstream systems.
+type DeliveryMetrics interface {
+	// RecordDeliveryAttempts records the attempts of a message that was acknowledged after processing, starting at 1.
+	RecordDeliveryAttempts(attempts uint64)
+}
+
+// StateMetrics records how long orchestrations spend in each state. DeliveryMetrics implementing it are notified of
+// each recorded state transition.
+type StateMetrics interface {
+	// ObserveTimeInState records the time an orchestration spent in a state before transitioning out of it.
+	ObserveTimeInState(from OrchestrationState, d time.Duration)
+}
+
+// DeadLetterQueue holds orchestration messages that could not be processed.
+type DeadLetterQueue interface {
+	// RedriveDLQ republishes up to limit dead-lettered messages accepted by the filter to the subject they were
+	// originally received on and removes them from the queue. A nil filter accepts all messages. Returns the number of
+	// messages redriven.
+	RedriveDLQ(ctx context.Context, filter func(data []byte) bool, limit int) (int, error)
+}
+
+// IndexRebuilder reconstructs the orchestration index from the recorded orchestration changes, e.g. after the index
+// was lost or corrupted.
+type IndexRebuilder interface {
+	// RebuildFromEvents replays the recorded changes, folds them into the current state of each orchestration and
+	// writes the result to dst. Entries already in dst are replaced.
+	RebuildFromEvents(ctx context.Context, dst store.EntityStore[*OrchestrationEntry]) error
+}
+
+// Retrier triggers the reprocessing of stuck orchestrations on request of an operator.
+type Retrier interface {
+	// Retry republishes the current state of the orchestration and re-enqueues the pending activities of its current
+	// step so that it is reprocessed promptly instead of after the next redelivery or sweep. Returns
+	// ErrAlreadyTerminal if the orchestration has already completed or errored.
+	Retry(ctx context.Context, id string) error
+}
+
+// EntryValidator validates candidate orchestrations as they are validated before being recorded in the index, without
+// publishing or recording them.
+type EntryValidator interface {
+	// ValidateEntry decodes and validates the serialized orchestration, returning the problems found. The result is
+	// empty if the orchestration is valid.
+	ValidateEntry(ctx context.Context, data []byte) []string
+}
+
+// EntryNotifier notifies of changes recorded to orchestration index entries.
+type EntryNotifier interface {
+	// NotifyEntryChange returns a channel signaled when the entry of the orchestration is recorded, and a function
+	// ending the notifications. Signals are coalesced while not received. Only changes recorded by this runtime are
+	// signaled.
+	NotifyEntryChange(id string) (<-chan struct{}, func())
+}
+
+// Maintenance pauses recording orchestration changes on request of an operator, e.g. while the database is migrated.
+type Maintenance interface {
+	// SetMaintenance enables or disables maintenance mode.
+	SetMaintenance(enabled bool)
+	// Maintenance returns true if maintenance mode is enabled.
+	Maintenance() bool
+}
+
+// TypePauser pauses recording changes of individual orchestration types on request of an operator, e.g. during an
+// incident affecting only their orchestrations.
+type TypePauser interface {
+	// PauseType pauses recording changes of the orchestration type.
+	PauseType(orchestrationType string)
+	// ResumeType resumes recording changes of the orchestration type.
+	ResumeType(orchestrationType string)
+	// PausedTypes returns the paused orchestration types ordered by name.
+	PausedTypes() []string
+}
+
+// Filter selects the orchestrations a bulk operation applies to.
+type Filter struct {
+	// States selects orchestrations in any of the states. At least one state is required.
+	States []OrchestrationState
+	// OlderThan selects orchestrations whose state timestamp precedes it. Not applied if zero.
+	OlderThan time.Time
+	// OrchestrationType selects orchestrations of the type. Not applied if empty.
+	OrchestrationType model.OrchestrationType
+	// Force applies a transition to the selected orchestrations even if the lifecycle does not allow it.
+	Force bool
+}
+
+// Predicate returns the predicate selecting the orchestration entries matching the filter.
+func (f Filter) Predicate() query.Predicate {
+	states := make([]any, 0, len(f.States))
+	for _, state := range f.States {
+		states = append(states, state)
+	}
+	predicates := []query.Predicate{query.In("state", states...)}
+	if !f.OlderThan.IsZero() {
+		predicates = append(predicates, query.Lt("stateTimestamp", f.OlderThan))
+	}
+	if f.OrchestrationType != "" {
+		predicates = append(predicates, query.Eq("orchestrationType", f.OrchestrationType.String()))
+	}
+	return query.And(predicates...)
+}
+
+// BulkTransitioner changes the state of many orchestrations at once on request of an operator, e.g. to fail
+// orchestrations stuck during an incident.
+type BulkTransitioner interface {
+	// BulkTransition moves the orchestrations matching the filter to the given state and returns the result per
+	// orchestration, so that callers can retry the orchestrations that failed. A TransitionAudit recording the reason
+	// is emitted for each transitioned orchestration. Returns types.ErrInvalidInput if the filter selects no state or
+	// the target state, if the reason is empty or, unless forced, if the lifecycle does not allow the transition from a
+	// selected state.
+	BulkTransition(ctx context.Context, filter Filter, to OrchestrationState, reason string) ([]BulkResult, error)
+}
+
+// BulkResult is the outcome of a bulk operation for a single entity.
+type BulkResult struct {
+	ID      string
+	Success bool
+	// Err is the reason the operation failed for the entity, nil on success.
+	Err error
+}
+
+// TemplateRegistry creates orchestrations from named templates to reduce the boilerplate of creating similar
+// orchestrations.
+type TemplateRegistry interface {
+	// Register adds the template under the given name. Returns types.ErrInvalidInput for an empty name or a template
+	// without an orchestration type, and types.ErrConflict if a template with the name is already registered.
+	Register(name string, template Template) error
+
+	// CreateFromTemplate mints an orchestration with the defaults of the named template and the given overrides applied
+	// and publishes its initial state. Returns the index entry of the orchestration, or types.ErrNotFound if no
+	// template is registered under the name.
+	CreateFromTemplate(ctx context.Context, name string, overrides ...TemplateOption) (*OrchestrationEntry, error)
+}
+
+// TransitionAudit records a state transition applied by an operator rather than by processing the orchestration.
+type TransitionAudit struct {
+	OrchestrationID   string                  `json:"orchestrationId"`
+	CorrelationID     string                  `json:"correlationId"`
+	OrchestrationType model.OrchestrationType `json:"orchestrationType"`
+	From              OrchestrationState      `json:"from"`
+	To                OrchestrationState      `json:"to"`
+	Reason            string                  `json:"reason"`
+	Forced            bool                    `json:"forced"`
+	Timestamp         time.Time               `json:"timestamp"`
+}
+
 // ProvisionManager handles orchestration execution and resource management.
 type ProvisionManager interface {
 
@@ -57,6 +239,10 @@ type ProvisionManager interface {
 
 	// CountOrchestrations returns the number of orchestrations matching the given predicate.
 	CountOrchestrations(ctx context.Context, predicate query.Predicate) (int64, error)
+
+	// GetOrchestrationEntry returns the index entry of an orchestration. Returns types.ErrNotFound if the orchestration
+	// has not been recorded in the index.
+	GetOrchestrationEntry(ctx context.Context, orchestrationID string) (*OrchestrationEntry, error)
 }
 
 // Orchestrator manages asynchronous execution of orchestrations.
@@ -0,0 +1,167 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FieldNaming is the naming convention of serialized field names.
+type FieldNaming string
+
+const (
+	// FieldNamingCamelCase uses the field names declared in the json struct tags, e.g. correlationId.
+	FieldNamingCamelCase FieldNaming = "camelCase"
+	// FieldNamingSnakeCase converts the field names declared in the json struct tags to snake case, e.g. correlation_id.
+	FieldNamingSnakeCase FieldNaming = "snake_case"
+)
+
+// ParseFieldNaming converts a configuration value to a FieldNaming. An empty value selects FieldNamingCamelCase.
+func ParseFieldNaming(value string) (FieldNaming, error) {
+	switch strings.ToLower(value) {
+	case "", strings.ToLower(string(FieldNamingCamelCase)):
+		return FieldNamingCamelCase, nil
+	case string(FieldNamingSnakeCase):
+		return FieldNamingSnakeCase, nil
+	default:
+		return "", fmt.Errorf("invalid field naming: %s", value)
+	}
+}
+
+// JSONSerializer serializes structs such as OrchestrationEntry to JSON with a configurable wire format. The zero value
+// produces the same output as json.Marshal.
+//
+// Only the top-level fields of the struct are renamed and omitted; field values, e.g. labels, are serialized unchanged.
+type JSONSerializer struct {
+	// Naming selects the convention of field names.
+	Naming FieldNaming
+	// OmitEmpty omits all fields with empty values. Otherwise, fields are only omitted as declared by their struct tags.
+	OmitEmpty bool
+}
+
+// Marshal serializes the struct or pointer to a struct. Fields are written in declaration order.
+func (s JSONSerializer) Marshal(v any) ([]byte, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return []byte("null"), nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot serialize non-struct type %T", v)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty, omitZero, skip := parseJSONTag(field)
+		if skip {
+			continue
+		}
+		value := val.Field(i)
+		if (s.OmitEmpty || omitEmpty) && isEmptyValue(value) || (s.OmitEmpty || omitZero) && value.IsZero() {
+			continue
+		}
+
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("error serializing field %s: %w", field.Name, err)
+		}
+		key, _ := json.Marshal(s.fieldName(name))
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (s JSONSerializer) fieldName(name string) string {
+	if s.Naming == FieldNamingSnakeCase {
+		return toSnakeCase(name)
+	}
+	return name
+}
+
+// parseJSONTag returns the serialized name of the field and its omit options. skip is true for fields tagged "-".
+func parseJSONTag(field reflect.StructField) (name string, omitEmpty bool, omitZero bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			omitEmpty = true
+		case "omitzero":
+			omitZero = true
+		}
+	}
+	return name, omitEmpty, omitZero, false
+}
+
+// isEmptyValue mirrors the omitempty semantics of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// toSnakeCase converts a camel case name to snake case, keeping acronyms together, e.g. correlationID becomes
+// correlation_id.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,128 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSerializer_DefaultMatchesStructTags(t *testing.T) {
+	for _, entry := range []*OrchestrationEntry{testSerializerEntry(), {ID: "orch-2"}} {
+		expected, err := json.Marshal(entry)
+		require.NoError(t, err)
+
+		data, err := JSONSerializer{}.Marshal(entry)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(data))
+	}
+}
+
+func TestJSONSerializer_Policies(t *testing.T) {
+	entry := testSerializerEntry()
+
+	tests := []struct {
+		name       string
+		serializer JSONSerializer
+		expected   string
+	}{
+		{
+			name:       "camel case",
+			serializer: JSONSerializer{Naming: FieldNamingCamelCase},
+			expected: `{"id":"orch-1","version":0,"correlationId":"corr-1","state":2,` +
+				`"stateTimestamp":"2025-06-01T12:00:00Z","createdTimestamp":"2025-06-01T11:00:00Z",` +
+				`"orchestrationType":"cfm.orchestration.vpa.deploy","labels":{"customerTier":"gold"}}`,
+		},
+		{
+			name:       "snake case",
+			serializer: JSONSerializer{Naming: FieldNamingSnakeCase},
+			expected: `{"id":"orch-1","version":0,"correlation_id":"corr-1","state":2,` +
+				`"state_timestamp":"2025-06-01T12:00:00Z","created_timestamp":"2025-06-01T11:00:00Z",` +
+				`"orchestration_type":"cfm.orchestration.vpa.deploy","labels":{"customerTier":"gold"}}`,
+		},
+		{
+			name:       "camel case omitting empty fields",
+			serializer: JSONSerializer{Naming: FieldNamingCamelCase, OmitEmpty: true},
+			expected: `{"id":"orch-1","correlationId":"corr-1","state":2,` +
+				`"stateTimestamp":"2025-06-01T12:00:00Z","createdTimestamp":"2025-06-01T11:00:00Z",` +
+				`"orchestrationType":"cfm.orchestration.vpa.deploy","labels":{"customerTier":"gold"}}`,
+		},
+		{
+			name:       "snake case omitting empty fields",
+			serializer: JSONSerializer{Naming: FieldNamingSnakeCase, OmitEmpty: true},
+			expected: `{"id":"orch-1","correlation_id":"corr-1","state":2,` +
+				`"state_timestamp":"2025-06-01T12:00:00Z","created_timestamp":"2025-06-01T11:00:00Z",` +
+				`"orchestration_type":"cfm.orchestration.vpa.deploy","labels":{"customerTier":"gold"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.serializer.Marshal(entry)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestJSONSerializer_NonStruct(t *testing.T) {
+	_, err := JSONSerializer{}.Marshal("entry")
+	assert.Error(t, err)
+
+	data, err := JSONSerializer{}.Marshal((*OrchestrationEntry)(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+}
+
+func TestParseFieldNaming(t *testing.T) {
+	for value, expected := range map[string]FieldNaming{
+		"":           FieldNamingCamelCase,
+		"camelCase":  FieldNamingCamelCase,
+		"camelcase":  FieldNamingCamelCase,
+		"snake_case": FieldNamingSnakeCase,
+	} {
+		naming, err := ParseFieldNaming(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, naming)
+	}
+	_, err := ParseFieldNaming("kebab-case")
+	assert.Error(t, err)
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"id":                "id",
+		"correlationId":     "correlation_id",
+		"orchestrationType": "orchestration_type",
+		"correlationID":     "correlation_id",
+		"IDValue":           "id_value",
+		"step2Value":        "step2_value",
+	} {
+		assert.Equal(t, expected, toSnakeCase(name))
+	}
+}
+
+func testSerializerEntry() *OrchestrationEntry {
+	return &OrchestrationEntry{
+		ID:                "orch-1",
+		CorrelationID:     "corr-1",
+		State:             OrchestrationStateCompleted,
+		StateTimestamp:    time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		CreatedTimestamp:  time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC),
+		OrchestrationType: "cfm.orchestration.vpa.deploy",
+		Labels:            map[string]string{"customerTier": "gold"},
+	}
+}
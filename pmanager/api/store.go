@@ -13,17 +13,26 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"iter"
+	"maps"
 	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/model"
 	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
 	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
 )
 
 const (
 	OrchestrationIndexKey system.ServiceType = "pmstore:OrchestrationIndex"
+	OutboxStoreKey        system.ServiceType = "pmstore:OutboxStore"
+	RawPayloadStoreKey    system.ServiceType = "pmstore:RawPayloadStore"
 )
 
 // DefinitionStore manages OrchestrationDefinition and ActivityDefinitions.
@@ -70,6 +79,163 @@ type DefinitionStore interface {
 	ListActivityDefinitions(ctx context.Context) ([]ActivityDefinition, error)
 }
 
+// OrchestrationIndex provides query access to orchestration entries.
+type OrchestrationIndex interface {
+	store.EntityStore[*OrchestrationEntry]
+
+	// List returns up to limit entries matching the predicate (or all entries if the predicate is nil), ordered by
+	// (StateTimestamp, ID) and starting after the given cursor. An empty cursor starts from the beginning. The returned
+	// cursor is opaque and empty when no more entries remain. Malformed cursors return types.ErrInvalidInput.
+	List(ctx context.Context, predicate query.Predicate, cursor string, limit int) ([]*OrchestrationEntry, string, error)
+
+	// FindStateByID returns the state and version of the entry with the given ID without loading the entire entry.
+	// Returns types.ErrNotFound if the entry does not exist.
+	FindStateByID(ctx context.Context, id string) (OrchestrationState, int64, error)
+
+	// FindByTimeRange returns up to limit entries whose timestamp selected by field is within [from, to), ordered by
+	// that timestamp and the ID. Returns types.ErrInvalidInput for an unknown field, an empty range or a non-positive
+	// limit.
+	FindByTimeRange(ctx context.Context, field TimeField, from, to time.Time, limit int) ([]*OrchestrationEntry, error)
+
+	// FindByLabel returns up to limit entries having the label key set to value, ordered by (StateTimestamp, ID).
+	// Returns types.ErrInvalidInput for an empty key or a non-positive limit.
+	FindByLabel(ctx context.Context, key, value string, limit int) ([]*OrchestrationEntry, error)
+
+	// ClaimNext atomically claims the entry in the given state with the oldest state timestamp for workerID until the
+	// lease elapses, so that the index can be used as a work queue. Entries claimed by other workers are skipped until
+	// their lease expires. Returns the claimed entry or types.ErrNotFound if no entry can be claimed, and
+	// types.ErrInvalidInput for an empty worker ID or a non-positive lease.
+	ClaimNext(ctx context.Context, state OrchestrationState, workerID string, lease time.Duration) (*OrchestrationEntry, error)
+}
+
+// ValidateClaim checks the arguments of OrchestrationIndex.ClaimNext.
+func ValidateClaim(workerID string, lease time.Duration) error {
+	if workerID == "" {
+		return fmt.Errorf("%w: worker ID must not be empty", types.ErrInvalidInput)
+	}
+	if lease <= 0 {
+		return fmt.Errorf("%w: lease must be positive", types.ErrInvalidInput)
+	}
+	return nil
+}
+
+// OutboxMessage is a message recorded in the transaction of the state change that emits it and published afterward.
+type OutboxMessage struct {
+	ID               string    `json:"id"`
+	Subject          string    `json:"subject"`
+	Payload          []byte    `json:"payload"`
+	CreatedTimestamp time.Time `json:"createdTimestamp"`
+}
+
+// OutboxStore records messages to publish within store transactions. Since a message is only recorded if the
+// transaction commits, state changes and the messages they emit are atomic.
+type OutboxStore interface {
+
+	// Add records the message. It must be called in the transaction performing the state change.
+	Add(ctx context.Context, message *OutboxMessage) error
+
+	// FindPending returns up to limit messages that have not been sent, in the order they were added. Returns
+	// types.ErrInvalidInput for a non-positive limit.
+	FindPending(ctx context.Context, limit int) ([]*OutboxMessage, error)
+
+	// MarkSent marks the message as sent and removes it, so that it is not published again and sent messages do not
+	// accumulate. Returns types.ErrNotFound if the message does not exist.
+	MarkSent(ctx context.Context, id string) error
+}
+
+// RawPayload is the raw message that produced a revision of an orchestration entry, see OrchestrationEntry.Revision. It
+// includes fields dropped when the message is decoded.
+type RawPayload struct {
+	ID               string    `json:"id"`
+	Revision         uint64    `json:"revision"`
+	Payload          []byte    `json:"payload"`
+	CreatedTimestamp time.Time `json:"createdTimestamp"`
+}
+
+// RawPayloadStore records the raw messages producing orchestration entry changes for audit. Payloads are keyed by the
+// entry ID and revision.
+type RawPayloadStore interface {
+
+	// Save records the payload, replacing a payload recorded for the same entry revision. It must be called in the
+	// transaction recording the change.
+	Save(ctx context.Context, payload *RawPayload) error
+
+	// Find returns the payload that produced the given revision of the entry. Returns types.ErrNotFound if no payload
+	// was recorded.
+	Find(ctx context.Context, id string, revision uint64) (*RawPayload, error)
+}
+
+// CheckUniqueCorrelation returns types.ErrAlreadyExists if the index holds an orchestration other than the entry with
+// the same correlation ID and orchestration type. It must be called in the transaction creating the entry.
+func CheckUniqueCorrelation(ctx context.Context, index store.EntityStore[*OrchestrationEntry], entry *OrchestrationEntry) error {
+	predicate := query.And(
+		query.Eq("correlationId", entry.CorrelationID),
+		query.Eq("orchestrationType", entry.OrchestrationType.String()),
+		query.Neq("id", entry.ID))
+	existing, err := index.FindFirstByPredicate(ctx, predicate)
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to lookup orchestrations correlated with %s: %w", entry.ID, err)
+	}
+	return fmt.Errorf("%w: orchestration %s of type %s has correlation ID %s", types.ErrAlreadyExists, existing.ID,
+		entry.OrchestrationType, entry.CorrelationID)
+}
+
+// CreateBatch creates the entries, each in its own transaction so that an entry failing, e.g. because it already exists,
+// does not roll back the others. Returns the result per entry in the order of the entries.
+func CreateBatch(
+	ctx context.Context,
+	trxContext store.TransactionContext,
+	index store.EntityStore[*OrchestrationEntry],
+	entries []*OrchestrationEntry) []BulkResult {
+	results := make([]BulkResult, 0, len(entries))
+	for _, entry := range entries {
+		err := trxContext.Execute(ctx, func(ctx context.Context) error {
+			_, err := index.Create(ctx, entry)
+			return err
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to create orchestration entry %s: %w", entry.ID, err)
+		}
+		results = append(results, BulkResult{ID: entry.ID, Success: err == nil, Err: err})
+	}
+	return results
+}
+
+// TimeField selects the timestamp of an orchestration entry used for time range searches.
+type TimeField string
+
+const (
+	TimeFieldCreated TimeField = "createdTimestamp"
+	TimeFieldState   TimeField = "stateTimestamp"
+)
+
+// TimeRangeQuery returns the predicate selecting entries within [from, to) for the time field and the key function
+// ordering entries by it.
+func TimeRangeQuery(
+	field TimeField,
+	from time.Time,
+	to time.Time) (query.Predicate, store.CursorKeyFunc[*OrchestrationEntry], error) {
+	var keyFn store.CursorKeyFunc[*OrchestrationEntry]
+	switch field {
+	case TimeFieldCreated:
+		keyFn = func(entry *OrchestrationEntry) store.Cursor {
+			return store.Cursor{Timestamp: entry.CreatedTimestamp, ID: entry.ID}
+		}
+	case TimeFieldState:
+		keyFn = OrchestrationEntryCursor
+	default:
+		return nil, nil, fmt.Errorf("%w: invalid time field %s", types.ErrInvalidInput, field)
+	}
+	if !from.Before(to) {
+		return nil, nil, fmt.Errorf("%w: time range start must be before its end", types.ErrInvalidInput)
+	}
+	predicate := query.And(query.Gte(query.Field(field), from), query.Lt(query.Field(field), to))
+	return predicate, keyFn, nil
+}
+
 type OrchestrationEntry struct {
 	ID                string                  `json:"id"`
 	Version           int64                   `json:"version"`
@@ -78,6 +244,29 @@ type OrchestrationEntry struct {
 	StateTimestamp    time.Time               `json:"stateTimestamp"`
 	CreatedTimestamp  time.Time               `json:"createdTimestamp"`
 	OrchestrationType model.OrchestrationType `json:"orchestrationType"`
+	Deadline          time.Time               `json:"deadline,omitzero"`
+	// Checkpoint holds step data persisted so that processing resumes where it left off after a restart.
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+	// Labels holds operator-defined metadata, e.g. the region or customer tier, used to filter orchestrations.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Mode selects the handler variant processing the entry, e.g. an experimental code path. Empty for the default.
+	Mode string `json:"mode,omitempty"`
+	// Revision is the monotonic sequence of the orchestration change the entry was recorded from, zero if unknown.
+	Revision uint64 `json:"revision,omitempty"`
+	// ClaimedBy is the worker holding a claim on the entry, see OrchestrationIndex.ClaimNext. Empty if not claimed.
+	ClaimedBy string `json:"claimedBy,omitempty"`
+	// LeaseExpiry is the time the claim expires, after which the entry can be claimed again.
+	LeaseExpiry time.Time `json:"leaseExpiry,omitzero"`
+	// RetryPolicy overrides the retry defaults of the watcher for the orchestration. Nil for the defaults.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// PendingState is a state awaiting confirmation by a downstream acknowledgement. It is promoted to State once
+	// confirmed and discarded at PendingExpiry otherwise, leaving State unchanged. Nil if no state is pending.
+	PendingState  *OrchestrationState `json:"pendingState,omitempty"`
+	PendingExpiry time.Time           `json:"pendingExpiry,omitzero"`
+	// FailureReason classifies the error the orchestration failed with once the watcher recorded it as errored.
+	FailureReason FailureReason `json:"failureReason,omitempty"`
+	// RemediationHint guides operators in resolving the failure, empty if no guidance is available.
+	RemediationHint string `json:"remediationHint,omitempty"`
 }
 
 func (o *OrchestrationEntry) GetID() string {
@@ -91,3 +280,71 @@ func (o *OrchestrationEntry) GetVersion() int64 {
 func (o *OrchestrationEntry) IncrementVersion() {
 	o.Version++
 }
+
+// Clone returns a deep copy of the entry that can be modified without affecting the entry, e.g. when handing recorded
+// entries to subscribers or projectors.
+func (o *OrchestrationEntry) Clone() *OrchestrationEntry {
+	clone := *o
+	clone.Checkpoint = bytes.Clone(o.Checkpoint)
+	clone.Labels = maps.Clone(o.Labels)
+	if o.RetryPolicy != nil {
+		policy := *o.RetryPolicy
+		clone.RetryPolicy = &policy
+	}
+	if o.PendingState != nil {
+		pending := *o.PendingState
+		clone.PendingState = &pending
+	}
+	return &clone
+}
+
+// Expired returns true if the entry has a deadline that has passed and it is still in an intermediate state.
+func (o *OrchestrationEntry) Expired(now time.Time) bool {
+	return isExpired(o.State, o.Deadline, now)
+}
+
+// Pending returns true if the entry has a pending state that has not expired.
+func (o *OrchestrationEntry) Pending(now time.Time) bool {
+	return o.PendingState != nil && now.Before(o.PendingExpiry)
+}
+
+// Claimable returns true if the entry is not claimed or its lease has expired.
+func (o *OrchestrationEntry) Claimable(now time.Time) bool {
+	return o.ClaimedBy == "" || !now.Before(o.LeaseExpiry)
+}
+
+// SaveCheckpoint serializes data and stores it as the checkpoint of the orchestration entry. It should be called in
+// the transaction processing the step so that the checkpoint is committed together with the step.
+func SaveCheckpoint(ctx context.Context, index store.EntityStore[*OrchestrationEntry], id string, data any) error {
+	checkpoint, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error serializing checkpoint for orchestration %s: %w", id, err)
+	}
+	entry, err := index.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	entry.Checkpoint = checkpoint
+	return index.Update(ctx, entry)
+}
+
+// LoadCheckpoint deserializes the checkpoint of the orchestration entry into v. Returns false if no checkpoint was
+// saved.
+func LoadCheckpoint(ctx context.Context, index store.EntityStore[*OrchestrationEntry], id string, v any) (bool, error) {
+	entry, err := index.FindByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if len(entry.Checkpoint) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(entry.Checkpoint, v); err != nil {
+		return false, fmt.Errorf("error deserializing checkpoint for orchestration %s: %w", id, err)
+	}
+	return true, nil
+}
+
+// OrchestrationEntryCursor returns the keyset pagination position of the entry.
+func OrchestrationEntryCursor(entry *OrchestrationEntry) store.Cursor {
+	return store.Cursor{Timestamp: entry.StateTimestamp, ID: entry.ID}
+}
@@ -0,0 +1,59 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrchestrationEntry_Clone(t *testing.T) {
+	pending := OrchestrationStateCompleted
+	original := &OrchestrationEntry{
+		ID:                "orch-1",
+		Version:           2,
+		State:             OrchestrationStateRunning,
+		StateTimestamp:    time.Now(),
+		Checkpoint:        json.RawMessage(`{"step":"deploy"}`),
+		Labels:            map[string]string{"region": "eu"},
+		OrchestrationType: "cfm.provision",
+		RetryPolicy:       &RetryPolicy{MaxAttempts: 2},
+		PendingState:      &pending,
+	}
+
+	clone := original.Clone()
+	assert.Equal(t, original, clone)
+
+	clone.Labels["region"] = "us"
+	clone.Labels["tier"] = "gold"
+	clone.Checkpoint[2] = 'X'
+	clone.State = OrchestrationStateCompleted
+	clone.RetryPolicy.MaxAttempts = 5
+	*clone.PendingState = OrchestrationStateErrored
+
+	assert.Equal(t, map[string]string{"region": "eu"}, original.Labels)
+	assert.Equal(t, 2, original.RetryPolicy.MaxAttempts)
+	assert.JSONEq(t, `{"step":"deploy"}`, string(original.Checkpoint))
+	assert.Equal(t, OrchestrationStateRunning, original.State)
+	assert.Equal(t, OrchestrationStateCompleted, *original.PendingState)
+}
+
+func TestOrchestrationEntry_Clone_NilFields(t *testing.T) {
+	clone := (&OrchestrationEntry{ID: "orch-1"}).Clone()
+
+	assert.Nil(t, clone.Labels)
+	assert.Nil(t, clone.Checkpoint)
+}
@@ -0,0 +1,84 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"maps"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+)
+
+// Template defines the defaults of orchestrations created from it, see TemplateRegistry.
+type Template struct {
+	OrchestrationType model.OrchestrationType
+	Labels            map[string]string
+	InitialState      OrchestrationState
+	// StepCount is the number of execution steps the orchestration is created with.
+	StepCount int
+}
+
+// TemplateOption overrides a default of the template an orchestration is created from.
+type TemplateOption func(*Orchestration)
+
+// WithTemplateID sets the orchestration ID instead of generating one.
+func WithTemplateID(id string) TemplateOption {
+	return func(o *Orchestration) {
+		o.ID = id
+	}
+}
+
+// WithTemplateCorrelationID sets the correlation ID, which defaults to the orchestration ID.
+func WithTemplateCorrelationID(correlationID string) TemplateOption {
+	return func(o *Orchestration) {
+		o.CorrelationID = correlationID
+	}
+}
+
+// WithTemplateLabel sets a label in addition to or replacing a label of the template.
+func WithTemplateLabel(key string, value string) TemplateOption {
+	return func(o *Orchestration) {
+		if o.Labels == nil {
+			o.Labels = make(map[string]string)
+		}
+		o.Labels[key] = value
+	}
+}
+
+// WithTemplateState sets the initial state instead of the state of the template.
+func WithTemplateState(state OrchestrationState) TemplateOption {
+	return func(o *Orchestration) {
+		o.State = state
+	}
+}
+
+// WithTemplateDeadline sets the deadline of the orchestration.
+func WithTemplateDeadline(deadline time.Time) TemplateOption {
+	return func(o *Orchestration) {
+		o.Deadline = deadline
+	}
+}
+
+// NewOrchestration mints an orchestration with the defaults of the template. Labels are copied so that overrides do not
+// modify the template.
+func (t Template) NewOrchestration(id string, now time.Time) *Orchestration {
+	return &Orchestration{
+		ID:                id,
+		State:             t.InitialState,
+		StateTimestamp:    now,
+		CreatedTimestamp:  now,
+		OrchestrationType: t.OrchestrationType,
+		Steps:             make([]OrchestrationStep, t.StepCount),
+		Labels:            maps.Clone(t.Labels),
+	}
+}
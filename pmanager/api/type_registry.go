@@ -0,0 +1,145 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+)
+
+const TypeRegistryKey system.ServiceType = "pmapi:TypeRegistry"
+
+// DefaultTerminalStates are the states no further changes are recorded for unless configured otherwise for a type.
+var DefaultTerminalStates = []OrchestrationState{OrchestrationStateCompleted, OrchestrationStateErrored}
+
+// lifecycle is the transition graph of the orchestration lifecycle. Orchestrations that expire before completing are
+// compensated.
+var lifecycle = map[OrchestrationState][]OrchestrationState{
+	OrchestrationStateInitialized: {
+		OrchestrationStateRunning,
+		OrchestrationStateCompleted,
+		OrchestrationStateErrored,
+		OrchestrationStateCompensating,
+	},
+	OrchestrationStateRunning: {
+		OrchestrationStateCompleted,
+		OrchestrationStateErrored,
+		OrchestrationStateCompensating,
+	},
+	OrchestrationStateCompensating: {
+		OrchestrationStateCompleted,
+		OrchestrationStateErrored,
+	},
+}
+
+var stateNames = map[string]OrchestrationState{
+	"initialized":  OrchestrationStateInitialized,
+	"running":      OrchestrationStateRunning,
+	"completed":    OrchestrationStateCompleted,
+	"errored":      OrchestrationStateErrored,
+	"compensating": OrchestrationStateCompensating,
+}
+
+// CanTransition returns true if the orchestration lifecycle allows the transition between the states.
+func CanTransition(from OrchestrationState, to OrchestrationState) bool {
+	return slices.Contains(lifecycle[from], to)
+}
+
+// ParseOrchestrationState converts a case-insensitive state name, e.g. "completed", to an OrchestrationState.
+func ParseOrchestrationState(name string) (OrchestrationState, error) {
+	state, found := stateNames[strings.ToLower(name)]
+	if !found {
+		return 0, fmt.Errorf("%w: invalid orchestration state: %s", types.ErrInvalidInput, name)
+	}
+	return state, nil
+}
+
+// TypeDescriptor describes an orchestration type with its terminal states and the state transitions allowed for it.
+// Transitions maps a state to the states that may follow it, terminal states have no transitions.
+type TypeDescriptor struct {
+	Name           model.OrchestrationType                     `json:"name"`
+	TerminalStates []OrchestrationState                        `json:"terminalStates"`
+	Transitions    map[OrchestrationState][]OrchestrationState `json:"transitions"`
+}
+
+// TypeRegistry holds the orchestration types known at runtime so that they can be enumerated, e.g. by UIs or to
+// validate requests.
+type TypeRegistry struct {
+	mu    sync.RWMutex
+	types map[model.OrchestrationType]TypeDescriptor
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: make(map[model.OrchestrationType]TypeDescriptor)}
+}
+
+// Register adds or replaces an orchestration type. If no terminal states are given, DefaultTerminalStates apply.
+func (r *TypeRegistry) Register(name model.OrchestrationType, terminalStates ...OrchestrationState) error {
+	if name == "" {
+		return fmt.Errorf("%w: orchestration type name is missing", types.ErrInvalidInput)
+	}
+	if len(terminalStates) == 0 {
+		terminalStates = DefaultTerminalStates
+	}
+	for _, state := range terminalStates {
+		if state > OrchestrationStateCompensating {
+			return fmt.Errorf("%w: invalid terminal state %d for orchestration type %s", types.ErrInvalidInput, state, name)
+		}
+	}
+	terminalStates = slices.Clone(terminalStates)
+	slices.Sort(terminalStates)
+	terminalStates = slices.Compact(terminalStates)
+
+	transitions := make(map[OrchestrationState][]OrchestrationState, len(lifecycle))
+	for state, next := range lifecycle {
+		if !slices.Contains(terminalStates, state) {
+			transitions[state] = slices.Clone(next)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[name] = TypeDescriptor{Name: name, TerminalStates: terminalStates, Transitions: transitions}
+	return nil
+}
+
+// Describe returns the descriptors of all registered types ordered by name.
+func (r *TypeRegistry) Describe() []TypeDescriptor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	descriptors := make([]TypeDescriptor, 0, len(r.types))
+	for _, name := range slices.Sorted(maps.Keys(r.types)) {
+		descriptor := r.types[name]
+		descriptors = append(descriptors, TypeDescriptor{
+			Name:           descriptor.Name,
+			TerminalStates: slices.Clone(descriptor.TerminalStates),
+			Transitions:    cloneTransitions(descriptor.Transitions),
+		})
+	}
+	return descriptors
+}
+
+func cloneTransitions(transitions map[OrchestrationState][]OrchestrationState) map[OrchestrationState][]OrchestrationState {
+	cloned := make(map[OrchestrationState][]OrchestrationState, len(transitions))
+	for state, next := range transitions {
+		cloned[state] = slices.Clone(next)
+	}
+	return cloned
+}
@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package api
+
+import (
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeRegistry_Describe(t *testing.T) {
+	registry := NewTypeRegistry()
+	require.NoError(t, registry.Register("cfm.provision"))
+	require.NoError(t, registry.Register("cfm.compensate", OrchestrationStateCompleted, OrchestrationStateCompensating))
+
+	descriptors := registry.Describe()
+
+	require.Len(t, descriptors, 2)
+	compensate := descriptors[0]
+	assert.Equal(t, model.OrchestrationType("cfm.compensate"), compensate.Name)
+	assert.Equal(t, []OrchestrationState{OrchestrationStateCompleted, OrchestrationStateCompensating}, compensate.TerminalStates)
+	assert.NotContains(t, compensate.Transitions, OrchestrationStateCompensating, "terminal states have no transitions")
+	assert.Equal(t, []OrchestrationState{OrchestrationStateCompleted, OrchestrationStateErrored, OrchestrationStateCompensating},
+		compensate.Transitions[OrchestrationStateRunning])
+
+	provision := descriptors[1]
+	assert.Equal(t, model.OrchestrationType("cfm.provision"), provision.Name)
+	assert.Equal(t, DefaultTerminalStates, provision.TerminalStates)
+	assert.Equal(t, []OrchestrationState{OrchestrationStateCompleted, OrchestrationStateErrored},
+		provision.Transitions[OrchestrationStateCompensating])
+	assert.NotContains(t, provision.Transitions, OrchestrationStateCompleted)
+}
+
+func TestTypeRegistry_DescribeReturnsCopies(t *testing.T) {
+	registry := NewTypeRegistry()
+	require.NoError(t, registry.Register("cfm.provision"))
+
+	registry.Describe()[0].TerminalStates[0] = OrchestrationStateRunning
+
+	assert.Equal(t, DefaultTerminalStates, registry.Describe()[0].TerminalStates)
+}
+
+func TestTypeRegistry_RegisterInvalid(t *testing.T) {
+	registry := NewTypeRegistry()
+
+	assert.ErrorIs(t, registry.Register(""), types.ErrInvalidInput)
+	assert.ErrorIs(t, registry.Register("cfm.provision", OrchestrationState(42)), types.ErrInvalidInput)
+	assert.Empty(t, registry.Describe())
+}
+
+func TestParseOrchestrationState(t *testing.T) {
+	state, err := ParseOrchestrationState("Compensating")
+	require.NoError(t, err)
+	assert.Equal(t, OrchestrationStateCompensating, state)
+
+	_, err = ParseOrchestrationState("bogus")
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
@@ -26,10 +26,27 @@ import (
 type OrchestrationState uint
 
 const (
-	OrchestrationStateInitialized OrchestrationState = 0
-	OrchestrationStateRunning     OrchestrationState = 1
-	OrchestrationStateCompleted   OrchestrationState = 2
-	OrchestrationStateErrored     OrchestrationState = 3
+	OrchestrationStateInitialized  OrchestrationState = 0
+	OrchestrationStateRunning      OrchestrationState = 1
+	OrchestrationStateCompleted    OrchestrationState = 2
+	OrchestrationStateErrored      OrchestrationState = 3
+	OrchestrationStateCompensating OrchestrationState = 4
+)
+
+// FailureReason classifies the error an orchestration failed with so that operators can act on it.
+type FailureReason string
+
+const (
+	// FailureReasonTimeout indicates that recording the orchestration exceeded its processing timeout.
+	FailureReasonTimeout FailureReason = "ProcessingTimeout"
+	// FailureReasonPoolExhausted indicates that no store connection was available.
+	FailureReasonPoolExhausted FailureReason = "StorePoolExhausted"
+	// FailureReasonThrottled indicates that the store rejected requests because of a rate limit.
+	FailureReasonThrottled FailureReason = "StoreThrottled"
+	// FailureReasonConflict indicates that the entry was modified concurrently on every attempt.
+	FailureReasonConflict FailureReason = "StoreConflict"
+	// FailureReasonStore indicates any other store error.
+	FailureReasonStore FailureReason = "StoreError"
 )
 
 // Orchestration is a collection of activities that are executed to allocate resources in the system. Activities are
@@ -47,6 +64,41 @@ type Orchestration struct {
 	ProcessingData    map[string]any          `json:"processingData"`
 	OutputData        map[string]any          `json:"outputData"`
 	Completed         map[string]struct{}     `json:"completed"`
+	Deadline          time.Time               `json:"deadline,omitzero"`
+	Labels            map[string]string       `json:"labels,omitempty"`
+	Mode              string                  `json:"mode,omitempty"`
+	RetryPolicy       *RetryPolicy            `json:"retryPolicy,omitempty"`
+	// ManualRetries counts the retries triggered by operators, see Retrier.
+	ManualRetries int `json:"manualRetries,omitempty"`
+	// MinConsumerVersion is the lowest watcher version able to process the orchestration, stamped by publishers that
+	// write fields older watchers do not understand. Zero if any version can process it.
+	MinConsumerVersion int `json:"minConsumerVersion,omitempty"`
+	// Expiry is the time after which the change is obsolete and dropped by the watcher instead of recorded, e.g. a
+	// command that sat in the stream during a long outage. It applies to a single change, writers of later changes
+	// reset it. Zero if the change does not expire.
+	Expiry time.Time `json:"expiry,omitzero"`
+}
+
+// RetryPolicy overrides the retry defaults of the watcher for a single orchestration, e.g. to give up early on
+// orchestrations that cannot tolerate long retries. Zero fields fall back to the defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the number of consecutive failures recording a change after which the orchestration is errored.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// BackoffBase is the initial redelivery delay, doubled with every attempt.
+	BackoffBase time.Duration `json:"backoffBase,omitempty"`
+}
+
+// Expired returns true if the orchestration has a deadline that has passed and it is still in an intermediate state.
+func (o *Orchestration) Expired(now time.Time) bool {
+	return isExpired(o.State, o.Deadline, now)
+}
+
+// isExpired returns true if a deadline is set, has passed and the state is not yet terminal or compensating.
+func isExpired(state OrchestrationState, deadline time.Time, now time.Time) bool {
+	if deadline.IsZero() || !now.After(deadline) {
+		return false
+	}
+	return state == OrchestrationStateInitialized || state == OrchestrationStateRunning
 }
 
 func (o *Orchestration) SetState(state OrchestrationState) {
@@ -128,6 +180,15 @@ type ActivityMessage struct {
 	Activity        Activity `json:"activity"`
 }
 
+// CompensationMessage is emitted when an orchestration misses its deadline and the resources it allocated must be
+// cleaned up.
+type CompensationMessage struct {
+	OrchestrationID   string                  `json:"orchestrationID"`
+	CorrelationID     string                  `json:"correlationId"`
+	OrchestrationType model.OrchestrationType `json:"orchestrationType"`
+	Deadline          time.Time               `json:"deadline"`
+}
+
 type MappingEntry struct {
 	Source string `json:"source"`
 	Target string `json:"target"`
@@ -65,12 +65,30 @@ func generateOrchestrationEndpoints(r spec.Generator) {
 		option.Response(http.StatusOK, []v1alpha1.OrchestrationEntry{}),
 	)
 
+	orchestrations.Post("validate",
+		option.Summary("Validate an Orchestration"),
+		option.Description("Validate a candidate Orchestration as it is validated before being recorded, without "+
+			"publishing or recording it. Returns the problems found, which are empty if the Orchestration is valid."),
+		option.Request(v1alpha1.Orchestration{}),
+		option.Response(http.StatusOK, ValidationResponse{}),
+	)
+
 	orchestrations.Get("/{id}",
 		option.Summary("Get an Orchestration"),
 		option.Description("Retrieve an Orchestration by ID"),
 		option.Request(new(IDParam)),
 		option.Response(http.StatusOK, v1alpha1.Orchestration{}),
 	)
+
+	orchestrations.Get("/{id}/entry",
+		option.Summary("Get an Orchestration Entry"),
+		option.Description("Retrieve the index entry of an Orchestration by ID, tagged with a hash of its content as ETag. "+
+			"Returns 304 if the If-None-Match header matches the current ETag. The wait parameter holds the request "+
+			"until the entry changes or the wait elapses."),
+		option.Request(new(EntryParams)),
+		option.Response(http.StatusOK, v1alpha1.OrchestrationEntry{}),
+		option.Response(http.StatusNotModified, nil),
+	)
 }
 
 func generateActivityDefinitionEndpoints(r spec.Generator) {
@@ -127,3 +145,14 @@ type TypeParam struct {
 type IDParam struct {
 	ID string `path:"id" required:"true"`
 }
+
+type EntryParams struct {
+	ID          string `path:"id" required:"true"`
+	Wait        string `query:"wait" description:"Maximum time to wait for a change, e.g. 30s"`
+	IfNoneMatch string `header:"If-None-Match"`
+}
+
+type ValidationResponse struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
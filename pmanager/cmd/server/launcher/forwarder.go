@@ -0,0 +1,24 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build !kafka
+
+package launcher
+
+import (
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/spf13/viper"
+)
+
+// registerStateForwarder is a no-op, state forwarders are only included when building with the kafka tag.
+func registerStateForwarder(*system.ServiceAssembler, *viper.Viper) {
+}
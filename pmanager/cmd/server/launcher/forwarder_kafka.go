@@ -0,0 +1,30 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build kafka
+
+package launcher
+
+import (
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/kafkaforwarder"
+	"github.com/spf13/viper"
+)
+
+const kafkaKey = "kafka"
+
+// registerStateForwarder mirrors orchestration state changes to Kafka when it is configured.
+func registerStateForwarder(assembler *system.ServiceAssembler, vConfig *viper.Viper) {
+	if vConfig.IsSet(kafkaKey) {
+		assembler.Register(&kafkaforwarder.KafkaForwarderServiceAssembly{})
+	}
+}
@@ -73,6 +73,8 @@ func (p provisionManager) Start(ctx context.Context, manifest *model.Orchestrati
 		if err != nil {
 			return types.NewFatalWrappedError(err, "error instantiating orchestration for %s", manifestID)
 		}
+		orch.Deadline = manifest.Deadline
+		orch.Labels = manifest.Labels
 		err = p.orchestrator.Execute(ctx, orch)
 		if err != nil {
 			return types.NewFatalWrappedError(err, "error executing orchestration %s for %s", orch.ID, manifestID)
@@ -123,3 +125,13 @@ func (p provisionManager) CountOrchestrations(ctx context.Context, predicate que
 	})
 	return count, err
 }
+
+func (p provisionManager) GetOrchestrationEntry(ctx context.Context, orchestrationID string) (*api.OrchestrationEntry, error) {
+	var entry *api.OrchestrationEntry
+	err := p.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		entry, err = p.index.FindByID(ctx, orchestrationID)
+		return err
+	})
+	return entry, err
+}
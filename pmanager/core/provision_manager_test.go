@@ -623,3 +623,28 @@ func createTestOrchestrationDefinition(orchestrationType string) *api.Orchestrat
 		},
 	}
 }
+
+// TestGetOrchestrationEntry tests reading an entry from the index
+func TestGetOrchestrationEntry(t *testing.T) {
+	ctx := context.Background()
+	mockEntityStore := cmocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	trxContext := store.NoOpTransactionContext{}
+
+	mockEntityStore.On("FindByID", ctx, "orch-1").
+		Return(&api.OrchestrationEntry{ID: "orch-1", Version: 2}, nil)
+	mockEntityStore.On("FindByID", ctx, "missing").
+		Return(nil, types.ErrNotFound)
+
+	pm := &provisionManager{
+		index:      mockEntityStore,
+		trxContext: trxContext,
+	}
+
+	entry, err := pm.GetOrchestrationEntry(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), entry.Version)
+
+	_, err = pm.GetOrchestrationEntry(ctx, "missing")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+	mockEntityStore.AssertExpectations(t)
+}
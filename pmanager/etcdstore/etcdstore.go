@@ -0,0 +1,460 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build etcd
+
+// Package etcdstore provides a store.EntityStore backed by etcd for deployments already running an etcd cluster. It is
+// only built with the etcd build tag, which requires the go.etcd.io/etcd/client/v3 module.
+package etcdstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// maxTxnOps is the number of operations batched into a single transaction, below the default server limit of 128.
+const maxTxnOps = 100
+
+// StateFunc returns the state of an entity used to maintain the state index.
+type StateFunc[T store.EntityType] func(entity T) string
+
+// NewEtcdEntityStore creates a store keeping each entity as a JSON value under prefix followed by the entity ID. Locks
+// and the state index are kept under sibling prefixes, e.g. /cfm/orchestrations-locks/ and /cfm/orchestrations-states/
+// for the prefix /cfm/orchestrations/. The state index is not maintained if state is nil.
+func NewEtcdEntityStore[T store.EntityType](client *clientv3.Client, prefix string, state StateFunc[T]) *EtcdEntityStore[T] {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	base := strings.TrimSuffix(prefix, "/")
+	return &EtcdEntityStore[T]{
+		client:      client,
+		prefix:      prefix,
+		lockPrefix:  base + "-locks/",
+		statePrefix: base + "-states/",
+		state:       state,
+		matcher:     &query.DefaultFieldMatcher{},
+	}
+}
+
+// EtcdEntityStore implements store.EntityStore using one etcd key per entity. Updates use the mod revision of the key
+// for optimistic concurrency: an update fails with types.ErrVersionConflict if the key is modified concurrently.
+// Predicates are evaluated in memory over all entities under the prefix. FindByState reads the state index instead,
+// which holds an empty key <state prefix><state>/<id> per entity and is written in the transaction of the entity.
+//
+// etcd operations are not enlisted in a store.TransactionContext, each operation is applied atomically on its own.
+type EtcdEntityStore[T store.EntityType] struct {
+	client      *clientv3.Client
+	prefix      string
+	lockPrefix  string
+	statePrefix string
+	state       StateFunc[T]
+	matcher     query.FieldMatcher
+}
+
+func (s *EtcdEntityStore[T]) FindByID(ctx context.Context, id string) (T, error) {
+	entity, _, err := s.get(ctx, id)
+	return entity, err
+}
+
+func (s *EtcdEntityStore[T]) Exists(ctx context.Context, id string) (bool, error) {
+	resp, err := s.client.Get(ctx, s.key(id), clientv3.WithCountOnly())
+	if err != nil {
+		return false, fmt.Errorf("failed to check entity existence: %w", err)
+	}
+	return resp.Count > 0, nil
+}
+
+func (s *EtcdEntityStore[T]) Create(ctx context.Context, entity T) (T, error) {
+	if entity.GetID() == "" {
+		var zero T
+		return zero, types.ErrInvalidInput
+	}
+	value, err := json.Marshal(entity)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to serialize entity: %w", err)
+	}
+
+	key := s.key(entity.GetID())
+	ops := []clientv3.Op{clientv3.OpPut(key, string(value))}
+	if s.state != nil {
+		ops = append(ops, clientv3.OpPut(s.stateKey(s.state(entity), entity.GetID()), ""))
+	}
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to create entity: %w", err)
+	}
+	if !resp.Succeeded {
+		var zero T
+		return zero, types.ErrConflict
+	}
+	return entity, nil
+}
+
+func (s *EtcdEntityStore[T]) Update(ctx context.Context, entity T) error {
+	if entity.GetID() == "" {
+		return types.ErrInvalidInput
+	}
+	existing, modRevision, err := s.get(ctx, entity.GetID())
+	if err != nil {
+		return err
+	}
+
+	entity.IncrementVersion()
+	value, err := json.Marshal(entity)
+	if err != nil {
+		return fmt.Errorf("failed to serialize entity: %w", err)
+	}
+
+	key := s.key(entity.GetID())
+	ops := []clientv3.Op{clientv3.OpPut(key, string(value))}
+	if s.state != nil {
+		if oldState, newState := s.state(existing), s.state(entity); oldState != newState {
+			ops = append(ops,
+				clientv3.OpDelete(s.stateKey(oldState, entity.GetID())),
+				clientv3.OpPut(s.stateKey(newState, entity.GetID()), ""))
+		}
+	}
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+	if !resp.Succeeded {
+		// Modified or deleted concurrently since it was read
+		return types.ErrVersionConflict
+	}
+	return nil
+}
+
+func (s *EtcdEntityStore[T]) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return types.ErrInvalidInput
+	}
+	if s.state == nil {
+		resp, err := s.client.Delete(ctx, s.key(id))
+		if err != nil {
+			return fmt.Errorf("failed to delete entity: %w", err)
+		}
+		if resp.Deleted == 0 {
+			return types.ErrNotFound
+		}
+		return nil
+	}
+
+	// The state of the stored entity determines the index key to delete
+	existing, modRevision, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	key := s.key(id)
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(clientv3.OpDelete(key), clientv3.OpDelete(s.stateKey(s.state(existing), id))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+	if !resp.Succeeded {
+		return types.ErrVersionConflict
+	}
+	return nil
+}
+
+// FindByState returns the entities in the given state ordered by ID, reading only the entities listed in the state
+// index. Returns types.ErrInvalidInput if the store does not maintain a state index.
+func (s *EtcdEntityStore[T]) FindByState(ctx context.Context, state string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		entities, err := s.findByState(ctx, state)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for _, entity := range entities {
+			if !yield(entity, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *EtcdEntityStore[T]) findByState(ctx context.Context, state string) ([]T, error) {
+	if s.state == nil {
+		return nil, types.ErrInvalidInput
+	}
+	statePrefix := s.stateKey(state, "")
+	resp, err := s.client.Get(ctx, statePrefix,
+		clientv3.WithPrefix(),
+		clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state index: %w", err)
+	}
+
+	entities := make([]T, 0, len(resp.Kvs))
+	for batch := range slices.Chunk(resp.Kvs, maxTxnOps) {
+		ops := make([]clientv3.Op, len(batch))
+		for i, kv := range batch {
+			ops[i] = clientv3.OpGet(s.key(strings.TrimPrefix(string(kv.Key), statePrefix)))
+		}
+		txn, err := s.client.Txn(ctx).Then(ops...).Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query entities: %w", err)
+		}
+		for _, op := range txn.Responses {
+			// Entities deleted since the index was read are skipped
+			for _, kv := range op.GetResponseRange().Kvs {
+				var entity T
+				if err := json.Unmarshal(kv.Value, &entity); err != nil {
+					return nil, fmt.Errorf("failed to deserialize entity %s: %w", kv.Key, err)
+				}
+				entities = append(entities, entity)
+			}
+		}
+	}
+	return entities, nil
+}
+
+func (s *EtcdEntityStore[T]) GetAll(ctx context.Context) iter.Seq2[T, error] {
+	return s.GetAllPaginated(ctx, store.DefaultPaginationOptions())
+}
+
+func (s *EtcdEntityStore[T]) GetAllCount(ctx context.Context) (int64, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("failed to count entities: %w", err)
+	}
+	return resp.Count, nil
+}
+
+func (s *EtcdEntityStore[T]) GetAllPaginated(ctx context.Context, opts store.PaginationOptions) iter.Seq2[T, error] {
+	return s.paginateEntities(ctx, nil, opts)
+}
+
+func (s *EtcdEntityStore[T]) FindByPredicate(ctx context.Context, predicate query.Predicate) iter.Seq2[T, error] {
+	return s.paginateEntities(ctx, predicate, store.DefaultPaginationOptions())
+}
+
+func (s *EtcdEntityStore[T]) FindByPredicatePaginated(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts store.PaginationOptions) iter.Seq2[T, error] {
+
+	return s.paginateEntities(ctx, predicate, opts)
+}
+
+// paginateEntities yields the entities matching the predicate (or all if predicate is nil) ordered by ID.
+func (s *EtcdEntityStore[T]) paginateEntities(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts store.PaginationOptions) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+		entities, err := s.list(ctx, predicate)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+
+		start := max(opts.Offset, 0)
+		end := int64(len(entities))
+		if opts.Limit > 0 {
+			end = min(end, start+opts.Limit)
+		}
+		for i := start; i < end; i++ {
+			if !yield(entities[i], nil) {
+				return
+			}
+		}
+	}
+}
+
+// FindFirstByPredicate returns the first entity matching the predicate or types.ErrNotFound if none found
+func (s *EtcdEntityStore[T]) FindFirstByPredicate(ctx context.Context, predicate query.Predicate) (T, error) {
+	entities, err := s.list(ctx, predicate)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if len(entities) == 0 {
+		var zero T
+		return zero, types.ErrNotFound
+	}
+	return entities[0], nil
+}
+
+func (s *EtcdEntityStore[T]) CountByPredicate(ctx context.Context, predicate query.Predicate) (int64, error) {
+	entities, err := s.list(ctx, predicate)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(entities)), nil
+}
+
+func (s *EtcdEntityStore[T]) DeleteByPredicate(ctx context.Context, predicate query.Predicate) error {
+	entities, err := s.list(ctx, predicate)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		// Entities deleted concurrently are skipped
+		if err := s.Delete(ctx, entity.GetID()); err != nil && !errors.Is(err, types.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// TryLock acquires the named lock by creating a key attached to a lease. etcd deletes the key when the lease expires
+// after ttl, so the lock is freed if the holder crashes.
+func (s *EtcdEntityStore[T]) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	release := func() {}
+	if name == "" || ttl <= 0 {
+		return false, release, types.ErrInvalidInput
+	}
+
+	// Lease TTLs have a granularity of seconds
+	lease, err := s.client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return false, release, fmt.Errorf("failed to grant lease for lock %s: %w", name, err)
+	}
+
+	key := s.lockPrefix + name
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, "", clientv3.WithLease(lease.ID))).
+		Commit()
+	if err != nil || !resp.Succeeded {
+		_, _ = s.client.Revoke(context.Background(), lease.ID)
+		if err != nil {
+			return false, release, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+		}
+		return false, release, nil
+	}
+
+	release = func() {
+		// Revoking the lease deletes the lock key
+		_, _ = s.client.Revoke(context.Background(), lease.ID)
+	}
+	return true, release, nil
+}
+
+// Export writes all entities to w as JSON Lines ordered by ID.
+func (s *EtcdEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return store.ExportJSONLines(ctx, w, s.PageByID)
+}
+
+// Import creates the entities read from r, failing with types.ErrConflict if an entity already exists.
+func (s *EtcdEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return store.ImportJSONLines(ctx, r, func(ctx context.Context, entity T) error {
+		_, err := s.Create(ctx, entity)
+		return err
+	})
+}
+
+// PageByID returns up to limit entities ordered by ID, starting after afterID. Keys sort by ID since they share the
+// prefix.
+func (s *EtcdEntityStore[T]) PageByID(ctx context.Context, afterID string, limit int) ([]T, error) {
+	start := s.prefix
+	if afterID != "" {
+		// The smallest key sorting after the key of afterID
+		start = s.key(afterID) + "\x00"
+	}
+	resp, err := s.client.Get(ctx, start,
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(s.prefix)),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		clientv3.WithLimit(int64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+
+	entities := make([]T, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var entity T
+		if err := json.Unmarshal(kv.Value, &entity); err != nil {
+			return nil, fmt.Errorf("failed to deserialize entity %s: %w", kv.Key, err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// get returns the entity and the mod revision of its key.
+func (s *EtcdEntityStore[T]) get(ctx context.Context, id string) (T, int64, error) {
+	var zero T
+	resp, err := s.client.Get(ctx, s.key(id))
+	if err != nil {
+		return zero, 0, fmt.Errorf("failed to query entity: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return zero, 0, types.ErrNotFound
+	}
+	var entity T
+	if err := json.Unmarshal(resp.Kvs[0].Value, &entity); err != nil {
+		return zero, 0, fmt.Errorf("failed to deserialize entity %s: %w", id, err)
+	}
+	return entity, resp.Kvs[0].ModRevision, nil
+}
+
+// list returns the entities matching the predicate (or all if predicate is nil) ordered by ID.
+func (s *EtcdEntityStore[T]) list(ctx context.Context, predicate query.Predicate) ([]T, error) {
+	resp, err := s.client.Get(ctx, s.prefix,
+		clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+
+	entities := make([]T, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var entity T
+		if err := json.Unmarshal(kv.Value, &entity); err != nil {
+			return nil, fmt.Errorf("failed to deserialize entity %s: %w", kv.Key, err)
+		}
+		if predicate == nil || predicate.Matches(entity, s.matcher) {
+			entities = append(entities, entity)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (s *EtcdEntityStore[T]) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *EtcdEntityStore[T]) stateKey(state, id string) string {
+	return s.statePrefix + state + "/" + id
+}
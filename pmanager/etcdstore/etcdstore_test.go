@@ -0,0 +1,266 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build etcd
+
+package etcdstore
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type testEntity struct {
+	ID      string `json:"id"`
+	Value   string `json:"value"`
+	Version int64  `json:"version"`
+}
+
+func (e *testEntity) GetID() string     { return e.ID }
+func (e *testEntity) GetVersion() int64 { return e.Version }
+func (e *testEntity) IncrementVersion() { e.Version++ }
+
+func TestEtcdEntityStore_CRUD(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.Create(ctx, &testEntity{ID: "entity-1", Value: "created"})
+	require.NoError(t, err)
+
+	exists, err := estore.Exists(ctx, "entity-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	found, err := estore.FindByID(ctx, "entity-1")
+	require.NoError(t, err)
+	assert.Equal(t, "created", found.Value)
+
+	found.Value = "updated"
+	require.NoError(t, estore.Update(ctx, found))
+	found, err = estore.FindByID(ctx, "entity-1")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", found.Value)
+	assert.Equal(t, int64(1), found.Version)
+
+	require.NoError(t, estore.Delete(ctx, "entity-1"))
+	_, err = estore.FindByID(ctx, "entity-1")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestEtcdEntityStore_Errors(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.Create(ctx, &testEntity{ID: "entity-1"})
+	require.NoError(t, err)
+
+	_, err = estore.Create(ctx, &testEntity{ID: "entity-1"})
+	assert.ErrorIs(t, err, types.ErrConflict)
+
+	_, err = estore.Create(ctx, &testEntity{})
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	err = estore.Update(ctx, &testEntity{ID: "missing"})
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	err = estore.Delete(ctx, "missing")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestEtcdEntityStore_Update_VersionConflict(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.Create(ctx, &testEntity{ID: "entity-1", Value: "created"})
+	require.NoError(t, err)
+
+	// Another writer modifies the entity after the store read it and before it commits the update
+	client, err := clientv3.New(clientv3.Config{Endpoints: testClient.Endpoints(), DialTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	client.KV = &racingKV{KV: client.KV, race: func() {
+		_, err := testClient.Put(ctx, estore.key("entity-1"), `{"id":"entity-1","value":"concurrent","version":1}`)
+		require.NoError(t, err)
+	}}
+	estore.client = client
+
+	err = estore.Update(ctx, &testEntity{ID: "entity-1", Value: "updated"})
+	require.ErrorIs(t, err, types.ErrVersionConflict)
+	require.ErrorIs(t, err, types.ErrConflict)
+
+	found, err := estore.FindByID(ctx, "entity-1")
+	require.NoError(t, err)
+	assert.Equal(t, "concurrent", found.Value)
+}
+
+func TestEtcdEntityStore_FindByState(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	for _, entity := range []*testEntity{{ID: "c", Value: "running"}, {ID: "a", Value: "running"}, {ID: "b", Value: "done"}} {
+		_, err := estore.Create(ctx, entity)
+		require.NoError(t, err)
+	}
+
+	findIDs := func(state string) []string {
+		var ids []string
+		for entity, err := range estore.FindByState(ctx, state) {
+			require.NoError(t, err)
+			ids = append(ids, entity.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"a", "c"}, findIDs("running"))
+	assert.Equal(t, []string{"b"}, findIDs("done"))
+
+	// A state change moves the entity between index keys
+	found, err := estore.FindByID(ctx, "c")
+	require.NoError(t, err)
+	found.Value = "done"
+	require.NoError(t, estore.Update(ctx, found))
+	assert.Equal(t, []string{"a"}, findIDs("running"))
+	assert.Equal(t, []string{"b", "c"}, findIDs("done"))
+
+	require.NoError(t, estore.Delete(ctx, "b"))
+	assert.Equal(t, []string{"c"}, findIDs("done"))
+
+	count, err := estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "index keys must not be listed as entities")
+}
+
+func TestEtcdEntityStore_Queries(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	for _, entity := range []*testEntity{{ID: "c", Value: "x"}, {ID: "a", Value: "x"}, {ID: "b", Value: "y"}} {
+		_, err := estore.Create(ctx, entity)
+		require.NoError(t, err)
+	}
+
+	count, err := estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	var ids []string
+	for entity, err := range estore.GetAll(ctx) {
+		require.NoError(t, err)
+		ids = append(ids, entity.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+
+	predicate := query.Eq("Value", "x")
+	count, err = estore.CountByPredicate(ctx, predicate)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	first, err := estore.FindFirstByPredicate(ctx, predicate)
+	require.NoError(t, err)
+	assert.Equal(t, "a", first.ID)
+
+	require.NoError(t, estore.DeleteByPredicate(ctx, predicate))
+	count, err = estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestEtcdEntityStore_TryLock(t *testing.T) {
+	estore := newTestStore(t)
+	ctx := context.Background()
+
+	acquired, release, err := estore.TryLock(ctx, "sweeper", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, _, err = estore.TryLock(ctx, "sweeper", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	count, err := estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count, "locks must not be listed as entities")
+
+	release()
+	acquired, release, err = estore.TryLock(ctx, "sweeper", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	release()
+}
+
+// testClient is connected to the etcd container started by TestMain.
+var testClient *clientv3.Client
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "gcr.io/etcd-development/etcd:v3.5.21",
+			ExposedPorts: []string{"2379/tcp"},
+			Cmd: []string{"etcd",
+				"--listen-client-urls", "http://0.0.0.0:2379",
+				"--advertise-client-urls", "http://0.0.0.0:2379"},
+			WaitingFor: wait.ForListeningPort("2379/tcp"),
+		},
+		Started: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	endpoint, err := container.PortEndpoint(ctx, "2379/tcp", "http")
+	if err != nil {
+		panic(err)
+	}
+	testClient, err = clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	_ = testClient.Close()
+	_ = container.Terminate(ctx)
+	os.Exit(code)
+}
+
+// newTestStore creates a store under a prefix unique to the test, indexing entities by their value.
+func newTestStore(t *testing.T) *EtcdEntityStore[*testEntity] {
+	prefix := "/cfm/" + t.Name()
+	t.Cleanup(func() {
+		_, _ = testClient.Delete(context.Background(), prefix, clientv3.WithPrefix())
+	})
+	return NewEtcdEntityStore[*testEntity](testClient, prefix, func(entity *testEntity) string {
+		return entity.Value
+	})
+}
+
+// racingKV runs race once before the first transaction, e.g. to modify a key concurrently.
+type racingKV struct {
+	clientv3.KV
+	race func()
+	once sync.Once
+}
+
+func (k *racingKV) Txn(ctx context.Context) clientv3.Txn {
+	k.once.Do(k.race)
+	return k.KV.Txn(ctx)
+}
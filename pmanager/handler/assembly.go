@@ -29,6 +29,7 @@ type response struct {
 
 type HandlerServiceAssembly struct {
 	system.DefaultServiceAssembly
+	handler *PMHandler
 }
 
 func (h *HandlerServiceAssembly) Name() string {
@@ -47,6 +48,7 @@ func (h *HandlerServiceAssembly) Init(context *system.InitContext) error {
 	definitionManager := context.Registry.Resolve(api.DefinitionManagerKey).(api.DefinitionManager)
 	txContext := context.Registry.Resolve(store.TransactionContextKey).(store.TransactionContext)
 	handler := NewHandler(provisionManager, definitionManager, txContext, context.LogMonitor)
+	h.handler = handler
 
 	router.Route("/api/v1alpha1", func(r chi.Router) {
 		h.registerV1Alpha1(r, handler)
@@ -55,12 +57,64 @@ func (h *HandlerServiceAssembly) Init(context *system.InitContext) error {
 	return nil
 }
 
+func (h *HandlerServiceAssembly) Prepare(context *system.InitContext) error {
+	healthCheck, found := context.Registry.ResolveOptional(api.HealthCheckKey)
+	if found {
+		h.handler.healthCheck = healthCheck.(api.HealthCheck)
+	}
+	entryValidator, found := context.Registry.ResolveOptional(api.EntryValidatorKey)
+	if found {
+		h.handler.entryValidator = entryValidator.(api.EntryValidator)
+	}
+	entryNotifier, found := context.Registry.ResolveOptional(api.EntryNotifierKey)
+	if found {
+		h.handler.entryNotifier = entryNotifier.(api.EntryNotifier)
+	}
+	maintenance, found := context.Registry.ResolveOptional(api.MaintenanceKey)
+	if found {
+		h.handler.maintenance = maintenance.(api.Maintenance)
+	}
+	typePauser, found := context.Registry.ResolveOptional(api.TypePauserKey)
+	if found {
+		h.handler.typePauser = typePauser.(api.TypePauser)
+	}
+	metrics, found := context.Registry.ResolveOptional(store.TransactionMetricsKey)
+	if found {
+		if stats, ok := metrics.(transactionStats); ok {
+			h.handler.transactionStats = stats
+		}
+	}
+	return nil
+}
+
 func (h *HandlerServiceAssembly) registerV1Alpha1(router chi.Router, handler *PMHandler) {
 	h.registerActivityDefinitionRoutes(router, handler)
 	h.registerOrchestrationDefinitionRoutes(router, handler)
 
 	h.registerOrchestrationRoutes(router, handler)
 	router.Get("/health", handler.health)
+	router.Get("/metrics/transactions", handler.transactionMetrics)
+	router.Get("/maintenance", handler.getMaintenance)
+	router.Put("/maintenance", handler.setMaintenance)
+	router.Route("/paused-types", func(r chi.Router) {
+		r.Get("/", handler.getPausedTypes)
+		r.Route("/{orchestrationType}", func(r chi.Router) {
+			r.Put("/", func(w http.ResponseWriter, req *http.Request) {
+				orchestrationType, found := handler.ExtractPathVariable(w, req, "orchestrationType")
+				if !found {
+					return
+				}
+				handler.pauseType(w, req, orchestrationType)
+			})
+			r.Delete("/", func(w http.ResponseWriter, req *http.Request) {
+				orchestrationType, found := handler.ExtractPathVariable(w, req, "orchestrationType")
+				if !found {
+					return
+				}
+				handler.resumeType(w, req, orchestrationType)
+			})
+		})
+	})
 }
 
 func (h *HandlerServiceAssembly) registerOrchestrationRoutes(router chi.Router, handler *PMHandler) {
@@ -69,6 +123,7 @@ func (h *HandlerServiceAssembly) registerOrchestrationRoutes(router chi.Router,
 		r.Post("/query", func(w http.ResponseWriter, req *http.Request) {
 			handler.queryOrchestrations(w, req, "/orchestrations/query")
 		})
+		r.Post("/validate", handler.validateOrchestration)
 
 		r.Route("/{orchestrationID}", func(r chi.Router) {
 			r.Post("/", func(w http.ResponseWriter, req *http.Request) {
@@ -85,6 +140,13 @@ func (h *HandlerServiceAssembly) registerOrchestrationRoutes(router chi.Router,
 				}
 				handler.getOrchestration(w, req, orchestrationID)
 			})
+			r.Get("/entry", func(w http.ResponseWriter, req *http.Request) {
+				orchestrationID, found := handler.ExtractPathVariable(w, req, "orchestrationID")
+				if !found {
+					return
+				}
+				handler.getOrchestrationEntry(w, req, orchestrationID)
+			})
 		})
 	})
 }
@@ -13,7 +13,15 @@
 package handler
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/handler"
 	"github.com/metaform/connector-fabric-manager/common/model"
@@ -23,11 +31,28 @@ import (
 	"github.com/metaform/connector-fabric-manager/pmanager/model/v1alpha1"
 )
 
+const (
+	// maxEntryWait bounds how long a request for an orchestration entry waits for the entry to change.
+	maxEntryWait = 60 * time.Second
+
+	defaultEntryPollInterval = 5 * time.Second
+)
+
 type PMHandler struct {
 	handler.HttpHandler
 	provisionManager  api.ProvisionManager
 	definitionManager api.DefinitionManager
 	txContext         store.TransactionContext
+	healthCheck       api.HealthCheck
+	entryValidator    api.EntryValidator
+	entryNotifier     api.EntryNotifier
+	maintenance       api.Maintenance
+	typePauser        api.TypePauser
+	transactionStats  transactionStats
+
+	// entryPollInterval is the interval at which waiting entry requests re-read the entry, so that changes not
+	// notified, e.g. recorded by other runtimes, are observed.
+	entryPollInterval time.Duration
 }
 
 func NewHandler(
@@ -42,6 +67,7 @@ func NewHandler(
 		provisionManager:  provisionManager,
 		definitionManager: definitionManager,
 		txContext:         txContext,
+		entryPollInterval: defaultEntryPollInterval,
 	}
 }
 
@@ -101,11 +127,164 @@ func (h *PMHandler) createOrchestration(w http.ResponseWriter, req *http.Request
 	h.ResponseAccepted(w, orchestration)
 }
 
-func (h *PMHandler) health(w http.ResponseWriter, _ *http.Request) {
+// validationResponse lists the problems found validating an orchestration. Problems is empty if it is valid.
+type validationResponse struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+// validateOrchestration validates a candidate orchestration as the orchestration index would before recording it,
+// without publishing or recording it.
+func (h *PMHandler) validateOrchestration(w http.ResponseWriter, req *http.Request) {
+	if h.InvalidMethod(w, req, http.MethodPost) {
+		return
+	}
+	if h.entryValidator == nil {
+		h.WriteError(w, "Orchestration validation is not available", http.StatusNotImplemented)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		h.WriteError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer req.Body.Close()
+
+	problems := h.entryValidator.ValidateEntry(req.Context(), body)
+	h.ResponseOK(w, validationResponse{Valid: len(problems) == 0, Problems: problems})
+}
+
+func (h *PMHandler) health(w http.ResponseWriter, req *http.Request) {
+	if h.healthCheck != nil {
+		if err := h.healthCheck.CheckHealth(req.Context()); err != nil {
+			h.Monitor.Warnf("Health check failed: %v", err)
+			h.WriteError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
 	response := response{Message: "OK"}
 	h.ResponseOK(w, response)
 }
 
+// maintenanceState reports whether recording orchestration changes is paused for maintenance.
+type maintenanceState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// getMaintenance returns whether the orchestration index is in maintenance mode.
+func (h *PMHandler) getMaintenance(w http.ResponseWriter, req *http.Request) {
+	if h.InvalidMethod(w, req, http.MethodGet) {
+		return
+	}
+	if h.maintenance == nil {
+		h.WriteError(w, "Maintenance mode is not available", http.StatusNotImplemented)
+		return
+	}
+	h.ResponseOK(w, maintenanceState{Enabled: h.maintenance.Maintenance()})
+}
+
+// setMaintenance enables or disables the maintenance mode of the orchestration index. In maintenance mode,
+// orchestration changes are redelivered later without accessing the index, e.g. while the database is migrated.
+func (h *PMHandler) setMaintenance(w http.ResponseWriter, req *http.Request) {
+	if h.InvalidMethod(w, req, http.MethodPut) {
+		return
+	}
+	if h.maintenance == nil {
+		h.WriteError(w, "Maintenance mode is not available", http.StatusNotImplemented)
+		return
+	}
+	var state maintenanceState
+	if !h.ReadPayload(w, req, &state) {
+		return
+	}
+	h.maintenance.SetMaintenance(state.Enabled)
+	h.ResponseOK(w, maintenanceState{Enabled: h.maintenance.Maintenance()})
+}
+
+// pausedTypesResponse lists the orchestration types whose changes are not recorded.
+type pausedTypesResponse struct {
+	Types []string `json:"types"`
+}
+
+// getPausedTypes returns the paused orchestration types ordered by name.
+func (h *PMHandler) getPausedTypes(w http.ResponseWriter, req *http.Request) {
+	if h.InvalidMethod(w, req, http.MethodGet) {
+		return
+	}
+	if h.typePauser == nil {
+		h.WriteError(w, "Pausing orchestration types is not available", http.StatusNotImplemented)
+		return
+	}
+	h.ResponseOK(w, pausedTypesResponse{Types: h.pausedTypes()})
+}
+
+// pauseType pauses recording changes of the orchestration type. Like in maintenance mode, its changes are redelivered
+// later without accessing the index while other types are processed.
+func (h *PMHandler) pauseType(w http.ResponseWriter, req *http.Request, orchestrationType string) {
+	if h.InvalidMethod(w, req, http.MethodPut) {
+		return
+	}
+	if h.typePauser == nil {
+		h.WriteError(w, "Pausing orchestration types is not available", http.StatusNotImplemented)
+		return
+	}
+	h.typePauser.PauseType(orchestrationType)
+	h.ResponseOK(w, pausedTypesResponse{Types: h.pausedTypes()})
+}
+
+// resumeType resumes recording changes of the orchestration type.
+func (h *PMHandler) resumeType(w http.ResponseWriter, req *http.Request, orchestrationType string) {
+	if h.InvalidMethod(w, req, http.MethodDelete) {
+		return
+	}
+	if h.typePauser == nil {
+		h.WriteError(w, "Pausing orchestration types is not available", http.StatusNotImplemented)
+		return
+	}
+	h.typePauser.ResumeType(orchestrationType)
+	h.ResponseOK(w, pausedTypesResponse{Types: h.pausedTypes()})
+}
+
+// pausedTypes returns the paused types, an empty list rather than null if none is paused.
+func (h *PMHandler) pausedTypes() []string {
+	if paused := h.typePauser.PausedTypes(); paused != nil {
+		return paused
+	}
+	return []string{}
+}
+
+// transactionStats exposes the transaction outcomes counted by the registered store.TransactionMetrics, such as a
+// store.TransactionCounter.
+type transactionStats interface {
+	Commits() int64
+	Rollbacks() int64
+	RollbackRatio() float64
+}
+
+// transactionMetricsResponse reports the outcome of the store transactions since the process started.
+type transactionMetricsResponse struct {
+	Commits       int64   `json:"commits"`
+	Rollbacks     int64   `json:"rollbacks"`
+	RollbackRatio float64 `json:"rollbackRatio"`
+}
+
+// transactionMetrics returns the number of committed and rolled back store transactions. A high rollback ratio signals
+// contention or bugs.
+func (h *PMHandler) transactionMetrics(w http.ResponseWriter, req *http.Request) {
+	if h.InvalidMethod(w, req, http.MethodGet) {
+		return
+	}
+	if h.transactionStats == nil {
+		h.WriteError(w, "Transaction metrics are not available", http.StatusNotImplemented)
+		return
+	}
+	h.ResponseOK(w, transactionMetricsResponse{
+		Commits:       h.transactionStats.Commits(),
+		Rollbacks:     h.transactionStats.Rollbacks(),
+		RollbackRatio: h.transactionStats.RollbackRatio(),
+	})
+}
+
 func (h *PMHandler) deleteOrchestrationDefinition(w http.ResponseWriter, req *http.Request, oType string) {
 	if h.InvalidMethod(w, req, http.MethodDelete) {
 		return
@@ -154,7 +333,7 @@ func (h *PMHandler) getOrchestration(w http.ResponseWriter, req *http.Request, i
 	}
 	orchestration, err := h.provisionManager.GetOrchestration(req.Context(), id)
 	if err != nil {
-		h.HandleError(w, err)
+		handler.WriteError(w, err)
 		return
 	}
 
@@ -162,13 +341,105 @@ func (h *PMHandler) getOrchestration(w http.ResponseWriter, req *http.Request, i
 	h.ResponseOK(w, response)
 }
 
+// getOrchestrationEntry returns the index entry of the orchestration, tagged with a hash of its content as ETag. Requests
+// whose If-None-Match header matches the current ETag are answered with 304 Not Modified. The optional wait parameter,
+// a duration such as 30s, holds such requests until the entry changes or the wait elapses, so that clients can
+// long-poll for changes instead of polling repeatedly. Waiting requests re-read the entry when notified of a change,
+// see api.EntryNotifier, and at the poll interval.
+func (h *PMHandler) getOrchestrationEntry(w http.ResponseWriter, req *http.Request, id string) {
+	if h.InvalidMethod(w, req, http.MethodGet) {
+		return
+	}
+	wait, err := parseWait(req.URL.Query().Get("wait"))
+	if err != nil {
+		h.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	waitCtx, cancel := context.WithTimeout(req.Context(), wait)
+	defer cancel()
+
+	ifNoneMatch := req.Header.Get("If-None-Match")
+	var changes <-chan struct{} // blocks if changes are not notified
+	if wait > 0 && ifNoneMatch != "" && h.entryNotifier != nil {
+		// Subscribe before reading the entry so that no change is missed in between
+		var stop func()
+		changes, stop = h.entryNotifier.NotifyEntryChange(id)
+		defer stop()
+	}
+	poll := time.NewTicker(h.entryPollInterval)
+	defer poll.Stop()
+	for {
+		entry, err := h.provisionManager.GetOrchestrationEntry(req.Context(), id)
+		if err != nil {
+			h.HandleError(w, err)
+			return
+		}
+		response := v1alpha1.ToOrchestrationEntry(entry)
+		etag, err := entryETag(&response)
+		if err != nil {
+			h.HandleError(w, err)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if !etagMatches(ifNoneMatch, etag) {
+			h.ResponseOK(w, response)
+			return
+		}
+		select {
+		case <-waitCtx.Done():
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-changes:
+		case <-poll.C:
+		}
+	}
+}
+
+// parseWait parses the wait parameter of a long-polling request. Waits are capped at maxEntryWait.
+func parseWait(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait: %s", value)
+	}
+	return min(wait, maxEntryWait), nil
+}
+
+// entryETag derives a strong ETag from a hash of the served entry. The version alone is not sufficient as stores
+// replicated from orchestration changes do not increment it on every change.
+func entryETag(entry *v1alpha1.OrchestrationEntry) (string, error) {
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize orchestration entry: %w", err)
+	}
+	sum := sha256.Sum256(serialized)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches returns true if the If-None-Match header lists the ETag or is a wildcard. Weak validators are compared
+// by their opaque tag as specified for If-None-Match.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *PMHandler) getActivityDefinitions(w http.ResponseWriter, req *http.Request) {
 	if h.InvalidMethod(w, req, http.MethodGet) {
 		return
 	}
 	definitions, err := h.definitionManager.GetActivityDefinitions(req.Context())
 	if err != nil {
-		h.HandleError(w, err)
+		handler.WriteError(w, err)
 		return
 	}
 	converted := make([]v1alpha1.ActivityDefinition, len(definitions))
@@ -185,7 +456,7 @@ func (h *PMHandler) getOrchestrationDefinitions(w http.ResponseWriter, req *http
 	}
 	definitions, err := h.definitionManager.GetOrchestrationDefinitions(req.Context())
 	if err != nil {
-		h.HandleError(w, err)
+		handler.WriteError(w, err)
 		return
 	}
 	converted := make([]v1alpha1.OrchestrationDefinition, len(definitions))
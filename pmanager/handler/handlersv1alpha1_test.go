@@ -0,0 +1,373 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/model/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrchestrationEntry_ETag(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3}}
+	handler := newEntryHandler(manager)
+
+	recorder := httptest.NewRecorder()
+	handler.getOrchestrationEntry(recorder, httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry", nil), "orch-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, testETag(t, manager.entry), recorder.Header().Get("ETag"))
+	var entry v1alpha1.OrchestrationEntry
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &entry))
+	assert.Equal(t, "orch-1", entry.ID)
+	assert.Equal(t, int64(3), entry.Version)
+}
+
+func TestGetOrchestrationEntry_NotModified(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3}}
+	handler := newEntryHandler(manager)
+	etag := testETag(t, manager.entry)
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"stale", ` + etag, `*`} {
+		req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		recorder := httptest.NewRecorder()
+
+		handler.getOrchestrationEntry(recorder, req, "orch-1")
+
+		assert.Equal(t, http.StatusNotModified, recorder.Code, ifNoneMatch)
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		assert.Empty(t, recorder.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	recorder := httptest.NewRecorder()
+	handler.getOrchestrationEntry(recorder, req, "orch-1")
+	assert.Equal(t, http.StatusOK, recorder.Code, "stale tags are answered with the current entry")
+}
+
+func TestGetOrchestrationEntry_ETagChangesWithoutVersion(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3, Revision: 7}}
+	handler := newEntryHandler(manager)
+	etag := testETag(t, manager.entry)
+
+	// Entries replicated from orchestration changes may keep their version while the state changes
+	manager.update(&api.OrchestrationEntry{ID: "orch-1", Version: 3, Revision: 8, State: api.OrchestrationStateCompleted})
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry", nil)
+	req.Header.Set("If-None-Match", etag)
+	recorder := httptest.NewRecorder()
+	handler.getOrchestrationEntry(recorder, req, "orch-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"))
+}
+
+func TestGetOrchestrationEntry_LongPollTimeout(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3}}
+	handler := newEntryHandler(manager)
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry?wait=50ms", nil)
+	req.Header.Set("If-None-Match", testETag(t, manager.entry))
+	recorder := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.getOrchestrationEntry(recorder, req, "orch-1")
+
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Greater(t, manager.readCount(), 1, "the entry is re-read while waiting")
+}
+
+func TestGetOrchestrationEntry_LongPollChange(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3}}
+	handler := newEntryHandler(manager)
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry?wait=10s", nil)
+	req.Header.Set("If-None-Match", testETag(t, manager.entry))
+	recorder := httptest.NewRecorder()
+
+	changed := &api.OrchestrationEntry{ID: "orch-1", Version: 4, State: api.OrchestrationStateCompleted}
+	time.AfterFunc(20*time.Millisecond, func() {
+		manager.update(changed)
+	})
+	handler.getOrchestrationEntry(recorder, req, "orch-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, testETag(t, changed), recorder.Header().Get("ETag"))
+}
+
+func TestGetOrchestrationEntry_LongPollNotifiedChange(t *testing.T) {
+	manager := &entryProvisionManager{entry: &api.OrchestrationEntry{ID: "orch-1", Version: 3}}
+	handler := newEntryHandler(manager)
+	handler.entryPollInterval = time.Hour
+	notifier := &entryNotifier{changes: make(chan struct{}, 1)}
+	handler.entryNotifier = notifier
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry?wait=10s", nil)
+	req.Header.Set("If-None-Match", testETag(t, manager.entry))
+	recorder := httptest.NewRecorder()
+
+	changed := &api.OrchestrationEntry{ID: "orch-1", Version: 4, State: api.OrchestrationStateCompleted}
+	time.AfterFunc(20*time.Millisecond, func() {
+		manager.update(changed)
+		notifier.changes <- struct{}{}
+	})
+	handler.getOrchestrationEntry(recorder, req, "orch-1")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, testETag(t, changed), recorder.Header().Get("ETag"))
+	assert.Equal(t, 2, manager.readCount(), "the entry is re-read once notified")
+	assert.Equal(t, []string{"orch-1"}, notifier.ids)
+}
+
+func TestGetOrchestrationEntry_Errors(t *testing.T) {
+	handler := newEntryHandler(&entryProvisionManager{})
+
+	recorder := httptest.NewRecorder()
+	handler.getOrchestrationEntry(recorder, httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry", nil), "orch-1")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.getOrchestrationEntry(recorder, httptest.NewRequest(http.MethodGet, "/orchestrations/orch-1/entry?wait=soon", nil), "orch-1")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// testETag returns the ETag served for the entry.
+func testETag(t *testing.T, entry *api.OrchestrationEntry) string {
+	response := v1alpha1.ToOrchestrationEntry(entry)
+	etag, err := entryETag(&response)
+	require.NoError(t, err)
+	return etag
+}
+
+func newEntryHandler(manager api.ProvisionManager) *PMHandler {
+	handler := NewHandler(manager, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	handler.entryPollInterval = 5 * time.Millisecond
+	return handler
+}
+
+// entryProvisionManager serves a single orchestration entry that can be updated concurrently.
+type entryProvisionManager struct {
+	api.ProvisionManager
+	mu    sync.Mutex
+	entry *api.OrchestrationEntry
+	reads int
+}
+
+func (m *entryProvisionManager) GetOrchestrationEntry(context.Context, string) (*api.OrchestrationEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	if m.entry == nil {
+		return nil, types.ErrNotFound
+	}
+	return m.entry, nil
+}
+
+func (m *entryProvisionManager) update(entry *api.OrchestrationEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entry = entry
+}
+
+func (m *entryProvisionManager) readCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reads
+}
+
+// entryNotifier signals the changes sent on its channel for any entry.
+type entryNotifier struct {
+	changes chan struct{}
+	ids     []string
+}
+
+func (n *entryNotifier) NotifyEntryChange(id string) (<-chan struct{}, func()) {
+	n.ids = append(n.ids, id)
+	return n.changes, func() {}
+}
+
+func TestValidateOrchestration(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	handler.entryValidator = validatorFunc(func(data []byte) []string {
+		if strings.Contains(string(data), `"id":""`) {
+			return []string{"orchestration id is missing"}
+		}
+		return []string{}
+	})
+
+	for body, expected := range map[string]validationResponse{
+		`{"id":"orch-1"}`: {Valid: true, Problems: []string{}},
+		`{"id":""}`:       {Valid: false, Problems: []string{"orchestration id is missing"}},
+	} {
+		recorder := httptest.NewRecorder()
+		handler.validateOrchestration(recorder, httptest.NewRequest(http.MethodPost, "/orchestrations/validate",
+			strings.NewReader(body)))
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var response validationResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, expected, response)
+	}
+}
+
+func TestValidateOrchestration_NoValidator(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+
+	recorder := httptest.NewRecorder()
+	handler.validateOrchestration(recorder, httptest.NewRequest(http.MethodPost, "/orchestrations/validate",
+		strings.NewReader(`{}`)))
+
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+}
+
+func TestTransactionMetrics(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	counter := &store.TransactionCounter{}
+	counter.RecordCommit()
+	counter.RecordCommit()
+	counter.RecordCommit()
+	counter.RecordRollback()
+	handler.transactionStats = counter
+
+	recorder := httptest.NewRecorder()
+	handler.transactionMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics/transactions", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response transactionMetricsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, transactionMetricsResponse{Commits: 3, Rollbacks: 1, RollbackRatio: 0.25}, response)
+}
+
+func TestTransactionMetrics_NotAvailable(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+
+	recorder := httptest.NewRecorder()
+	handler.transactionMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics/transactions", nil))
+
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+}
+
+// validatorFunc adapts a function to api.EntryValidator.
+type validatorFunc func(data []byte) []string
+
+func (f validatorFunc) ValidateEntry(_ context.Context, data []byte) []string {
+	return f(data)
+}
+
+func TestMaintenance(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	maintenance := &maintenanceSwitch{}
+	handler.maintenance = maintenance
+
+	recorder := httptest.NewRecorder()
+	handler.setMaintenance(recorder, httptest.NewRequest(http.MethodPut, "/maintenance", strings.NewReader(`{"enabled":true}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, maintenance.enabled)
+
+	recorder = httptest.NewRecorder()
+	handler.getMaintenance(recorder, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var state maintenanceState
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &state))
+	assert.True(t, state.Enabled)
+
+	recorder = httptest.NewRecorder()
+	handler.setMaintenance(recorder, httptest.NewRequest(http.MethodPut, "/maintenance", strings.NewReader(`{"enabled":false}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.False(t, maintenance.enabled)
+}
+
+func TestMaintenance_NotAvailable(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+
+	recorder := httptest.NewRecorder()
+	handler.getMaintenance(recorder, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+}
+
+type maintenanceSwitch struct {
+	enabled bool
+}
+
+func (m *maintenanceSwitch) SetMaintenance(enabled bool) { m.enabled = enabled }
+
+func (m *maintenanceSwitch) Maintenance() bool { return m.enabled }
+
+func TestPausedTypes(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	pauser := &typePauser{paused: map[string]bool{}}
+	handler.typePauser = pauser
+
+	recorder := httptest.NewRecorder()
+	handler.pauseType(recorder, httptest.NewRequest(http.MethodPut, "/paused-types/provision", nil), "provision")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, pausedTypesResponse{Types: []string{"provision"}}, readPausedTypes(t, recorder))
+
+	recorder = httptest.NewRecorder()
+	handler.getPausedTypes(recorder, httptest.NewRequest(http.MethodGet, "/paused-types", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, pausedTypesResponse{Types: []string{"provision"}}, readPausedTypes(t, recorder))
+
+	recorder = httptest.NewRecorder()
+	handler.resumeType(recorder, httptest.NewRequest(http.MethodDelete, "/paused-types/provision", nil), "provision")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, pausedTypesResponse{Types: []string{}}, readPausedTypes(t, recorder))
+}
+
+func TestPausedTypes_NotAvailable(t *testing.T) {
+	handler := NewHandler(nil, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+
+	recorder := httptest.NewRecorder()
+	handler.pauseType(recorder, httptest.NewRequest(http.MethodPut, "/paused-types/provision", nil), "provision")
+
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+}
+
+func readPausedTypes(t *testing.T, recorder *httptest.ResponseRecorder) pausedTypesResponse {
+	var response pausedTypesResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response
+}
+
+type typePauser struct {
+	paused map[string]bool
+}
+
+func (p *typePauser) PauseType(orchestrationType string) { p.paused[orchestrationType] = true }
+
+func (p *typePauser) ResumeType(orchestrationType string) { delete(p.paused, orchestrationType) }
+
+func (p *typePauser) PausedTypes() []string {
+	var paused []string
+	for orchestrationType := range p.paused {
+		paused = append(paused, orchestrationType)
+	}
+	return paused
+}
@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build kafka
+
+package kafkaforwarder
+
+import (
+	"strings"
+
+	"github.com/metaform/connector-fabric-manager/common/runtime"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+const (
+	brokersKey = "kafka.brokers"
+	topicKey   = "kafka.topic"
+
+	fieldNamingKey = "kafka.fieldNaming"
+	omitEmptyKey   = "kafka.omitEmpty"
+)
+
+// KafkaForwarderServiceAssembly provides an api.StateForwarder writing orchestration state changes to Kafka.
+type KafkaForwarderServiceAssembly struct {
+	system.DefaultServiceAssembly
+	forwarder *KafkaStateForwarder
+}
+
+func (a *KafkaForwarderServiceAssembly) Name() string {
+	return "Kafka State Forwarder"
+}
+
+func (a *KafkaForwarderServiceAssembly) Provides() []system.ServiceType {
+	return []system.ServiceType{api.StateForwarderKey}
+}
+
+func (a *KafkaForwarderServiceAssembly) Init(ctx *system.InitContext) error {
+	brokers := ctx.Config.GetStringSlice(brokersKey)
+	topic := ctx.Config.GetString(topicKey)
+	err := runtime.CheckRequiredParams(brokersKey, strings.Join(brokers, ","), topicKey, topic)
+	if err != nil {
+		return err
+	}
+
+	fieldNaming, err := api.ParseFieldNaming(ctx.GetConfigStrOrDefault(fieldNamingKey, ""))
+	if err != nil {
+		return err
+	}
+	serializer := api.JSONSerializer{Naming: fieldNaming, OmitEmpty: ctx.Config.GetBool(omitEmptyKey)}
+
+	a.forwarder = NewKafkaStateForwarder(brokers, topic, serializer)
+	ctx.Registry.Register(api.StateForwarderKey, a.forwarder)
+	return nil
+}
+
+func (a *KafkaForwarderServiceAssembly) Shutdown() error {
+	if a.forwarder != nil {
+		return a.forwarder.Close()
+	}
+	return nil
+}
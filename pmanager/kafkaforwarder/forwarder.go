@@ -0,0 +1,57 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build kafka
+
+package kafkaforwarder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaStateForwarder mirrors orchestration state changes to a Kafka topic. Messages are keyed by orchestration ID so
+// that all changes to an orchestration are written to the same partition and consumed in order.
+type KafkaStateForwarder struct {
+	writer     *kafka.Writer
+	serializer api.JSONSerializer
+}
+
+func NewKafkaStateForwarder(brokers []string, topic string, serializer api.JSONSerializer) *KafkaStateForwarder {
+	return &KafkaStateForwarder{
+		serializer: serializer,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+func (f *KafkaStateForwarder) Forward(ctx context.Context, entry *api.OrchestrationEntry) error {
+	data, err := f.serializer.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshalling orchestration entry %s: %w", entry.ID, err)
+	}
+	if err := f.writer.WriteMessages(ctx, kafka.Message{Key: []byte(entry.ID), Value: data}); err != nil {
+		return fmt.Errorf("error writing orchestration entry %s to topic %s: %w", entry.ID, f.writer.Topic, err)
+	}
+	return nil
+}
+
+func (f *KafkaStateForwarder) Close() error {
+	return f.writer.Close()
+}
@@ -13,7 +13,6 @@
 package memorystore
 
 import (
-	"github.com/metaform/connector-fabric-manager/common/memorystore"
 	"github.com/metaform/connector-fabric-manager/common/system"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
 )
@@ -27,13 +26,14 @@ func (m MemoryStoreServiceAssembly) Name() string {
 }
 
 func (m MemoryStoreServiceAssembly) Provides() []system.ServiceType {
-	return []system.ServiceType{api.DefinitionStoreKey, api.OrchestrationIndexKey}
+	return []system.ServiceType{api.DefinitionStoreKey, api.OrchestrationIndexKey, api.OutboxStoreKey,
+		api.RawPayloadStoreKey}
 }
 
 func (m MemoryStoreServiceAssembly) Init(context *system.InitContext) error {
 	context.Registry.Register(api.DefinitionStoreKey, NewDefinitionStore())
-	context.Registry.Register(
-		api.OrchestrationIndexKey,
-		memorystore.NewInMemoryEntityStore[*api.OrchestrationEntry]())
+	context.Registry.Register(api.OrchestrationIndexKey, NewOrchestrationIndex())
+	context.Registry.Register(api.OutboxStoreKey, NewOutboxStore())
+	context.Registry.Register(api.RawPayloadStoreKey, NewRawPayloadStore())
 	return nil
 }
@@ -0,0 +1,145 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/memorystore"
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// OrchestrationIndex is an in-memory api.OrchestrationIndex.
+type OrchestrationIndex struct {
+	*memorystore.InMemoryEntityStore[*api.OrchestrationEntry]
+
+	// claimMu serializes claims so that an entry is not claimed by concurrent workers
+	claimMu sync.Mutex
+}
+
+func NewOrchestrationIndex() *OrchestrationIndex {
+	return &OrchestrationIndex{InMemoryEntityStore: memorystore.NewInMemoryEntityStore[*api.OrchestrationEntry]()}
+}
+
+func (i *OrchestrationIndex) List(
+	ctx context.Context,
+	predicate query.Predicate,
+	cursor string,
+	limit int) ([]*api.OrchestrationEntry, string, error) {
+	return i.ListByCursor(ctx, predicate, cursor, limit, api.OrchestrationEntryCursor)
+}
+
+func (i *OrchestrationIndex) FindStateByID(ctx context.Context, id string) (api.OrchestrationState, int64, error) {
+	entry, err := i.FindByID(ctx, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return entry.State, entry.Version, nil
+}
+
+func (i *OrchestrationIndex) FindByTimeRange(
+	ctx context.Context,
+	field api.TimeField,
+	from time.Time,
+	to time.Time,
+	limit int) ([]*api.OrchestrationEntry, error) {
+	predicate, keyFn, err := api.TimeRangeQuery(field, from, to)
+	if err != nil {
+		return nil, err
+	}
+	entries, _, err := i.ListByCursor(ctx, predicate, "", limit, keyFn)
+	return entries, err
+}
+
+// FindByLabel scans all entries for the label.
+func (i *OrchestrationIndex) FindByLabel(
+	ctx context.Context,
+	key string,
+	value string,
+	limit int) ([]*api.OrchestrationEntry, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%w: label key must not be empty", types.ErrInvalidInput)
+	}
+	entries, _, err := i.ListByCursor(ctx, labelPredicate{key: key, value: value}, "", limit, api.OrchestrationEntryCursor)
+	return entries, err
+}
+
+// ClaimNext selects and updates the entry while holding the claim lock. Updates through other methods are not
+// serialized with claims.
+func (i *OrchestrationIndex) ClaimNext(
+	ctx context.Context,
+	state api.OrchestrationState,
+	workerID string,
+	lease time.Duration) (*api.OrchestrationEntry, error) {
+	if err := api.ValidateClaim(workerID, lease); err != nil {
+		return nil, err
+	}
+	i.claimMu.Lock()
+	defer i.claimMu.Unlock()
+
+	now := time.Now()
+	entries, _, err := i.ListByCursor(ctx, claimablePredicate{state: state, now: now}, "", 1, api.OrchestrationEntryCursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, types.ErrNotFound
+	}
+	entry := entries[0]
+	entry.ClaimedBy = workerID
+	entry.LeaseExpiry = now.Add(lease)
+	if err := i.Update(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// claimablePredicate matches entries in the state that are not claimed or whose lease has expired.
+type claimablePredicate struct {
+	state api.OrchestrationState
+	now   time.Time
+}
+
+func (p claimablePredicate) Matches(obj any, _ query.FieldMatcher) bool {
+	entry, ok := obj.(*api.OrchestrationEntry)
+	return ok && entry.State == p.state && entry.Claimable(p.now)
+}
+
+func (p claimablePredicate) String() string {
+	return fmt.Sprintf("state = %d AND claimable at %s", p.state, p.now.Format(time.RFC3339))
+}
+
+// labelPredicate matches entries having the label key set to value. Label keys may contain dots and are therefore
+// not matched using field paths.
+type labelPredicate struct {
+	key   string
+	value string
+}
+
+func (p labelPredicate) Matches(obj any, _ query.FieldMatcher) bool {
+	entry, ok := obj.(*api.OrchestrationEntry)
+	if !ok {
+		return false
+	}
+	value, found := entry.Labels[p.key]
+	return found && value == p.value
+}
+
+func (p labelPredicate) String() string {
+	return fmt.Sprintf("labels[%s] = %s", p.key, p.value)
+}
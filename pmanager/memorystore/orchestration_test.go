@@ -0,0 +1,304 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestrationIndex_FindStateByID(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+
+	_, err := index.Create(ctx, &api.OrchestrationEntry{
+		ID:             "orch-1",
+		CorrelationID:  "corr-1",
+		State:          api.OrchestrationStateRunning,
+		StateTimestamp: time.Now(),
+	})
+	require.NoError(t, err)
+
+	state, version, err := index.FindStateByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, state)
+	assert.Equal(t, int64(0), version)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	entry.State = api.OrchestrationStateCompleted
+	require.NoError(t, index.Update(ctx, entry))
+
+	state, version, err = index.FindStateByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, state)
+	assert.Equal(t, int64(1), version)
+}
+
+func TestOrchestrationIndex_FindStateByID_NotFound(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+
+	_, _, err := index.FindStateByID(ctx, "missing")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	_, err = index.FindByID(ctx, "missing")
+	assert.ErrorIs(t, err, types.ErrNotFound, "FindStateByID and FindByID must report missing entries consistently")
+
+	_, err = index.Create(ctx, &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning})
+	require.NoError(t, err)
+	require.NoError(t, index.Delete(ctx, "orch-1"))
+
+	_, _, err = index.FindStateByID(ctx, "orch-1")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestOrchestrationIndex_FindByTimeRange(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Entries are created an hour apart and change state in reverse order
+	for i := range 5 {
+		_, err := index.Create(ctx, &api.OrchestrationEntry{
+			ID:               fmt.Sprintf("orch-%d", i),
+			State:            api.OrchestrationStateRunning,
+			CreatedTimestamp: base.Add(time.Duration(i) * time.Hour),
+			StateTimestamp:   base.Add(time.Duration(10-i) * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := index.FindByTimeRange(ctx, api.TimeFieldCreated, base.Add(time.Hour), base.Add(4*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-1", "orch-2", "orch-3"}, entryIDs(entries))
+
+	entries, err = index.FindByTimeRange(ctx, api.TimeFieldState, base.Add(7*time.Hour), base.Add(11*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-3", "orch-2", "orch-1", "orch-0"}, entryIDs(entries), "ordered by state timestamp")
+
+	entries, err = index.FindByTimeRange(ctx, api.TimeFieldState, base.Add(7*time.Hour), base.Add(11*time.Hour), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-3", "orch-2"}, entryIDs(entries))
+
+	entries, err = index.FindByTimeRange(ctx, api.TimeFieldCreated, base.Add(-2*time.Hour), base, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestOrchestrationIndex_FindByTimeRange_InvalidInput(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := index.FindByTimeRange(ctx, "deadline", now, now.Add(time.Hour), 10)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	_, err = index.FindByTimeRange(ctx, api.TimeFieldCreated, now, now, 10)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	_, err = index.FindByTimeRange(ctx, api.TimeFieldCreated, now, now.Add(time.Hour), 0)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+func TestOrchestrationIndex_FindByLabel(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tiers := []string{"gold", "silver", "gold", "bronze", "gold"}
+	for i, tier := range tiers {
+		_, err := index.Create(ctx, &api.OrchestrationEntry{
+			ID:             fmt.Sprintf("orch-%d", i),
+			State:          api.OrchestrationStateRunning,
+			StateTimestamp: base.Add(time.Duration(len(tiers)-i) * time.Hour),
+			Labels:         map[string]string{"customer.tier": tier, "region": "eu"},
+		})
+		require.NoError(t, err)
+	}
+	_, err := index.Create(ctx, &api.OrchestrationEntry{ID: "unlabeled", StateTimestamp: base})
+	require.NoError(t, err)
+
+	entries, err := index.FindByLabel(ctx, "customer.tier", "gold", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-4", "orch-2", "orch-0"}, entryIDs(entries), "ordered by state timestamp")
+
+	entries, err = index.FindByLabel(ctx, "customer.tier", "gold", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-4", "orch-2"}, entryIDs(entries))
+
+	entries, err = index.FindByLabel(ctx, "region", "us", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Labels are updatable
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	entry.Labels["customer.tier"] = "gold"
+	require.NoError(t, index.Update(ctx, entry))
+
+	entries, err = index.FindByLabel(ctx, "customer.tier", "gold", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orch-4", "orch-2", "orch-1", "orch-0"}, entryIDs(entries))
+}
+
+func TestOrchestrationIndex_FindByLabel_InvalidInput(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+
+	_, err := index.FindByLabel(ctx, "", "gold", 10)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	_, err = index.FindByLabel(ctx, "tier", "gold", 0)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+func entryIDs(entries []*api.OrchestrationEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestOrchestrationIndex_Checkpoint(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+
+	_, err := index.Create(ctx, &api.OrchestrationEntry{
+		ID:             "orch-1",
+		State:          api.OrchestrationStateRunning,
+		StateTimestamp: time.Now(),
+		Checkpoint:     []byte(`{"step":1,"resource":"r-1"}`),
+	})
+	require.NoError(t, err)
+
+	type checkpoint struct {
+		Step     int    `json:"step"`
+		Resource string `json:"resource"`
+	}
+	var loaded checkpoint
+	found, err := api.LoadCheckpoint(ctx, index, "orch-1", &loaded)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, checkpoint{Step: 1, Resource: "r-1"}, loaded)
+
+	require.NoError(t, api.SaveCheckpoint(ctx, index, "orch-1", checkpoint{Step: 2, Resource: "r-2"}))
+
+	found, err = api.LoadCheckpoint(ctx, index, "orch-1", &loaded)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, checkpoint{Step: 2, Resource: "r-2"}, loaded)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), entry.Version)
+}
+
+func TestOrchestrationIndex_LoadCheckpoint_NotSaved(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+
+	_, err := index.Create(ctx, &api.OrchestrationEntry{ID: "orch-1", StateTimestamp: time.Now()})
+	require.NoError(t, err)
+
+	var loaded map[string]any
+	found, err := api.LoadCheckpoint(ctx, index, "orch-1", &loaded)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = api.LoadCheckpoint(ctx, index, "missing", &loaded)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestOrchestrationIndex_ClaimNext_Concurrent(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	const entries = 10
+	for i := range entries {
+		_, err := index.Create(ctx, &api.OrchestrationEntry{
+			ID:             fmt.Sprintf("orch-%d", i),
+			State:          api.OrchestrationStateRunning,
+			StateTimestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[string]string)
+	var wg sync.WaitGroup
+	for worker := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := index.ClaimNext(ctx, api.OrchestrationStateRunning, fmt.Sprintf("worker-%d", worker), time.Minute)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			assert.NotContains(t, claimed, entry.ID, "an entry must only be claimed once")
+			claimed[entry.ID] = entry.ClaimedBy
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, claimed, entries)
+
+	_, err := index.ClaimNext(ctx, api.OrchestrationStateRunning, "worker-late", time.Minute)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestOrchestrationIndex_ClaimNext_OldestFirstAndLeaseExpiry(t *testing.T) {
+	index := NewOrchestrationIndex()
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, state := range []api.OrchestrationState{api.OrchestrationStateCompleted, api.OrchestrationStateRunning, api.OrchestrationStateRunning} {
+		_, err := index.Create(ctx, &api.OrchestrationEntry{
+			ID:             fmt.Sprintf("orch-%d", i),
+			State:          state,
+			StateTimestamp: base.Add(-time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	entry, err := index.ClaimNext(ctx, api.OrchestrationStateRunning, "worker-1", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "orch-2", entry.ID, "the entry with the oldest state timestamp is claimed first")
+	assert.Equal(t, "worker-1", entry.ClaimedBy)
+
+	stored, err := index.FindByID(ctx, "orch-2")
+	require.NoError(t, err)
+	assert.Equal(t, "worker-1", stored.ClaimedBy)
+
+	time.Sleep(5 * time.Millisecond)
+	entry, err = index.ClaimNext(ctx, api.OrchestrationStateRunning, "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "orch-2", entry.ID, "entries with an expired lease are claimable again")
+	assert.Equal(t, "worker-2", entry.ClaimedBy)
+
+	_, err = index.ClaimNext(ctx, api.OrchestrationStateRunning, "", time.Minute)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+	_, err = index.ClaimNext(ctx, api.OrchestrationStateRunning, "worker-3", 0)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// OutboxStore is an in-memory api.OutboxStore. Since in-memory transactions cannot be rolled back, messages are
+// recorded as soon as they are added. Sent messages are removed.
+type OutboxStore struct {
+	mu       sync.RWMutex
+	messages []*api.OutboxMessage
+}
+
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{}
+}
+
+func (s *OutboxStore) Add(_ context.Context, message *api.OutboxMessage) error {
+	if message.ID == "" {
+		return types.ErrInvalidInput
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexOf(message.ID) >= 0 {
+		return types.ErrConflict
+	}
+	copied := *message
+	s.messages = append(s.messages, &copied)
+	return nil
+}
+
+func (s *OutboxStore) FindPending(_ context.Context, limit int) ([]*api.OutboxMessage, error) {
+	if limit <= 0 {
+		return nil, types.ErrInvalidInput
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pending := make([]*api.OutboxMessage, 0, min(limit, len(s.messages)))
+	for _, message := range s.messages[:min(limit, len(s.messages))] {
+		copied := *message
+		pending = append(pending, &copied)
+	}
+	return pending, nil
+}
+
+func (s *OutboxStore) MarkSent(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return types.ErrNotFound
+	}
+	s.messages = slices.Delete(s.messages, i, i+1)
+	return nil
+}
+
+func (s *OutboxStore) indexOf(id string) int {
+	return slices.IndexFunc(s.messages, func(m *api.OutboxMessage) bool { return m.ID == id })
+}
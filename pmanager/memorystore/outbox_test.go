@@ -0,0 +1,52 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxStore_PendingUntilSent(t *testing.T) {
+	outbox := NewOutboxStore()
+	ctx := context.Background()
+
+	for _, id := range []string{"msg-1", "msg-2", "msg-3"} {
+		require.NoError(t, outbox.Add(ctx, &api.OutboxMessage{ID: id, Subject: "event.test", Payload: []byte(id)}))
+	}
+	assert.ErrorIs(t, outbox.Add(ctx, &api.OutboxMessage{ID: "msg-1"}), types.ErrConflict)
+	assert.ErrorIs(t, outbox.Add(ctx, &api.OutboxMessage{}), types.ErrInvalidInput)
+
+	pending, err := outbox.FindPending(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "msg-1", pending[0].ID)
+	assert.Equal(t, "msg-2", pending[1].ID)
+
+	require.NoError(t, outbox.MarkSent(ctx, "msg-2"))
+	assert.ErrorIs(t, outbox.MarkSent(ctx, "msg-2"), types.ErrNotFound)
+
+	pending, err = outbox.FindPending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "msg-1", pending[0].ID)
+	assert.Equal(t, "msg-3", pending[1].ID)
+
+	_, err = outbox.FindPending(ctx, 0)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
@@ -0,0 +1,62 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// RawPayloadStore is an in-memory api.RawPayloadStore. Since in-memory transactions cannot be rolled back, payloads
+// are recorded as soon as they are saved.
+type RawPayloadStore struct {
+	mu       sync.RWMutex
+	payloads map[rawPayloadKey]*api.RawPayload
+}
+
+type rawPayloadKey struct {
+	id       string
+	revision uint64
+}
+
+func NewRawPayloadStore() *RawPayloadStore {
+	return &RawPayloadStore{payloads: make(map[rawPayloadKey]*api.RawPayload)}
+}
+
+func (s *RawPayloadStore) Save(_ context.Context, payload *api.RawPayload) error {
+	if payload.ID == "" {
+		return types.ErrInvalidInput
+	}
+	copied := *payload
+	copied.Payload = slices.Clone(payload.Payload)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[rawPayloadKey{id: payload.ID, revision: payload.Revision}] = &copied
+	return nil
+}
+
+func (s *RawPayloadStore) Find(_ context.Context, id string, revision uint64) (*api.RawPayload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	payload, found := s.payloads[rawPayloadKey{id: id, revision: revision}]
+	if !found {
+		return nil, types.ErrNotFound
+	}
+	copied := *payload
+	copied.Payload = slices.Clone(payload.Payload)
+	return &copied, nil
+}
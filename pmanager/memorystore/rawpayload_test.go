@@ -0,0 +1,44 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package memorystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawPayloadStore_SaveAndFind(t *testing.T) {
+	payloads := NewRawPayloadStore()
+	ctx := context.Background()
+
+	require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 0, Payload: []byte("created")}))
+	require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 1, Payload: []byte("updated")}))
+	assert.ErrorIs(t, payloads.Save(ctx, &api.RawPayload{}), types.ErrInvalidInput)
+
+	payload, err := payloads.Find(ctx, "orch-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), payload.Payload)
+
+	require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 1, Payload: []byte("redelivered")}))
+	payload, err = payloads.Find(ctx, "orch-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("redelivered"), payload.Payload, "saving a revision again replaces its payload")
+
+	_, err = payloads.Find(ctx, "orch-1", 2)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
@@ -47,6 +47,7 @@ type OrchestrationDefinition struct {
 
 type OrchestrationEntry struct {
 	ID                string                  `json:"id"`
+	Version           int64                   `json:"version"`
 	CorrelationID     string                  `json:"correlationId"`
 	State             int                     `json:"state"`
 	StateTimestamp    time.Time               `json:"stateTimestamp"`
@@ -113,6 +113,7 @@ func ToMappingEntries(entries []api.MappingEntry) []MappingEntry {
 func ToOrchestrationEntry(entry *api.OrchestrationEntry) OrchestrationEntry {
 	return OrchestrationEntry{
 		ID:                entry.ID,
+		Version:           entry.Version,
 		CorrelationID:     entry.CorrelationID,
 		State:             int(entry.State),
 		StateTimestamp:    entry.StateTimestamp,
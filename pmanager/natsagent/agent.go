@@ -25,6 +25,10 @@ import (
 
 const (
 	timeout = 10 * time.Second
+
+	workersKey              = "workers"
+	maxConcurrentPerTypeKey = "maxConcurrentPerType"
+	nakOnTypeLimitKey       = "nakOnTypeLimit"
 )
 
 // AgentServiceAssembly provides common functionality for NATS-based agents
@@ -68,12 +72,20 @@ func (a *AgentServiceAssembly) Start(startCtx *system.StartContext) error {
 		Config:   startCtx.Config,
 	}
 
+	var maxConcurrentPerType map[string]int
+	if err = startCtx.Config.UnmarshalKey(maxConcurrentPerTypeKey, &maxConcurrentPerType); err != nil {
+		return fmt.Errorf("invalid %s configuration: %w", maxConcurrentPerTypeKey, err)
+	}
+
 	executor := &natsorchestration.NatsActivityExecutor{
-		Client:            natsclient.NewMsgClient(a.natsClient),
-		StreamName:        a.streamName,
-		ActivityType:      a.activityType,
-		ActivityProcessor: a.newProcessor(actx),
-		Monitor:           startCtx.LogMonitor,
+		Client:               natsclient.NewMsgClient(a.natsClient),
+		StreamName:           a.streamName,
+		ActivityType:         a.activityType,
+		ActivityProcessor:    a.newProcessor(actx),
+		Monitor:              startCtx.LogMonitor,
+		Workers:              startCtx.Config.GetInt(workersKey),
+		MaxConcurrentPerType: maxConcurrentPerType,
+		NakOnTypeLimit:       startCtx.Config.GetBool(nakOnTypeLimitKey),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -16,7 +16,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,21 +33,37 @@ type NatsActivityExecutor struct {
 	ActivityType      string
 	ActivityProcessor api.ActivityProcessor
 	Monitor           system.LogMonitor
+	// Naming determines the stream, subjects and consumer names. If nil, the default strategy for StreamName is used.
+	Naming natsclient.NamingStrategy
+	// Workers is the number of messages processed concurrently. Messages are processed sequentially if not set.
+	Workers int
+	// MaxConcurrentPerType limits the number of messages processed concurrently for an orchestration type.
+	MaxConcurrentPerType map[string]int
+	// NakOnTypeLimit redelivers messages for an orchestration type at its limit after TypeLimitDelay instead of
+	// waiting for a slot. Waiting messages occupy their worker, so the wait is bounded by TypeLimitDelay, after which
+	// the message is redelivered as well.
+	NakOnTypeLimit bool
+	TypeLimitDelay time.Duration
+
+	limiter *TypeLimiter
 }
 
+const defaultTypeLimitDelay = time.Second
+
 // Execute starts a goroutine to process messages from the activity queue.
 func (e *NatsActivityExecutor) Execute(ctx context.Context) error {
-	stream, err := e.Client.Stream(ctx, e.StreamName)
+	stream, err := e.Client.Stream(ctx, e.naming().StreamName())
 	if err != nil {
 		return fmt.Errorf("error opening stream: %w", err)
 	}
 
-	consumerName := strings.ReplaceAll(e.ActivityType, ".", "-")
+	consumerName := e.naming().DurableName(e.ActivityType)
 	consumer, err := stream.Consumer(ctx, consumerName)
 	if err != nil {
 		return fmt.Errorf("error connecting to consumer %s: %w", consumerName, err)
 	}
 
+	e.limiter = NewTypeLimiter(e.MaxConcurrentPerType)
 	go func() {
 		err := e.processLoop(ctx, consumer)
 		if err != nil {
@@ -61,6 +77,12 @@ func (e *NatsActivityExecutor) Execute(ctx context.Context) error {
 // It runs continuously until the provided context is canceled or an error occurs.
 // Returns an error if message fetching or processing fails.
 func (e *NatsActivityExecutor) processLoop(ctx context.Context, consumer jetstream.Consumer) error {
+	var workers chan struct{}
+	var wg sync.WaitGroup
+	if e.Workers > 1 {
+		workers = make(chan struct{}, e.Workers)
+		defer wg.Wait()
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -72,14 +94,34 @@ func (e *NatsActivityExecutor) processLoop(ctx context.Context, consumer jetstre
 			}
 
 			for message := range messageBatch.Messages() {
-				if err = e.processMessage(ctx, message); err != nil {
-					e.Monitor.Warnf("Error processing message: %v", err)
+				if workers == nil {
+					e.handleMessage(ctx, message)
+					continue
 				}
+				select {
+				case workers <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				wg.Add(1)
+				go func() {
+					defer func() {
+						<-workers
+						wg.Done()
+					}()
+					e.handleMessage(ctx, message)
+				}()
 			}
 		}
 	}
 }
 
+func (e *NatsActivityExecutor) handleMessage(ctx context.Context, message jetstream.Msg) {
+	if err := e.processMessage(ctx, message); err != nil {
+		e.Monitor.Warnf("Error processing message: %v", err)
+	}
+}
+
 // processMessage processes a single message from the JetStream consumer by delegating to its ActivityProcessor. When
 // processing is complete, the orchestration state is updated, messages for the next activities are enqueued if the
 // orchestration can proceed, and the original message is acknowledged.
@@ -100,6 +142,12 @@ func (e *NatsActivityExecutor) processMessage(ctx context.Context, message jetst
 		return fmt.Errorf("failed to read orchestration data: %w", err)
 	}
 
+	release, acquired, err := e.acquireTypeSlot(ctx, orchestration.OrchestrationType, message)
+	if !acquired {
+		return err
+	}
+	defer release()
+
 	activityContext := api.NewActivityContext(
 		ctx,
 		orchestration.ID,
@@ -170,8 +218,8 @@ func (e *NatsActivityExecutor) processOnActivityCompletion(
 		return err
 	}
 
-	// Return if orchestration is in the error state since processing should stop
-	if orchestration.State == api.OrchestrationStateErrored {
+	// Return if the orchestration errored or is being compensated since processing should stop
+	if haltsProcessing(orchestration.State) {
 		return natsclient.AckMessage(message)
 	}
 
@@ -194,7 +242,7 @@ func (e *NatsActivityExecutor) processOnActivityCompletion(
 	}
 
 	// Enqueue next activities
-	if err := EnqueueActivityMessages(activityContext.Context(), orchestration.ID, next, e.Client); err != nil {
+	if err := EnqueueActivityMessages(activityContext.Context(), orchestration.ID, next, e.Client, e.naming()); err != nil {
 		// Failed redeliver the message
 		err = natsclient.NakError(message, err)
 		return fmt.Errorf("failed to enqueue next orchestration activities %s: %w", oMessage.OrchestrationID, err)
@@ -208,15 +256,20 @@ func (e *NatsActivityExecutor) handleOrchestrationCompletion(
 	orchestration api.Orchestration,
 	revision uint64,
 	message jetstream.Msg) error {
-	// Mark as completed
-	_, _, err := UpdateOrchestration(activityContext.Context(), orchestration, revision, e.Client, func(o *api.Orchestration) {
-		o.SetState(api.OrchestrationStateCompleted)
+	// Mark as completed unless the orchestration errored or its compensation started concurrently
+	updated, _, err := UpdateOrchestration(activityContext.Context(), orchestration, revision, e.Client, func(o *api.Orchestration) {
+		if !haltsProcessing(o.State) {
+			o.SetState(api.OrchestrationStateCompleted)
+		}
 	})
 	if err != nil {
 		// Error marking, redeliver the message
 		err = natsclient.NakError(message, err)
 		return fmt.Errorf("failed to mark orchestration %s as completed: %v", orchestration.ID, err)
 	}
+	if updated.State != api.OrchestrationStateCompleted {
+		return natsclient.AckMessage(message)
+	}
 
 	err = e.publishResponse(activityContext, orchestration)
 	if err != nil {
@@ -226,6 +279,12 @@ func (e *NatsActivityExecutor) handleOrchestrationCompletion(
 	return natsclient.AckMessage(message)
 }
 
+// haltsProcessing returns true if the activities of an orchestration in the state must not proceed. Orchestrations
+// that passed their deadline are compensated by the DeadlineSweeper.
+func haltsProcessing(state api.OrchestrationState) bool {
+	return state == api.OrchestrationStateErrored || state == api.OrchestrationStateCompensating
+}
+
 func (e *NatsActivityExecutor) publishResponse(activityContext api.ActivityContext, orchestration api.Orchestration) error {
 	response := &model.OrchestrationResponse{
 		ID:                uuid.New().String(),
@@ -239,10 +298,52 @@ func (e *NatsActivityExecutor) publishResponse(activityContext api.ActivityConte
 	if err != nil {
 		return fmt.Errorf("failed to marshal orchestration response: %w", err)
 	}
-	_, err = e.Client.Publish(activityContext.Context(), natsclient.CFMOrchestrationResponseSubject, ser)
+	_, err = e.Client.Publish(activityContext.Context(), e.naming().Subject(natsclient.CFMOrchestrationResponse), ser)
 	return err
 }
 
+// acquireTypeSlot acquires a processing slot for the orchestration type. If the type is at its limit, the message is
+// redelivered later and false is returned, immediately if the executor is configured to nak or once the wait for a slot
+// exceeds TypeLimitDelay otherwise.
+func (e *NatsActivityExecutor) acquireTypeSlot(
+	ctx context.Context,
+	orchestrationType model.OrchestrationType,
+	message jetstream.Msg) (func(), bool, error) {
+
+	if release, ok := e.limiter.TryAcquire(orchestrationType); ok {
+		return release, true, nil
+	}
+	delay := e.TypeLimitDelay
+	if delay <= 0 {
+		delay = defaultTypeLimitDelay
+	}
+	if !e.NakOnTypeLimit {
+		// The wait is bounded since it occupies the worker, otherwise a type at its limit could take all workers and
+		// starve the other types
+		waitCtx, cancel := context.WithTimeout(ctx, delay)
+		release, err := e.limiter.Acquire(waitCtx, orchestrationType)
+		cancel()
+		if err == nil {
+			return release, true, nil
+		}
+		if ctx.Err() != nil {
+			// The message is redelivered once the ack wait expires
+			return nil, false, ctx.Err()
+		}
+	}
+	if err := message.NakWithDelay(delay); err != nil {
+		return nil, false, fmt.Errorf("failed to defer message for orchestration type %s: %w", orchestrationType, err)
+	}
+	return nil, false, nil
+}
+
+func (e *NatsActivityExecutor) naming() natsclient.NamingStrategy {
+	if e.Naming == nil {
+		return natsclient.DefaultNamingStrategy{Stream: e.StreamName}
+	}
+	return e.Naming
+}
+
 // handleRetryError handles retriable errors by persisting the orchestration state and re-delivering the message using a Nak.
 func (e *NatsActivityExecutor) handleRetryError(
 	activityContext api.ActivityContext,
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
 	"github.com/metaform/connector-fabric-manager/common/system"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
 	"github.com/nats-io/nats.go/jetstream"
@@ -160,7 +161,7 @@ func TestEnqueueMessages_Errors(t *testing.T) {
 			mockClient := mocks.NewMockMsgClient(t)
 			tt.setupMock(mockClient)
 
-			err := EnqueueActivityMessages(context.Background(), "test-oid", tt.activities, mockClient)
+			err := EnqueueActivityMessages(context.Background(), "test-oid", tt.activities, mockClient, natsclient.DefaultNamingStrategy{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -14,35 +14,194 @@ package natsorchestration
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/metaform/connector-fabric-manager/common/model"
 	"github.com/metaform/connector-fabric-manager/common/natsclient"
 	"github.com/metaform/connector-fabric-manager/common/store"
 	"github.com/metaform/connector-fabric-manager/common/system"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
-	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 const (
-	setupStreamKey = "setupStream"
+	setupStreamKey            = "setupStream"
+	watcherDurableKey         = "watcher.durable"
+	watcherDeliverPolicyKey   = "watcher.deliverPolicy"
+	watcherStartSequenceKey   = "watcher.startSequence"
+	watcherCloudEventsKey     = "watcher.cloudEvents"
+	watcherBackoffInitialKey  = "watcher.backoff.initial"
+	watcherBackoffMaxKey      = "watcher.backoff.max"
+	watcherAutoProvisionKey   = "watcher.autoProvision"
+	watcherAckBatchSizeKey    = "watcher.ackBatch.size"
+	watcherAckBatchFlushKey   = "watcher.ackBatch.flushInterval"
+	watcherVersionKey         = "watcher.version"
+	minConsumerVersionKey     = "orchestrator.minConsumerVersion"
+	orchestratorExpiryKey     = "orchestrator.expiryTTL"
+	watcherControlSubjectKey  = "watcher.controlSubject"
+	watcherClockSkewKey       = "watcher.clockSkewTolerance"
+	watcherMaxPanicsKey       = "watcher.maxPanics"
+	watcherPriorityWindowKey  = "watcher.priorityWindow"
+	watcherProcessTimeoutKey  = "watcher.processingTimeout"
+	watcherHeartbeatKey       = "watcher.heartbeatInterval"
+	watcherLogKeysKey         = "watcher.logKeys"
+	watcherMalformedKey       = "watcher.malformedPolicy"
+	watcherFetchBatchKey      = "watcher.fetchBatch"
+	watcherMaxWaitingKey      = "watcher.maxWaiting"
+	watcherDebounceKey        = "watcher.debounceWindow"
+	watcherCorrelatedKey      = "watcher.correlatedBatches"
+	watcherResumeBatchesKey   = "watcher.correlatedBatches.resume"
+	watcherSourcesKey         = "watcher.sources"
+	watcherStrictDecodeKey    = "watcher.strictDecode"
+	watcherAckSyncKey         = "watcher.ackSync"
+	watcherMaxAttemptsKey     = "watcher.maxAttempts"
+	watcherHeaderFilterKey    = "watcher.headerFilter"
+	watcherPoolMessagesKey    = "watcher.poolExhaustion.messages"
+	watcherPoolPauseKey       = "watcher.poolExhaustion.pause"
+	watcherBootstrapKey       = "watcher.bootstrap"
+	watcherReplayKey          = "watcher.bootstrapReplay"
+	watcherTransitionsKey     = "watcher.validateTransitions"
+	watcherSLOThresholdKey    = "watcher.sloThreshold"
+	watcherUniqueCorrKey      = "watcher.uniqueCorrelationPerType"
+	watcherMaxEntryBytesKey   = "watcher.maxEntryBytes"
+	watcherStormThresholdKey  = "watcher.redeliveryStorm.threshold"
+	watcherStormWindowKey     = "watcher.redeliveryStorm.window"
+	watcherStormBackoffKey    = "watcher.redeliveryStorm.backoffFactor"
+	dlqSampleRateKey          = "dlq.sampleRate"
+	deadlineSweepIntervalKey  = "deadline.sweepInterval"
+	retentionPoliciesKey      = "retention.policies"
+	typesKey                  = "types"
+	retentionPurgeIntervalKey = "retention.purgeInterval"
+	outboxEnabledKey          = "outbox.enabled"
+	outboxRelayIntervalKey    = "outbox.relayInterval"
+	outboxFieldNamingKey      = "outbox.fieldNaming"
+	outboxOmitEmptyKey        = "outbox.omitEmpty"
+	rawPayloadsEnabledKey     = "rawPayloads.enabled"
+	provisionEnabledKey       = "provision.enabled"
+	consistencySampleKey      = "consistency.sampleSize"
+	consistencyIntervalKey    = "consistency.interval"
+	consistencyGraceKey       = "consistency.grace"
+
+	defaultDeadlineSweepInterval  = 30   // seconds
+	defaultRetentionPurgeInterval = 3600 // seconds
+	defaultOutboxRelayInterval    = 1000 // milliseconds
+	defaultWatcherBackoffInitial  = 1    // seconds
+	defaultWatcherBackoffMax      = 30   // seconds
+	defaultWatcherAckBatchSize    = 0    // acks are not batched
+	defaultWatcherAckBatchFlush   = 100  // milliseconds
+	defaultWatcherClockSkew       = 1000 // milliseconds
+	defaultConsistencyInterval    = 300  // seconds
+	defaultConsistencyGrace       = 60   // seconds
 )
 
+// OrchestratorOption configures the NATS orchestrator service assembly.
+type OrchestratorOption func(*natsOrchestratorServiceAssembly)
+
+// WithNamingStrategy sets the strategy used to name the stream, subjects and consumers. Defaults to
+// natsclient.DefaultNamingStrategy for the configured stream.
+func WithNamingStrategy(naming natsclient.NamingStrategy) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.naming = naming
+	}
+}
+
+// WithProjector registers a projector maintaining a read model of the orchestrations recorded in the index, see
+// OrchestrationIndexWatcher.RegisterProjector.
+func WithProjector(projector api.Projector) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.projectors = append(a.projectors, projector)
+	}
+}
+
+// WithHandler registers a handler for the recorded entries of the orchestration type and mode, see
+// api.HandlerRegistry. An empty mode registers the default handler of the type.
+func WithHandler(orchestrationType model.OrchestrationType, mode string, handler api.EntryHandler) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		if a.handlers == nil {
+			a.handlers = api.NewHandlerRegistry()
+		}
+		a.handlers.Register(orchestrationType, mode, handler)
+	}
+}
+
+// WithDeliveryMetrics sets the metrics recording the delivery attempts of processed orchestration messages. Defaults to
+// a DeliveryHistogram.
+func WithDeliveryMetrics(metrics api.DeliveryMetrics) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.deliveryMetrics = metrics
+	}
+}
+
+// WithSLOBreachHook sets the hook invoked for orchestration changes recorded later than the processing SLO configured
+// by watcher.sloThreshold allows, see SLOBreachHook.
+func WithSLOBreachHook(hook SLOBreachHook) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.sloBreachHook = hook
+	}
+}
+
+// WithRedeliveryStormHook sets the hook invoked when the share of redelivered messages reaches the threshold configured
+// by watcher.redeliveryStorm.threshold, see RedeliveryStormHook.
+func WithRedeliveryStormHook(hook RedeliveryStormHook) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.stormHook = hook
+	}
+}
+
+// WithConsistencyHook sets the hook invoked for changes found not to be reflected by the index when consistency checks
+// are enabled by consistency.sampleSize, see ConsistencyChecker.
+func WithConsistencyHook(hook ConsistencyHook) OrchestratorOption {
+	return func(a *natsOrchestratorServiceAssembly) {
+		a.consistencyHook = hook
+	}
+}
+
 type natsOrchestratorServiceAssembly struct {
 	uri        string
 	bucket     string
-	streamName string
+	naming     natsclient.NamingStrategy
 	natsClient *natsclient.NatsClient
 	system.DefaultServiceAssembly
-	processCancel context.CancelFunc
-	subscription  *nats.Subscription
+	processCancel   context.CancelFunc
+	watcher         *OrchestrationIndexWatcher
+	consumer        jetstream.Consumer
+	bootstrap       EventSource
+	replayInOrder   bool
+	sweeper         *DeadlineSweeper
+	sweepInterval   time.Duration
+	purger          *RetentionPurger
+	purgeInterval   time.Duration
+	relay           *OutboxRelay
+	relayInterval   time.Duration
+	projectors      []api.Projector
+	handlers        *api.HandlerRegistry
+	deliveryMetrics api.DeliveryMetrics
+	sloBreachHook   SLOBreachHook
+	stormHook       RedeliveryStormHook
+	consistency     *ConsistencyChecker
+	checkInterval   time.Duration
+	consistencyHook ConsistencyHook
+	sources         []Source
+	sourceClients   []*natsclient.NatsClient
 }
 
-func NewOrchestratorServiceAssembly(uri string, bucket string, streamName string) system.ServiceAssembly {
-	return &natsOrchestratorServiceAssembly{
-		uri:        uri,
-		bucket:     bucket,
-		streamName: streamName,
+func NewOrchestratorServiceAssembly(
+	uri string,
+	bucket string,
+	streamName string,
+	opts ...OrchestratorOption) system.ServiceAssembly {
+	assembly := &natsOrchestratorServiceAssembly{
+		uri:             uri,
+		bucket:          bucket,
+		naming:          natsclient.DefaultNamingStrategy{Stream: streamName},
+		deliveryMetrics: NewDeliveryHistogram(defaultDeliveryBuckets),
 	}
+	for _, opt := range opts {
+		opt(assembly)
+	}
+	return assembly
 }
 
 func (a *natsOrchestratorServiceAssembly) Name() string {
@@ -50,11 +209,14 @@ func (a *natsOrchestratorServiceAssembly) Name() string {
 }
 
 func (a *natsOrchestratorServiceAssembly) Provides() []system.ServiceType {
-	return []system.ServiceType{api.OrchestratorKey, natsclient.NatsClientKey}
+	return []system.ServiceType{api.OrchestratorKey, natsclient.NatsClientKey, api.HealthCheckKey, api.DeadLetterQueueKey,
+		api.TypeRegistryKey, api.DeliveryMetricsKey, api.IndexRebuilderKey, api.RetrierKey,
+		api.EntryValidatorKey, api.BulkTransitionerKey, api.TemplateRegistryKey, api.EntryNotifierKey,
+		api.MaintenanceKey, api.TypePauserKey}
 }
 
 func (d *natsOrchestratorServiceAssembly) Requires() []system.ServiceType {
-	return []system.ServiceType{api.OrchestrationIndexKey, store.TransactionContextKey}
+	return []system.ServiceType{api.OrchestrationIndexKey, api.OutboxStoreKey, store.TransactionContextKey}
 }
 
 func (a *natsOrchestratorServiceAssembly) Init(ctx *system.InitContext) error {
@@ -69,13 +231,15 @@ func (a *natsOrchestratorServiceAssembly) Init(ctx *system.InitContext) error {
 	natsContext := context.Background()
 	defer natsContext.Done()
 
-	setupStream := true
-	if ctx.Config.IsSet(setupStreamKey) {
+	// Provisioning creates the stream with the consumers and verifies existing resources are compatible
+	provision := ctx.Config.IsSet(provisionEnabledKey) && ctx.Config.GetBool(provisionEnabledKey)
+	setupStream := !provision
+	if !provision && ctx.Config.IsSet(setupStreamKey) {
 		setupStream = ctx.Config.GetBool(setupStreamKey)
 	}
 
 	if setupStream {
-		_, err = natsclient.SetupStream(natsContext, natsClient, a.streamName)
+		_, err = natsclient.SetupStream(natsContext, natsClient, a.naming.StreamName())
 		if err != nil {
 			return fmt.Errorf("error initializing NATS stream: %w", err)
 		}
@@ -84,31 +248,350 @@ func (a *natsOrchestratorServiceAssembly) Init(ctx *system.InitContext) error {
 	index := ctx.Registry.Resolve(api.OrchestrationIndexKey).(store.EntityStore[*api.OrchestrationEntry])
 	trxContext := ctx.Registry.Resolve(store.TransactionContextKey).(store.TransactionContext)
 
-	watcher := &OrchestrationIndexWatcher{
+	cloudEventFormat, err := ParseCloudEventFormat(ctx.GetConfigStrOrDefault(watcherCloudEventsKey, string(CloudEventFormatNone)))
+	if err != nil {
+		return err
+	}
+
+	a.watcher = &OrchestrationIndexWatcher{
 		index:      index,
 		trxContext: trxContext,
 		monitor:    ctx.LogMonitor,
+		decoder: CloudEventsDecoder{
+			DefaultFormat: cloudEventFormat,
+			Strict:        ctx.Config.IsSet(watcherStrictDecodeKey) && ctx.Config.GetBool(watcherStrictDecodeKey),
+		},
+		backoff: ExponentialBackoff{
+			Initial: time.Duration(ctx.GetConfigIntOrDefault(watcherBackoffInitialKey, defaultWatcherBackoffInitial)) * time.Second,
+			Max:     time.Duration(ctx.GetConfigIntOrDefault(watcherBackoffMaxKey, defaultWatcherBackoffMax)) * time.Second,
+		},
+		version:            ctx.GetConfigIntOrDefault(watcherVersionKey, 0),
+		controlSubject:     ctx.GetConfigStrOrDefault(watcherControlSubjectKey, ""),
+		clockSkewTolerance: time.Duration(ctx.GetConfigIntOrDefault(watcherClockSkewKey, defaultWatcherClockSkew)) * time.Millisecond,
+		maxPanics:          ctx.GetConfigIntOrDefault(watcherMaxPanicsKey, defaultMaxPanics),
+		maxAttempts:        ctx.GetConfigIntOrDefault(watcherMaxAttemptsKey, 0),
+		maxEntryBytes:      ctx.GetConfigIntOrDefault(watcherMaxEntryBytesKey, 0),
+		priorityWindow:     ctx.GetConfigIntOrDefault(watcherPriorityWindowKey, 1),
+		fetchBatch:         ctx.GetConfigIntOrDefault(watcherFetchBatchKey, 1),
+		deliveryMetrics:    a.deliveryMetrics,
+		onSLOBreach:        a.sloBreachHook,
+		sloThreshold:       time.Duration(ctx.GetConfigIntOrDefault(watcherSLOThresholdKey, 0)) * time.Millisecond,
+		processingTimeout:  time.Duration(ctx.GetConfigIntOrDefault(watcherProcessTimeoutKey, 0)) * time.Millisecond,
+		heartbeatInterval:  time.Duration(ctx.GetConfigIntOrDefault(watcherHeartbeatKey, 0)) * time.Millisecond,
+	}
+
+	// The storm threshold is the percentage of redelivered messages in the window, disabled when zero
+	if threshold := ctx.GetConfigIntOrDefault(watcherStormThresholdKey, 0); threshold > 0 {
+		window := ctx.GetConfigIntOrDefault(watcherStormWindowKey, defaultStormWindow)
+		if threshold > 100 || window <= 0 {
+			return fmt.Errorf("invalid redelivery storm configuration: threshold %d%%, window %d", threshold, window)
+		}
+		a.watcher.redeliveries = newRedeliveryTracker(window, float64(threshold)/100)
+		a.watcher.onRedeliveryStorm = a.stormHook
+		a.watcher.stormBackoffFactor = ctx.GetConfigIntOrDefault(watcherStormBackoffKey, 1)
 	}
-	a.subscription, err = a.natsClient.JetStream.Conn().Subscribe("$KV."+a.bucket+".>", func(msg *nats.Msg) {
-		watcher.onMessage(msg.Data, msg)
+
+	for _, projector := range a.projectors {
+		a.watcher.RegisterProjector(projector)
+	}
+	if a.handlers != nil {
+		a.watcher.RegisterProjector(a.handlers)
+	}
+
+	if err := ctx.Config.UnmarshalKey(watcherHeaderFilterKey, &a.watcher.headerFilter); err != nil {
+		return fmt.Errorf("invalid %s configuration: %w", watcherHeaderFilterKey, err)
+	}
+	if err := ctx.Config.UnmarshalKey(watcherLogKeysKey, &a.watcher.logKeys); err != nil {
+		return fmt.Errorf("invalid %s configuration: %w", watcherLogKeysKey, err)
+	}
+
+	poolMessages := DefaultPoolExhaustedMessages
+	if ctx.Config.IsSet(watcherPoolMessagesKey) {
+		// An empty list disables the classifier
+		poolMessages = ctx.Config.GetStringSlice(watcherPoolMessagesKey)
+	}
+	if len(poolMessages) > 0 {
+		a.watcher.poolExhausted = MatchErrorMessages(poolMessages...)
+		a.watcher.poolPause = time.Duration(ctx.GetConfigIntOrDefault(watcherPoolPauseKey, 0)) * time.Millisecond
+	}
+
+	a.watcher.ackSync = ctx.Config.IsSet(watcherAckSyncKey) && ctx.Config.GetBool(watcherAckSyncKey)
+	a.watcher.enforceUniqueCorrelationPerType = ctx.Config.IsSet(watcherUniqueCorrKey) && ctx.Config.GetBool(watcherUniqueCorrKey)
+	a.watcher.validateTransitions = ctx.Config.IsSet(watcherTransitionsKey) && ctx.Config.GetBool(watcherTransitionsKey)
+	a.watcher.correlatedBatches = ctx.Config.IsSet(watcherCorrelatedKey) && ctx.Config.GetBool(watcherCorrelatedKey)
+	a.watcher.resumeCorrelatedBatches = ctx.Config.IsSet(watcherResumeBatchesKey) && ctx.Config.GetBool(watcherResumeBatchesKey)
+	if batchSize := ctx.GetConfigIntOrDefault(watcherAckBatchSizeKey, defaultWatcherAckBatchSize); batchSize > 1 {
+		if a.watcher.ackSync {
+			return fmt.Errorf("%s cannot be combined with %s", watcherAckSyncKey, watcherAckBatchSizeKey)
+		}
+		flushInterval := time.Duration(ctx.GetConfigIntOrDefault(watcherAckBatchFlushKey, defaultWatcherAckBatchFlush)) * time.Millisecond
+		a.watcher.acks = NewAckBatcher(batchSize, flushInterval, ctx.LogMonitor)
+	}
+
+	if window := ctx.GetConfigIntOrDefault(watcherDebounceKey, 0); window > 0 {
+		if a.watcher.correlatedBatches {
+			return fmt.Errorf("%s cannot be combined with %s", watcherCorrelatedKey, watcherDebounceKey)
+		}
+		a.watcher.debouncer = newDebouncer(time.Duration(window) * time.Millisecond)
+	}
+
+	malformedPolicy, err := ParseMalformedPolicy(ctx.GetConfigStrOrDefault(watcherMalformedKey, string(MalformedDeadLetter)))
+	if err != nil {
+		return err
+	}
+	a.watcher.malformedPolicy = malformedPolicy
+
+	deliverPolicy, err := ParseDeliverPolicy(ctx.GetConfigStrOrDefault(watcherDeliverPolicyKey, string(DeliverNew)))
+	if err != nil {
+		return err
+	}
+	consumerConfig, err := newWatcherConsumerConfig(a.bucket, WatcherConfig{
+		Durable:       a.naming.DurableName(ctx.GetConfigStrOrDefault(watcherDurableKey, defaultWatcherDurable)),
+		DeliverPolicy: deliverPolicy,
+		StartSequence: uint64(ctx.GetConfigIntOrDefault(watcherStartSequenceKey, 0)),
+		MaxWaiting:    ctx.GetConfigIntOrDefault(watcherMaxWaitingKey, 0),
 	})
+	if err != nil {
+		return err
+	}
+	if provision {
+		if err = NewProvisioner(natsClient.JetStream, a.naming, a.bucket, consumerConfig).Provision(natsContext); err != nil {
+			return err
+		}
+	}
+	provisionConsumer := func(ctx context.Context) (jetstream.Consumer, error) {
+		return a.natsClient.JetStream.CreateOrUpdateConsumer(ctx, kvStreamName(a.bucket), consumerConfig)
+	}
+	a.consumer, err = provisionConsumer(natsContext)
+	if err != nil {
+		return fmt.Errorf("error initializing orchestration index consumer: %w", err)
+	}
+	if a.watcher.debouncer != nil {
+		if err = a.watcher.debouncer.checkAckWait(a.consumer.CachedInfo().Config.AckWait); err != nil {
+			return fmt.Errorf("invalid %s: %w", watcherDebounceKey, err)
+		}
+	}
+	a.watcher.consumer = a.consumer
+	autoProvision := true
+	if ctx.Config.IsSet(watcherAutoProvisionKey) {
+		autoProvision = ctx.Config.GetBool(watcherAutoProvisionKey)
+	}
+	if autoProvision {
+		a.watcher.provisionConsumer = provisionConsumer
+	}
+	if err = a.connectSources(ctx, consumerConfig, autoProvision); err != nil {
+		return err
+	}
+	ctx.Registry.Register(api.HealthCheckKey, a.watcher)
+	ctx.Registry.Register(api.EntryValidatorKey, a.watcher)
+	ctx.Registry.Register(api.EntryNotifierKey, a.watcher)
+	ctx.Registry.Register(api.MaintenanceKey, a.watcher)
+	ctx.Registry.Register(api.TypePauserKey, a.watcher)
+	ctx.Registry.Register(api.DeliveryMetricsKey, a.deliveryMetrics)
 
 	client := natsclient.NewMsgClient(natsClient)
+
+	// The consumer is only created if missing so that settings made by operators are kept
+	dlqConsumer, err := ensureConsumer(natsContext, natsClient.JetStream, a.naming.StreamName(), newDLQConsumerConfig(a.naming))
+	if err != nil {
+		return fmt.Errorf("error initializing dead letter queue consumer: %w", err)
+	}
+	a.watcher.deadLetters = NewDeadLetterQueue(client, dlqConsumer, a.naming.DLQSubject(), ctx.LogMonitor)
+	if ctx.Config.IsSet(dlqSampleRateKey) {
+		sampleRate := ctx.Config.GetFloat64(dlqSampleRateKey)
+		if sampleRate < 0 || sampleRate > 1 {
+			return fmt.Errorf("invalid %s: %v, must be between 0 and 1", dlqSampleRateKey, sampleRate)
+		}
+		a.watcher.deadLetters.SampleRate = sampleRate
+	}
+	ctx.Registry.Register(api.DeadLetterQueueKey, a.watcher.deadLetters)
+
+	events := StreamEventSource{JetStream: natsClient.JetStream, Bucket: a.bucket}
+	if ctx.Config.IsSet(watcherBootstrapKey) && ctx.Config.GetBool(watcherBootstrapKey) {
+		// The consumer has been created above, so changes made while bootstrapping are delivered afterward
+		a.bootstrap = StreamEventSource{JetStream: natsClient.JetStream, Bucket: a.bucket, LatestOnly: true}
+	}
+	if ctx.Config.IsSet(watcherReplayKey) && ctx.Config.GetBool(watcherReplayKey) {
+		// The full history is replayed in order, e.g. to populate a cold store
+		a.bootstrap = StreamEventSource{JetStream: natsClient.JetStream, Bucket: a.bucket}
+		a.replayInOrder = true
+	}
+	ctx.Registry.Register(api.IndexRebuilderKey, NewIndexRebuilder(events, a.watcher.decoder, trxContext, ctx.LogMonitor))
+	ctx.Registry.Register(api.RetrierKey, NewManualRetrier(client, a.naming, ctx.LogMonitor))
+	if sampleSize := ctx.GetConfigIntOrDefault(consistencySampleKey, 0); sampleSize > 0 {
+		tail := StreamEventSource{JetStream: natsClient.JetStream, Bucket: a.bucket, Last: uint64(sampleSize)}
+		grace := time.Duration(ctx.GetConfigIntOrDefault(consistencyGraceKey, defaultConsistencyGrace)) * time.Second
+		a.consistency = NewConsistencyChecker(tail, a.watcher.decoder, index, trxContext, grace, a.consistencyHook, ctx.LogMonitor)
+		a.checkInterval = time.Duration(ctx.GetConfigIntOrDefault(consistencyIntervalKey, defaultConsistencyInterval)) * time.Second
+	}
+
+	// Compensation messages are always relayed through the outbox, state changes only if enabled
+	outboxStore := ctx.Registry.Resolve(api.OutboxStoreKey).(api.OutboxStore)
+	a.relay = NewOutboxRelay(outboxStore, trxContext, client, ctx.LogMonitor)
+	a.relay.lock = jobLock{locker: index, trxContext: trxContext, name: outboxRelayLock}
+	a.relayInterval = time.Duration(ctx.GetConfigIntOrDefault(outboxRelayIntervalKey, defaultOutboxRelayInterval)) * time.Millisecond
+
+	a.sweeper = NewDeadlineSweeper(index, trxContext, client, outboxStore, ctx.LogMonitor)
+	a.sweeper.naming = a.naming
+	a.sweepInterval = time.Duration(ctx.GetConfigIntOrDefault(deadlineSweepIntervalKey, defaultDeadlineSweepInterval)) * time.Second
+	if ctx.Config.IsSet(outboxEnabledKey) && ctx.Config.GetBool(outboxEnabledKey) {
+		a.watcher.messageOutbox = outboxStore
+		a.watcher.messageNaming = a.naming
+		fieldNaming, err := api.ParseFieldNaming(ctx.GetConfigStrOrDefault(outboxFieldNamingKey, ""))
+		if err != nil {
+			return err
+		}
+		a.watcher.messageSerializer = api.JSONSerializer{Naming: fieldNaming, OmitEmpty: ctx.Config.GetBool(outboxOmitEmptyKey)}
+	}
+
+	transitioner := NewBulkTransitioner(index, trxContext, ctx.LogMonitor)
+	transitioner.outboxStore = a.watcher.messageOutbox
+	transitioner.naming = a.naming
+	ctx.Registry.Register(api.BulkTransitionerKey, transitioner)
+	ctx.Registry.Register(api.TemplateRegistryKey, NewTemplateRegistry(client, ctx.LogMonitor))
+
+	if ctx.Config.IsSet(rawPayloadsEnabledKey) && ctx.Config.GetBool(rawPayloadsEnabledKey) {
+		rawPayloads, found := ctx.Registry.ResolveOptional(api.RawPayloadStoreKey)
+		if !found {
+			return fmt.Errorf("raw payloads enabled but no raw payload store is configured")
+		}
+		a.watcher.rawPayloads = rawPayloads.(api.RawPayloadStore)
+	}
+
+	var policies []retentionPolicy
+	if err := ctx.Config.UnmarshalKey(retentionPoliciesKey, &policies); err != nil {
+		return fmt.Errorf("error reading retention policies: %w", err)
+	}
+	retention, err := parseRetention(policies)
+	if err != nil {
+		return err
+	}
+	if len(retention) > 0 {
+		a.purger = NewRetentionPurger(index, trxContext, retention, ctx.LogMonitor)
+		a.purgeInterval = time.Duration(ctx.GetConfigIntOrDefault(retentionPurgeIntervalKey, defaultRetentionPurgeInterval)) * time.Second
+	}
+
+	var typeConfigs []typeConfig
+	if err := ctx.Config.UnmarshalKey(typesKey, &typeConfigs); err != nil {
+		return fmt.Errorf("error reading orchestration types: %w", err)
+	}
+	typeRegistry, err := newTypeRegistry(typeConfigs, policies)
+	if err != nil {
+		return err
+	}
+	ctx.Registry.Register(api.TypeRegistryKey, typeRegistry)
+	if a.watcher.typeAckWaits, err = typeAckWaits(typeConfigs); err != nil {
+		return err
+	}
+
 	orchestrator := NewNatsOrchestrator(client, ctx.LogMonitor)
+	orchestrator.Naming = a.naming
+	orchestrator.MinConsumerVersion = ctx.GetConfigIntOrDefault(minConsumerVersionKey, 0)
+	orchestrator.ExpiryTTL = time.Duration(ctx.GetConfigIntOrDefault(orchestratorExpiryKey, 0)) * time.Second
 	ctx.Registry.Register(api.OrchestratorKey, orchestrator)
 
 	return nil
 }
 
+// connectSources connects the additional sources consumed by the watcher, e.g. the NATS clusters of other regions.
+// A source that cannot be connected is reported by the watcher and does not prevent the others from being consumed.
+func (a *natsOrchestratorServiceAssembly) connectSources(
+	ctx *system.InitContext,
+	consumerConfig jetstream.ConsumerConfig,
+	autoProvision bool) error {
+	var configs []sourceConfig
+	if err := ctx.Config.UnmarshalKey(watcherSourcesKey, &configs); err != nil {
+		return fmt.Errorf("error reading watcher sources: %w", err)
+	}
+	if err := validateSources(configs); err != nil {
+		return err
+	}
+	for _, config := range configs {
+		client, err := natsclient.NewNatsClient(config.URI, a.bucket)
+		if err != nil {
+			ctx.LogMonitor.Warnf("Error connecting orchestration source %s: %v", config.Name, err)
+			a.watcher.FailSource(config.Name, err)
+			continue
+		}
+		a.sourceClients = append(a.sourceClients, client)
+		provisionConsumer := func(ctx context.Context) (jetstream.Consumer, error) {
+			return client.JetStream.CreateOrUpdateConsumer(ctx, kvStreamName(a.bucket), consumerConfig)
+		}
+		consumer, err := provisionConsumer(context.Background())
+		if err != nil {
+			ctx.LogMonitor.Warnf("Error initializing consumer of orchestration source %s: %v", config.Name, err)
+			a.watcher.FailSource(config.Name, err)
+			continue
+		}
+		source := Source{Name: config.Name, Consumer: consumer}
+		if autoProvision {
+			source.Provision = provisionConsumer
+		}
+		a.sources = append(a.sources, source)
+	}
+	return nil
+}
+
+func (a *natsOrchestratorServiceAssembly) Prepare(ctx *system.InitContext) error {
+	forwarder, found := ctx.Registry.ResolveOptional(api.StateForwarderKey)
+	if found {
+		a.watcher.outbox = NewStateOutbox(forwarder.(api.StateForwarder), defaultStateOutboxCapacity, ctx.LogMonitor)
+	}
+	return nil
+}
+
+func (a *natsOrchestratorServiceAssembly) Start(_ *system.StartContext) error {
+	var ctx context.Context
+	ctx, a.processCancel = context.WithCancel(context.Background())
+	go func() {
+		if a.bootstrap != nil {
+			bootstrap := a.watcher.Bootstrap
+			if a.replayInOrder {
+				bootstrap = a.watcher.ReplayInOrder
+			}
+			if err := bootstrap(ctx, a.bootstrap); err != nil {
+				// Live changes are still processed, the index is completed as orchestrations change
+				a.watcher.monitor.Warnf("Error bootstrapping orchestration index: %v", err)
+			}
+		}
+		err := a.watcher.processLoop(ctx, a.consumer)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			a.watcher.monitor.Warnf("Error processing orchestration index changes: %v", err)
+		}
+	}()
+	a.watcher.RunSources(ctx, a.sources)
+	if a.watcher.acks != nil {
+		go a.watcher.acks.Run(ctx)
+	}
+	if a.watcher.outbox != nil {
+		go a.watcher.outbox.Run(ctx)
+	}
+	a.watcher.RunProjections(ctx)
+	go a.sweeper.Run(ctx, a.sweepInterval)
+	// Pending states are expired at the deadline sweep interval
+	go a.watcher.RunPendingExpiry(ctx, a.sweepInterval)
+	if a.purger != nil {
+		go a.purger.Run(ctx, a.purgeInterval)
+	}
+	go a.relay.Run(ctx, a.relayInterval)
+	if a.consistency != nil {
+		go a.consistency.Run(ctx, a.checkInterval)
+	}
+	return nil
+}
+
 func (a *natsOrchestratorServiceAssembly) Shutdown() error {
 	if a.processCancel != nil {
 		a.processCancel()
 	}
-	if a.subscription != nil {
-		_ = a.subscription.Unsubscribe()
+	if a.watcher != nil && a.watcher.acks != nil {
+		a.watcher.acks.Flush(context.Background())
 	}
 	if a.natsClient != nil {
 		a.natsClient.Connection.Close()
 	}
+	for _, client := range a.sourceClients {
+		client.Connection.Close()
+	}
 	return nil
 }
@@ -16,7 +16,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/natsclient"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
@@ -25,9 +25,14 @@ import (
 
 // EnqueueActivityMessages enqueues the given activities for processing.
 //
-// Messages are sent to a named durable queue corresponding to the activity type. For example, messages for the
-// 'test-activity' type will be routed to the 'event.test-activity' queue.
-func EnqueueActivityMessages(ctx context.Context, orchestrationID string, activities []api.Activity, client natsclient.MsgClient) error {
+// Messages are sent to a named durable queue corresponding to the activity type. For example, using the default naming
+// strategy, messages for the 'test-activity' type will be routed to the 'event.test-activity' queue.
+func EnqueueActivityMessages(
+	ctx context.Context,
+	orchestrationID string,
+	activities []api.Activity,
+	client natsclient.MsgClient,
+	naming natsclient.NamingStrategy) error {
 	for _, activity := range activities {
 		// route to queue
 		payload, err := json.Marshal(api.ActivityMessage{
@@ -38,10 +43,8 @@ func EnqueueActivityMessages(ctx context.Context, orchestrationID string, activi
 			return fmt.Errorf("error marshalling activity payload: %w", err)
 		}
 
-		// Strip out periods since they denote a subject hierarchy for NATS
-		subject := natsclient.CFMSubjectPrefix + "." + strings.ReplaceAll(activity.Type.String(), ".", "-")
 		msg := &nats.Msg{
-			Subject: subject,
+			Subject: naming.Subject(activity.Type.String()),
 			Data:    payload,
 		}
 		_, err = client.PublishMsg(ctx, msg)
@@ -77,6 +80,8 @@ func UpdateOrchestration(
 	updateFn func(*api.Orchestration)) (api.Orchestration, uint64, error) {
 	for {
 		updateFn(&orchestration)
+		// The expiry applies to the change that stamped it only
+		orchestration.Expiry = time.Time{}
 		// TODO break after number of retries using exponential backoff
 		serialized, err := json.Marshal(orchestration)
 		if err != nil {
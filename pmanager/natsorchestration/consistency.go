@@ -0,0 +1,172 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// Reasons of a ConsistencyDiscrepancy.
+const (
+	DiscrepancyMissing = "missing" // the orchestration is not in the index
+	DiscrepancyBehind  = "behind"  // the index holds an older state of the orchestration
+)
+
+// ConsistencyDiscrepancy is an orchestration change retained by the stream that is not reflected by the index.
+type ConsistencyDiscrepancy struct {
+	OrchestrationID string
+	Sequence        uint64
+	Reason          string
+}
+
+// ConsistencyHook is invoked for each discrepancy found by a ConsistencyChecker.
+type ConsistencyHook func(discrepancy ConsistencyDiscrepancy)
+
+// ConsistencyChecker samples recent orchestration changes and verifies that the index reflects them, detecting changes
+// that were acknowledged but not recorded. Changes more recent than the grace period are skipped since they may still
+// be processed by the watcher.
+type ConsistencyChecker struct {
+	events        EventSource
+	decoder       MessageDecoder
+	index         store.EntityStore[*api.OrchestrationEntry]
+	trxContext    store.TransactionContext
+	onDiscrepancy ConsistencyHook
+	grace         time.Duration
+	discrepancies atomic.Int64
+	monitor       system.LogMonitor
+	lock          jobLock
+	now           func() time.Time
+}
+
+// NewConsistencyChecker creates a checker verifying the changes replayed by events, typically the tail of the stream,
+// see StreamEventSource.Last. The hook is optional.
+func NewConsistencyChecker(
+	events EventSource,
+	decoder MessageDecoder,
+	index store.EntityStore[*api.OrchestrationEntry],
+	trxContext store.TransactionContext,
+	grace time.Duration,
+	hook ConsistencyHook,
+	monitor system.LogMonitor) *ConsistencyChecker {
+	if decoder == nil {
+		decoder = JSONDecoder{}
+	}
+	return &ConsistencyChecker{
+		events:        events,
+		decoder:       decoder,
+		index:         index,
+		trxContext:    trxContext,
+		onDiscrepancy: hook,
+		grace:         grace,
+		monitor:       monitor,
+		lock:          jobLock{locker: index, trxContext: trxContext, name: consistencyCheckerLock},
+		now:           time.Now,
+	}
+}
+
+// Discrepancies returns the number of discrepancies found since the checker was created.
+func (c *ConsistencyChecker) Discrepancies() int64 {
+	return c.discrepancies.Load()
+}
+
+// Run checks the index at the given interval until the context is canceled. Checks are skipped while another replica
+// checks.
+func (c *ConsistencyChecker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.lock.run(ctx, func(ctx context.Context) error {
+				_, err := c.Check(ctx)
+				return err
+			})
+			if err != nil {
+				c.monitor.Warnf("Error checking orchestration index consistency: %v", err)
+			}
+		}
+	}
+}
+
+// Check compares the latest sampled change of each orchestration to its index entry and returns the discrepancies
+// ordered by orchestration ID. A change is reflected if the watcher would not record it given the entry, see
+// isRecorded. Changes that cannot be decoded are skipped.
+func (c *ConsistencyChecker) Check(ctx context.Context) ([]ConsistencyDiscrepancy, error) {
+	sampled := make(map[string]*api.OrchestrationEntry)
+	cutoff := c.now().Add(-c.grace)
+	for event, err := range c.events.Replay(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := c.decoder.Decode(event.Data, event.Header)
+		if err != nil {
+			continue
+		}
+		entry := createEntry(decoded.Orchestration)
+		entry.Revision = event.Sequence
+		if current, found := sampled[entry.ID]; found && isStale(entry, current, 0) {
+			continue
+		}
+		sampled[entry.ID] = entry
+	}
+
+	var discrepancies []ConsistencyDiscrepancy
+	for _, id := range slices.Sorted(maps.Keys(sampled)) {
+		entry := sampled[id]
+		if entry.StateTimestamp.After(cutoff) {
+			continue
+		}
+		var stored *api.OrchestrationEntry
+		err := c.trxContext.Execute(ctx, func(ctx context.Context) error {
+			var err error
+			stored, err = c.index.FindByID(ctx, id)
+			return err
+		})
+		var reason string
+		switch {
+		case errors.Is(err, types.ErrNotFound):
+			reason = DiscrepancyMissing
+		case err != nil:
+			return nil, fmt.Errorf("error reading orchestration entry %s: %w", id, err)
+		case isRecorded(entry, stored, 0):
+			reason = DiscrepancyBehind
+		default:
+			continue
+		}
+		discrepancies = append(discrepancies, ConsistencyDiscrepancy{OrchestrationID: id, Sequence: entry.Revision, Reason: reason})
+	}
+
+	for _, discrepancy := range discrepancies {
+		c.discrepancies.Add(1)
+		c.monitor.Warnf("Orchestration %s is %s in the index for change %d",
+			discrepancy.OrchestrationID, discrepancy.Reason, discrepancy.Sequence)
+		if c.onDiscrepancy != nil {
+			c.onDiscrepancy(discrepancy)
+		}
+	}
+	return discrepancies, nil
+}
@@ -0,0 +1,87 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyChecker_Check(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	change := func(id string, state api.OrchestrationState, age time.Duration) (*api.OrchestrationEntry, []byte) {
+		orchestration := createWatcherOrchestration(id, "corr-"+id, state)
+		orchestration.StateTimestamp = now.Add(-age)
+		data, err := json.Marshal(orchestration)
+		require.NoError(t, err)
+		return createEntry(orchestration), data
+	}
+
+	index := memorystore.NewOrchestrationIndex()
+	recorded, recordedData := change("orch-1", api.OrchestrationStateRunning, time.Hour)
+	recorded.Revision = 1
+	_, err := index.Create(ctx, recorded)
+	require.NoError(t, err)
+	behind, _ := change("orch-2", api.OrchestrationStateInitialized, 2*time.Hour)
+	behind.Revision = 2
+	_, err = index.Create(ctx, behind)
+	require.NoError(t, err)
+	_, behindData := change("orch-2", api.OrchestrationStateRunning, time.Hour)
+	_, missingData := change("orch-3", api.OrchestrationStateRunning, time.Hour)
+	_, recentData := change("orch-4", api.OrchestrationStateRunning, time.Second)
+
+	events := sliceEventSource{
+		{Data: recordedData, Sequence: 1},
+		{Data: behindData, Sequence: 3},
+		// The change was acknowledged but never recorded
+		{Data: missingData, Sequence: 4},
+		// Recent changes may still be processed
+		{Data: recentData, Sequence: 5},
+		{Data: []byte("not an orchestration"), Sequence: 6},
+	}
+	var reported []ConsistencyDiscrepancy
+	checker := NewConsistencyChecker(events, nil, index, &store.NoOpTransactionContext{}, time.Minute,
+		func(discrepancy ConsistencyDiscrepancy) { reported = append(reported, discrepancy) }, system.NoopMonitor{})
+	checker.now = func() time.Time { return now }
+
+	discrepancies, err := checker.Check(ctx)
+	require.NoError(t, err)
+
+	expected := []ConsistencyDiscrepancy{
+		{OrchestrationID: "orch-2", Sequence: 3, Reason: DiscrepancyBehind},
+		{OrchestrationID: "orch-3", Sequence: 4, Reason: DiscrepancyMissing},
+	}
+	assert.Equal(t, expected, discrepancies)
+	assert.Equal(t, expected, reported)
+	assert.Equal(t, int64(2), checker.Discrepancies())
+}
+
+func TestConsistencyChecker_Check_ReplayFails(t *testing.T) {
+	events := failingEventSource{err: assert.AnError}
+	checker := NewConsistencyChecker(events, nil, memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{}, 0, nil,
+		system.NoopMonitor{})
+
+	_, err := checker.Check(context.Background())
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, checker.Discrepancies())
+}
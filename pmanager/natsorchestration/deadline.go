@@ -0,0 +1,181 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// DeadlineSweeper looks for orchestrations that have passed their deadline while still in an intermediate state.
+// Expired orchestrations are transitioned to api.OrchestrationStateCompensating and an api.CompensationMessage is
+// emitted so that resources allocated by completed activities can be actively cleaned up. Compensation messages are
+// recorded in the outbox and published by the OutboxRelay, so that they are not lost if publishing fails.
+type DeadlineSweeper struct {
+	index       store.EntityStore[*api.OrchestrationEntry]
+	trxContext  store.TransactionContext
+	client      natsclient.MsgClient
+	outboxStore api.OutboxStore
+	naming      natsclient.NamingStrategy
+	monitor     system.LogMonitor
+	lock        jobLock
+	now         func() time.Time
+}
+
+func NewDeadlineSweeper(
+	index store.EntityStore[*api.OrchestrationEntry],
+	trxContext store.TransactionContext,
+	client natsclient.MsgClient,
+	outboxStore api.OutboxStore,
+	monitor system.LogMonitor) *DeadlineSweeper {
+	return &DeadlineSweeper{
+		index:       index,
+		trxContext:  trxContext,
+		client:      client,
+		outboxStore: outboxStore,
+		naming:      natsclient.DefaultNamingStrategy{},
+		monitor:     monitor,
+		lock:        jobLock{locker: index, trxContext: trxContext, name: deadlineSweeperLock},
+		now:         time.Now,
+	}
+}
+
+// Run sweeps expired orchestrations at the given interval until the context is canceled. Sweeps are skipped while
+// another replica sweeps.
+func (s *DeadlineSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.lock.run(ctx, func(ctx context.Context) error {
+				_, err := s.Sweep(ctx)
+				return err
+			})
+			if err != nil {
+				s.monitor.Warnf("Error sweeping orchestration deadlines: %v", err)
+			}
+		}
+	}
+}
+
+// Sweep compensates all orchestrations whose deadline has passed and returns the number of compensated orchestrations.
+// Failures compensating an individual orchestration are logged and retried on the next sweep.
+func (s *DeadlineSweeper) Sweep(ctx context.Context) (int, error) {
+	now := s.now()
+
+	var expired []*api.OrchestrationEntry
+	err := s.trxContext.Execute(ctx, func(ctx context.Context) error {
+		predicate := query.And(
+			query.In("state", api.OrchestrationStateInitialized, api.OrchestrationStateRunning),
+			query.Lt("deadline", now))
+		for entry, err := range s.index.FindByPredicate(ctx, predicate) {
+			if err != nil {
+				return err
+			}
+			// Stores may represent a missing deadline as the zero time, which precedes any cutoff
+			if entry.Expired(now) {
+				expired = append(expired, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired orchestrations: %w", err)
+	}
+
+	count := 0
+	for _, entry := range expired {
+		compensated, err := s.compensate(ctx, entry, now)
+		if err != nil {
+			s.monitor.Warnf("Failed to compensate orchestration %s: %v", entry.ID, err)
+			continue
+		}
+		if compensated {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// compensate transitions the orchestration to the compensating state and records the compensation message in the
+// outbox. The KV store is the source of truth: if the orchestration progressed since the index was updated, or a
+// concurrent update wins the revision check, the orchestration is not compensated.
+//
+// The orchestration is updated in the transaction recording the index entry and the message, so that both are rolled
+// back if it fails. If the transaction fails after the update, the orchestration is compensating while its entry is
+// not, and the next sweep records the entry and the message without updating the orchestration again.
+func (s *DeadlineSweeper) compensate(ctx context.Context, entry *api.OrchestrationEntry, now time.Time) (bool, error) {
+	orchestration, revision, err := ReadOrchestration(ctx, entry.ID, s.client)
+	if err != nil {
+		return false, err
+	}
+	recorded := orchestration.State == api.OrchestrationStateCompensating
+	if !recorded && !orchestration.Expired(now) {
+		return false, nil
+	}
+
+	var serialized []byte
+	if !recorded {
+		orchestration.SetState(api.OrchestrationStateCompensating)
+		orchestration.Expiry = time.Time{}
+		if serialized, err = json.Marshal(orchestration); err != nil {
+			return false, fmt.Errorf("failed to marshal orchestration %s: %w", orchestration.ID, err)
+		}
+	}
+	payload, err := json.Marshal(api.CompensationMessage{
+		OrchestrationID:   orchestration.ID,
+		CorrelationID:     orchestration.CorrelationID,
+		OrchestrationType: orchestration.OrchestrationType,
+		Deadline:          orchestration.Deadline,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal compensation message for %s: %w", orchestration.ID, err)
+	}
+
+	err = s.trxContext.Execute(ctx, func(ctx context.Context) error {
+		if !recorded {
+			if _, err := s.client.Update(ctx, orchestration.ID, serialized, revision); err != nil {
+				return fmt.Errorf("failed to update orchestration %s: %w", orchestration.ID, err)
+			}
+		}
+		// Record the transition in the index so the orchestration is not picked up again before the watcher catches up
+		entry.State = orchestration.State
+		entry.StateTimestamp = orchestration.StateTimestamp
+		if err := s.index.Update(ctx, entry); err != nil {
+			return fmt.Errorf("failed to update orchestration entry %s: %w", orchestration.ID, err)
+		}
+		return s.outboxStore.Add(ctx, &api.OutboxMessage{
+			ID:               uuid.New().String(),
+			Subject:          s.naming.Subject(natsclient.CFMOrchestrationCompensation),
+			Payload:          payload,
+			CreatedTimestamp: now,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,315 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestration_Expired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		state    api.OrchestrationState
+		deadline time.Time
+		expected bool
+	}{
+		{"no deadline", api.OrchestrationStateRunning, time.Time{}, false},
+		{"deadline not reached", api.OrchestrationStateRunning, now.Add(time.Minute), false},
+		{"initialized past deadline", api.OrchestrationStateInitialized, now.Add(-time.Minute), true},
+		{"running past deadline", api.OrchestrationStateRunning, now.Add(-time.Minute), true},
+		{"completed past deadline", api.OrchestrationStateCompleted, now.Add(-time.Minute), false},
+		{"errored past deadline", api.OrchestrationStateErrored, now.Add(-time.Minute), false},
+		{"compensating past deadline", api.OrchestrationStateCompensating, now.Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orchestration := api.Orchestration{State: tt.state, Deadline: tt.deadline}
+			assert.Equal(t, tt.expected, orchestration.Expired(now))
+			entry := api.OrchestrationEntry{State: tt.state, Deadline: tt.deadline}
+			assert.Equal(t, tt.expected, entry.Expired(now))
+		})
+	}
+}
+
+func TestDeadlineSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	expired := createWatcherOrchestration("expired", "corr-1", api.OrchestrationStateRunning)
+	expired.Deadline = now.Add(-time.Minute)
+	pending := createWatcherOrchestration("pending", "corr-2", api.OrchestrationStateRunning)
+	pending.Deadline = now.Add(time.Minute)
+	noDeadline := createWatcherOrchestration("no-deadline", "corr-3", api.OrchestrationStateInitialized)
+	completed := createWatcherOrchestration("completed", "corr-4", api.OrchestrationStateCompleted)
+	completed.Deadline = now.Add(-time.Minute)
+	for _, o := range []api.Orchestration{expired, pending, noDeadline, completed} {
+		_, err := index.Create(ctx, createEntry(o))
+		require.NoError(t, err)
+	}
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "expired").Return(newTestKVEntry(t, expired, 7), nil).Once()
+
+	var updated api.Orchestration
+	client.EXPECT().Update(mock.Anything, "expired", mock.Anything, uint64(7)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &updated))
+		}).
+		Return(uint64(8), nil).Once()
+
+	outboxStore := memorystore.NewOutboxStore()
+	sweeper := createTestSweeper(index, client, outboxStore, now)
+
+	count, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The KV orchestration is transitioned to compensating
+	assert.Equal(t, api.OrchestrationStateCompensating, updated.State)
+
+	// The compensation message is recorded in the outbox and identifies the orchestration
+	messages, err := outboxStore.FindPending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, natsclient.CFMOrchestrationCompensationSubject, messages[0].Subject)
+	var message api.CompensationMessage
+	require.NoError(t, json.Unmarshal(messages[0].Payload, &message))
+	assert.Equal(t, "expired", message.OrchestrationID)
+	assert.Equal(t, "corr-1", message.CorrelationID)
+	assert.Equal(t, expired.OrchestrationType, message.OrchestrationType)
+	assert.True(t, expired.Deadline.Equal(message.Deadline))
+
+	// The index records the transition
+	entry, err := index.FindByID(ctx, "expired")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompensating, entry.State)
+
+	// Other entries are untouched
+	for _, id := range []string{"pending", "no-deadline", "completed"} {
+		entry, err := index.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.NotEqual(t, api.OrchestrationStateCompensating, entry.State)
+	}
+
+	// A subsequent sweep does not compensate again
+	count, err = sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+// The KV store is the source of truth: an orchestration that completed before the index caught up is not compensated
+func TestDeadlineSweeper_Sweep_OrchestrationProgressed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.Deadline = now.Add(-time.Minute)
+	_, err := index.Create(ctx, createEntry(orchestration))
+	require.NoError(t, err)
+
+	orchestration.State = api.OrchestrationStateCompleted
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, orchestration, 3), nil).Once()
+
+	outboxStore := memorystore.NewOutboxStore()
+	count, err := createTestSweeper(index, client, outboxStore, now).Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	client.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assertNoOutboxMessages(t, outboxStore)
+}
+
+// A concurrent update wins the revision check and no compensation message is emitted
+func TestDeadlineSweeper_Sweep_RevisionConflict(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.Deadline = now.Add(-time.Minute)
+	_, err := index.Create(ctx, createEntry(orchestration))
+	require.NoError(t, err)
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, orchestration, 3), nil).Once()
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(3)).
+		Return(uint64(0), jetstream.ErrKeyExists).Once()
+
+	outboxStore := memorystore.NewOutboxStore()
+	count, err := createTestSweeper(index, client, outboxStore, now).Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assertNoOutboxMessages(t, outboxStore)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
+
+// An orchestration compensated by a sweep whose transaction failed afterward is recorded by the next sweep without
+// being updated again
+func TestDeadlineSweeper_Sweep_AlreadyCompensating(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.Deadline = now.Add(-time.Minute)
+	_, err := index.Create(ctx, createEntry(orchestration))
+	require.NoError(t, err)
+
+	orchestration.SetState(api.OrchestrationStateCompensating)
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, orchestration, 4), nil).Once()
+
+	outboxStore := memorystore.NewOutboxStore()
+	count, err := createTestSweeper(index, client, outboxStore, now).Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	client.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompensating, entry.State)
+	pending, err := outboxStore.FindPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func assertNoOutboxMessages(t *testing.T, outboxStore api.OutboxStore) {
+	pending, err := outboxStore.FindPending(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func createTestSweeper(
+	index store.EntityStore[*api.OrchestrationEntry],
+	client natsclient.MsgClient,
+	outboxStore api.OutboxStore,
+	now time.Time) *DeadlineSweeper {
+	return &DeadlineSweeper{
+		index:       index,
+		trxContext:  &store.NoOpTransactionContext{},
+		client:      client,
+		outboxStore: outboxStore,
+		naming:      natsclient.DefaultNamingStrategy{},
+		monitor:     system.NoopMonitor{},
+		now:         func() time.Time { return now },
+	}
+}
+
+// testKVEntry is a jetstream.KeyValueEntry returning a serialized orchestration.
+type testKVEntry struct {
+	jetstream.KeyValueEntry
+	value    []byte
+	revision uint64
+}
+
+func newTestKVEntry(t *testing.T, orchestration api.Orchestration, revision uint64) jetstream.KeyValueEntry {
+	data, err := json.Marshal(orchestration)
+	require.NoError(t, err)
+	return &testKVEntry{value: data, revision: revision}
+}
+
+func (e *testKVEntry) Value() []byte {
+	return e.value
+}
+
+func (e *testKVEntry) Revision() uint64 {
+	return e.revision
+}
+
+// An activity completing after the compensation of its orchestration started neither enqueues the next activities
+// nor completes the orchestration
+func TestNatsActivityExecutor_CompletionAfterCompensation(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []api.OrchestrationStep
+		// completions is the number of updates succeeding before the compensation
+		completions int
+	}{
+		{
+			name: "next activities",
+			steps: []api.OrchestrationStep{
+				{Activities: []api.Activity{{ID: "A1", Type: "test.activity"}}},
+				{Activities: []api.Activity{{ID: "A2", Type: "test.activity"}}},
+			},
+		},
+		{
+			name:        "orchestration completion",
+			steps:       []api.OrchestrationStep{{Activities: []api.Activity{{ID: "A1", Type: "test.activity"}}}},
+			completions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orchestration := api.Orchestration{
+				ID:                "orch-1",
+				State:             api.OrchestrationStateRunning,
+				OrchestrationType: model.VPADeployType,
+				ProcessingData:    map[string]any{},
+				OutputData:        map[string]any{},
+				Completed:         map[string]struct{}{},
+				Steps:             tt.steps,
+			}
+			compensating := orchestration
+			compensating.State = api.OrchestrationStateCompensating
+
+			client := mocks.NewMockMsgClient(t)
+			client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, orchestration, 1), nil).Once()
+			for range tt.completions {
+				client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(1)).Return(uint64(2), nil).Once()
+			}
+			client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(1)).Return(uint64(0), jetstream.ErrKeyExists).Once()
+			client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, compensating, 3), nil).Once()
+			var updated api.Orchestration
+			client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(3)).
+				Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+					require.NoError(t, json.Unmarshal(value, &updated))
+				}).
+				Return(uint64(4), nil).Once()
+
+			executor := &NatsActivityExecutor{
+				Client:            client,
+				StreamName:        "cfm-stream",
+				ActivityType:      "test.activity",
+				ActivityProcessor: &TestCompleteActivityProcessor{},
+				Monitor:           system.NoopMonitor{},
+			}
+
+			require.NoError(t, executor.processMessage(context.Background(), newActivityMsg(t, "orch-1")))
+
+			// No activity message or response is published and the orchestration remains compensating
+			client.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+			assert.Equal(t, api.OrchestrationStateCompensating, updated.State)
+		})
+	}
+}
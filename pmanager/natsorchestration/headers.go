@@ -0,0 +1,226 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Headers of orchestration messages. MessageHeaders provides typed access to them.
+const (
+	// MsgIDHeader carries the message ID used by the stream to discard duplicates.
+	MsgIDHeader = nats.MsgIdHdr
+
+	// TraceparentHeader carries the W3C trace context of the change.
+	TraceparentHeader = "traceparent"
+
+	// ActorHeader carries the identity of the user or system that initiated the change, e.g. for auditing.
+	ActorHeader = "X-Actor"
+
+	// ContentTypeHeader carries the media type of the payload, e.g. CloudEventsJSONContentType.
+	ContentTypeHeader = "Content-Type"
+
+	// PriorityHeader carries the priority of an orchestration change. Higher values are more urgent, messages without
+	// a valid priority have priority 0.
+	PriorityHeader = "X-Priority"
+
+	// ExpiryHeader is stamped by publishers on messages that must not be processed after the given time, formatted as
+	// RFC 3339, e.g. commands that are obsolete after a long outage.
+	ExpiryHeader = "X-Expiry"
+
+	// PendingTimeoutHeader marks a change as pending for the given duration, formatted as a Go duration. The state of
+	// the change is recorded as the pending state of the entry, which keeps its current state until the pending state
+	// is confirmed by a message carrying the ConfirmPendingHeader. Unconfirmed pending states are discarded once the
+	// timeout elapses, see ExpirePending.
+	PendingTimeoutHeader = "X-Pending-Timeout"
+
+	// ConfirmPendingHeader marks a change as the confirmation of the pending state it carries, e.g. once a downstream
+	// system acknowledged it. Confirmations not matching an unexpired pending state are acknowledged without recording
+	// the change.
+	ConfirmPendingHeader = "X-Confirm-Pending"
+
+	// MinConsumerVersionHeader is stamped by publishers on messages that older watchers cannot process. Its value is
+	// the lowest watcher version that understands the message.
+	MinConsumerVersionHeader = "Cfm-Min-Consumer-Version"
+
+	// DeadLetterReasonHeader contains the error that caused a message to be dead-lettered.
+	DeadLetterReasonHeader = "Cfm-Dead-Letter-Reason"
+	// OriginalSubjectHeader contains the subject a dead-lettered message was received on.
+	OriginalSubjectHeader = "Cfm-Original-Subject"
+	// OriginalSequenceHeader contains the stream sequence of a dead-lettered message.
+	OriginalSequenceHeader = "Cfm-Original-Sequence"
+	// RedriveCountHeader contains the number of times a message was redriven from the DLQ.
+	RedriveCountHeader = "Cfm-Redrive-Count"
+	// SampleRateHeader contains the sample rate in effect when a message was dead-lettered. It is only set when not all
+	// unprocessable messages are published to the DLQ.
+	SampleRateHeader = "Cfm-Dead-Letter-Sample-Rate"
+
+	expectedSubjectSeqHdr = "Nats-Expected-Last-Subject-Sequence"
+)
+
+// MessageHeaders provides typed access to the headers of orchestration messages. Getters return the zero value if the
+// header is not set; getters of numeric and time headers return an error if the header is malformed.
+type MessageHeaders nats.Header
+
+func (h MessageHeaders) get(name string) string {
+	return nats.Header(h).Get(name)
+}
+
+func (h MessageHeaders) set(name string, value string) {
+	nats.Header(h).Set(name, value)
+}
+
+func (h MessageHeaders) GetMsgID() string {
+	return h.get(MsgIDHeader)
+}
+
+func (h MessageHeaders) SetMsgID(id string) {
+	h.set(MsgIDHeader, id)
+}
+
+func (h MessageHeaders) GetTraceparent() string {
+	return h.get(TraceparentHeader)
+}
+
+func (h MessageHeaders) SetTraceparent(traceparent string) {
+	h.set(TraceparentHeader, traceparent)
+}
+
+func (h MessageHeaders) GetActor() string {
+	return h.get(ActorHeader)
+}
+
+func (h MessageHeaders) SetActor(actor string) {
+	h.set(ActorHeader, actor)
+}
+
+func (h MessageHeaders) GetContentType() string {
+	return h.get(ContentTypeHeader)
+}
+
+func (h MessageHeaders) SetContentType(contentType string) {
+	h.set(ContentTypeHeader, contentType)
+}
+
+// GetPriority returns the priority of the change, 0 if the header is not set or malformed.
+func (h MessageHeaders) GetPriority() int {
+	priority, err := strconv.Atoi(h.get(PriorityHeader))
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+func (h MessageHeaders) SetPriority(priority int) {
+	h.set(PriorityHeader, strconv.Itoa(priority))
+}
+
+func (h MessageHeaders) GetExpiry() (time.Time, error) {
+	value := h.get(ExpiryHeader)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func (h MessageHeaders) SetExpiry(expiry time.Time) {
+	h.set(ExpiryHeader, expiry.UTC().Format(time.RFC3339Nano))
+}
+
+func (h MessageHeaders) GetPendingTimeout() (time.Duration, error) {
+	value := h.get(PendingTimeoutHeader)
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func (h MessageHeaders) SetPendingTimeout(timeout time.Duration) {
+	h.set(PendingTimeoutHeader, timeout.String())
+}
+
+// GetConfirmPending returns true if the header is set to any value.
+func (h MessageHeaders) GetConfirmPending() bool {
+	return h.get(ConfirmPendingHeader) != ""
+}
+
+func (h MessageHeaders) SetConfirmPending() {
+	h.set(ConfirmPendingHeader, "true")
+}
+
+func (h MessageHeaders) GetMinConsumerVersion() (int, error) {
+	value := h.get(MinConsumerVersionHeader)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func (h MessageHeaders) SetMinConsumerVersion(version int) {
+	h.set(MinConsumerVersionHeader, strconv.Itoa(version))
+}
+
+func (h MessageHeaders) GetDeadLetterReason() string {
+	return h.get(DeadLetterReasonHeader)
+}
+
+func (h MessageHeaders) SetDeadLetterReason(reason string) {
+	h.set(DeadLetterReasonHeader, reason)
+}
+
+func (h MessageHeaders) GetOriginalSubject() string {
+	return h.get(OriginalSubjectHeader)
+}
+
+func (h MessageHeaders) SetOriginalSubject(subject string) {
+	h.set(OriginalSubjectHeader, subject)
+}
+
+func (h MessageHeaders) GetOriginalSequence() (uint64, error) {
+	value := h.get(OriginalSequenceHeader)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func (h MessageHeaders) SetOriginalSequence(sequence uint64) {
+	h.set(OriginalSequenceHeader, strconv.FormatUint(sequence, 10))
+}
+
+func (h MessageHeaders) GetRedriveCount() (int, error) {
+	value := h.get(RedriveCountHeader)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func (h MessageHeaders) SetRedriveCount(count int) {
+	h.set(RedriveCountHeader, strconv.Itoa(count))
+}
+
+func (h MessageHeaders) GetSampleRate() (float64, error) {
+	value := h.get(SampleRateHeader)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func (h MessageHeaders) SetSampleRate(rate float64) {
+	h.set(SampleRateHeader, strconv.FormatFloat(rate, 'g', -1, 64))
+}
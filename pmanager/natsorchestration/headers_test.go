@@ -0,0 +1,99 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageHeaders_RoundTrip(t *testing.T) {
+	expiry := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	headers := MessageHeaders{}
+	headers.SetMsgID("msg-1")
+	headers.SetTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	headers.SetActor("operator@example.com")
+	headers.SetContentType(CloudEventsJSONContentType)
+	headers.SetPriority(7)
+	headers.SetExpiry(expiry)
+	headers.SetPendingTimeout(90 * time.Second)
+	headers.SetConfirmPending()
+	headers.SetMinConsumerVersion(3)
+	headers.SetDeadLetterReason("malformed orchestration message")
+	headers.SetOriginalSubject("$KV.orchestrations.orch-1")
+	headers.SetOriginalSequence(42)
+	headers.SetRedriveCount(2)
+	headers.SetSampleRate(0.25)
+
+	// Headers are read back from the NATS representation
+	read := MessageHeaders(nats.Header(headers))
+	assert.Equal(t, "msg-1", read.GetMsgID())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", read.GetTraceparent())
+	assert.Equal(t, "operator@example.com", read.GetActor())
+	assert.Equal(t, CloudEventsJSONContentType, read.GetContentType())
+	assert.Equal(t, 7, read.GetPriority())
+	readExpiry, err := read.GetExpiry()
+	require.NoError(t, err)
+	assert.True(t, expiry.Equal(readExpiry))
+	timeout, err := read.GetPendingTimeout()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, timeout)
+	assert.True(t, read.GetConfirmPending())
+	version, err := read.GetMinConsumerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.Equal(t, "malformed orchestration message", read.GetDeadLetterReason())
+	assert.Equal(t, "$KV.orchestrations.orch-1", read.GetOriginalSubject())
+	sequence, err := read.GetOriginalSequence()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), sequence)
+	count, err := read.GetRedriveCount()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	rate, err := read.GetSampleRate()
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, rate)
+
+	assert.Equal(t, "msg-1", nats.Header(headers).Get(nats.MsgIdHdr))
+	assert.Equal(t, "7", nats.Header(headers).Get("X-Priority"))
+}
+
+func TestMessageHeaders_MissingAndMalformed(t *testing.T) {
+	empty := MessageHeaders{}
+	assert.Zero(t, empty.GetPriority())
+	assert.False(t, empty.GetConfirmPending())
+	expiry, err := empty.GetExpiry()
+	require.NoError(t, err)
+	assert.True(t, expiry.IsZero())
+	count, err := empty.GetRedriveCount()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	malformed := MessageHeaders(nats.Header{
+		PriorityHeader:         []string{"urgent"},
+		ExpiryHeader:           []string{"tomorrow"},
+		PendingTimeoutHeader:   []string{"soon"},
+		OriginalSequenceHeader: []string{"-1"},
+	})
+	assert.Zero(t, malformed.GetPriority())
+	_, err = malformed.GetExpiry()
+	assert.Error(t, err)
+	_, err = malformed.GetPendingTimeout()
+	assert.Error(t, err)
+	_, err = malformed.GetOriginalSequence()
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+)
+
+// Names of the locks held by periodic jobs.
+const (
+	deadlineSweeperLock    = "cfm-deadline-sweeper"
+	retentionPurgerLock    = "cfm-retention-purger"
+	outboxRelayLock        = "cfm-outbox-relay"
+	consistencyCheckerLock = "cfm-consistency-checker"
+	pendingExpiryLock      = "cfm-pending-expiry"
+)
+
+// jobLockTTL bounds how long a job run holds its lock. Locks of crashed replicas are freed once it expires.
+const jobLockTTL = 5 * time.Minute
+
+// jobLocker acquires named locks, see store.EntityStore.TryLock.
+type jobLocker interface {
+	TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error)
+}
+
+// jobLock runs a periodic job on a single replica at a time. The zero value runs jobs without locking.
+type jobLock struct {
+	locker     jobLocker
+	trxContext store.TransactionContext
+	name       string
+}
+
+// run runs the job unless another replica holds the lock, in which case the run is skipped. The lock is acquired and
+// released in a transaction kept open while the job runs its own transactions with ctx, since stores may scope locks
+// to the transaction acquiring them.
+func (l jobLock) run(ctx context.Context, job func(ctx context.Context) error) error {
+	if l.locker == nil {
+		return job(ctx)
+	}
+	return l.trxContext.Execute(ctx, func(lockCtx context.Context) error {
+		acquired, release, err := l.locker.TryLock(lockCtx, l.name, jobLockTTL)
+		if err != nil {
+			return fmt.Errorf("failed to acquire lock %s: %w", l.name, err)
+		}
+		if !acquired {
+			return nil
+		}
+		defer release()
+		return job(ctx)
+	})
+}
@@ -0,0 +1,56 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLock_SkipsRunWhileHeld(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	lock := jobLock{locker: index, trxContext: &store.NoOpTransactionContext{}, name: deadlineSweeperLock}
+	ctx := context.Background()
+
+	runs := 0
+	err := lock.run(ctx, func(ctx context.Context) error {
+		runs++
+		// A second replica skips the job while the first runs it
+		return lock.run(ctx, func(context.Context) error {
+			runs++
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs)
+
+	require.NoError(t, lock.run(ctx, func(context.Context) error {
+		runs++
+		return nil
+	}))
+	assert.Equal(t, 2, runs, "the lock is released after the run")
+}
+
+func TestJobLock_ZeroValueRunsJob(t *testing.T) {
+	runs := 0
+	require.NoError(t, jobLock{}.run(context.Background(), func(context.Context) error {
+		runs++
+		return nil
+	}))
+	assert.Equal(t, 1, runs)
+}
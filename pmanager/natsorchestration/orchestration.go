@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/metaform/connector-fabric-manager/common/natsclient"
 	"github.com/metaform/connector-fabric-manager/common/store"
@@ -31,15 +32,23 @@ import (
 // and reliably processed by a NatsActivityExecutor that handles the activity type.
 type NatsOrchestrator struct {
 	Client     natsclient.MsgClient
+	Naming     natsclient.NamingStrategy
 	index      store.EntityStore[*api.OrchestrationEntry]
 	trxContext store.TransactionContext
 	monitor    system.LogMonitor
+
+	// MinConsumerVersion is stamped on executed orchestrations so that watchers of older versions defer them to
+	// newer watchers during a rolling deployment, see api.Orchestration.MinConsumerVersion. Zero to not stamp.
+	MinConsumerVersion int
+	// ExpiryTTL is the time after which executed orchestrations not yet recorded by the watcher are dropped, see
+	// api.Orchestration.Expiry. Zero if they do not expire.
+	ExpiryTTL time.Duration
 }
 
 func NewNatsOrchestrator(
 	client natsclient.MsgClient,
 	monitor system.LogMonitor) *NatsOrchestrator {
-	return &NatsOrchestrator{Client: client, monitor: monitor}
+	return &NatsOrchestrator{Client: client, Naming: natsclient.DefaultNamingStrategy{}, monitor: monitor}
 }
 
 func (o *NatsOrchestrator) GetOrchestration(ctx context.Context, id string) (*api.Orchestration, error) {
@@ -64,6 +73,10 @@ func (o *NatsOrchestrator) GetOrchestration(ctx context.Context, id string) (*ap
 func (o *NatsOrchestrator) Execute(ctx context.Context, orchestration *api.Orchestration) error {
 	// TODO validate orchestration - this should include a check to see if there are no steps or steps with no activities
 
+	orchestration.MinConsumerVersion = max(orchestration.MinConsumerVersion, o.MinConsumerVersion)
+	if o.ExpiryTTL > 0 {
+		orchestration.Expiry = time.Now().Add(o.ExpiryTTL)
+	}
 	serializedOrchestration, err := json.Marshal(orchestration)
 	if err != nil {
 		return fmt.Errorf("error marshalling orchestration: %w", err)
@@ -86,7 +99,7 @@ func (o *NatsOrchestrator) Execute(ctx context.Context, orchestration *api.Orche
 	if len(activities) == 0 {
 		return fmt.Errorf("orchestration has no activities: %s", orchestration.ID)
 	}
-	err = EnqueueActivityMessages(ctx, orchestration.ID, activities, o.Client)
+	err = EnqueueActivityMessages(ctx, orchestration.ID, activities, o.Client, o.Naming)
 	if err != nil {
 		return err
 	}
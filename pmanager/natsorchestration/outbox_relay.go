@@ -0,0 +1,151 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+)
+
+const defaultOutboxBatchSize = 100
+
+// emitStateChange records a state change message in the outbox. It is called in the transaction updating the index
+// so that the entry and the message are committed atomically.
+func (w *OrchestrationIndexWatcher) emitStateChange(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	existing *api.OrchestrationEntry) error {
+	if w.messageOutbox == nil || !isStateChange(entry, existing, w.clockSkewTolerance) {
+		return nil
+	}
+	payload, err := w.messageSerializer.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state change of orchestration %s: %w", entry.ID, err)
+	}
+	return w.messageOutbox.Add(ctx, &api.OutboxMessage{
+		ID:               uuid.New().String(),
+		Subject:          stateChangeSubject(w.messageNaming, entry),
+		Payload:          payload,
+		CreatedTimestamp: time.Now(),
+	})
+}
+
+// stateChangeSubject returns the subject the state change of the entry is published to. Terminal state changes are
+// routed to the terminal subject of the orchestration type.
+func stateChangeSubject(naming natsclient.NamingStrategy, entry *api.OrchestrationEntry) string {
+	if isTerminal(entry.State) {
+		return naming.TerminalSubject(string(entry.OrchestrationType))
+	}
+	return naming.Subject(natsclient.CFMOrchestrationStateChange)
+}
+
+// StateChangeSubjects returns the subjects state changes of orchestrations of the given types are published to, for
+// consumers subscribing to both non-terminal and terminal state changes.
+func StateChangeSubjects(naming natsclient.NamingStrategy, orchTypes ...model.OrchestrationType) []string {
+	subjects := []string{naming.Subject(natsclient.CFMOrchestrationStateChange)}
+	for _, orchType := range orchTypes {
+		subjects = append(subjects, naming.TerminalSubject(string(orchType)))
+	}
+	return subjects
+}
+
+// OutboxRelay publishes messages recorded in the outbox and marks them as sent. Messages are published with their
+// outbox ID as the NATS message ID, so that JetStream discards duplicates if the relay fails after publishing but
+// before a message is marked as sent.
+type OutboxRelay struct {
+	store      api.OutboxStore
+	trxContext store.TransactionContext
+	client     natsclient.MsgClient
+	monitor    system.LogMonitor
+	BatchSize  int
+
+	// lock runs the relay on a single replica at a time. Outbox stores do not provide locks, typically the index does.
+	lock jobLock
+}
+
+func NewOutboxRelay(
+	outboxStore api.OutboxStore,
+	trxContext store.TransactionContext,
+	client natsclient.MsgClient,
+	monitor system.LogMonitor) *OutboxRelay {
+	return &OutboxRelay{
+		store:      outboxStore,
+		trxContext: trxContext,
+		client:     client,
+		monitor:    monitor,
+		BatchSize:  defaultOutboxBatchSize,
+	}
+}
+
+// Run relays pending messages at the given interval until the context is canceled. Runs are skipped while another
+// replica relays messages.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.lock.run(ctx, func(ctx context.Context) error {
+				_, err := r.Relay(ctx)
+				return err
+			})
+			if err != nil {
+				r.monitor.Warnf("Error relaying outbox messages: %v", err)
+			}
+		}
+	}
+}
+
+// Relay publishes up to BatchSize pending messages in the order they were recorded and returns the number of sent
+// messages. Relaying stops at the first message that cannot be published to preserve ordering; it is retried on the
+// next run.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	var pending []*api.OutboxMessage
+	err := r.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		pending, err = r.store.FindPending(ctx, r.BatchSize)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query outbox messages: %w", err)
+	}
+
+	count := 0
+	for _, message := range pending {
+		msg := &nats.Msg{Subject: message.Subject, Data: message.Payload, Header: nats.Header{}}
+		MessageHeaders(msg.Header).SetMsgID(message.ID)
+		if _, err := r.client.PublishMsg(ctx, msg); err != nil {
+			return count, fmt.Errorf("failed to publish outbox message %s: %w", message.ID, err)
+		}
+		err := r.trxContext.Execute(ctx, func(ctx context.Context) error {
+			return r.store.MarkSent(ctx, message.ID)
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to mark outbox message %s sent: %w", message.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
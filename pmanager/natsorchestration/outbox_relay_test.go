@@ -0,0 +1,146 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testStateChangeSubject = "event.cfm-orchestration-state-change"
+	testTerminalSubject    = "event.terminal.TestType"
+)
+
+func TestOnMessage_Outbox_StateChangesRecorded(t *testing.T) {
+	outbox := memorystore.NewOutboxStore()
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.messageOutbox = outbox
+	watcher.messageNaming = natsclient.DefaultNamingStrategy{}
+
+	for _, state := range []api.OrchestrationState{
+		api.OrchestrationStateRunning,
+		api.OrchestrationStateRunning, // redelivered, not a state change
+		api.OrchestrationStateCompleted,
+	} {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	pending, err := outbox.FindPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	for i, state := range []api.OrchestrationState{api.OrchestrationStateRunning, api.OrchestrationStateCompleted} {
+		var entry api.OrchestrationEntry
+		require.NoError(t, json.Unmarshal(pending[i].Payload, &entry))
+		assert.Equal(t, "orch-1", entry.ID)
+		assert.Equal(t, state, entry.State)
+	}
+	assert.Equal(t, testStateChangeSubject, pending[0].Subject, "non-terminal states are published to the state change subject")
+	assert.Equal(t, testTerminalSubject, pending[1].Subject, "terminal states are published to the terminal subject")
+}
+
+func TestStateChangeSubject_RoutesTerminalStates(t *testing.T) {
+	naming := natsclient.DefaultNamingStrategy{}
+
+	running := createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	completed := createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	errored := createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateErrored))
+
+	assert.Equal(t, testStateChangeSubject, stateChangeSubject(naming, running))
+	assert.Equal(t, testTerminalSubject, stateChangeSubject(naming, completed))
+	assert.Equal(t, testTerminalSubject, stateChangeSubject(naming, errored))
+	assert.Equal(t, []string{testStateChangeSubject, testTerminalSubject}, StateChangeSubjects(naming, "TestType"))
+}
+
+func TestOnMessage_Outbox_FailedUpdateNotRecorded(t *testing.T) {
+	outbox := memorystore.NewOutboxStore()
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("database unavailable")}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.messageOutbox = outbox
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	pending, err := outbox.FindPending(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutboxRelay_PublishesAndMarksSent(t *testing.T) {
+	ctx := context.Background()
+	outbox := memorystore.NewOutboxStore()
+	for _, id := range []string{"msg-1", "msg-2"} {
+		require.NoError(t, outbox.Add(ctx, &api.OutboxMessage{ID: id, Subject: testStateChangeSubject, Payload: []byte(id)}))
+	}
+
+	client := mocks.NewMockMsgClient(t)
+	mock.InOrder(
+		client.EXPECT().PublishMsg(mock.Anything, matchOutboxMessage("msg-1")).Return(&jetstream.PubAck{}, nil).Once(),
+		client.EXPECT().PublishMsg(mock.Anything, matchOutboxMessage("msg-2")).Return(&jetstream.PubAck{}, nil).Once(),
+	)
+
+	relay := NewOutboxRelay(outbox, &store.NoOpTransactionContext{}, client, system.NoopMonitor{})
+	count, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	pending, err := outbox.FindPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutboxRelay_PublishFailure_RemainsPending(t *testing.T) {
+	ctx := context.Background()
+	outbox := memorystore.NewOutboxStore()
+	for _, id := range []string{"msg-1", "msg-2"} {
+		require.NoError(t, outbox.Add(ctx, &api.OutboxMessage{ID: id, Subject: testStateChangeSubject, Payload: []byte(id)}))
+	}
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	relay := NewOutboxRelay(outbox, &store.NoOpTransactionContext{}, client, system.NoopMonitor{})
+	count, err := relay.Relay(ctx)
+
+	require.Error(t, err)
+	assert.Equal(t, 0, count)
+	pending, err := outbox.FindPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2, "relaying stops at the first failure to preserve ordering")
+}
+
+// matchOutboxMessage matches the published outbox message with the given ID, which is used as the NATS message ID.
+func matchOutboxMessage(id string) any {
+	return mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testStateChangeSubject && msg.Header.Get(nats.MsgIdHdr) == id && string(msg.Data) == id
+	})
+}
@@ -0,0 +1,161 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Provisioner creates the JetStream resources the orchestrator requires if they do not exist: the stream component
+// messages are published to, the orchestration index consumer of the KV bucket and the DLQ consumer. Provisioning is
+// idempotent. Existing resources are left unchanged if their configuration is compatible, otherwise provisioning fails
+// rather than overwriting settings an operator may have made deliberately.
+type Provisioner struct {
+	jetStream     jetstream.JetStream
+	stream        jetstream.StreamConfig
+	indexStream   string
+	indexConsumer jetstream.ConsumerConfig
+	dlqConsumer   jetstream.ConsumerConfig
+}
+
+// NewProvisioner creates a provisioner for the resources named by the naming strategy and the index consumer of the KV
+// bucket, see newWatcherConsumerConfig.
+func NewProvisioner(
+	jetStream jetstream.JetStream,
+	naming natsclient.NamingStrategy,
+	bucket string,
+	indexConsumer jetstream.ConsumerConfig) *Provisioner {
+	return &Provisioner{
+		jetStream:     jetStream,
+		stream:        newStreamConfig(naming),
+		indexStream:   kvStreamName(bucket),
+		indexConsumer: indexConsumer,
+		dlqConsumer:   newDLQConsumerConfig(naming),
+	}
+}
+
+// Provision creates the stream and consumers that do not exist and verifies the existing ones are compatible.
+func (p *Provisioner) Provision(ctx context.Context) error {
+	if err := p.provisionStream(ctx); err != nil {
+		return err
+	}
+	if err := p.provisionConsumer(ctx, p.indexStream, p.indexConsumer); err != nil {
+		return err
+	}
+	return p.provisionConsumer(ctx, p.stream.Name, p.dlqConsumer)
+}
+
+func (p *Provisioner) provisionStream(ctx context.Context) error {
+	stream, err := p.jetStream.Stream(ctx, p.stream.Name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		stream, err = p.jetStream.CreateStream(ctx, p.stream)
+		if errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			// Created concurrently, e.g. by another instance
+			stream, err = p.jetStream.Stream(ctx, p.stream.Name)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error provisioning stream %s: %w", p.stream.Name, err)
+	}
+	return checkStreamConfig(p.stream, stream.CachedInfo().Config)
+}
+
+func (p *Provisioner) provisionConsumer(ctx context.Context, streamName string, config jetstream.ConsumerConfig) error {
+	_, err := ensureConsumer(ctx, p.jetStream, streamName, config)
+	return err
+}
+
+// ensureConsumer returns the durable consumer of the stream, creating it if it does not exist. An existing consumer is
+// left unchanged and returned if its configuration is compatible.
+func ensureConsumer(
+	ctx context.Context,
+	jetStream jetstream.JetStream,
+	streamName string,
+	config jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	consumer, err := jetStream.Consumer(ctx, streamName, config.Durable)
+	if errors.Is(err, jetstream.ErrConsumerNotFound) {
+		consumer, err = jetStream.CreateConsumer(ctx, streamName, config)
+		if errors.Is(err, jetstream.ErrConsumerExists) {
+			// Created concurrently with a different configuration
+			consumer, err = jetStream.Consumer(ctx, streamName, config.Durable)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error provisioning consumer %s on stream %s: %w", config.Durable, streamName, err)
+	}
+	if err = checkConsumerConfig(streamName, config, consumer.CachedInfo().Config); err != nil {
+		return nil, err
+	}
+	return consumer, nil
+}
+
+// checkStreamConfig returns an error if the existing stream does not retain messages as expected or does not capture
+// all expected subjects. Additional subjects are compatible.
+func checkStreamConfig(expected, existing jetstream.StreamConfig) error {
+	if existing.Retention != expected.Retention {
+		return fmt.Errorf("incompatible stream %s: retention policy is %s, expected %s",
+			expected.Name, existing.Retention, expected.Retention)
+	}
+	for _, subject := range expected.Subjects {
+		if !slices.Contains(existing.Subjects, subject) {
+			return fmt.Errorf("incompatible stream %s: subject %s is not captured", expected.Name, subject)
+		}
+	}
+	return nil
+}
+
+// checkConsumerConfig returns an error if the existing consumer does not read the expected messages with explicit
+// acknowledgements. MaxWaiting is only compared when set since the server applies a default otherwise.
+func checkConsumerConfig(streamName string, expected, existing jetstream.ConsumerConfig) error {
+	mismatch := func(setting string, actual, wanted any) error {
+		return fmt.Errorf("incompatible consumer %s on stream %s: %s is %v, expected %v",
+			expected.Durable, streamName, setting, actual, wanted)
+	}
+	switch {
+	case existing.AckPolicy != expected.AckPolicy:
+		return mismatch("ack policy", existing.AckPolicy, expected.AckPolicy)
+	case existing.FilterSubject != expected.FilterSubject:
+		return mismatch("filter subject", existing.FilterSubject, expected.FilterSubject)
+	case existing.DeliverPolicy != expected.DeliverPolicy:
+		return mismatch("deliver policy", existing.DeliverPolicy, expected.DeliverPolicy)
+	case existing.OptStartSeq != expected.OptStartSeq:
+		return mismatch("start sequence", existing.OptStartSeq, expected.OptStartSeq)
+	case expected.MaxWaiting > 0 && existing.MaxWaiting != expected.MaxWaiting:
+		return mismatch("max waiting", existing.MaxWaiting, expected.MaxWaiting)
+	}
+	return nil
+}
+
+// newStreamConfig returns the configuration of the work queue stream component messages are published to.
+func newStreamConfig(naming natsclient.NamingStrategy) jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:      naming.StreamName(),
+		Retention: jetstream.WorkQueuePolicy,
+		Subjects:  []string{natsclient.CFMSubjectPrefix + ".*", natsclient.CFMTerminalSubjectPrefix + ".*"},
+	}
+}
+
+// newDLQConsumerConfig returns the configuration of the consumer reading dead-lettered messages for redrives.
+func newDLQConsumerConfig(naming natsclient.NamingStrategy) jetstream.ConsumerConfig {
+	return jetstream.ConsumerConfig{
+		Durable:       naming.DurableName(deadLetterDurable),
+		FilterSubject: naming.DLQSubject(),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	}
+}
@@ -0,0 +1,65 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStreamConfig(t *testing.T) {
+	expected := newStreamConfig(natsclient.DefaultNamingStrategy{Stream: "cfm-stream"})
+
+	require.NoError(t, checkStreamConfig(expected, expected))
+
+	additional := expected
+	additional.Subjects = append([]string{"custom.*"}, expected.Subjects...)
+	assert.NoError(t, checkStreamConfig(expected, additional), "additional subjects are compatible")
+
+	limits := expected
+	limits.Retention = jetstream.LimitsPolicy
+	assert.ErrorContains(t, checkStreamConfig(expected, limits), "retention policy")
+
+	missing := expected
+	missing.Subjects = expected.Subjects[:1]
+	assert.ErrorContains(t, checkStreamConfig(expected, missing), "is not captured")
+}
+
+func TestCheckConsumerConfig(t *testing.T) {
+	expected, err := newWatcherConsumerConfig("cfm-orchestrations", WatcherConfig{})
+	require.NoError(t, err)
+
+	existing := expected
+	existing.MaxWaiting = 512
+	require.NoError(t, checkConsumerConfig("KV_cfm-orchestrations", expected, existing), "server defaults are compatible")
+
+	for name, modify := range map[string]func(config *jetstream.ConsumerConfig){
+		"ack policy":     func(config *jetstream.ConsumerConfig) { config.AckPolicy = jetstream.AckNonePolicy },
+		"filter subject": func(config *jetstream.ConsumerConfig) { config.FilterSubject = "$KV.other.>" },
+		"deliver policy": func(config *jetstream.ConsumerConfig) { config.DeliverPolicy = jetstream.DeliverAllPolicy },
+		"start sequence": func(config *jetstream.ConsumerConfig) { config.OptStartSeq = 42 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			incompatible := expected
+			modify(&incompatible)
+			assert.ErrorContains(t, checkConsumerConfig("KV_cfm-orchestrations", expected, incompatible), name)
+		})
+	}
+
+	expected.MaxWaiting = 16
+	assert.ErrorContains(t, checkConsumerConfig("KV_cfm-orchestrations", expected, existing), "max waiting")
+}
@@ -0,0 +1,214 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const replayBatchSize = 100
+
+// OrchestrationEvent is a recorded orchestration change replayed by an EventSource.
+type OrchestrationEvent struct {
+	Data   []byte
+	Header nats.Header
+
+	// Sequence orders the changes of an orchestration, zero if unknown. Changes without a sequence are ordered by
+	// their state timestamp.
+	Sequence uint64
+}
+
+// EventSource replays recorded orchestration changes.
+type EventSource interface {
+	Replay(ctx context.Context) iter.Seq2[OrchestrationEvent, error]
+}
+
+// StreamEventSource replays the KV stream backing the orchestration bucket up to its last message at the time of the
+// replay. Only the changes retained by the stream are replayed; since the latest change of each key is retained, this
+// suffices to rebuild the current state.
+type StreamEventSource struct {
+	JetStream jetstream.JetStream
+	Bucket    string
+
+	// LatestOnly replays only the latest change of each orchestration, which is sufficient to restore the current
+	// state and faster than replaying the history retained by the stream.
+	LatestOnly bool
+
+	// Last replays only the last messages of the stream when positive, e.g. to sample recent changes. Takes precedence
+	// over LatestOnly.
+	Last uint64
+}
+
+func (s StreamEventSource) Replay(ctx context.Context) iter.Seq2[OrchestrationEvent, error] {
+	return func(yield func(OrchestrationEvent, error) bool) {
+		stream, err := s.JetStream.Stream(ctx, kvStreamName(s.Bucket))
+		if err != nil {
+			yield(OrchestrationEvent{}, fmt.Errorf("error reading orchestration stream: %w", err))
+			return
+		}
+		info, err := stream.Info(ctx)
+		if err != nil {
+			yield(OrchestrationEvent{}, fmt.Errorf("error reading orchestration stream: %w", err))
+			return
+		}
+		if info.State.Msgs == 0 {
+			return
+		}
+		deliverPolicy := jetstream.DeliverAllPolicy
+		if s.LatestOnly {
+			// The last message of the stream is the latest change of its key and thus still delivered last
+			deliverPolicy = jetstream.DeliverLastPerSubjectPolicy
+		}
+		var startSequence uint64
+		if s.Last > 0 {
+			deliverPolicy = jetstream.DeliverByStartSequencePolicy
+			startSequence = info.State.FirstSeq
+			if info.State.LastSeq >= s.Last && info.State.LastSeq-s.Last+1 > startSequence {
+				startSequence = info.State.LastSeq - s.Last + 1
+			}
+		}
+		// Ordered consumers are ephemeral and do not require acknowledgements
+		consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+			FilterSubjects: []string{"$KV." + s.Bucket + ".>"},
+			DeliverPolicy:  deliverPolicy,
+			OptStartSeq:    startSequence,
+		})
+		if err != nil {
+			yield(OrchestrationEvent{}, fmt.Errorf("error creating replay consumer: %w", err))
+			return
+		}
+		for {
+			batch, err := consumer.Fetch(replayBatchSize, jetstream.FetchMaxWait(time.Second))
+			if err != nil {
+				yield(OrchestrationEvent{}, fmt.Errorf("error replaying orchestration stream: %w", err))
+				return
+			}
+			received := 0
+			for msg := range batch.Messages() {
+				received++
+				metadata, err := msg.Metadata()
+				if err != nil {
+					yield(OrchestrationEvent{}, fmt.Errorf("error reading replayed message metadata: %w", err))
+					return
+				}
+				event := OrchestrationEvent{Data: msg.Data(), Header: msg.Headers(), Sequence: metadata.Sequence.Stream}
+				if !yield(event, nil) || metadata.Sequence.Stream >= info.State.LastSeq {
+					return
+				}
+			}
+			if err := batch.Error(); err != nil {
+				yield(OrchestrationEvent{}, fmt.Errorf("error replaying orchestration stream: %w", err))
+				return
+			}
+			if received == 0 {
+				// The remaining messages have been deleted since the replay started
+				return
+			}
+		}
+	}
+}
+
+// IndexRebuilder reconstructs the orchestration index from the changes replayed by an EventSource, see
+// api.IndexRebuilder.
+type IndexRebuilder struct {
+	events     EventSource
+	decoder    MessageDecoder
+	trxContext store.TransactionContext
+	monitor    system.LogMonitor
+}
+
+func NewIndexRebuilder(
+	events EventSource,
+	decoder MessageDecoder,
+	trxContext store.TransactionContext,
+	monitor system.LogMonitor) *IndexRebuilder {
+	if decoder == nil {
+		decoder = JSONDecoder{}
+	}
+	return &IndexRebuilder{events: events, decoder: decoder, trxContext: trxContext, monitor: monitor}
+}
+
+// RebuildFromEvents folds all changes before writing, so that dst receives the final state of each orchestration
+// regardless of the order in which its changes are replayed. Changes are ordered as by the watcher, see isStale.
+// Changes that cannot be decoded are skipped. Each entry is written in a separate transaction.
+func (r *IndexRebuilder) RebuildFromEvents(ctx context.Context, dst store.EntityStore[*api.OrchestrationEntry]) error {
+	entries, err := r.fold(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range slices.Sorted(maps.Keys(entries)) {
+		err := r.trxContext.Execute(ctx, func(ctx context.Context) error {
+			return writeRebuilt(ctx, dst, entries[id])
+		})
+		if err != nil {
+			return fmt.Errorf("error writing rebuilt orchestration entry %s: %w", id, err)
+		}
+	}
+	r.monitor.Infof("Rebuilt %d orchestration index entries", len(entries))
+	return nil
+}
+
+// fold reduces the replayed changes to the latest change of each orchestration.
+func (r *IndexRebuilder) fold(ctx context.Context) (map[string]*api.OrchestrationEntry, error) {
+	entries := make(map[string]*api.OrchestrationEntry)
+	skipped := 0
+	for event, err := range r.events.Replay(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := r.decoder.Decode(event.Data, event.Header)
+		if err != nil {
+			skipped++
+			continue
+		}
+		entry := createEntry(decoded.Orchestration)
+		entry.Revision = event.Sequence
+		if current, found := entries[entry.ID]; found && isStale(entry, current, 0) {
+			continue
+		}
+		entries[entry.ID] = entry
+	}
+	if skipped > 0 {
+		r.monitor.Warnf("Skipped %d orchestration changes that could not be decoded while rebuilding the index", skipped)
+	}
+	return entries, nil
+}
+
+// writeRebuilt creates the entry or replaces an existing one, keeping its checkpoint and version.
+func writeRebuilt(ctx context.Context, dst store.EntityStore[*api.OrchestrationEntry], entry *api.OrchestrationEntry) error {
+	existing, err := dst.FindByID(ctx, entry.ID)
+	if errors.Is(err, types.ErrNotFound) {
+		_, err = dst.Create(ctx, entry)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// Orchestration changes do not carry the checkpoint
+	entry.Checkpoint = existing.Checkpoint
+	entry.Version = existing.Version
+	return dst.Update(ctx, entry)
+}
@@ -0,0 +1,130 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexRebuilder_RebuildFromEvents(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	change := func(id string, state api.OrchestrationState, offset time.Duration) []byte {
+		orchestration := createWatcherOrchestration(id, "corr-"+id, state)
+		orchestration.StateTimestamp = base.Add(offset)
+		data, err := json.Marshal(orchestration)
+		require.NoError(t, err)
+		return data
+	}
+
+	events := sliceEventSource{
+		// orch-1 changes are replayed out of order and ordered by sequence
+		{Data: change("orch-1", api.OrchestrationStateRunning, time.Minute), Sequence: 2},
+		{Data: change("orch-1", api.OrchestrationStateCompleted, time.Second), Sequence: 3},
+		{Data: change("orch-1", api.OrchestrationStateInitialized, 0), Sequence: 1},
+		// orch-2 changes carry no sequence and are ordered by timestamp
+		{Data: change("orch-2", api.OrchestrationStateCompensating, 2*time.Minute)},
+		{Data: change("orch-2", api.OrchestrationStateRunning, time.Minute)},
+		// orch-3 is replaced in the destination
+		{Data: change("orch-3", api.OrchestrationStateErrored, time.Minute), Sequence: 4},
+		{Data: []byte("not an orchestration"), Sequence: 5},
+	}
+
+	dst := memorystore.NewOrchestrationIndex()
+	stale := createEntry(createWatcherOrchestration("orch-3", "corr-orch-3", api.OrchestrationStateRunning))
+	stale.Checkpoint = json.RawMessage(`{"step":"deploy"}`)
+	_, err := dst.Create(ctx, stale)
+	require.NoError(t, err)
+
+	rebuilder := NewIndexRebuilder(events, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+	require.NoError(t, rebuilder.RebuildFromEvents(ctx, dst))
+
+	expected := map[string]api.OrchestrationState{
+		"orch-1": api.OrchestrationStateCompleted,
+		"orch-2": api.OrchestrationStateCompensating,
+		"orch-3": api.OrchestrationStateErrored,
+	}
+	count, err := dst.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(expected)), count)
+	for id, state := range expected {
+		entry, err := dst.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, state, entry.State, id)
+		assert.Equal(t, "corr-"+id, entry.CorrelationID)
+	}
+
+	entry, err := dst.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), entry.Revision)
+	entry, err = dst.FindByID(ctx, "orch-3")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"step":"deploy"}`, string(entry.Checkpoint), "the checkpoint of replaced entries is kept")
+}
+
+func TestIndexRebuilder_ReplayErrorNotWritten(t *testing.T) {
+	ctx := context.Background()
+	data, err := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	require.NoError(t, err)
+	events := failingEventSource{events: []OrchestrationEvent{{Data: data, Sequence: 1}}, err: errors.New("stream unavailable")}
+
+	dst := memorystore.NewOrchestrationIndex()
+	rebuilder := NewIndexRebuilder(events, nil, &store.NoOpTransactionContext{}, system.NoopMonitor{})
+
+	assert.ErrorContains(t, rebuilder.RebuildFromEvents(ctx, dst), "stream unavailable")
+	count, err := dst.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count, "nothing is written if the replay fails")
+}
+
+// sliceEventSource replays the events in order.
+type sliceEventSource []OrchestrationEvent
+
+func (s sliceEventSource) Replay(context.Context) iter.Seq2[OrchestrationEvent, error] {
+	return func(yield func(OrchestrationEvent, error) bool) {
+		for _, event := range s {
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// failingEventSource replays the events and then fails.
+type failingEventSource struct {
+	events []OrchestrationEvent
+	err    error
+}
+
+func (s failingEventSource) Replay(ctx context.Context) iter.Seq2[OrchestrationEvent, error] {
+	return func(yield func(OrchestrationEvent, error) bool) {
+		for event := range sliceEventSource(s.events).Replay(ctx) {
+			if !yield(event, nil) {
+				return
+			}
+		}
+		yield(OrchestrationEvent{}, s.err)
+	}
+}
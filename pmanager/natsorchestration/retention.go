@@ -0,0 +1,186 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// defaultPurgePageSize is the number of expired entries loaded and deleted at a time.
+const defaultPurgePageSize = 100
+
+// RetentionPurger hard-deletes index entries of finished orchestrations once they are older than the retention
+// configured for their orchestration type. Entries of types without a configured retention are kept indefinitely.
+type RetentionPurger struct {
+	index      store.EntityStore[*api.OrchestrationEntry]
+	trxContext store.TransactionContext
+	retention  map[model.OrchestrationType]time.Duration
+	monitor    system.LogMonitor
+	lock       jobLock
+	now        func() time.Time
+	pageSize   int
+}
+
+func NewRetentionPurger(
+	index store.EntityStore[*api.OrchestrationEntry],
+	trxContext store.TransactionContext,
+	retention map[model.OrchestrationType]time.Duration,
+	monitor system.LogMonitor) *RetentionPurger {
+	return &RetentionPurger{
+		index:      index,
+		trxContext: trxContext,
+		retention:  retention,
+		monitor:    monitor,
+		lock:       jobLock{locker: index, trxContext: trxContext, name: retentionPurgerLock},
+		now:        time.Now,
+		pageSize:   defaultPurgePageSize,
+	}
+}
+
+// Run purges expired entries at the given interval until the context is canceled. Runs are skipped while another
+// replica purges.
+func (p *RetentionPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.lock.run(ctx, func(ctx context.Context) error {
+				_, err := p.Purge(ctx)
+				return err
+			})
+			if err != nil {
+				p.monitor.Warnf("Error purging orchestration entries: %v", err)
+			}
+		}
+	}
+}
+
+// Purge deletes all completed or errored entries whose state timestamp is older than the retention of their type and
+// returns the number of purged entries per type. The type and cutoff are evaluated by the store and expired entries
+// are loaded and deleted a page at a time, so that memory use does not grow with the size of the index. Failures
+// deleting an individual entry are logged and retried on the next run.
+func (p *RetentionPurger) Purge(ctx context.Context) (map[model.OrchestrationType]int, error) {
+	now := p.now()
+	counts := make(map[model.OrchestrationType]int)
+	for _, orchestrationType := range slices.Sorted(maps.Keys(p.retention)) {
+		filter := api.Filter{
+			States:            []api.OrchestrationState{api.OrchestrationStateCompleted, api.OrchestrationStateErrored},
+			OlderThan:         now.Add(-p.retention[orchestrationType]),
+			OrchestrationType: orchestrationType,
+		}
+		purged, err := p.purgeExpired(ctx, filter.Predicate())
+		if purged > 0 {
+			counts[orchestrationType] = purged
+		}
+		if err != nil {
+			return counts, fmt.Errorf("failed to query expired orchestration entries: %w", err)
+		}
+	}
+	if len(counts) > 0 {
+		p.monitor.Infof("Purged orchestration entries: %s", formatPurgeCounts(counts))
+	}
+	return counts, nil
+}
+
+// purgeExpired deletes the entries matching the predicate page by page and returns the number of deleted entries. It
+// stops once a page is not full or none of its entries could be deleted, as the failed entries would be loaded again.
+func (p *RetentionPurger) purgeExpired(ctx context.Context, predicate query.Predicate) (int, error) {
+	pageSize := p.pageSize
+	if pageSize <= 0 {
+		pageSize = defaultPurgePageSize
+	}
+	purged := 0
+	for {
+		var expired []string
+		err := p.trxContext.Execute(ctx, func(ctx context.Context) error {
+			opts := store.PaginationOptions{Limit: int64(pageSize)}
+			for entry, err := range p.index.FindByPredicatePaginated(ctx, predicate, opts) {
+				if err != nil {
+					return err
+				}
+				expired = append(expired, entry.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+
+		deleted := 0
+		for _, id := range expired {
+			err := p.trxContext.Execute(ctx, func(ctx context.Context) error {
+				return p.index.Delete(ctx, id)
+			})
+			if err != nil {
+				p.monitor.Warnf("Failed to purge orchestration entry %s: %v", id, err)
+				continue
+			}
+			deleted++
+		}
+		purged += deleted
+		if len(expired) < pageSize || deleted == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// formatPurgeCounts renders the per-type counts in a stable order.
+func formatPurgeCounts(counts map[model.OrchestrationType]int) string {
+	orchestrationTypes := slices.Sorted(maps.Keys(counts))
+	parts := make([]string, 0, len(orchestrationTypes))
+	for _, orchestrationType := range orchestrationTypes {
+		parts = append(parts, fmt.Sprintf("%s=%d", orchestrationType, counts[orchestrationType]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// retentionPolicy is the configured retention period of an orchestration type. Policies are configured as a list
+// since orchestration types contain dots, which are interpreted as key separators in configuration maps.
+type retentionPolicy struct {
+	Type   string `mapstructure:"type"`
+	Period string `mapstructure:"period"`
+}
+
+// parseRetention converts configured retention policies to durations keyed by orchestration type.
+func parseRetention(policies []retentionPolicy) (map[model.OrchestrationType]time.Duration, error) {
+	retention := make(map[model.OrchestrationType]time.Duration, len(policies))
+	for _, policy := range policies {
+		if policy.Type == "" {
+			return nil, fmt.Errorf("retention policy is missing the orchestration type")
+		}
+		duration, err := time.ParseDuration(policy.Period)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention for orchestration type %s: %w", policy.Type, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("invalid retention for orchestration type %s: must be positive", policy.Type)
+		}
+		retention[model.OrchestrationType(policy.Type)] = duration
+	}
+	return retention, nil
+}
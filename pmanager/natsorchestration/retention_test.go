@@ -0,0 +1,144 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPurger_Purge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	shortLived := createWatcherOrchestration("short-expired", "corr-1", api.OrchestrationStateCompleted)
+	shortLived.OrchestrationType = "ShortType"
+	shortLived.StateTimestamp = now.Add(-2 * time.Hour)
+	shortErrored := createWatcherOrchestration("short-errored", "corr-2", api.OrchestrationStateErrored)
+	shortErrored.OrchestrationType = "ShortType"
+	shortErrored.StateTimestamp = now.Add(-2 * time.Hour)
+	shortRunning := createWatcherOrchestration("short-running", "corr-3", api.OrchestrationStateRunning)
+	shortRunning.OrchestrationType = "ShortType"
+	shortRunning.StateTimestamp = now.Add(-2 * time.Hour)
+	longLived := createWatcherOrchestration("long-retained", "corr-4", api.OrchestrationStateCompleted)
+	longLived.OrchestrationType = "LongType"
+	longLived.StateTimestamp = now.Add(-2 * time.Hour)
+	unconfigured := createWatcherOrchestration("unconfigured", "corr-5", api.OrchestrationStateCompleted)
+	unconfigured.StateTimestamp = now.Add(-48 * time.Hour)
+	for _, o := range []api.Orchestration{shortLived, shortErrored, shortRunning, longLived, unconfigured} {
+		_, err := index.Create(ctx, createEntry(o))
+		require.NoError(t, err)
+	}
+
+	purger := createTestPurger(index, map[model.OrchestrationType]time.Duration{
+		"ShortType": time.Hour,
+		"LongType":  24 * time.Hour,
+	}, now)
+
+	counts, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.OrchestrationType]int{"ShortType": 2}, counts)
+
+	for _, id := range []string{"short-expired", "short-errored"} {
+		_, err = index.FindByID(ctx, id)
+		assert.ErrorIs(t, err, types.ErrNotFound, "entry %s should be purged", id)
+	}
+	for _, id := range []string{"short-running", "long-retained", "unconfigured"} {
+		_, err = index.FindByID(ctx, id)
+		assert.NoError(t, err, "entry %s should be retained", id)
+	}
+}
+
+func TestRetentionPurger_Purge_Paged(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+	for _, id := range []string{"orch-1", "orch-2", "orch-3"} {
+		expired := createWatcherOrchestration(id, "corr-"+id, api.OrchestrationStateCompleted)
+		expired.StateTimestamp = now.Add(-2 * time.Hour)
+		_, err := index.Create(ctx, createEntry(expired))
+		require.NoError(t, err)
+	}
+
+	purger := createTestPurger(index, map[model.OrchestrationType]time.Duration{"TestType": time.Hour}, now)
+	purger.pageSize = 2
+
+	counts, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.OrchestrationType]int{"TestType": 3}, counts)
+	count, err := index.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestRetentionPurger_Purge_NothingExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	completed := createWatcherOrchestration("completed", "corr-1", api.OrchestrationStateCompleted)
+	_, err := index.Create(ctx, createEntry(completed))
+	require.NoError(t, err)
+
+	purger := createTestPurger(index, map[model.OrchestrationType]time.Duration{"TestType": time.Hour}, now)
+
+	counts, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestParseRetention(t *testing.T) {
+	retention, err := parseRetention([]retentionPolicy{
+		{Type: "cfm.orchestration.vpa.deploy", Period: "720h"},
+		{Type: "cfm.orchestration.vpa.dispose", Period: "24h"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[model.OrchestrationType]time.Duration{
+		model.VPADeployType:  720 * time.Hour,
+		model.VPADisposeType: 24 * time.Hour,
+	}, retention)
+
+	_, err = parseRetention([]retentionPolicy{{Type: "TestType", Period: "invalid"}})
+	assert.Error(t, err)
+	_, err = parseRetention([]retentionPolicy{{Type: "TestType", Period: "-1h"}})
+	assert.Error(t, err)
+	_, err = parseRetention([]retentionPolicy{{Period: "1h"}})
+	assert.Error(t, err)
+}
+
+func TestFormatPurgeCounts(t *testing.T) {
+	assert.Equal(t, "a=1, b=3", formatPurgeCounts(map[model.OrchestrationType]int{"b": 3, "a": 1}))
+}
+
+func createTestPurger(
+	index store.EntityStore[*api.OrchestrationEntry],
+	retention map[model.OrchestrationType]time.Duration,
+	now time.Time) *RetentionPurger {
+	return &RetentionPurger{
+		index:      index,
+		trxContext: &store.NoOpTransactionContext{},
+		retention:  retention,
+		monitor:    system.NoopMonitor{},
+		now:        func() time.Time { return now },
+	}
+}
@@ -0,0 +1,89 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ManualRetrier republishes orchestrations on request of an operator, see api.Retrier.
+type ManualRetrier struct {
+	client  natsclient.MsgClient
+	naming  natsclient.NamingStrategy
+	monitor system.LogMonitor
+}
+
+func NewManualRetrier(client natsclient.MsgClient, naming natsclient.NamingStrategy, monitor system.LogMonitor) *ManualRetrier {
+	return &ManualRetrier{client: client, naming: naming, monitor: monitor}
+}
+
+// Retry writes the orchestration back to the KV store with its manual retry counter incremented, which publishes it
+// to the orchestration subject again, and re-enqueues the pending activities of its current step so that processing
+// resumes. The KV store is the source of truth: the state is checked and updated against the same revision so that a
+// concurrent transition to a terminal state is not overwritten.
+func (r *ManualRetrier) Retry(ctx context.Context, id string) error {
+	orchestration, revision, err := ReadOrchestration(ctx, id, r.client)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return fmt.Errorf("%w: orchestration %s", types.ErrNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	if isTerminal(orchestration.State) {
+		return fmt.Errorf("%w: %s", api.ErrAlreadyTerminal, id)
+	}
+
+	orchestration.ManualRetries++
+	orchestration.Expiry = time.Time{}
+	serialized, err := json.Marshal(orchestration)
+	if err != nil {
+		return fmt.Errorf("failed to marshal orchestration %s: %w", id, err)
+	}
+	if _, err = r.client.Update(ctx, id, serialized, revision); err != nil {
+		return fmt.Errorf("failed to republish orchestration %s: %w", id, err)
+	}
+	pending := pendingActivities(&orchestration)
+	if err = EnqueueActivityMessages(ctx, id, pending, r.client, r.naming); err != nil {
+		return fmt.Errorf("failed to re-enqueue activities of orchestration %s: %w", id, err)
+	}
+	r.monitor.Infof("Republished orchestration %s for manual retry %d, re-enqueued %d activities",
+		id, orchestration.ManualRetries, len(pending))
+	return nil
+}
+
+// pendingActivities returns the activities of the current step, the first step not completed, that have not completed.
+// Activities of later steps are enqueued once the current step completes.
+func pendingActivities(orchestration *api.Orchestration) []api.Activity {
+	for _, step := range orchestration.Steps {
+		var pending []api.Activity
+		for _, activity := range step.Activities {
+			if _, completed := orchestration.Completed[activity.ID]; !completed {
+				pending = append(pending, activity)
+			}
+		}
+		if len(pending) > 0 {
+			return pending
+		}
+	}
+	return nil
+}
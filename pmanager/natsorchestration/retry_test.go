@@ -0,0 +1,94 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualRetrier_Retry_RepublishesRunningOrchestration(t *testing.T) {
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	running.ManualRetries = 1
+	running.Steps = []api.OrchestrationStep{
+		{Activities: []api.Activity{{ID: "a1", Type: "provision"}}},
+		{Activities: []api.Activity{{ID: "a2", Type: "provision"}, {ID: "a3", Type: "configure"}}},
+		{Activities: []api.Activity{{ID: "a4", Type: "provision"}}},
+	}
+	running.Completed = map[string]struct{}{"a1": {}, "a2": {}}
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, running, 7), nil).Once()
+	var republished api.Orchestration
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(7)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &republished))
+		}).
+		Return(uint64(8), nil).Once()
+	var enqueued []api.ActivityMessage
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, msg *nats.Msg, _ ...jetstream.PublishOpt) {
+			assert.Equal(t, natsclient.DefaultNamingStrategy{}.Subject("configure"), msg.Subject)
+			var activityMsg api.ActivityMessage
+			require.NoError(t, json.Unmarshal(msg.Data, &activityMsg))
+			enqueued = append(enqueued, activityMsg)
+		}).
+		Return(&jetstream.PubAck{}, nil).Once()
+
+	err := NewManualRetrier(client, natsclient.DefaultNamingStrategy{}, system.NoopMonitor{}).Retry(context.Background(), "orch-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "orch-1", republished.ID)
+	assert.Equal(t, api.OrchestrationStateRunning, republished.State)
+	assert.Equal(t, 2, republished.ManualRetries)
+	require.Len(t, enqueued, 1, "only the pending activities of the current step are re-enqueued")
+	assert.Equal(t, "orch-1", enqueued[0].OrchestrationID)
+	assert.Equal(t, "a3", enqueued[0].Activity.ID)
+}
+
+func TestManualRetrier_Retry_TerminalOrchestrationRejected(t *testing.T) {
+	for _, state := range []api.OrchestrationState{api.OrchestrationStateCompleted, api.OrchestrationStateErrored} {
+		t.Run(fmt.Sprint(state), func(t *testing.T) {
+			// Update is not expected, the mock fails on unexpected calls
+			client := mocks.NewMockMsgClient(t)
+			orchestration := createWatcherOrchestration("orch-1", "corr-1", state)
+			client.EXPECT().Get(mock.Anything, "orch-1").Return(newTestKVEntry(t, orchestration, 3), nil).Once()
+
+			err := NewManualRetrier(client, natsclient.DefaultNamingStrategy{}, system.NoopMonitor{}).Retry(context.Background(), "orch-1")
+
+			assert.ErrorIs(t, err, api.ErrAlreadyTerminal)
+		})
+	}
+}
+
+func TestManualRetrier_Retry_UnknownOrchestration(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Get(mock.Anything, "orch-1").Return(nil, jetstream.ErrKeyNotFound).Once()
+
+	err := NewManualRetrier(client, natsclient.DefaultNamingStrategy{}, system.NoopMonitor{}).Retry(context.Background(), "orch-1")
+
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
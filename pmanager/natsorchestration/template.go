@@ -0,0 +1,104 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// TemplateRegistry creates orchestrations from registered templates and publishes them to the KV store, see
+// api.TemplateRegistry.
+type TemplateRegistry struct {
+	client    natsclient.MsgClient
+	monitor   system.LogMonitor
+	now       func() time.Time
+	mu        sync.RWMutex
+	templates map[string]api.Template
+}
+
+func NewTemplateRegistry(client natsclient.MsgClient, monitor system.LogMonitor) *TemplateRegistry {
+	return &TemplateRegistry{
+		client:    client,
+		monitor:   monitor,
+		now:       time.Now,
+		templates: make(map[string]api.Template),
+	}
+}
+
+func (r *TemplateRegistry) Register(name string, template api.Template) error {
+	if name == "" {
+		return fmt.Errorf("%w: template name must not be empty", types.ErrInvalidInput)
+	}
+	if template.OrchestrationType == "" {
+		return fmt.Errorf("%w: template %s has no orchestration type", types.ErrInvalidInput, name)
+	}
+	if template.StepCount < 0 {
+		return fmt.Errorf("%w: template %s has a negative step count", types.ErrInvalidInput, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.templates[name]; exists {
+		return fmt.Errorf("%w: template %s is already registered", types.ErrConflict, name)
+	}
+	r.templates[name] = template
+	return nil
+}
+
+// CreateFromTemplate writes the orchestration to the KV store, which publishes its initial state to the orchestration
+// subject. The write fails if an orchestration with the ID already exists.
+func (r *TemplateRegistry) CreateFromTemplate(
+	ctx context.Context,
+	name string,
+	overrides ...api.TemplateOption) (*api.OrchestrationEntry, error) {
+	r.mu.RLock()
+	template, found := r.templates[name]
+	r.mu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("%w: template %s", types.ErrNotFound, name)
+	}
+
+	orchestration := template.NewOrchestration(uuid.New().String(), r.now())
+	for _, override := range overrides {
+		override(orchestration)
+	}
+	if orchestration.CorrelationID == "" {
+		orchestration.CorrelationID = orchestration.ID
+	}
+
+	serialized, err := json.Marshal(orchestration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal orchestration %s: %w", orchestration.ID, err)
+	}
+	// Revision zero only succeeds if the key does not exist
+	if _, err = r.client.Update(ctx, orchestration.ID, serialized, 0); err != nil {
+		var jsErr *jetstream.APIError
+		if errors.As(err, &jsErr) && jsErr.APIError().ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence {
+			return nil, fmt.Errorf("%w: orchestration %s", types.ErrConflict, orchestration.ID)
+		}
+		return nil, fmt.Errorf("failed to publish orchestration %s: %w", orchestration.ID, err)
+	}
+	r.monitor.Infof("Created orchestration %s from template %s", orchestration.ID, name)
+	return createEntry(*orchestration), nil
+}
@@ -0,0 +1,89 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateRegistry_CreateFromTemplate(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	var published api.Orchestration
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(0)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &published))
+		}).
+		Return(uint64(1), nil).
+		Once()
+
+	registry := NewTemplateRegistry(client, system.NoopMonitor{})
+	require.NoError(t, registry.Register("provision-eu", api.Template{
+		OrchestrationType: "cfm.provision",
+		Labels:            map[string]string{"region": "eu", "tier": "standard"},
+		InitialState:      api.OrchestrationStateInitialized,
+		StepCount:         3,
+	}))
+
+	entry, err := registry.CreateFromTemplate(context.Background(), "provision-eu",
+		api.WithTemplateID("orch-1"),
+		api.WithTemplateLabel("tier", "gold"),
+		api.WithTemplateState(api.OrchestrationStateRunning))
+	require.NoError(t, err)
+
+	assert.Equal(t, "orch-1", entry.ID)
+	assert.Equal(t, "orch-1", entry.CorrelationID, "the correlation ID defaults to the orchestration ID")
+	assert.Equal(t, "cfm.provision", entry.OrchestrationType.String())
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	assert.Equal(t, map[string]string{"region": "eu", "tier": "gold"}, entry.Labels)
+
+	assert.Equal(t, "orch-1", published.ID)
+	assert.Equal(t, api.OrchestrationStateRunning, published.State)
+	assert.Len(t, published.Steps, 3)
+
+	// Overrides do not modify the template
+	client.EXPECT().Update(mock.Anything, mock.Anything, mock.Anything, uint64(0)).Return(uint64(1), nil).Once()
+	entry, err = registry.CreateFromTemplate(context.Background(), "provision-eu")
+	require.NoError(t, err)
+	assert.NotEmpty(t, entry.ID)
+	assert.Equal(t, api.OrchestrationStateInitialized, entry.State)
+	assert.Equal(t, map[string]string{"region": "eu", "tier": "standard"}, entry.Labels)
+}
+
+func TestTemplateRegistry_Errors(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	registry := NewTemplateRegistry(client, system.NoopMonitor{})
+
+	assert.ErrorIs(t, registry.Register("", api.Template{OrchestrationType: "cfm.provision"}), types.ErrInvalidInput)
+	assert.ErrorIs(t, registry.Register("untyped", api.Template{}), types.ErrInvalidInput)
+	require.NoError(t, registry.Register("provision", api.Template{OrchestrationType: "cfm.provision"}))
+	assert.ErrorIs(t, registry.Register("provision", api.Template{OrchestrationType: "cfm.provision"}), types.ErrConflict)
+
+	_, err := registry.CreateFromTemplate(context.Background(), "unknown")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(0)).
+		Return(uint64(0), &jetstream.APIError{ErrorCode: jetstream.JSErrCodeStreamWrongLastSequence})
+	_, err = registry.CreateFromTemplate(context.Background(), "provision", api.WithTemplateID("orch-1"))
+	assert.ErrorIs(t, err, types.ErrConflict)
+}
@@ -0,0 +1,63 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/system"
+)
+
+// ReplayTransformer replays recorded orchestration changes through a transformation and publishes the results, e.g. to
+// upgrade the changes to a new schema version when migrating.
+type ReplayTransformer struct {
+	events  EventSource
+	client  natsclient.MsgClient
+	subject string
+	monitor system.LogMonitor
+}
+
+// NewReplayTransformer creates a transformer publishing the transformed changes to the target subject.
+func NewReplayTransformer(
+	events EventSource,
+	client natsclient.MsgClient,
+	subject string,
+	monitor system.LogMonitor) *ReplayTransformer {
+	return &ReplayTransformer{events: events, client: client, subject: subject, monitor: monitor}
+}
+
+// Transform applies fn to the payload of each replayed change in order and publishes the result, returning the number
+// of changes published. Changes fn returns an error for are logged and skipped. The replay stops if a change cannot be
+// replayed or published.
+func (t *ReplayTransformer) Transform(ctx context.Context, fn func([]byte) ([]byte, error)) (int, error) {
+	published, skipped := 0, 0
+	for event, err := range t.events.Replay(ctx) {
+		if err != nil {
+			return published, err
+		}
+		transformed, err := fn(event.Data)
+		if err != nil {
+			t.monitor.Warnf("Skipping orchestration change %d rejected by the transformation: %v", event.Sequence, err)
+			skipped++
+			continue
+		}
+		if _, err = t.client.Publish(ctx, t.subject, transformed); err != nil {
+			return published, fmt.Errorf("error publishing transformed orchestration change %d: %w", event.Sequence, err)
+		}
+		published++
+	}
+	t.monitor.Infof("Published %d transformed orchestration changes to %s, skipped %d", published, t.subject, skipped)
+	return published, nil
+}
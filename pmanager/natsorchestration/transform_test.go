@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testTransformSubject = "event.cfm-orchestration-v2"
+
+func TestReplayTransformer_Transform(t *testing.T) {
+	events := sliceEventSource{
+		{Data: []byte(`{"id":"orch-1","schema":1}`), Sequence: 1},
+		{Data: []byte("not an orchestration"), Sequence: 2},
+		{Data: []byte(`{"id":"orch-2","schema":1}`), Sequence: 3},
+	}
+	upgrade := func(data []byte) ([]byte, error) {
+		if !bytes.Contains(data, []byte(`"schema":1`)) {
+			return nil, errors.New("unknown schema")
+		}
+		return bytes.Replace(data, []byte(`"schema":1`), []byte(`"schema":2`), 1), nil
+	}
+
+	client := mocks.NewMockMsgClient(t)
+	var published []string
+	client.EXPECT().Publish(mock.Anything, testTransformSubject, mock.Anything).
+		Run(func(_ context.Context, _ string, payload []byte, _ ...jetstream.PublishOpt) {
+			published = append(published, string(payload))
+		}).
+		Return(&jetstream.PubAck{}, nil).Times(2)
+
+	count, err := NewReplayTransformer(events, client, testTransformSubject, system.NoopMonitor{}).
+		Transform(context.Background(), upgrade)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{`{"id":"orch-1","schema":2}`, `{"id":"orch-2","schema":2}`}, published)
+}
+
+func TestReplayTransformer_Transform_PublishFailureStopsReplay(t *testing.T) {
+	events := sliceEventSource{{Data: []byte("first"), Sequence: 1}, {Data: []byte("second"), Sequence: 2}}
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().Publish(mock.Anything, testTransformSubject, []byte("first")).
+		Return(nil, errors.New("stream unavailable")).Once()
+
+	count, err := NewReplayTransformer(events, client, testTransformSubject, system.NoopMonitor{}).
+		Transform(context.Background(), func(data []byte) ([]byte, error) { return data, nil })
+
+	assert.ErrorContains(t, err, "stream unavailable")
+	assert.Zero(t, count)
+}
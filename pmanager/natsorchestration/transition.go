@@ -0,0 +1,166 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// BulkTransitioner transitions the index entries of orchestrations matching a filter on request of an operator. Since
+// the orchestrations are not republished, changes recorded afterward by the watcher replace the transitioned state
+// unless it is terminal.
+type BulkTransitioner struct {
+	index      store.EntityStore[*api.OrchestrationEntry]
+	trxContext store.TransactionContext
+	monitor    system.LogMonitor
+	now        func() time.Time
+
+	// outboxStore records the audit of each transition in the transaction of the transition when set, published to the
+	// audit subject of naming. Otherwise, the audit is logged.
+	outboxStore api.OutboxStore
+	naming      natsclient.NamingStrategy
+}
+
+func NewBulkTransitioner(
+	index store.EntityStore[*api.OrchestrationEntry],
+	trxContext store.TransactionContext,
+	monitor system.LogMonitor) *BulkTransitioner {
+	return &BulkTransitioner{
+		index:      index,
+		trxContext: trxContext,
+		monitor:    monitor,
+		now:        time.Now,
+		naming:     natsclient.DefaultNamingStrategy{},
+	}
+}
+
+// BulkTransition reads the matching entries and transitions each in its own transaction, so that an entry failing,
+// e.g. because a concurrent update causes a version conflict, does not roll back the others.
+func (t *BulkTransitioner) BulkTransition(
+	ctx context.Context,
+	filter api.Filter,
+	to api.OrchestrationState,
+	reason string) ([]api.BulkResult, error) {
+	if err := validateBulkTransition(filter, to, reason); err != nil {
+		return nil, err
+	}
+	now := t.now()
+
+	var matching []*api.OrchestrationEntry
+	err := t.trxContext.Execute(ctx, func(ctx context.Context) error {
+		for entry, err := range t.index.FindByPredicate(ctx, filter.Predicate()) {
+			if err != nil {
+				return err
+			}
+			matching = append(matching, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orchestrations to transition to state %d: %w", to, err)
+	}
+
+	results := make([]api.BulkResult, 0, len(matching))
+	transitioned := 0
+	for _, entry := range matching {
+		err := t.trxContext.Execute(ctx, func(ctx context.Context) error {
+			return t.transition(ctx, entry, to, reason, filter.Force, now)
+		})
+		if err != nil {
+			t.monitor.Warnf("Failed to transition orchestration %s to state %d: %v", entry.ID, to, err)
+		} else {
+			transitioned++
+		}
+		results = append(results, api.BulkResult{ID: entry.ID, Success: err == nil, Err: err})
+	}
+	t.monitor.Infof("Transitioned %d of %d orchestrations to state %d: %s", transitioned, len(results), to, reason)
+	return results, nil
+}
+
+// transition updates the entry and records the audit of the transition.
+func (t *BulkTransitioner) transition(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	to api.OrchestrationState,
+	reason string,
+	forced bool,
+	now time.Time) error {
+	audit := api.TransitionAudit{
+		OrchestrationID:   entry.ID,
+		CorrelationID:     entry.CorrelationID,
+		OrchestrationType: entry.OrchestrationType,
+		From:              entry.State,
+		To:                to,
+		Reason:            reason,
+		Forced:            forced,
+		Timestamp:         now,
+	}
+	entry.State = to
+	entry.StateTimestamp = now
+	if err := t.index.Update(ctx, entry); err != nil {
+		return fmt.Errorf("failed to update orchestration entry %s: %w", entry.ID, err)
+	}
+
+	if t.outboxStore == nil {
+		t.monitor.Infof("Transitioned orchestration %s from state %d to %d (forced: %t): %s",
+			audit.OrchestrationID, audit.From, audit.To, audit.Forced, audit.Reason)
+		return nil
+	}
+	payload, err := json.Marshal(audit)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transition audit of orchestration %s: %w", entry.ID, err)
+	}
+	return t.outboxStore.Add(ctx, &api.OutboxMessage{
+		ID:               uuid.New().String(),
+		Subject:          t.naming.Subject(natsclient.CFMOrchestrationAudit),
+		Payload:          payload,
+		CreatedTimestamp: now,
+	})
+}
+
+// validateBulkTransition checks the arguments of a bulk transition. Filters selecting the target state are rejected
+// since transitioned entries would be selected again.
+func validateBulkTransition(filter api.Filter, to api.OrchestrationState, reason string) error {
+	if len(filter.States) == 0 {
+		return fmt.Errorf("%w: the filter must select at least one state", types.ErrInvalidInput)
+	}
+	if to < api.OrchestrationStateInitialized || to > api.OrchestrationStateCompensating {
+		return fmt.Errorf("%w: invalid target state %d", types.ErrInvalidInput, to)
+	}
+	if slices.Contains(filter.States, to) {
+		return fmt.Errorf("%w: the filter must not select the target state %d", types.ErrInvalidInput, to)
+	}
+	if reason == "" {
+		return fmt.Errorf("%w: a reason is required", types.ErrInvalidInput)
+	}
+	if filter.Force {
+		return nil
+	}
+	for _, state := range filter.States {
+		if !api.CanTransition(state, to) {
+			return fmt.Errorf("%w: transition from state %d to %d is not allowed", types.ErrInvalidInput, state, to)
+		}
+	}
+	return nil
+}
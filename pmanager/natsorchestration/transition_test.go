@@ -0,0 +1,219 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkTransitioner_BulkTransition(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+
+	seeded := map[string]api.OrchestrationState{
+		"stuck-1":   api.OrchestrationStateRunning,
+		"stuck-2":   api.OrchestrationStateRunning,
+		"stuck-3":   api.OrchestrationStateRunning,
+		"recent":    api.OrchestrationStateRunning,
+		"completed": api.OrchestrationStateCompleted,
+		"pending":   api.OrchestrationStateInitialized,
+	}
+	for id, state := range seeded {
+		orch := createWatcherOrchestration(id, "corr-"+id, state)
+		orch.StateTimestamp = now.Add(-2 * time.Hour)
+		if id == "recent" {
+			orch.StateTimestamp = now
+		}
+		_, err := index.Create(ctx, createEntry(orch))
+		require.NoError(t, err)
+	}
+
+	outboxStore := memorystore.NewOutboxStore()
+	transitioner := createTestTransitioner(index, now)
+	transitioner.outboxStore = outboxStore
+
+	filter := api.Filter{States: []api.OrchestrationState{api.OrchestrationStateRunning}, OlderThan: now.Add(-time.Hour)}
+	results, err := transitioner.BulkTransition(ctx, filter, api.OrchestrationStateErrored, "incident 42")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []api.BulkResult{
+		{ID: "stuck-1", Success: true},
+		{ID: "stuck-2", Success: true},
+		{ID: "stuck-3", Success: true},
+	}, results)
+
+	expected := map[string]api.OrchestrationState{
+		"stuck-1":   api.OrchestrationStateErrored,
+		"stuck-2":   api.OrchestrationStateErrored,
+		"stuck-3":   api.OrchestrationStateErrored,
+		"recent":    api.OrchestrationStateRunning,
+		"completed": api.OrchestrationStateCompleted,
+		"pending":   api.OrchestrationStateInitialized,
+	}
+	for id, state := range expected {
+		entry, err := index.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, state, entry.State, "orchestration %s", id)
+	}
+
+	messages, err := outboxStore.FindPending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	var audit api.TransitionAudit
+	require.NoError(t, json.Unmarshal(messages[0].Payload, &audit))
+	assert.Equal(t, natsclient.DefaultNamingStrategy{}.Subject(natsclient.CFMOrchestrationAudit), messages[0].Subject)
+	assert.Equal(t, api.OrchestrationStateRunning, audit.From)
+	assert.Equal(t, api.OrchestrationStateErrored, audit.To)
+	assert.Equal(t, "incident 42", audit.Reason)
+	assert.False(t, audit.Forced)
+}
+
+func TestBulkTransitioner_BulkTransition_InvalidTransition(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := createTestStore(t)
+	_, err := index.Create(ctx, createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)))
+	require.NoError(t, err)
+	transitioner := createTestTransitioner(index, now)
+
+	filter := api.Filter{States: []api.OrchestrationState{api.OrchestrationStateCompleted}}
+	_, err = transitioner.BulkTransition(ctx, filter, api.OrchestrationStateRunning, "rerun")
+	require.ErrorIs(t, err, types.ErrInvalidInput)
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+
+	filter.Force = true
+	results, err := transitioner.BulkTransition(ctx, filter, api.OrchestrationStateRunning, "rerun")
+	require.NoError(t, err)
+	assert.Equal(t, []api.BulkResult{{ID: "orch-1", Success: true}}, results)
+	entry, err = index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
+
+func TestBulkTransitioner_BulkTransition_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := &conflictingIndex{EntityStore: createTestStore(t), conflicting: "orch-2"}
+	for _, id := range []string{"orch-1", "orch-2", "orch-3"} {
+		_, err := index.Create(ctx, createEntry(createWatcherOrchestration(id, "corr-"+id, api.OrchestrationStateRunning)))
+		require.NoError(t, err)
+	}
+	transitioner := createTestTransitioner(index, now)
+
+	filter := api.Filter{States: []api.OrchestrationState{api.OrchestrationStateRunning}}
+	results, err := transitioner.BulkTransition(ctx, filter, api.OrchestrationStateErrored, "incident 42")
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	byID := make(map[string]api.BulkResult)
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+	assert.True(t, byID["orch-1"].Success)
+	assert.True(t, byID["orch-3"].Success)
+	assert.False(t, byID["orch-2"].Success)
+	assert.ErrorIs(t, byID["orch-2"].Err, types.ErrConflict)
+
+	for id, state := range map[string]api.OrchestrationState{
+		"orch-1": api.OrchestrationStateErrored,
+		"orch-2": api.OrchestrationStateRunning,
+		"orch-3": api.OrchestrationStateErrored,
+	} {
+		entry, err := index.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, state, entry.State, "orchestration %s", id)
+	}
+}
+
+func TestCreateBatch_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	index := createTestStore(t)
+	_, err := index.Create(ctx, createEntry(createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)))
+	require.NoError(t, err)
+
+	entries := []*api.OrchestrationEntry{
+		createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)),
+		createEntry(createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)),
+		createEntry(createWatcherOrchestration("orch-3", "corr-3", api.OrchestrationStateRunning)),
+	}
+	results := api.CreateBatch(ctx, &store.NoOpTransactionContext{}, index, entries)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, api.BulkResult{ID: "orch-1", Success: true}, results[0])
+	assert.Equal(t, "orch-2", results[1].ID)
+	assert.False(t, results[1].Success)
+	assert.ErrorIs(t, results[1].Err, types.ErrConflict)
+	assert.Equal(t, api.BulkResult{ID: "orch-3", Success: true}, results[2])
+
+	count, err := index.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestValidateBulkTransition(t *testing.T) {
+	running := []api.OrchestrationState{api.OrchestrationStateRunning}
+	tests := []struct {
+		name   string
+		filter api.Filter
+		to     api.OrchestrationState
+		reason string
+	}{
+		{name: "no state", filter: api.Filter{}, to: api.OrchestrationStateErrored, reason: "reason"},
+		{name: "target state selected", filter: api.Filter{States: running}, to: api.OrchestrationStateRunning, reason: "reason"},
+		{name: "invalid target state", filter: api.Filter{States: running, Force: true}, to: 42, reason: "reason"},
+		{name: "no reason", filter: api.Filter{States: running}, to: api.OrchestrationStateErrored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, validateBulkTransition(tt.filter, tt.to, tt.reason), types.ErrInvalidInput)
+		})
+	}
+	assert.NoError(t, validateBulkTransition(api.Filter{States: running}, api.OrchestrationStateErrored, "reason"))
+}
+
+func createTestTransitioner(index store.EntityStore[*api.OrchestrationEntry], now time.Time) *BulkTransitioner {
+	return &BulkTransitioner{
+		index:      index,
+		trxContext: &store.NoOpTransactionContext{},
+		monitor:    system.NoopMonitor{},
+		now:        func() time.Time { return now },
+		naming:     natsclient.DefaultNamingStrategy{},
+	}
+}
+
+// conflictingIndex fails updates of the conflicting entry with a conflict, as if it was concurrently modified.
+type conflictingIndex struct {
+	store.EntityStore[*api.OrchestrationEntry]
+	conflicting string
+}
+
+func (i *conflictingIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	if entry.ID == i.conflicting {
+		return types.ErrConflict
+	}
+	return i.EntityStore.Update(ctx, entry)
+}
@@ -0,0 +1,74 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+)
+
+// TypeLimiter bounds the number of messages processed concurrently for each orchestration type so that a single slow
+// type cannot occupy all workers. Types without a configured limit are not bounded. A nil limiter bounds no type.
+type TypeLimiter struct {
+	slots map[model.OrchestrationType]chan struct{}
+}
+
+// NewTypeLimiter creates a limiter from a map of orchestration types to their maximum concurrency. Limits that are not
+// positive are ignored.
+func NewTypeLimiter(limits map[string]int) *TypeLimiter {
+	slots := make(map[model.OrchestrationType]chan struct{}, len(limits))
+	for orchestrationType, limit := range limits {
+		if limit > 0 {
+			slots[model.OrchestrationType(orchestrationType)] = make(chan struct{}, limit)
+		}
+	}
+	return &TypeLimiter{slots: slots}
+}
+
+// Acquire waits until a slot for the type is available or the context is canceled. The returned function releases
+// the slot.
+func (l *TypeLimiter) Acquire(ctx context.Context, orchestrationType model.OrchestrationType) (func(), error) {
+	slots, bounded := l.typeSlots(orchestrationType)
+	if !bounded {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire acquires a slot for the type without waiting, returning false if the type is at its limit.
+func (l *TypeLimiter) TryAcquire(orchestrationType model.OrchestrationType) (func(), bool) {
+	slots, bounded := l.typeSlots(orchestrationType)
+	if !bounded {
+		return func() {}, true
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+func (l *TypeLimiter) typeSlots(orchestrationType model.OrchestrationType) (chan struct{}, bool) {
+	if l == nil {
+		return nil, false
+	}
+	slots, bounded := l.slots[orchestrationType]
+	return slots, bounded
+}
@@ -0,0 +1,272 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	slowType = model.OrchestrationType("slow")
+	fastType = model.OrchestrationType("fast")
+)
+
+func TestTypeLimiter_SaturatedTypeDoesNotBlockOthers(t *testing.T) {
+	limiter := NewTypeLimiter(map[string]int{string(slowType): 1, string(fastType): 1})
+
+	release, err := limiter.Acquire(context.Background(), slowType)
+	require.NoError(t, err)
+
+	_, ok := limiter.TryAcquire(slowType)
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, slowType)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releaseFast, ok := limiter.TryAcquire(fastType)
+	require.True(t, ok)
+	releaseFast()
+
+	_, ok = limiter.TryAcquire("unbounded")
+	assert.True(t, ok)
+
+	release()
+	_, ok = limiter.TryAcquire(slowType)
+	assert.True(t, ok)
+}
+
+func TestNatsActivityExecutor_TypeLimit_OtherTypeProgresses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	processor := newBlockingProcessor("slow-1")
+	executor := newLimitedExecutor(t, processor, map[string]model.OrchestrationType{
+		"slow-1": slowType, "slow-2": slowType, "fast-1": fastType,
+	})
+	slow2 := newActivityMsg(t, "slow-2")
+	consumer := &queueConsumer{pending: []jetstream.Msg{newActivityMsg(t, "slow-1")}}
+
+	go func() { _ = executor.processLoop(ctx, consumer) }()
+	assert.Equal(t, "slow-1", processor.next(t))
+
+	// The fast type is processed while the slow type is at its limit
+	consumer.push(slow2, newActivityMsg(t, "fast-1"))
+	assert.Equal(t, "fast-1", processor.next(t))
+	assert.False(t, processor.started("slow-2"), "slow type must wait for a slot")
+	assert.Equal(t, 0, slow2.nakCount())
+
+	close(processor.release)
+	assert.Equal(t, "slow-2", processor.next(t))
+}
+
+func TestNatsActivityExecutor_TypeLimit_WaitIsBounded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	processor := newBlockingProcessor("slow-1")
+	defer close(processor.release)
+	executor := newLimitedExecutor(t, processor, map[string]model.OrchestrationType{
+		"slow-1": slowType, "slow-2": slowType, "fast-1": fastType,
+	})
+	executor.Workers = 2
+	executor.TypeLimitDelay = 20 * time.Millisecond
+	slow2 := newActivityMsg(t, "slow-2")
+	consumer := &queueConsumer{pending: []jetstream.Msg{newActivityMsg(t, "slow-1")}}
+
+	go func() { _ = executor.processLoop(ctx, consumer) }()
+	assert.Equal(t, "slow-1", processor.next(t))
+
+	// slow-2 occupies the second worker until its wait for a slot expires and it is redelivered
+	consumer.push(slow2, newActivityMsg(t, "fast-1"))
+	assert.Equal(t, "fast-1", processor.next(t))
+	assert.Equal(t, []time.Duration{20 * time.Millisecond}, slow2.nakDelays())
+	assert.False(t, processor.started("slow-2"))
+}
+
+func TestNatsActivityExecutor_TypeLimit_WaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	executor := newLimitedExecutor(t, newBlockingProcessor(""), map[string]model.OrchestrationType{"slow-1": slowType})
+	executor.TypeLimitDelay = time.Minute
+	release, err := executor.limiter.Acquire(ctx, slowType)
+	require.NoError(t, err)
+	defer release()
+
+	message := newActivityMsg(t, "slow-1")
+	done := make(chan error)
+	go func() {
+		_, _, err := executor.acquireTypeSlot(ctx, slowType, message)
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("waiting for a slot must stop when the context is canceled")
+	}
+	assert.Equal(t, 0, message.nakCount())
+}
+
+func TestNatsActivityExecutor_TypeLimit_NakWhenLimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	processor := newBlockingProcessor("slow-1")
+	defer close(processor.release)
+	executor := newLimitedExecutor(t, processor, map[string]model.OrchestrationType{
+		"slow-1": slowType, "slow-2": slowType, "fast-1": fastType,
+	})
+	executor.NakOnTypeLimit = true
+	executor.TypeLimitDelay = 5 * time.Second
+	slow2 := newActivityMsg(t, "slow-2")
+	consumer := &queueConsumer{pending: []jetstream.Msg{newActivityMsg(t, "slow-1")}}
+
+	go func() { _ = executor.processLoop(ctx, consumer) }()
+	assert.Equal(t, "slow-1", processor.next(t))
+
+	consumer.push(slow2, newActivityMsg(t, "fast-1"))
+	assert.Equal(t, "fast-1", processor.next(t))
+	require.Eventually(t, func() bool { return slow2.nakCount() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []time.Duration{5 * time.Second}, slow2.nakDelays())
+	assert.False(t, processor.started("slow-2"))
+}
+
+func newLimitedExecutor(
+	t *testing.T,
+	processor api.ActivityProcessor,
+	types map[string]model.OrchestrationType) *NatsActivityExecutor {
+
+	client := mocks.NewMockMsgClient(t)
+	for id, orchestrationType := range types {
+		orchestration := api.Orchestration{ID: id, OrchestrationType: orchestrationType, State: api.OrchestrationStateRunning}
+		client.EXPECT().Get(mock.Anything, id).Return(newTestKVEntry(t, orchestration, 1), nil).Maybe()
+	}
+	client.EXPECT().Update(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(2), nil).Maybe()
+
+	executor := &NatsActivityExecutor{
+		Client:               client,
+		StreamName:           "cfm-stream",
+		ActivityType:         "test.activity",
+		ActivityProcessor:    processor,
+		Monitor:              system.NoopMonitor{},
+		Workers:              3,
+		MaxConcurrentPerType: map[string]int{string(slowType): 1},
+	}
+	executor.limiter = NewTypeLimiter(executor.MaxConcurrentPerType)
+	return executor
+}
+
+// blockingProcessor reports the orchestrations it processes and blocks the given orchestration until release is
+// closed.
+type blockingProcessor struct {
+	blocked   string
+	release   chan struct{}
+	processed chan string
+	mu        sync.Mutex
+	seen      map[string]bool
+}
+
+func newBlockingProcessor(blocked string) *blockingProcessor {
+	return &blockingProcessor{
+		blocked:   blocked,
+		release:   make(chan struct{}),
+		processed: make(chan string, 10),
+		seen:      make(map[string]bool),
+	}
+}
+
+func (p *blockingProcessor) Process(ctx api.ActivityContext) api.ActivityResult {
+	p.mu.Lock()
+	p.seen[ctx.OID()] = true
+	p.mu.Unlock()
+	p.processed <- ctx.OID()
+	if ctx.OID() == p.blocked {
+		<-p.release
+	}
+	return api.ActivityResult{Result: api.ActivityResultWait}
+}
+
+func (p *blockingProcessor) next(t *testing.T) string {
+	select {
+	case id := <-p.processed:
+		return id
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for processing")
+		return ""
+	}
+}
+
+func (p *blockingProcessor) started(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.seen[id]
+}
+
+// activityMsg is an activity message received from the stream.
+type activityMsg struct {
+	jetstream.Msg
+	data   []byte
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func newActivityMsg(t *testing.T, orchestrationID string) *activityMsg {
+	data, err := json.Marshal(api.ActivityMessage{
+		OrchestrationID: orchestrationID,
+		Activity:        api.Activity{ID: "A1", Type: "test.activity"},
+	})
+	require.NoError(t, err)
+	return &activityMsg{data: data}
+}
+
+func (m *activityMsg) Data() []byte {
+	return m.data
+}
+
+func (m *activityMsg) Ack() error {
+	return nil
+}
+
+func (m *activityMsg) NakWithDelay(delay time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delays = append(m.delays, delay)
+	return nil
+}
+
+func (m *activityMsg) nakCount() int {
+	return len(m.nakDelays())
+}
+
+func (m *activityMsg) nakDelays() []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]time.Duration(nil), m.delays...)
+}
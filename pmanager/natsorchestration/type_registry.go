@@ -0,0 +1,70 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// typeConfig is the configuration of an orchestration type. Types are configured as a list since orchestration types
+// contain dots, which are interpreted as key separators in configuration maps.
+type typeConfig struct {
+	Type           string   `mapstructure:"type"`
+	TerminalStates []string `mapstructure:"terminalStates"`
+	// AckWait is the time in milliseconds messages of the type are expected to take to record, see
+	// OrchestrationIndexWatcher.processingTimeoutFor. The watcher default applies if zero.
+	AckWait int `mapstructure:"ackWait"`
+}
+
+// newTypeRegistry registers the configured orchestration types and the types configured with a retention policy.
+// Types without configured terminal states use api.DefaultTerminalStates.
+func newTypeRegistry(configs []typeConfig, policies []retentionPolicy) (*api.TypeRegistry, error) {
+	registry := api.NewTypeRegistry()
+	for _, policy := range policies {
+		if err := registry.Register(model.OrchestrationType(policy.Type)); err != nil {
+			return nil, err
+		}
+	}
+	for _, config := range configs {
+		terminalStates := make([]api.OrchestrationState, 0, len(config.TerminalStates))
+		for _, name := range config.TerminalStates {
+			state, err := api.ParseOrchestrationState(name)
+			if err != nil {
+				return nil, fmt.Errorf("error configuring orchestration type %s: %w", config.Type, err)
+			}
+			terminalStates = append(terminalStates, state)
+		}
+		if err := registry.Register(model.OrchestrationType(config.Type), terminalStates...); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// typeAckWaits returns the ack waits configured for orchestration types.
+func typeAckWaits(configs []typeConfig) (map[model.OrchestrationType]time.Duration, error) {
+	ackWaits := make(map[model.OrchestrationType]time.Duration)
+	for _, config := range configs {
+		if config.AckWait < 0 {
+			return nil, fmt.Errorf("invalid ack wait %d for orchestration type %s", config.AckWait, config.Type)
+		}
+		if config.AckWait > 0 {
+			ackWaits[model.OrchestrationType(config.Type)] = time.Duration(config.AckWait) * time.Millisecond
+		}
+	}
+	return ackWaits, nil
+}
@@ -0,0 +1,54 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTypeRegistry(t *testing.T) {
+	registry, err := newTypeRegistry(
+		[]typeConfig{{Type: "cfm.provision", TerminalStates: []string{"completed", "compensating"}}},
+		[]retentionPolicy{{Type: "cfm.deprovision", Period: "24h"}})
+	require.NoError(t, err)
+
+	descriptors := registry.Describe()
+
+	require.Len(t, descriptors, 2)
+	assert.Equal(t, model.OrchestrationType("cfm.deprovision"), descriptors[0].Name)
+	assert.Equal(t, api.DefaultTerminalStates, descriptors[0].TerminalStates)
+	assert.Equal(t, model.OrchestrationType("cfm.provision"), descriptors[1].Name)
+	assert.Equal(t, []api.OrchestrationState{api.OrchestrationStateCompleted, api.OrchestrationStateCompensating},
+		descriptors[1].TerminalStates)
+}
+
+func TestNewTypeRegistry_InvalidTerminalState(t *testing.T) {
+	_, err := newTypeRegistry([]typeConfig{{Type: "cfm.provision", TerminalStates: []string{"done"}}}, nil)
+
+	assert.ErrorContains(t, err, "cfm.provision")
+}
+
+func TestTypeAckWaits(t *testing.T) {
+	ackWaits, err := typeAckWaits([]typeConfig{{Type: "cfm.long", AckWait: 2000}, {Type: "cfm.default"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[model.OrchestrationType]time.Duration{"cfm.long": 2 * time.Second}, ackWaits)
+
+	_, err = typeAckWaits([]typeConfig{{Type: "cfm.long", AckWait: -1}})
+	require.Error(t, err)
+}
@@ -13,20 +13,93 @@
 package natsorchestration
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
 	"github.com/metaform/connector-fabric-manager/common/store"
 	"github.com/metaform/connector-fabric-manager/common/system"
 	"github.com/metaform/connector-fabric-manager/common/types"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 type MessageAck interface {
 	Ack(opts ...nats.AckOpt) error
 	Nak(opts ...nats.AckOpt) error
+	NakWithDelay(delay time.Duration, opts ...nats.AckOpt) error
+}
+
+// jetStreamMessageAck adapts a jetstream.Msg to MessageAck.
+type jetStreamMessageAck struct {
+	msg jetstream.Msg
+}
+
+func (a jetStreamMessageAck) Ack(...nats.AckOpt) error {
+	return a.msg.Ack()
+}
+
+func (a jetStreamMessageAck) Nak(...nats.AckOpt) error {
+	return a.msg.Nak()
+}
+
+func (a jetStreamMessageAck) NakWithDelay(delay time.Duration, _ ...nats.AckOpt) error {
+	return a.msg.NakWithDelay(delay)
+}
+
+func (a jetStreamMessageAck) DoubleAck(ctx context.Context) error {
+	return a.msg.DoubleAck(ctx)
+}
+
+func (a jetStreamMessageAck) NumDelivered() uint64 {
+	metadata, err := a.msg.Metadata()
+	if err != nil {
+		return 0
+	}
+	return metadata.NumDelivered
+}
+
+func (a jetStreamMessageAck) Subject() string {
+	return a.msg.Subject()
+}
+
+func (a jetStreamMessageAck) Data() []byte {
+	return a.msg.Data()
+}
+
+func (a jetStreamMessageAck) Headers() nats.Header {
+	return a.msg.Headers()
+}
+
+func (a jetStreamMessageAck) PublishTime() time.Time {
+	metadata, err := a.msg.Metadata()
+	if err != nil {
+		return time.Time{}
+	}
+	return metadata.Timestamp
+}
+
+func (a jetStreamMessageAck) StreamSequence() uint64 {
+	metadata, err := a.msg.Metadata()
+	if err != nil {
+		return 0
+	}
+	return metadata.Sequence.Stream
+}
+
+// stateReader is implemented by indexes that can look up the state of an entry without loading it, see
+// api.OrchestrationIndex.
+type stateReader interface {
+	FindStateByID(ctx context.Context, id string) (api.OrchestrationState, int64, error)
 }
 
 // OrchestrationIndexWatcher watches the underlying Jetsream KV subject for orchestration changes and updates the
@@ -37,57 +110,604 @@ type OrchestrationIndexWatcher struct {
 	index      store.EntityStore[*api.OrchestrationEntry]
 	trxContext store.TransactionContext
 	monitor    system.LogMonitor
+	decoder    MessageDecoder
+	backoff    BackoffStrategy
+
+	// consumer delivers the changes processed by RunN.
+	consumer jetstream.Consumer
+
+	// provisionConsumer recreates the consumer if it is deleted. When nil, processing stops instead.
+	provisionConsumer ConsumerProvisioner
+	health            watcherHealth
+
+	// acks defers acknowledgements when set. Naks are never deferred.
+	acks *AckBatcher
+
+	// ackSync waits for the server to confirm acknowledgements, see ackSynchronously.
+	ackSync bool
+
+	// deadLetters receives messages that cannot be processed. When nil, they are discarded.
+	deadLetters *DeadLetterQueue
+
+	// malformedPolicy determines whether malformed messages are dead-lettered or dropped.
+	malformedPolicy MalformedPolicy
+
+	// outbox forwards recorded state changes to an external system when set.
+	outbox *StateOutbox
+
+	// messageOutbox records state change messages in the index transaction when set. Messages are published to the
+	// subjects of messageNaming by the OutboxRelay, see stateChangeSubject.
+	messageOutbox     api.OutboxStore
+	messageNaming     natsclient.NamingStrategy
+	messageSerializer api.JSONSerializer
+
+	// rawPayloads records the raw message of each recorded change for audit when set, see saveRawPayload.
+	rawPayloads api.RawPayloadStore
+
+	// maintenance pauses recording changes, see SetMaintenance.
+	maintenance      atomic.Bool
+	maintenanceDelay time.Duration
+
+	// pausedTypes holds the orchestration types whose changes are deferred like in maintenance mode, see PauseType.
+	pausedTypes sync.Map
+
+	// version is compared against MinConsumerVersionHeader and the version stamped in the orchestration, see
+	// api.Orchestration.MinConsumerVersion. Messages requiring a newer version are redelivered after
+	// versionDeferDelay so that a newer watcher can process them during a rolling deployment.
+	version           int
+	versionDeferDelay time.Duration
+
+	// controlSubject receives messages instructing the watcher to drain, see Drain. The subject must be delivered by the
+	// watcher consumer, e.g. a key of the orchestration bucket. Disabled when empty.
+	controlSubject string
+	draining       atomic.Bool
+
+	// feed delivers recorded entries to subscribers, see Subscribe.
+	feed entryFeed
+
+	// expired counts the messages dropped because their expiry had passed, see ExpiredMessages.
+	expired atomic.Int64
+
+	// superseded counts the changes skipped because the index already held a newer change, see SupersededMessages.
+	superseded atomic.Int64
+
+	// headerFilter holds the headers or labels messages must carry to be processed, see matchesHeaderFilter. Messages
+	// not matching are acked and counted in filtered, see FilteredMessages.
+	headerFilter map[string]string
+	filtered     atomic.Int64
+
+	// poolExhausted classifies store errors caused by an exhausted connection pool. Fetching is paused for poolPause,
+	// defaulting to defaultPoolExhaustedPause, after such an error, see pauseOnPoolExhaustion. Disabled when nil.
+	poolExhausted    ErrorClassifier
+	poolPause        time.Duration
+	fetchPausedUntil atomic.Int64
+
+	// waitPollInterval is the interval at which WaitForState re-reads the index. Defaults to defaultWaitPollInterval.
+	waitPollInterval time.Duration
+
+	// projections apply recorded entries to read models, see RegisterProjector.
+	projections []*StateOutbox
+
+	// validators enforce business rules per orchestration type, see RegisterValidator.
+	validators map[string][]func(*api.OrchestrationEntry) error
+
+	// maxEntryBytes rejects entries whose serialized size exceeds it like malformed messages, see checkEntrySize.
+	// Unlimited when zero.
+	maxEntryBytes int
+
+	// enforceUniqueCorrelationPerType rejects creating a second orchestration of a type with the same correlation ID,
+	// see api.CheckUniqueCorrelation. Rejected changes are acknowledged.
+	enforceUniqueCorrelationPerType bool
+
+	// clockSkewTolerance is the clock skew between publishers tolerated when ordering changes by timestamp.
+	clockSkewTolerance time.Duration
+
+	// panics counts consecutive panics per orchestration. Messages are dead-lettered after maxPanics, see recoverPanic.
+	panics    failureCounter
+	maxPanics int
+
+	// retries counts consecutive transient failures per orchestration, see RetryCount. The orchestration is errored once
+	// maxAttempts or the MaxAttempts of its retry policy is reached, see retriesExhausted. Unlimited when zero.
+	retries     failureCounter
+	maxAttempts int
+
+	// retryPolicies holds the retry policies of the orchestrations being retried, see countRetry.
+	retryPolicies sync.Map
+
+	// deliveryMetrics records the delivery attempts of acknowledged messages when set.
+	deliveryMetrics api.DeliveryMetrics
+
+	// onSLOBreach is invoked for changes recorded more than sloThreshold after they were published, see checkSLO.
+	// Disabled when nil or the threshold is not positive.
+	onSLOBreach  SLOBreachHook
+	sloThreshold time.Duration
+
+	// redeliveries tracks the rolling redelivery rate to detect redelivery storms, which invoke onRedeliveryStorm and
+	// multiply redelivery delays by stormBackoffFactor while they last, see observeRedelivery. Disabled when nil.
+	redeliveries       *redeliveryTracker
+	onRedeliveryStorm  RedeliveryStormHook
+	stormBackoffFactor int
+
+	// processingTimeout bounds recording a change in the index. The context of all store calls is canceled once it
+	// expires. Disabled when zero.
+	processingTimeout time.Duration
+
+	// typeAckWaits overrides processingTimeout for orchestration types expected to take longer or shorter to record.
+	// Messages are reported in progress every heartbeatInterval until they are recorded or the timeout of their type
+	// expires, see startHeartbeat. Heartbeats are disabled when heartbeatInterval is zero.
+	typeAckWaits      map[model.OrchestrationType]time.Duration
+	heartbeatInterval time.Duration
+
+	// validateTransitions rejects changes the orchestration lifecycle does not allow, see checkTransition.
+	validateTransitions bool
+
+	// logKeys names the attributes of structured logs.
+	logKeys LogKeySchema
+
+	// inFlight tracks the messages being recorded, see InFlight.
+	inFlight inFlightRegistry
+
+	// debouncer coalesces the changes of an orchestration received within a window when set, see debounce.
+	debouncer *debouncer
+
+	// fetchBatch is the maximum number of messages fetched at once and thus in flight. Larger batches improve
+	// throughput, smaller batches leave fewer messages to be redelivered after a restart. Defaults to 1.
+	fetchBatch int
+
+	// sources tracks the sources started by RunSources, see Sources.
+	sources sourceRegistry
+
+	// correlatedBatches records the fetched changes sharing a correlation ID atomically, see processCorrelated. Only
+	// changes fetched together are recorded together, so it requires a fetchBatch greater than 1 to take effect.
+	correlatedBatches bool
+
+	// resumeCorrelatedBatches records the changes of a correlated batch that failed in individual transactions, see
+	// ProcessCorrelated. Changes sharing a correlation ID are then no longer guaranteed to be recorded atomically.
+	resumeCorrelatedBatches bool
+
+	// priorityWindow is the number of fetched messages reordered by priority, see processFetched. Disabled if not
+	// greater than 1.
+	priorityWindow int
+
+	// clock returns the current time, e.g. to check message expiry. Defaults to time.Now.
+	clock func() time.Time
+}
+
+// now returns the current time of the watcher clock.
+func (w *OrchestrationIndexWatcher) now() time.Time {
+	if w.clock == nil {
+		return time.Now()
+	}
+	return w.clock()
+}
+
+// sequencedMessage is implemented by messages exposing their stream sequence.
+type sequencedMessage interface {
+	StreamSequence() uint64
+}
+
+// processLoop fetches orchestration changes from the consumer and records them in the index until the context is
+// canceled or fetching fails. A deleted consumer is recreated if the watcher is configured to provision it. Errors
+// that stop processing are reported by CheckHealth.
+func (w *OrchestrationIndexWatcher) processLoop(ctx context.Context, consumer jetstream.Consumer) (err error) {
+	defer func() {
+		if err != nil && ctx.Err() == nil {
+			w.health.fail(err)
+		}
+	}()
+	return w.consume(ctx, Source{Consumer: consumer, Provision: w.provisionConsumer}, nil)
+}
+
+// consume fetches the orchestration changes of the source and records them in the index until the context is canceled
+// or fetching fails. state is nil for the default source.
+func (w *OrchestrationIndexWatcher) consume(ctx context.Context, source Source, state *sourceState) error {
+	consumer := source.Consumer
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			if w.draining.Load() {
+				w.finishDrain()
+				return nil
+			}
+			if err := w.waitFetchPause(ctx); err != nil {
+				return err
+			}
+			messageBatch, err := consumer.Fetch(max(w.fetchBatch, 1), jetstream.FetchMaxWait(time.Second))
+			if err != nil {
+				if !isConsumerDeleted(ctx, consumer, err) {
+					return err
+				}
+			} else {
+				w.processFetched(consumer, messageBatch, state)
+				// Other errors terminating a batch are transient and the next fetch is attempted
+				if err := messageBatch.Error(); err == nil || !isConsumerDeleted(ctx, consumer, err) {
+					continue
+				}
+			}
+			if consumer, err = w.recoverConsumer(ctx, source.Provision); err != nil {
+				return err
+			}
+		}
+	}
 }
 
 func (w *OrchestrationIndexWatcher) onMessage(data []byte, msg MessageAck) {
-	ctx := context.Background()
+	w.onHeaderMessage(data, nil, msg)
+}
+
+func (w *OrchestrationIndexWatcher) onHeaderMessage(data []byte, header nats.Header, msg MessageAck) {
+	w.onSourceMessage("", data, header, msg)
+}
+
+// onSourceMessage processes a message received from the named source. The default source has no name.
+func (w *OrchestrationIndexWatcher) onSourceMessage(source string, data []byte, header nats.Header, msg MessageAck) {
+	var orchestrationID string
+	defer w.recoverPanic(msg, &orchestrationID)
 
-	var orchestration api.Orchestration
-	err := json.Unmarshal(data, &orchestration)
+	decoded, admitted := w.admit(source, data, header, msg)
+	if !admitted {
+		return
+	}
+	if w.debouncer != nil {
+		w.debounce(data, decoded, msg)
+		return
+	}
+	w.recordDecoded(data, decoded, msg, &orchestrationID)
+}
+
+// admit decodes a message received from the named source and checks whether its change is to be recorded. Messages
+// whose change is not recorded, e.g. control messages or expired, deferred and malformed changes, are settled and
+// false is returned.
+func (w *OrchestrationIndexWatcher) admit(
+	source string,
+	data []byte,
+	header nats.Header,
+	msg MessageAck) (DecodedMessage, bool) {
+	if w.isControlMessage(msg) {
+		w.onControlMessage(msg)
+		return DecodedMessage{}, false
+	}
+	if w.maintenance.Load() {
+		w.deferMessage(msg)
+		return DecodedMessage{}, false
+	}
+	if w.requiresNewerVersion(header) {
+		w.deferToNewerVersion(msg)
+		return DecodedMessage{}, false
+	}
+	if w.isExpired(header) {
+		w.dropExpired(msg)
+		return DecodedMessage{}, false
+	}
+	decoded, err := w.decode(data, header)
 	if err != nil {
 		w.monitor.Infof("Failed to unmarshal orchestration entry: %v", err)
-		_ = msg.Ack()
+		w.settle(msg, "", decideAction(nil, nil, err, w.clockSkewTolerance), err)
+		return DecodedMessage{}, false
+	}
+	decoded.Source = source
+	if w.requiresNewerVersionPayload(&decoded.Orchestration) {
+		w.deferToNewerVersion(msg)
+		return DecodedMessage{}, false
+	}
+	if w.isExpiredChange(&decoded.Orchestration) {
+		w.dropExpired(msg)
+		return DecodedMessage{}, false
+	}
+	if !w.matchesHeaderFilter(header, decoded.Orchestration.Labels) {
+		w.dropFiltered(msg)
+		return DecodedMessage{}, false
+	}
+	if w.isTypePaused(decoded.Orchestration.OrchestrationType) {
+		w.deferMessage(msg)
+		return DecodedMessage{}, false
+	}
+	return decoded, true
+}
+
+// recordDecoded records the decoded orchestration change in the index and settles the message. orchestrationID is set
+// once the orchestration is known so that panics can be attributed to it.
+func (w *OrchestrationIndexWatcher) recordDecoded(
+	data []byte,
+	decoded DecodedMessage,
+	msg MessageAck,
+	orchestrationID *string) {
+	entry := createEntry(decoded.Orchestration)
+	labelSource(entry, decoded.Source)
+	*orchestrationID = entry.ID
+
+	ctx := context.Background()
+	timeout := w.processingTimeoutFor(entry.OrchestrationType)
+	if timeout > 0 {
+		// Store calls are interrupted once the timeout expires and the message is redelivered
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ctx, release := w.trackInFlight(ctx, entry.ID, msg)
+	defer release()
+	if err := w.validate(entry); err != nil {
+		w.monitor.Infow("Rejecting orchestration entry", w.entryLogFields(entry, err)...)
+		w.settle(msg, entry.ID, ActionDeadLetter, err)
+		return
+	}
+	if sequenced, ok := msg.(sequencedMessage); ok {
+		// Changes of a key in the orchestration bucket are stored in order, the stream sequence is the key revision
+		entry.Revision = sequenced.StreamSequence()
+	}
+	ctx = api.WithOrchestration(ctx, entry)
+	ctx, pendingErr := withPending(ctx, decoded.Header)
+	if pendingErr != nil {
+		w.monitor.Infow("Rejecting orchestration entry", w.entryLogFields(entry, pendingErr)...)
+		w.settle(msg, entry.ID, ActionDeadLetter, pendingErr)
 		return
 	}
+	var existing *api.OrchestrationEntry
+	stopHeartbeat := w.startHeartbeat(ctx, msg)
+	err := w.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		existing, err = w.record(ctx, entry)
+		if err != nil {
+			return err // roll back on error
+		}
+		if err = w.saveRawPayload(ctx, entry, existing, data); err != nil {
+			return err
+		}
+		return w.emitStateChange(ctx, entry, existing)
+	})
+	stopHeartbeat()
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.monitor.Warnw(fmt.Sprintf("Recording orchestration exceeded the processing timeout of %s", timeout),
+			w.entryLogFields(entry, err)...)
+	}
+	action := decideAction(entry, existing, err, w.clockSkewTolerance)
+	w.countRetry(entry, action)
+	if action == ActionNak {
+		w.pauseOnPoolExhaustion(err)
+	}
+	if action == ActionNak && w.retriesExhausted(msg, entry) {
+		if errored, erroredExisting, erroredErr := w.errorExhausted(msg, entry, err); erroredErr == nil {
+			entry, existing, err, action = errored, erroredExisting, nil, ActionAck
+		}
+	}
+	w.settle(msg, entry.ID, action, err)
+	w.panics.reset(entry.ID)
+	if err == nil && isRecorded(entry, existing, w.clockSkewTolerance) {
+		w.feed.publish(entry)
+		w.project(entry)
+		w.checkSLO(msg, entry)
+	}
+	if err == nil {
+		w.observeTimeInState(entry, existing)
+	}
+	if w.outbox != nil && err == nil && isStateChange(entry, existing, w.clockSkewTolerance) {
+		w.outbox.Enqueue(entry)
+	}
+}
+
+func (w *OrchestrationIndexWatcher) decode(data []byte, header nats.Header) (DecodedMessage, error) {
+	if w.decoder == nil {
+		return JSONDecoder{}.Decode(data, header)
+	}
+	return w.decoder.Decode(data, header)
+}
 
-	_ = w.trxContext.Execute(ctx, func(ctx context.Context) error {
-		currentEntry, err := w.index.FindByID(ctx, orchestration.ID)
-		if err != nil && !errors.Is(err, types.ErrNotFound) {
-			w.monitor.Infof("Failed to lookup orchestration entry: %v", err)
-			_ = msg.Nak()
-			return nil
+// record creates or updates the index entry, returning the entry found in the index before the change.
+func (w *OrchestrationIndexWatcher) record(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	if reader, ok := w.index.(stateReader); ok {
+		// Fast path: new entries and entries in a terminal state are handled without loading the existing entry
+		state, version, err := reader.FindStateByID(ctx, entry.ID)
+		switch {
+		case errors.Is(err, types.ErrNotFound):
+			return w.create(ctx, entry)
+		case err != nil:
+			w.monitor.Infow("Failed to lookup orchestration entry state", w.entryLogFields(entry, err)...)
+			return nil, err
+		case isTerminal(state):
+			return &api.OrchestrationEntry{ID: entry.ID, State: state, Version: version}, nil
 		}
+	}
 
-		entry := createEntry(orchestration)
-		if currentEntry != nil { // Found
-			// Only update if state and timestamp changed and not in a terminal state (messages may arrive out of order)
-			if (currentEntry.State == orchestration.State && orchestration.StateTimestamp == currentEntry.StateTimestamp) ||
-				currentEntry.State == api.OrchestrationStateCompleted ||
-				currentEntry.State == api.OrchestrationStateErrored {
-				return nil
-			}
-			entry.State = orchestration.State
-			entry.StateTimestamp = orchestration.StateTimestamp
-			if w.index.Update(ctx, entry) != nil {
-				w.monitor.Infof("Failed to update orchestration entry: %v", err)
-				_ = msg.Nak()
-				return nil
-			}
-			// w.monitor.Debugf("Orchestration index entry %s updated to state %s", orchestration.ID, orchestration.State)
+	currentEntry, err := w.index.FindByID(ctx, entry.ID)
+	if err != nil && !errors.Is(err, types.ErrNotFound) {
+		w.monitor.Infow("Failed to lookup orchestration entry", w.entryLogFields(entry, err)...)
+		return nil, err
+	}
+
+	if currentEntry == nil {
+		return w.create(ctx, entry)
+	}
+	return w.update(ctx, entry, currentEntry)
+}
+
+// create inserts a new index entry. If another message for the same orchestration created the entry after the
+// lookup, the conflict is resolved by re-reading the entry and applying the change as an update.
+func (w *OrchestrationIndexWatcher) create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	if err := checkPendingCreate(ctx, entry); err != nil {
+		w.monitor.Infow("Not creating orchestration entry", w.entryLogFields(entry, err)...)
+		return nil, err
+	}
+	if w.enforceUniqueCorrelationPerType {
+		if err := api.CheckUniqueCorrelation(ctx, w.index, entry); err != nil {
+			w.monitor.Infow("Not creating orchestration entry", w.entryLogFields(entry, err)...)
+			return nil, err
+		}
+	}
+	_, err := w.index.Create(ctx, entry)
+	if err == nil {
+		// w.monitor.Debugf("Created orchestration index entry %s in state %s", entry.ID, entry.State)
+		return nil, nil
+	}
+	if !errors.Is(err, types.ErrConflict) {
+		w.monitor.Infow("Failed to create orchestration entry", w.entryLogFields(entry, err)...)
+		return nil, err
+	}
+
+	currentEntry, err := w.index.FindByID(ctx, entry.ID)
+	if err != nil {
+		w.monitor.Infow("Failed to lookup concurrently created orchestration entry", w.entryLogFields(entry, err)...)
+		return nil, err
+	}
+	return w.update(ctx, entry, currentEntry)
+}
+
+// update applies the change to an existing index entry unless it is stale.
+func (w *OrchestrationIndexWatcher) update(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	currentEntry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+
+	if isSuperseded(entry, currentEntry, w.clockSkewTolerance) {
+		// Late delivery, acknowledged without regressing the entry
+		w.superseded.Add(1)
+		return currentEntry, nil
+	}
+	if isStale(entry, currentEntry, w.clockSkewTolerance) {
+		return currentEntry, nil
+	}
+	if err := w.checkTransition(ctx, entry, currentEntry); err != nil {
+		w.monitor.Infow("Rejecting orchestration entry", w.entryLogFields(entry, err)...)
+		return currentEntry, err
+	}
+	if err := w.resolvePending(ctx, entry, currentEntry); err != nil {
+		return currentEntry, err
+	}
+	if entry.Checkpoint == nil {
+		// Orchestration changes do not carry the checkpoint, keep the one saved during processing
+		entry.Checkpoint = currentEntry.Checkpoint
+	}
+	if entry.State == currentEntry.State {
+		// Orchestration changes do not carry claims, keep the claim on the entry until its state changes
+		entry.ClaimedBy, entry.LeaseExpiry = currentEntry.ClaimedBy, currentEntry.LeaseExpiry
+	}
+	if isUnchanged(entry, currentEntry) {
+		// Redelivered change, skip the redundant write
+		return currentEntry, nil
+	}
+	// Continue from the current version so that stores versioning updates count the recorded changes
+	entry.Version = currentEntry.Version
+	if err := w.index.Update(ctx, entry); err != nil {
+		w.monitor.Infow("Failed to update orchestration entry", w.entryLogFields(entry, err)...)
+		return currentEntry, err
+	}
+	// w.monitor.Debugf("Orchestration index entry %s updated to state %s", entry.ID, entry.State)
+	return currentEntry, nil
+}
+
+// settle performs the side effect of the given action on the message. cause is the processing error, if any.
+func (w *OrchestrationIndexWatcher) settle(msg MessageAck, orchestrationID string, action AckAction, cause error) {
+	var err error
+	w.observeRedelivery(msg)
+	switch action {
+	case ActionNak:
+		if delay := w.widenDelay(w.nakDelay(msg, orchestrationID, cause)); delay > 0 {
+			err = msg.NakWithDelay(delay)
 		} else {
-			_, err := w.index.Create(ctx, entry)
-			if err != nil {
-				w.monitor.Infof("Failed to create orchestration entry: %v", err)
-				_ = msg.Nak()
-				return nil
-			}
-			// w.monitor.Debugf("Created orchestration index entry %s in state %s", orchestration.ID, orchestration.State)
+			err = msg.Nak()
+		}
+	case ActionDeadLetter:
+		if w.malformedPolicy == MalformedDrop && errors.Is(cause, errMalformedMessage) {
+			w.monitor.Warnw("Dropping malformed message", w.idLogFields(orchestrationID, cause)...)
+			err = w.ack(msg)
+			break
 		}
-		if msg.Ack() != nil {
-			w.monitor.Infof("Failed to acknowledge message for orchestration %s: %v", orchestration.ID, err)
+		dlqMsg, ok := msg.(deadLetterMessage)
+		if w.deadLetters == nil || !ok {
+			// Ack so the message is not redelivered
+			w.monitor.Warnw("Discarding unprocessable message", w.idLogFields(orchestrationID, cause)...)
+			err = w.ack(msg)
+			break
 		}
+		if !w.deadLetters.Sample() {
+			w.monitor.Warnw("Discarding unprocessable message not sampled for the DLQ", w.idLogFields(orchestrationID, cause)...)
+			err = w.ack(msg)
+			break
+		}
+		if err = w.deadLetters.Publish(context.Background(), dlqMsg, cause); err != nil {
+			// Redeliver rather than lose the message
+			w.monitor.Warnw("Failed to dead-letter message", w.idLogFields(orchestrationID, err)...)
+			err = msg.Nak()
+			break
+		}
+		w.monitor.Warnw("Dead-lettered unprocessable message", w.idLogFields(orchestrationID, cause)...)
+		err = w.ack(msg)
+	default:
+		if err = w.ack(msg); err == nil {
+			w.recordDelivery(msg)
+		}
+	}
+	if err != nil {
+		w.monitor.Infow(fmt.Sprintf("Failed to %s message", action), w.idLogFields(orchestrationID, err)...)
+	}
+}
+
+func (w *OrchestrationIndexWatcher) ack(msg MessageAck) error {
+	if acker, ok := msg.(doubleAcker); ok && w.ackSync {
+		return w.ackSynchronously(acker)
+	}
+	if w.acks != nil {
+		w.acks.Add(msg)
 		return nil
-	})
+	}
+	return msg.Ack()
+}
+
+// decodeOrchestration deserializes and validates an orchestration received from the network. Unknown fields are
+// ignored unless strict is set, so that changes from publishers using a newer schema are recorded.
+func decodeOrchestration(data []byte, strict bool) (api.Orchestration, error) {
+	orchestration, err := unmarshalGuarded(data, strict)
+	if err != nil {
+		return api.Orchestration{}, err
+	}
+	if problems := orchestrationProblems(orchestration); len(problems) > 0 {
+		return api.Orchestration{}, fmt.Errorf("%w: %s", errMalformedMessage, strings.Join(problems, "; "))
+	}
+	return orchestration, nil
+}
+
+// unmarshalGuarded deserializes the orchestration. Custom unmarshalling of nested structures is guarded so that a panic
+// caused by malformed input is returned as an error. Errors wrap errMalformedMessage.
+func unmarshalGuarded(data []byte, strict bool) (orchestration api.Orchestration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: panic decoding orchestration: %v", errMalformedMessage, r)
+		}
+	}()
+
+	if err = unmarshalOrchestration(data, strict, &orchestration); err != nil {
+		return api.Orchestration{}, fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+	return orchestration, nil
+}
+
+// orchestrationProblems returns the schema violations of a deserialized orchestration.
+func orchestrationProblems(orchestration api.Orchestration) []string {
+	var problems []string
+	if orchestration.ID == "" {
+		problems = append(problems, "orchestration id is missing")
+	}
+	if orchestration.State > api.OrchestrationStateCompensating {
+		problems = append(problems, fmt.Sprintf("invalid orchestration state %d", orchestration.State))
+	}
+	return problems
+}
+
+// unmarshalOrchestration deserializes the orchestration, rejecting unknown fields if strict is set.
+func unmarshalOrchestration(data []byte, strict bool, orchestration *api.Orchestration) error {
+	if !strict {
+		return json.Unmarshal(data, orchestration)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(orchestration); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after orchestration")
+	}
+	return nil
 }
 
 func createEntry(orchestration api.Orchestration) *api.OrchestrationEntry {
@@ -98,6 +718,10 @@ func createEntry(orchestration api.Orchestration) *api.OrchestrationEntry {
 		State:             orchestration.State,
 		StateTimestamp:    orchestration.StateTimestamp,
 		CreatedTimestamp:  orchestration.CreatedTimestamp,
+		Deadline:          orchestration.Deadline,
+		Labels:            orchestration.Labels,
+		Mode:              orchestration.Mode,
+		RetryPolicy:       orchestration.RetryPolicy,
 	}
 	return entry
 }
@@ -0,0 +1,122 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/system"
+)
+
+// doubleAcker is implemented by messages that can be acknowledged synchronously, waiting for the server to confirm.
+type doubleAcker interface {
+	DoubleAck(ctx context.Context) error
+}
+
+// ErrAckNotConfirmed is returned if the server did not confirm a synchronous acknowledgement. The message may be
+// redelivered although it has been processed.
+var ErrAckNotConfirmed = errors.New("acknowledgement not confirmed")
+
+// ackSyncTimeout bounds waiting for the server to confirm a synchronous acknowledgement.
+const ackSyncTimeout = 5 * time.Second
+
+// ackSynchronously acknowledges the message and waits for the server to confirm. Unlike fire-and-forget acks, a lost
+// acknowledgement is detected, at the cost of a round trip per message. Since the change has already been recorded, a
+// redelivery is harmless: it is detected as a duplicate and acknowledged again.
+func (w *OrchestrationIndexWatcher) ackSynchronously(msg doubleAcker) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ackSyncTimeout)
+	defer cancel()
+	if err := msg.DoubleAck(ctx); err != nil {
+		w.monitor.Warnf("Acknowledgement not confirmed, the message may be redelivered: %v", err)
+		return fmt.Errorf("%w: %w", ErrAckNotConfirmed, err)
+	}
+	return nil
+}
+
+// AckBatcher defers acknowledging successfully processed messages and flushes them in batches, reducing the latency
+// added by acknowledging each message. Only use it for idempotent processing: messages of a batch that is not flushed,
+// e.g. on a crash, are redelivered once the consumer ack wait expires. The flush interval must therefore be well below
+// the ack wait.
+//
+// Messages are acknowledged in the order they were added. The last message of a batch is double-acked so that a flush
+// completes once the server has received the acknowledgements.
+type AckBatcher struct {
+	size     int
+	interval time.Duration
+	monitor  system.LogMonitor
+
+	mu      sync.Mutex
+	pending []MessageAck
+}
+
+// NewAckBatcher creates a batcher flushing when size messages are pending or the interval elapses.
+func NewAckBatcher(size int, interval time.Duration, monitor system.LogMonitor) *AckBatcher {
+	return &AckBatcher{
+		size:     size,
+		interval: interval,
+		monitor:  monitor,
+		pending:  make([]MessageAck, 0, size),
+	}
+}
+
+// Add defers acknowledging the message. The message must have been processed successfully.
+func (b *AckBatcher) Add(msg MessageAck) {
+	b.mu.Lock()
+	b.pending = append(b.pending, msg)
+	full := len(b.pending) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		b.Flush(context.Background())
+	}
+}
+
+// Flush acknowledges all pending messages.
+func (b *AckBatcher) Flush(ctx context.Context) {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = make([]MessageAck, 0, b.size)
+	b.mu.Unlock()
+
+	for i, msg := range batch {
+		var err error
+		if acker, ok := msg.(doubleAcker); ok && i == len(batch)-1 {
+			err = acker.DoubleAck(ctx)
+		} else {
+			err = msg.Ack()
+		}
+		if err != nil {
+			// The message is redelivered and processed again
+			b.monitor.Infof("Failed to acknowledge batched message: %v", err)
+		}
+	}
+}
+
+// Run flushes pending messages at the configured interval until the context is canceled.
+func (b *AckBatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckBatcher_AcksDeferredAndFlushedOnInterval(t *testing.T) {
+	batcher := NewAckBatcher(10, 20*time.Millisecond, system.NoopMonitor{})
+	first := newDoubleAckMessage()
+	second := newDoubleAckMessage()
+
+	batcher.Add(first)
+	batcher.Add(second)
+
+	assert.Equal(t, 0, first.AckCalls+first.doubleAcks, "acks must be deferred until flushed")
+	assert.Equal(t, 0, second.AckCalls+second.doubleAcks, "acks must be deferred until flushed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go batcher.Run(ctx)
+
+	select {
+	case <-second.doubleAcked:
+	case <-time.After(time.Second):
+		t.Fatal("pending acks were not flushed")
+	}
+	assert.Equal(t, 1, first.AckCalls)
+	assert.Equal(t, 0, first.doubleAcks)
+	assert.Equal(t, 0, second.AckCalls)
+	assert.Equal(t, 1, second.doubleAcks, "the last message of a batch is double-acked")
+}
+
+func TestAckBatcher_FlushedWhenFull(t *testing.T) {
+	batcher := NewAckBatcher(2, time.Hour, system.NoopMonitor{})
+	first := NewMockMessage(nil)
+	second := NewMockMessage(nil)
+
+	batcher.Add(first)
+	assert.Equal(t, 0, first.AckCalls)
+
+	batcher.Add(second)
+	assert.Equal(t, 1, first.AckCalls)
+	assert.Equal(t, 1, second.AckCalls)
+	assert.Equal(t, 0, batcher.pendingCount())
+}
+
+func TestOnMessage_AckBatcher_SuccessDeferredNakImmediate(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.acks = NewAckBatcher(10, time.Hour, system.NoopMonitor{})
+
+	mockStore.EXPECT().FindByID(mock.Anything, "orch-1").Return(nil, types.ErrNotFound).Once()
+	mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+	mockStore.EXPECT().FindByID(mock.Anything, "orch-2").Return(nil, errors.New("database unavailable")).Once()
+
+	succeeded := NewMockMessage(nil)
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, succeeded)
+
+	failed := NewMockMessage(nil)
+	data, _ = json.Marshal(createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning))
+	watcher.onMessage(data, failed)
+
+	assert.Equal(t, 0, succeeded.AckCalls, "ack must be deferred")
+	assert.Equal(t, 1, failed.NakCalls, "nak must not be deferred")
+	assert.Equal(t, 0, failed.AckCalls)
+
+	watcher.acks.Flush(context.Background())
+
+	assert.Equal(t, 1, succeeded.AckCalls)
+	assert.Equal(t, 0, failed.AckCalls, "failed messages must never be acked")
+	mockStore.AssertExpectations(t)
+}
+
+func TestOnMessage_AckSync_WaitsForConfirmation(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.ackSync = true
+
+	msg := newDoubleAckMessage()
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.doubleAcks)
+	assert.Equal(t, 0, msg.AckCalls, "fire-and-forget acks must not be used")
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestAck_AckSync_ConfirmationFailureReturned(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.ackSync = true
+
+	msg := newDoubleAckMessage()
+	msg.err = nats.ErrTimeout
+
+	err := watcher.ack(msg)
+
+	require.ErrorIs(t, err, ErrAckNotConfirmed)
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+	assert.Equal(t, 1, msg.doubleAcks)
+	assert.Equal(t, 0, msg.AckCalls, "a failed confirmation must not fall back to fire-and-forget acks")
+
+	// The processed message is neither acked again nor redelivered through a nak
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	failed := newDoubleAckMessage()
+	failed.err = nats.ErrTimeout
+	watcher.onMessage(data, failed)
+
+	assert.Equal(t, 1, failed.doubleAcks)
+	assert.Equal(t, 0, failed.NakCalls)
+}
+
+func (b *AckBatcher) pendingCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// doubleAckMessage records synchronous acknowledgements, failing them with err if set.
+type doubleAckMessage struct {
+	*MockMessage
+	doubleAcks  int
+	doubleAcked chan struct{}
+	err         error
+}
+
+func newDoubleAckMessage() *doubleAckMessage {
+	return &doubleAckMessage{MockMessage: NewMockMessage(nil), doubleAcked: make(chan struct{})}
+}
+
+func (m *doubleAckMessage) DoubleAck(context.Context) error {
+	m.doubleAcks++
+	close(m.doubleAcked)
+	return m.err
+}
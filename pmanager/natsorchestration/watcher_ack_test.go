@@ -122,6 +122,44 @@ func TestOnMessage_UpdateError_NakCalledNotAck(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
+// Update returns error - verify the transaction is rolled back exactly once
+func TestOnMessage_UpdateError_RollbackRecordedOnce(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	counter := &store.TransactionCounter{}
+	trxContext := store.NewMeteredTransactionContext(store.NoOpTransactionContext{}, counter)
+	watcher := createTestWatcher(mockStore, trxContext)
+
+	existingEntry := &api.OrchestrationEntry{
+		ID:                "orch-1",
+		CorrelationID:     "corr-1",
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    time.Now(),
+		CreatedTimestamp:  time.Now(),
+		OrchestrationType: "TestType",
+	}
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(existingEntry, nil).
+		Once()
+
+	mockStore.EXPECT().
+		Update(mock.Anything, mock.Anything).
+		Return(errors.New("update failed")).
+		Once()
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls, "Nak should be called exactly once when Update returns error")
+	assert.Equal(t, int64(1), counter.Rollbacks(), "Transaction should be rolled back exactly once")
+	assert.Equal(t, int64(0), counter.Commits(), "Transaction should not be committed")
+	mockStore.AssertExpectations(t)
+}
+
 // FindByID unexpected error - verify Nak is called, no further operations
 func TestOnMessage_FindByIDUnexpectedError_NakCalledImmediately(t *testing.T) {
 	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
@@ -223,6 +261,62 @@ func TestOnMessage_CreateTransientError_NakForRetry(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
+// Concurrent Create of the same entry - verify the losing message applies its change as an update and is acked
+func TestOnMessage_ConcurrentCreateConflict_AckedAsUpdate(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	trxContext := &store.NoOpTransactionContext{}
+	watcher := createTestWatcher(mockStore, trxContext)
+
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	completed := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+
+	// Both messages look up the entry before either has created it
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(nil, types.ErrNotFound).
+		Twice()
+
+	mockStore.EXPECT().
+		Create(mock.Anything, mock.MatchedBy(func(entry *api.OrchestrationEntry) bool {
+			return entry.ID == "orch-1"
+		})).
+		Return(&api.OrchestrationEntry{}, nil).
+		Once()
+
+	mockStore.EXPECT().
+		Create(mock.Anything, mock.MatchedBy(func(entry *api.OrchestrationEntry) bool {
+			return entry.ID == "orch-1"
+		})).
+		Return(nil, types.ErrConflict).
+		Once()
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(createEntry(running), nil).
+		Once()
+
+	mockStore.EXPECT().
+		Update(mock.Anything, mock.MatchedBy(func(entry *api.OrchestrationEntry) bool {
+			return entry.ID == "orch-1" && entry.State == api.OrchestrationStateCompleted
+		})).
+		Return(nil).
+		Once()
+
+	data1, _ := json.Marshal(running)
+	msg1 := NewMockMessage(data1)
+	data2, _ := json.Marshal(completed)
+	msg2 := NewMockMessage(data2)
+
+	watcher.onMessage(data1, msg1)
+	watcher.onMessage(data2, msg2)
+
+	assert.Equal(t, 1, msg1.AckCalls, "Ack should be called once for the winning create")
+	assert.Equal(t, 0, msg1.NakCalls, "Nak should not be called for the winning create")
+	assert.Equal(t, 1, msg2.AckCalls, "Ack should be called once for the conflicting create")
+	assert.Equal(t, 0, msg2.NakCalls, "Nak should not be called for the conflicting create")
+	mockStore.AssertExpectations(t)
+}
+
 // Update with state conflict - verify Nak for retry
 func TestOnMessage_UpdateStateConflict_NakForRetry(t *testing.T) {
 	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
@@ -345,11 +439,51 @@ func TestOnMessage_MalformedJSON_AckCalled(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
+// Duplicate message for an entry in a terminal state - verify Ack is called so it is not redelivered
+func TestOnMessage_TerminalStateSkipped_AckCalled(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	trxContext := &store.NoOpTransactionContext{}
+	watcher := createTestWatcher(mockStore, trxContext)
+
+	completed := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(createEntry(completed), nil).
+		Once()
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 0, msg.NakCalls, "Nak should not be called for a skipped message")
+	assert.Equal(t, 1, msg.AckCalls, "Ack should be called once for a skipped message")
+	mockStore.AssertExpectations(t)
+}
+
+// Missing orchestration ID - verify Ack is called without touching the store
+func TestOnMessage_MissingID_AckCalled(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	trxContext := &store.NoOpTransactionContext{}
+	watcher := createTestWatcher(mockStore, trxContext)
+
+	data := []byte(`{"correlationId":"corr-1","state":1}`)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 0, msg.NakCalls, "Nak should not be called for a message without an id")
+	assert.Equal(t, 1, msg.AckCalls, "Ack should be called for a message without an id")
+	mockStore.AssertExpectations(t)
+}
+
 // MockMessage implements MessageAck interface for testing Nak/Ack calls
 type MockMessage struct {
-	data     []byte
-	NakCalls int
-	AckCalls int
+	data      []byte
+	NakCalls  int
+	AckCalls  int
+	NakDelays []time.Duration
 }
 
 func NewMockMessage(data []byte) *MockMessage {
@@ -365,7 +499,65 @@ func (m *MockMessage) Nak(...nats.AckOpt) error {
 	return nil
 }
 
+func (m *MockMessage) NakWithDelay(delay time.Duration, _ ...nats.AckOpt) error {
+	m.NakCalls++
+	m.NakDelays = append(m.NakDelays, delay)
+	return nil
+}
+
 func (m *MockMessage) Ack(...nats.AckOpt) error {
 	m.AckCalls++
 	return nil
 }
+
+// Late delivery of a change older than the stored one - verify a single Ack without Update
+func TestOnMessage_SupersededByNewerEntry_AckedWithoutUpdate(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	stored := createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	stored.Revision = 5
+	stored.Version = 4
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(stored, nil).
+		Once()
+
+	data, _ := json.Marshal(orch)
+	msg := newDLQMessage("$KV.orchestrations.orch-1", 3, string(data), nil)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls, "Ack should be called once for a superseded change")
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, int64(1), watcher.SupersededMessages())
+	mockStore.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// Redelivered entry equal to the stored one - verify Ack without Update
+func TestOnMessage_UnchangedEntry_AckedWithoutUpdate(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	trxContext := &store.NoOpTransactionContext{}
+	watcher := createTestWatcher(mockStore, trxContext)
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	stored := createEntry(orch)
+	stored.Version = 3
+	stored.StateTimestamp = orch.StateTimestamp.Add(-time.Second)
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(stored, nil).
+		Once()
+
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls, "Ack should be called once for an unchanged entry")
+	assert.Equal(t, 0, msg.NakCalls)
+	mockStore.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
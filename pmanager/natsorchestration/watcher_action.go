@@ -0,0 +1,166 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// AckAction is the outcome of processing an orchestration change message.
+type AckAction int
+
+const (
+	// ActionAck acknowledges the message. The change was recorded or there was nothing to record.
+	ActionAck AckAction = iota
+	// ActionNak requests redelivery of the message after a transient failure.
+	ActionNak
+	// ActionDeadLetter removes a message that can never be processed successfully from the stream.
+	ActionDeadLetter
+)
+
+func (a AckAction) String() string {
+	switch a {
+	case ActionAck:
+		return "ack"
+	case ActionNak:
+		return "nak"
+	case ActionDeadLetter:
+		return "deadLetter"
+	default:
+		return "unknown"
+	}
+}
+
+// errMalformedMessage indicates a message could not be decoded into an orchestration.
+var errMalformedMessage = errors.New("malformed orchestration message")
+
+// MalformedPolicy determines how messages that cannot be decoded into an orchestration are settled.
+type MalformedPolicy string
+
+const (
+	// MalformedDeadLetter publishes malformed messages to the DLQ for inspection, recording the decode error in the
+	// DeadLetterReasonHeader. Messages are dropped if no DLQ is configured. This is the default.
+	MalformedDeadLetter MalformedPolicy = "deadLetter"
+	// MalformedDrop acknowledges and drops malformed messages.
+	MalformedDrop MalformedPolicy = "drop"
+)
+
+// ParseMalformedPolicy converts a configuration value to a MalformedPolicy. An empty value defaults to
+// MalformedDeadLetter.
+func ParseMalformedPolicy(value string) (MalformedPolicy, error) {
+	switch strings.ToLower(value) {
+	case "", strings.ToLower(string(MalformedDeadLetter)):
+		return MalformedDeadLetter, nil
+	case string(MalformedDrop):
+		return MalformedDrop, nil
+	default:
+		return "", fmt.Errorf("invalid watcher malformed policy: %s", value)
+	}
+}
+
+// decideAction determines how a message is settled. entry is the decoded entry or nil if the message could not be
+// decoded, existing is the entry currently recorded in the index or nil if none exists, and err is the error raised
+// while decoding or recording the entry. tolerance is the clock skew tolerated when comparing timestamps, see isStale.
+func decideAction(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry, err error, tolerance time.Duration) AckAction {
+	if entry == nil || errors.Is(err, errMalformedMessage) {
+		return ActionDeadLetter
+	}
+	if err == nil {
+		return ActionAck
+	}
+	if errors.Is(err, types.ErrAlreadyExists) || errors.Is(err, errNotPending) {
+		// The orchestration duplicates an existing one or confirms no pending state and is not recorded
+		return ActionAck
+	}
+	if errors.Is(err, types.ErrInvalidInput) || types.IsFatal(err) || types.IsClientError(err) {
+		return ActionDeadLetter
+	}
+	if errors.Is(err, types.ErrConflict) && existing != nil && isStale(entry, existing, tolerance) {
+		// A concurrent writer recorded a newer state, the change is superseded
+		return ActionAck
+	}
+	return ActionNak
+}
+
+// isStale returns true if the entry does not need to be recorded because the index already holds the same or a newer
+// change, or the existing entry is in a terminal state. Messages may arrive out of order.
+//
+// Changes are ordered by revision when both entries carry one. Otherwise, state timestamps are compared. Since the
+// clocks of publishers may be skewed, an entry is only considered older if its timestamp precedes the existing one by
+// more than tolerance.
+func isStale(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry, tolerance time.Duration) bool {
+	if isTerminal(existing.State) {
+		return true
+	}
+	if entry.Revision > 0 && existing.Revision > 0 {
+		return entry.Revision <= existing.Revision
+	}
+	return (existing.State == entry.State && existing.StateTimestamp.Equal(entry.StateTimestamp)) ||
+		entry.StateTimestamp.Add(tolerance).Before(existing.StateTimestamp)
+}
+
+// isSuperseded returns true if the index holds a strictly newer change than the entry, e.g. because the message was
+// delivered late. Changes are compared like in isStale, but redeliveries of the recorded change are not superseded.
+func isSuperseded(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry, tolerance time.Duration) bool {
+	if entry.Revision > 0 && existing.Revision > 0 {
+		return entry.Revision < existing.Revision
+	}
+	return entry.StateTimestamp.Add(tolerance).Before(existing.StateTimestamp)
+}
+
+// SupersededMessages returns the number of changes acknowledged without being recorded because the index already held
+// a newer change of the orchestration, see isSuperseded.
+func (w *OrchestrationIndexWatcher) SupersededMessages() int64 {
+	return w.superseded.Load()
+}
+
+// isRecorded returns true if the entry was created or updated given the entry found in the index before the change.
+func isRecorded(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry, tolerance time.Duration) bool {
+	return existing == nil || (!isStale(entry, existing, tolerance) && !isUnchanged(entry, existing))
+}
+
+// isUnchanged returns true if the entry equals the existing entry. The state timestamp, revision and version are not
+// compared since redelivered changes may carry a new timestamp and revision and incoming entries are not versioned.
+func isUnchanged(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry) bool {
+	return entry.ID == existing.ID &&
+		entry.CorrelationID == existing.CorrelationID &&
+		entry.State == existing.State &&
+		entry.OrchestrationType == existing.OrchestrationType &&
+		entry.CreatedTimestamp.Equal(existing.CreatedTimestamp) &&
+		entry.Deadline.Equal(existing.Deadline) &&
+		bytes.Equal(entry.Checkpoint, existing.Checkpoint) &&
+		maps.Equal(entry.Labels, existing.Labels) &&
+		entry.Mode == existing.Mode &&
+		equalRetryPolicy(entry.RetryPolicy, existing.RetryPolicy) &&
+		equalPendingState(entry, existing)
+}
+
+func equalRetryPolicy(policy *api.RetryPolicy, other *api.RetryPolicy) bool {
+	if policy == nil || other == nil {
+		return policy == other
+	}
+	return *policy == *other
+}
+
+// isTerminal returns true if no further state changes are recorded for an entry in the given state.
+func isTerminal(state api.OrchestrationState) bool {
+	return state == api.OrchestrationStateCompleted || state == api.OrchestrationStateErrored
+}
@@ -0,0 +1,133 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecideAction(t *testing.T) {
+	now := time.Now()
+	running := &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning, StateTimestamp: now}
+	runningLater := &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning, StateTimestamp: now.Add(time.Second)}
+	completed := &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateCompleted, StateTimestamp: now}
+	errored := &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateErrored, StateTimestamp: now}
+	transient := errors.New("database unavailable")
+
+	tests := []struct {
+		name     string
+		entry    *api.OrchestrationEntry
+		existing *api.OrchestrationEntry
+		err      error
+		expected AckAction
+	}{
+		{"malformed message", nil, nil, fmt.Errorf("%w: bad json", errMalformedMessage), ActionDeadLetter},
+		{"no entry without error", nil, nil, nil, ActionDeadLetter},
+		{"created", running, nil, nil, ActionAck},
+		{"updated", runningLater, running, nil, ActionAck},
+		{"duplicate skipped", running, running, nil, ActionAck},
+		{"terminal completed skipped", running, completed, nil, ActionAck},
+		{"terminal errored skipped", running, errored, nil, ActionAck},
+		{"transient create error", running, nil, transient, ActionNak},
+		{"transient update error", runningLater, running, transient, ActionNak},
+		{"recoverable error", running, nil, types.NewRecoverableError("lock timeout"), ActionNak},
+		{"create conflict", running, nil, types.ErrConflict, ActionNak},
+		{"update conflict with newer state", runningLater, running, types.ErrConflict, ActionNak},
+		{"update conflict superseded by terminal state", runningLater, completed, types.ErrConflict, ActionAck},
+		{"update conflict with same state", running, running, types.ErrConflict, ActionAck},
+		{"wrapped conflict superseded", runningLater, errored, fmt.Errorf("update: %w", types.ErrConflict), ActionAck},
+		{"invalid input", running, nil, types.ErrInvalidInput, ActionDeadLetter},
+		{"wrapped invalid input", running, running, fmt.Errorf("create: %w", types.ErrInvalidInput), ActionDeadLetter},
+		{"fatal error", running, nil, types.NewFatalError("corrupted"), ActionDeadLetter},
+		{"client error", running, nil, types.NewClientError("bad request"), ActionDeadLetter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decideAction(tt.entry, tt.existing, tt.err, 0))
+		})
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	now := time.Now()
+	entry := func(state api.OrchestrationState, timestamp time.Time, revision uint64) *api.OrchestrationEntry {
+		return &api.OrchestrationEntry{ID: "orch-1", State: state, StateTimestamp: timestamp, Revision: revision}
+	}
+	running := api.OrchestrationStateRunning
+	initialized := api.OrchestrationStateInitialized
+
+	tests := []struct {
+		name     string
+		entry    *api.OrchestrationEntry
+		existing *api.OrchestrationEntry
+		expected bool
+	}{
+		{"newer timestamp", entry(running, now, 0), entry(initialized, now.Add(-time.Minute), 0), false},
+		{"same state and timestamp", entry(running, now, 0), entry(running, now, 0), true},
+		{"older within skew window", entry(running, now.Add(-500*time.Millisecond), 0), entry(initialized, now, 0), false},
+		{"older beyond skew window", entry(initialized, now.Add(-2*time.Second), 0), entry(running, now, 0), true},
+		{"terminal existing entry", entry(running, now, 0), entry(api.OrchestrationStateCompleted, now.Add(-time.Minute), 0), true},
+		{"newer revision with older timestamp", entry(running, now.Add(-time.Minute), 5), entry(initialized, now, 4), false},
+		{"older revision with newer timestamp", entry(initialized, now.Add(time.Minute), 3), entry(running, now, 4), true},
+		{"same revision", entry(running, now, 4), entry(running, now, 4), true},
+		{"revision unknown for existing entry", entry(running, now, 5), entry(initialized, now.Add(-time.Minute), 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isStale(tt.entry, tt.existing, time.Second))
+		})
+	}
+}
+
+func TestIsSuperseded(t *testing.T) {
+	now := time.Now()
+	entry := func(timestamp time.Time, revision uint64) *api.OrchestrationEntry {
+		return &api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning, StateTimestamp: timestamp, Revision: revision}
+	}
+
+	tests := []struct {
+		name     string
+		entry    *api.OrchestrationEntry
+		existing *api.OrchestrationEntry
+		expected bool
+	}{
+		{"older revision", entry(now.Add(time.Minute), 3), entry(now, 4), true},
+		{"same revision", entry(now, 4), entry(now, 4), false},
+		{"newer revision", entry(now.Add(-time.Minute), 5), entry(now, 4), false},
+		{"older beyond skew window", entry(now.Add(-2*time.Second), 0), entry(now, 0), true},
+		{"older within skew window", entry(now.Add(-500*time.Millisecond), 0), entry(now, 0), false},
+		{"same timestamp", entry(now, 0), entry(now, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isSuperseded(tt.entry, tt.existing, time.Second))
+		})
+	}
+}
+
+func TestAckAction_String(t *testing.T) {
+	assert.Equal(t, "ack", ActionAck.String())
+	assert.Equal(t, "nak", ActionNak.String())
+	assert.Equal(t, "deadLetter", ActionDeadLetter.String())
+	assert.Equal(t, "unknown", AckAction(99).String())
+}
@@ -0,0 +1,253 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// BackoffStrategy determines how long redelivery of a message is delayed after a transient failure.
+type BackoffStrategy interface {
+	// Delay returns the redelivery delay for the given delivery attempt, starting at 1.
+	Delay(attempt uint64) time.Duration
+}
+
+// ExponentialBackoff doubles the delay with every delivery attempt, starting at Initial and capped at Max.
+type ExponentialBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (b ExponentialBackoff) Delay(attempt uint64) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	delay := b.Initial
+	for i := uint64(1); i < attempt; i++ {
+		delay *= 2
+		if b.Max > 0 && delay >= b.Max {
+			return b.Max
+		}
+	}
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+// deliveryCounter is implemented by messages that track how often they have been delivered.
+type deliveryCounter interface {
+	NumDelivered() uint64
+}
+
+// nakDelay returns the redelivery delay for a message of the orchestration that failed with the given error. A retry
+// delay reported by the error takes precedence over the backoff strategy, which is applied to the delivery attempt or
+// the retry count of the orchestration, whichever is higher. Zero means the message is redelivered immediately.
+func (w *OrchestrationIndexWatcher) nakDelay(msg MessageAck, orchestrationID string, err error) time.Duration {
+	if delay, ok := types.RetryAfter(err); ok {
+		return delay
+	}
+	backoff := w.backoffFor(orchestrationID)
+	if backoff == nil {
+		return 0
+	}
+	return backoff.Delay(max(uint64(1), w.attempts(msg, orchestrationID)))
+}
+
+// attempts returns the number of failed attempts to process changes of the orchestration, the retry count or the
+// delivery count of the message, whichever is higher. The delivery count is kept by the server, so it also covers
+// attempts made by other watcher instances or before a restart.
+func (w *OrchestrationIndexWatcher) attempts(msg MessageAck, orchestrationID string) uint64 {
+	attempts := uint64(w.retries.count(orchestrationID))
+	if counter, ok := msg.(deliveryCounter); ok {
+		attempts = max(attempts, counter.NumDelivered())
+	}
+	return attempts
+}
+
+// backoffFor returns the backoff strategy of the orchestration. A BackoffBase set by its retry policy replaces the
+// initial delay of the watcher backoff, keeping its maximum.
+func (w *OrchestrationIndexWatcher) backoffFor(orchestrationID string) BackoffStrategy {
+	policy := w.retryPolicy(orchestrationID)
+	if policy == nil || policy.BackoffBase <= 0 {
+		return w.backoff
+	}
+	backoff := ExponentialBackoff{Initial: policy.BackoffBase}
+	if defaults, ok := w.backoff.(ExponentialBackoff); ok {
+		backoff.Max = defaults.Max
+	}
+	return backoff
+}
+
+// retryPolicy returns the retry policy of an orchestration being retried, nil if the defaults apply.
+func (w *OrchestrationIndexWatcher) retryPolicy(orchestrationID string) *api.RetryPolicy {
+	if policy, ok := w.retryPolicies.Load(orchestrationID); ok {
+		return policy.(*api.RetryPolicy)
+	}
+	return nil
+}
+
+// retriesExhausted returns true if the orchestration failed as often as allowed by its retry policy or, if the policy
+// does not set MaxAttempts, the watcher default.
+func (w *OrchestrationIndexWatcher) retriesExhausted(msg MessageAck, entry *api.OrchestrationEntry) bool {
+	maxAttempts := w.maxAttempts
+	if entry.RetryPolicy != nil && entry.RetryPolicy.MaxAttempts > 0 {
+		maxAttempts = entry.RetryPolicy.MaxAttempts
+	}
+	return maxAttempts > 0 && w.attempts(msg, entry.ID) >= uint64(maxAttempts)
+}
+
+// errorExhausted records the orchestration as errored once its retries are exhausted so that the change is no longer
+// redelivered. The entry and its state change carry the failure reason classified from the cause. Returns the errored
+// entry and the entry found in the index before, or an error if the index could not be updated, in which case the
+// change is retried.
+func (w *OrchestrationIndexWatcher) errorExhausted(
+	msg MessageAck,
+	entry *api.OrchestrationEntry,
+	cause error) (*api.OrchestrationEntry, *api.OrchestrationEntry, error) {
+	w.monitor.Warnw(fmt.Sprintf("Giving up on orchestration after %d attempts", w.attempts(msg, entry.ID)),
+		w.entryLogFields(entry, cause)...)
+	errored := entry.Clone()
+	errored.State = api.OrchestrationStateErrored
+	errored.StateTimestamp = w.now()
+	errored.FailureReason, errored.RemediationHint = w.classifyFailure(cause)
+	ctx := api.WithOrchestration(context.Background(), errored)
+	var existing *api.OrchestrationEntry
+	err := w.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		existing, err = w.record(ctx, errored)
+		if err != nil {
+			return err
+		}
+		return w.emitStateChange(ctx, errored, existing)
+	})
+	if err != nil {
+		w.monitor.Warnw("Failed to record orchestration as errored", w.entryLogFields(entry, err)...)
+		return nil, nil, err
+	}
+	w.countRetry(errored, ActionAck)
+	return errored, existing, nil
+}
+
+// RetryCount returns the number of consecutive failures recording changes of the orchestration. The count is reset
+// once a change of the orchestration is recorded or settled without redelivery.
+func (w *OrchestrationIndexWatcher) RetryCount(orchestrationID string) int {
+	return w.retries.count(orchestrationID)
+}
+
+// countRetry tracks the retry count and retry policy of the orchestration given the action settling its message.
+func (w *OrchestrationIndexWatcher) countRetry(entry *api.OrchestrationEntry, action AckAction) {
+	if action != ActionNak {
+		w.retries.reset(entry.ID)
+		w.retryPolicies.Delete(entry.ID)
+		return
+	}
+	w.retries.record(entry.ID)
+	if entry.RetryPolicy != nil {
+		w.retryPolicies.Store(entry.ID, entry.RetryPolicy)
+	} else {
+		w.retryPolicies.Delete(entry.ID)
+	}
+}
+
+// defaultFailureTTL is how long a failure count is kept without further failures. It evicts the counts of
+// orchestrations whose changes are no longer delivered, e.g. because the server stopped redelivering them.
+const defaultFailureTTL = time.Hour
+
+// failureCounter counts consecutive failures per orchestration. Counts without a failure for ttl expire, so that the
+// counter does not grow with orchestrations that stopped failing without a success being recorded. The zero value is
+// ready to use.
+type failureCounter struct {
+	mu        sync.Mutex
+	counts    map[string]failureCount
+	lastSweep time.Time
+
+	// ttl defaults to defaultFailureTTL when zero
+	ttl time.Duration
+	// now defaults to time.Now when nil
+	now func() time.Time
+}
+
+type failureCount struct {
+	count   int
+	updated time.Time
+}
+
+// record increments and returns the number of consecutive failures for the key. Expired counts are evicted at most
+// once per ttl.
+func (c *failureCounter) record(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock()
+	if c.counts == nil {
+		c.counts = make(map[string]failureCount)
+		c.lastSweep = now
+	}
+	if now.Sub(c.lastSweep) >= c.expiry() {
+		for k, failures := range c.counts {
+			if c.expired(failures, now) {
+				delete(c.counts, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	failures := c.counts[key]
+	if c.expired(failures, now) {
+		failures.count = 0
+	}
+	failures.count++
+	failures.updated = now
+	c.counts[key] = failures
+	return failures.count
+}
+
+// reset clears the failure count of the key.
+func (c *failureCounter) reset(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.counts, key)
+}
+
+func (c *failureCounter) count(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failures := c.counts[key]
+	if c.expired(failures, c.clock()) {
+		return 0
+	}
+	return failures.count
+}
+
+func (c *failureCounter) expired(failures failureCount, now time.Time) bool {
+	return now.Sub(failures.updated) >= c.expiry()
+}
+
+func (c *failureCounter) expiry() time.Duration {
+	if c.ttl <= 0 {
+		return defaultFailureTTL
+	}
+	return c.ttl
+}
+
+func (c *failureCounter) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
@@ -0,0 +1,259 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialBackoff_Delay(t *testing.T) {
+	backoff := ExponentialBackoff{Initial: time.Second, Max: 10 * time.Second}
+
+	assert.Equal(t, time.Second, backoff.Delay(0))
+	assert.Equal(t, time.Second, backoff.Delay(1))
+	assert.Equal(t, 2*time.Second, backoff.Delay(2))
+	assert.Equal(t, 8*time.Second, backoff.Delay(4))
+	assert.Equal(t, 10*time.Second, backoff.Delay(5))
+	assert.Equal(t, 10*time.Second, backoff.Delay(1000))
+
+	assert.Equal(t, time.Duration(0), ExponentialBackoff{}.Delay(3))
+	assert.Equal(t, 4*time.Second, ExponentialBackoff{Initial: time.Second}.Delay(3))
+}
+
+// Store error carrying a retry hint - verify the Nak delay matches the hint rather than the backoff
+func TestOnMessage_RetryAfterError_NakDelayFromError(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(nil, types.NewRetryAfterError(7*time.Second, "rate limited")).
+		Once()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.Equal(t, []time.Duration{7 * time.Second}, msg.NakDelays)
+}
+
+// Store error without a retry hint - verify the backoff strategy determines the Nak delay
+func TestOnMessage_TransientError_NakDelayFromBackoff(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(nil, errors.New("database unavailable")).
+		Once()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 3}
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, []time.Duration{4 * time.Second}, msg.NakDelays)
+}
+
+// No backoff strategy and no retry hint - verify the message is redelivered immediately
+func TestOnMessage_TransientError_NoBackoff_ImmediateNak(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+
+	mockStore.EXPECT().
+		FindByID(mock.Anything, "orch-1").
+		Return(nil, errors.New("database unavailable")).
+		Once()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Empty(t, msg.NakDelays)
+}
+
+// Consecutive failures of an orchestration grow the backoff, a recorded change resets it
+func TestOnMessage_RetryCount_ResetOnSuccess(t *testing.T) {
+	index := &flakyIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+
+	process := func(state api.OrchestrationState) *MockMessage {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		return msg
+	}
+
+	index.failures = 1
+	msg := process(api.OrchestrationStateRunning)
+	require.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, []time.Duration{time.Second}, msg.NakDelays)
+	assert.Equal(t, 1, watcher.RetryCount("orch-1"))
+
+	msg = process(api.OrchestrationStateRunning)
+	require.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, watcher.RetryCount("orch-1"))
+
+	index.failures = 2
+	msg = process(api.OrchestrationStateCompleted)
+	assert.Equal(t, []time.Duration{time.Second}, msg.NakDelays, "the backoff starts over after a success")
+	assert.Equal(t, 1, watcher.RetryCount("orch-1"))
+
+	msg = process(api.OrchestrationStateCompleted)
+	assert.Equal(t, []time.Duration{2 * time.Second}, msg.NakDelays)
+	assert.Equal(t, 2, watcher.RetryCount("orch-1"))
+}
+
+// A retry policy of the orchestration overrides the watcher defaults
+func TestOnMessage_RetryPolicy_ErroredAfterMaxAttempts(t *testing.T) {
+	index := &flakyIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failures: 2}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+	watcher.maxAttempts = 5
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orch.RetryPolicy = &api.RetryPolicy{MaxAttempts: 2, BackoffBase: 100 * time.Millisecond}
+	data, _ := json.Marshal(orch)
+
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond}, msg.NakDelays, "the backoff base of the policy applies")
+
+	msg = NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, 1, msg.AckCalls, "the change must not be redelivered once the retries are exhausted")
+	assert.Equal(t, 0, watcher.RetryCount("orch-1"))
+
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateErrored, entry.State)
+	assert.Equal(t, orch.RetryPolicy, entry.RetryPolicy)
+}
+
+// Without a retry policy the watcher defaults apply
+func TestOnMessage_RetryPolicy_DefaultsWithoutPolicy(t *testing.T) {
+	index := &flakyIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failures: 2}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+	watcher.maxAttempts = 5
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	for attempt := 1; attempt <= 2; attempt++ {
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.NakCalls)
+		assert.Equal(t, []time.Duration{time.Duration(attempt) * time.Second}, msg.NakDelays)
+	}
+
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls)
+
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
+
+// Deliveries counted by the server exhaust the retries, e.g. if earlier attempts were made by another watcher
+func TestOnMessage_RetryPolicy_ExhaustedByDeliveryCount(t *testing.T) {
+	index := &flakyIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failures: 1}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.maxAttempts = 3
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 3}
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, 1, msg.AckCalls, "the change must not be redelivered once the retries are exhausted")
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateErrored, entry.State)
+}
+
+func TestFailureCounter_Expiry(t *testing.T) {
+	now := time.Now()
+	counter := failureCounter{ttl: time.Minute, now: func() time.Time { return now }}
+
+	assert.Equal(t, 1, counter.record("orch-1"))
+	assert.Equal(t, 2, counter.record("orch-1"))
+	counter.record("orch-2")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 0, counter.count("orch-1"), "counts expire without further failures")
+	assert.Equal(t, 1, counter.record("orch-1"), "expired counts start over")
+	assert.NotContains(t, counter.counts, "orch-2", "expired counts are evicted")
+}
+
+// flakyIndex fails the given number of creates and updates with err, or a generic error if nil, before delegating.
+type flakyIndex struct {
+	*memorystore.OrchestrationIndex
+	failures int
+	err      error
+}
+
+func (i *flakyIndex) failure() error {
+	i.failures--
+	if i.err != nil {
+		return i.err
+	}
+	return errors.New("database unavailable")
+}
+
+func (i *flakyIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	if i.failures > 0 {
+		return nil, i.failure()
+	}
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *flakyIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	if i.failures > 0 {
+		return i.failure()
+	}
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+// deliveredMockMessage is a MockMessage reporting its delivery count.
+type deliveredMockMessage struct {
+	*MockMessage
+	delivered uint64
+}
+
+func (m *deliveredMockMessage) NumDelivered() uint64 {
+	return m.delivered
+}
@@ -0,0 +1,236 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// CorrelatedChange is an orchestration change and the message it was delivered with.
+type CorrelatedChange struct {
+	Entry *api.OrchestrationEntry
+	Msg   MessageAck
+	// Data is the raw message of the change, recorded if the watcher records raw payloads. Optional.
+	Data []byte
+}
+
+// admittedMessage is a fetched message whose change is to be recorded, see admit.
+type admittedMessage struct {
+	data    []byte
+	decoded DecodedMessage
+	msg     jetStreamMessageAck
+}
+
+// processCorrelated records the fetched messages, recording the changes of each correlation ID atomically, see
+// ProcessCorrelated. Changes without a correlation ID and correlation IDs with a single fetched change are recorded
+// individually. The changes of an orchestration share its correlation ID, so they are recorded in order.
+//
+// Correlated changes are not debounced and are recorded without the processing timeout, heartbeats and retry limits
+// applied to individual changes. Correlation IDs with a change carrying a pending directive are recorded
+// individually, see withPending. Invalid changes are dead-lettered and the other changes of their correlation ID
+// recorded without them.
+func (w *OrchestrationIndexWatcher) processCorrelated(messages []jetstream.Msg, source *sourceState) {
+	groups := make(map[string][]admittedMessage)
+	var correlationIDs []string
+	for _, message := range messages {
+		admitted, ok := w.admitFetched(message, source.receive())
+		if !ok {
+			continue
+		}
+		correlationID := admitted.decoded.Orchestration.CorrelationID
+		if _, found := groups[correlationID]; !found {
+			correlationIDs = append(correlationIDs, correlationID)
+		}
+		groups[correlationID] = append(groups[correlationID], admitted)
+	}
+	for _, correlationID := range correlationIDs {
+		group := groups[correlationID]
+		if correlationID == "" || len(group) == 1 || slices.ContainsFunc(group, hasPendingDirective) {
+			for _, admitted := range group {
+				w.recordAdmitted(admitted)
+			}
+			continue
+		}
+		w.recordCorrelated(correlationID, group)
+	}
+}
+
+// admitFetched admits the fetched message, see admit. Panics are recovered like when processing the message.
+func (w *OrchestrationIndexWatcher) admitFetched(message jetstream.Msg, source string) (admitted admittedMessage, ok bool) {
+	var orchestrationID string
+	msg := jetStreamMessageAck{msg: message}
+	defer w.recoverPanic(msg, &orchestrationID)
+
+	decoded, ok := w.admit(source, message.Data(), message.Headers(), msg)
+	return admittedMessage{data: message.Data(), decoded: decoded, msg: msg}, ok
+}
+
+// recordAdmitted records the change of an admitted message on its own.
+func (w *OrchestrationIndexWatcher) recordAdmitted(admitted admittedMessage) {
+	var orchestrationID string
+	defer w.recoverPanic(admitted.msg, &orchestrationID)
+	w.recordDecoded(admitted.data, admitted.decoded, admitted.msg, &orchestrationID)
+}
+
+// recordCorrelated records the changes of admitted messages sharing the correlation ID atomically.
+func (w *OrchestrationIndexWatcher) recordCorrelated(correlationID string, group []admittedMessage) {
+	changes := make([]CorrelatedChange, 0, len(group))
+	for _, admitted := range group {
+		entry := createEntry(admitted.decoded.Orchestration)
+		labelSource(entry, admitted.decoded.Source)
+		if err := w.validate(entry); err != nil {
+			w.monitor.Infow("Rejecting orchestration entry", w.entryLogFields(entry, err)...)
+			w.settle(admitted.msg, entry.ID, ActionDeadLetter, err)
+			continue
+		}
+		// Changes of a key in the orchestration bucket are stored in order, the stream sequence is the key revision
+		entry.Revision = admitted.msg.StreamSequence()
+		changes = append(changes, CorrelatedChange{Entry: entry, Msg: admitted.msg, Data: admitted.data})
+	}
+	if len(changes) == 0 {
+		return
+	}
+	if err := w.ProcessCorrelated(context.Background(), changes); err != nil {
+		w.monitor.Infof("Failed to record changes correlated by %s: %v", correlationID, err)
+	}
+}
+
+// hasPendingDirective returns true if the message carries a pending timeout or confirmation, see withPending.
+func hasPendingDirective(admitted admittedMessage) bool {
+	header := admitted.decoded.Header
+	return header.Get(PendingTimeoutHeader) != "" || header.Get(ConfirmPendingHeader) != ""
+}
+
+// ProcessCorrelated records changes of orchestrations sharing a correlation ID atomically and acks their messages
+// together. If recording the batch fails, the transaction is rolled back and all messages are redelivered.
+//
+// Watchers configured to resume correlated batches instead record each change of a failed batch in its own
+// transaction: the messages of the changes recorded are acked and only the messages of the failed changes are
+// redelivered, so that successful work is not repeated at the expense of atomicity. The returned error then joins the
+// errors of the failed changes. Invalid batches are never resumed.
+func (w *OrchestrationIndexWatcher) ProcessCorrelated(ctx context.Context, changes []CorrelatedChange) error {
+	err := w.recordBatch(ctx, changes)
+	if err == nil || !w.resumeCorrelatedBatches || errors.Is(err, types.ErrInvalidInput) {
+		for _, change := range changes {
+			w.settleCorrelated(change, err)
+		}
+		return err
+	}
+
+	w.monitor.Infof("Failed to record correlated changes as a batch, recording them individually: %v", err)
+	var errs []error
+	for _, change := range changes {
+		err := w.recordBatch(ctx, []CorrelatedChange{change})
+		w.settleCorrelated(change, err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// settleCorrelated settles the message of a correlated change given the error recording it.
+func (w *OrchestrationIndexWatcher) settleCorrelated(change CorrelatedChange, err error) {
+	var orchestrationID string
+	if change.Entry != nil {
+		orchestrationID = change.Entry.ID
+	}
+	action := ActionAck
+	if err != nil && !errors.Is(err, types.ErrAlreadyExists) {
+		action = ActionNak
+		w.pauseOnPoolExhaustion(err)
+	}
+	if change.Entry != nil {
+		w.countRetry(change.Entry, action)
+	}
+	w.settle(change.Msg, orchestrationID, action, err)
+}
+
+// ProcessBatch records the given entries in a single transaction. The entries must share a correlation ID. Each entry
+// is loaded, checked for staleness and written as if processed on its own, in order. If recording any entry fails, the
+// transaction is rolled back and none of the changes are recorded.
+func (w *OrchestrationIndexWatcher) ProcessBatch(ctx context.Context, entries []*api.OrchestrationEntry) error {
+	changes := make([]CorrelatedChange, len(entries))
+	for i, entry := range entries {
+		changes[i] = CorrelatedChange{Entry: entry}
+	}
+	return w.recordBatch(ctx, changes)
+}
+
+// recordBatch records the changes in a single transaction, see ProcessBatch. The raw messages of the changes are
+// recorded with them if available.
+func (w *OrchestrationIndexWatcher) recordBatch(ctx context.Context, changes []CorrelatedChange) error {
+	entries := make([]*api.OrchestrationEntry, len(changes))
+	for i, change := range changes {
+		entries[i] = change.Entry
+	}
+	if err := validateBatch(entries); err != nil {
+		return err
+	}
+	existing := make([]*api.OrchestrationEntry, len(entries))
+	err := w.trxContext.Execute(ctx, func(ctx context.Context) error {
+		for i, entry := range entries {
+			entryCtx := api.WithOrchestration(ctx, entry)
+			var err error
+			if existing[i], err = w.record(entryCtx, entry); err != nil {
+				return fmt.Errorf("error recording orchestration %s: %w", entry.ID, err) // roll back on error
+			}
+			if changes[i].Data != nil {
+				if err = w.saveRawPayload(entryCtx, entry, existing[i], changes[i].Data); err != nil {
+					return err
+				}
+			}
+			if err = w.emitStateChange(entryCtx, entry, existing[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if isRecorded(entry, existing[i], w.clockSkewTolerance) {
+			w.feed.publish(entry)
+			w.project(entry)
+		}
+		w.observeTimeInState(entry, existing[i])
+		if w.outbox != nil && isStateChange(entry, existing[i], w.clockSkewTolerance) {
+			w.outbox.Enqueue(entry)
+		}
+	}
+	return nil
+}
+
+// validateBatch checks the entries of a batch are complete and share a correlation ID.
+func validateBatch(entries []*api.OrchestrationEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: batch is empty", types.ErrInvalidInput)
+	}
+	for _, entry := range entries {
+		if entry == nil || entry.ID == "" {
+			return fmt.Errorf("%w: batch entry id is missing", types.ErrInvalidInput)
+		}
+		if entry.CorrelationID == "" || entry.CorrelationID != entries[0].CorrelationID {
+			return fmt.Errorf("%w: batch entries must share a correlation id", types.ErrInvalidInput)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessCorrelated_AllRecordedAndAcked(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	changes := []CorrelatedChange{
+		correlatedChange("orch-1", "corr-1", api.OrchestrationStateRunning),
+		correlatedChange("orch-2", "corr-1", api.OrchestrationStateRunning),
+	}
+	require.NoError(t, watcher.ProcessCorrelated(context.Background(), changes))
+
+	for _, change := range changes {
+		assert.Equal(t, 1, change.Msg.(*MockMessage).AckCalls)
+		entry, err := index.FindByID(context.Background(), change.Entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	}
+}
+
+func TestProcessCorrelated_FailureNaksAll(t *testing.T) {
+	index := &failingUpdateIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failID: "orch-2"}
+	trxContext := &recordingTransactionContext{}
+	watcher := createTestWatcher(index, trxContext)
+	ctx := context.Background()
+
+	for _, id := range []string{"orch-1", "orch-2"} {
+		_, err := index.Create(ctx, createEntry(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateInitialized)))
+		require.NoError(t, err)
+	}
+
+	changes := []CorrelatedChange{
+		correlatedChange("orch-1", "corr-1", api.OrchestrationStateRunning),
+		correlatedChange("orch-2", "corr-1", api.OrchestrationStateRunning),
+	}
+	err := watcher.ProcessCorrelated(ctx, changes)
+
+	require.Error(t, err)
+	assert.Equal(t, 1, trxContext.executions, "correlated changes must not be recorded individually")
+	for _, change := range changes {
+		msg := change.Msg.(*MockMessage)
+		assert.Equal(t, 1, msg.NakCalls, "the change %s must be redelivered", change.Entry.ID)
+		assert.Equal(t, 0, msg.AckCalls)
+	}
+}
+
+func TestProcessCorrelated_ResumedPartialFailureNaksOnlyFailedChange(t *testing.T) {
+	index := &failingUpdateIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failID: "orch-2"}
+	trxContext := &recordingTransactionContext{}
+	watcher := createTestWatcher(index, trxContext)
+	watcher.resumeCorrelatedBatches = true
+	ctx := context.Background()
+
+	for _, id := range []string{"orch-1", "orch-2", "orch-3"} {
+		_, err := index.Create(ctx, createEntry(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateInitialized)))
+		require.NoError(t, err)
+	}
+
+	changes := []CorrelatedChange{
+		correlatedChange("orch-1", "corr-1", api.OrchestrationStateRunning),
+		correlatedChange("orch-2", "corr-1", api.OrchestrationStateRunning),
+		correlatedChange("orch-3", "corr-1", api.OrchestrationStateRunning),
+	}
+	err := watcher.ProcessCorrelated(ctx, changes)
+
+	require.Error(t, err)
+	assert.Equal(t, 4, trxContext.executions, "the batch must be resumed with one transaction per change")
+	assert.Equal(t, 2, trxContext.rollbacks)
+	for _, change := range changes {
+		msg := change.Msg.(*MockMessage)
+		entry, err := index.FindByID(ctx, change.Entry.ID)
+		require.NoError(t, err)
+		if change.Entry.ID == "orch-2" {
+			assert.Equal(t, 1, msg.NakCalls, "the failed change must be redelivered")
+			assert.Equal(t, 0, msg.AckCalls)
+			assert.Equal(t, api.OrchestrationStateInitialized, entry.State)
+			continue
+		}
+		assert.Equal(t, 1, msg.AckCalls, "the change %s must be acked", change.Entry.ID)
+		assert.Equal(t, 0, msg.NakCalls)
+		assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	}
+}
+
+func TestProcessCorrelated_InvalidBatchNaksAll(t *testing.T) {
+	trxContext := &recordingTransactionContext{}
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), trxContext)
+
+	changes := []CorrelatedChange{
+		correlatedChange("orch-1", "corr-1", api.OrchestrationStateRunning),
+		correlatedChange("orch-2", "corr-2", api.OrchestrationStateRunning),
+	}
+	err := watcher.ProcessCorrelated(context.Background(), changes)
+
+	require.ErrorIs(t, err, types.ErrInvalidInput)
+	assert.Equal(t, 0, trxContext.executions)
+	for _, change := range changes {
+		assert.Equal(t, 1, change.Msg.(*MockMessage).NakCalls)
+	}
+}
+
+func TestProcessBatch_RejectsUnrelatedEntries(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+
+	err := watcher.ProcessBatch(context.Background(), []*api.OrchestrationEntry{
+		createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)),
+		createEntry(createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)),
+	})
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+
+	err = watcher.ProcessBatch(context.Background(), nil)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+func TestProcessFetched_CorrelatedBatches_RecordedTogether(t *testing.T) {
+	ctx := context.Background()
+	index := &failingUpdateIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), failID: "orch-2"}
+	trxContext := &recordingTransactionContext{}
+	watcher := createTestWatcher(index, trxContext)
+	watcher.correlatedBatches = true
+
+	for _, id := range []string{"orch-1", "orch-2"} {
+		_, err := index.Create(ctx, createEntry(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateInitialized)))
+		require.NoError(t, err)
+	}
+	correlated := []*correlatedMsg{
+		newCorrelatedMsg(t, "orch-1", "corr-1", api.OrchestrationStateRunning),
+		newCorrelatedMsg(t, "orch-2", "corr-1", api.OrchestrationStateRunning),
+	}
+	uncorrelated := newCorrelatedMsg(t, "orch-3", "corr-3", api.OrchestrationStateRunning)
+
+	messages := make(chan jetstream.Msg, 3)
+	messages <- correlated[0]
+	messages <- uncorrelated
+	messages <- correlated[1]
+	close(messages)
+	watcher.processFetched(nil, stubBatch{messages: messages}, nil)
+
+	assert.Equal(t, 2, trxContext.executions, "the correlated changes must be recorded in one transaction")
+	assert.Equal(t, 1, trxContext.rollbacks)
+	for _, msg := range correlated {
+		assert.Equal(t, 1, msg.naks, "the correlated change %s must be redelivered", msg.subject)
+		assert.Equal(t, 0, msg.acks)
+	}
+	assert.Equal(t, 1, uncorrelated.acks)
+}
+
+func TestProcessCorrelated_PendingDirectiveRecordedIndividually(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	trxContext := &recordingTransactionContext{}
+	watcher := createTestWatcher(index, trxContext)
+
+	pending := newCorrelatedMsg(t, "orch-1", "corr-1", api.OrchestrationStateRunning)
+	pending.header.Set(ConfirmPendingHeader, "true")
+	other := newCorrelatedMsg(t, "orch-2", "corr-1", api.OrchestrationStateRunning)
+
+	watcher.processCorrelated([]jetstream.Msg{pending, other}, nil)
+
+	assert.Equal(t, 2, trxContext.executions)
+	assert.Equal(t, 1, other.acks)
+}
+
+func correlatedChange(id, correlationID string, state api.OrchestrationState) CorrelatedChange {
+	return CorrelatedChange{
+		Entry: createEntry(createWatcherOrchestration(id, correlationID, state)),
+		Msg:   NewMockMessage(nil),
+	}
+}
+
+// correlatedMsg is a fetched orchestration change counting its naks.
+type correlatedMsg struct {
+	subjectMsg
+	header nats.Header
+	naks   int
+}
+
+func newCorrelatedMsg(t *testing.T, id, correlationID string, state api.OrchestrationState) *correlatedMsg {
+	data, err := json.Marshal(createWatcherOrchestration(id, correlationID, state))
+	require.NoError(t, err)
+	return &correlatedMsg{subjectMsg: subjectMsg{subject: "$KV.cfm-orchestrations." + id, data: data}, header: nats.Header{}}
+}
+
+func (m *correlatedMsg) Headers() nats.Header { return m.header }
+
+func (m *correlatedMsg) Nak() error {
+	m.naks++
+	return nil
+}
+
+func (m *correlatedMsg) NakWithDelay(time.Duration) error {
+	return m.Nak()
+}
+
+// failingUpdateIndex fails updates of the entry with failID.
+type failingUpdateIndex struct {
+	*memorystore.OrchestrationIndex
+	failID string
+}
+
+func (i *failingUpdateIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	if entry.ID == i.failID {
+		return errors.New("database unavailable")
+	}
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+// recordingTransactionContext records executed transactions and the ones rolled back due to an error.
+type recordingTransactionContext struct {
+	executions int
+	rollbacks  int
+}
+
+func (c *recordingTransactionContext) Execute(ctx context.Context, callback func(ctx context.Context) error) error {
+	c.executions++
+	err := callback(ctx)
+	if err != nil {
+		c.rollbacks++
+	}
+	return err
+}
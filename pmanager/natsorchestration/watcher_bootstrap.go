@@ -0,0 +1,101 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// Bootstrap populates the index with the current state of the orchestrations replayed by the event source before live
+// changes are processed, e.g. from a StreamEventSource replaying only the latest change of each orchestration. Only the
+// latest change of each orchestration is recorded. Changes are recorded like live changes, so entries already holding
+// the same or a newer change are not modified and live changes delivered again after bootstrapping are skipped.
+//
+// To not miss changes made while bootstrapping, the consumer the watcher reads from must exist before Bootstrap is
+// called.
+func (w *OrchestrationIndexWatcher) Bootstrap(ctx context.Context, events EventSource) error {
+	entries, err := NewIndexRebuilder(events, w.decoder, w.trxContext, w.monitor).fold(ctx)
+	if err != nil {
+		return fmt.Errorf("error bootstrapping orchestration index: %w", err)
+	}
+	recorded := 0
+	for _, id := range slices.Sorted(maps.Keys(entries)) {
+		entry := entries[id]
+		var existing *api.OrchestrationEntry
+		err := w.trxContext.Execute(api.WithOrchestration(ctx, entry), func(ctx context.Context) error {
+			var err error
+			existing, err = w.record(ctx, entry)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error bootstrapping orchestration index entry %s: %w", id, err)
+		}
+		if isRecorded(entry, existing, w.clockSkewTolerance) {
+			recorded++
+		}
+	}
+	w.monitor.Infof("Bootstrapped orchestration index, recorded %d of %d orchestrations", recorded, len(entries))
+	return nil
+}
+
+// ReplayInOrder reconstructs the index from all changes replayed by the event source, e.g. to populate a cold store
+// from the full stream. Unlike Bootstrap, changes are recorded one by one in sequence order and are not subject to
+// transition validation, so that the final state is reached even if the replayed history contains transitions
+// validation would reject. Changes processed afterward are validated again. Changes that cannot be decoded are skipped.
+func (w *OrchestrationIndexWatcher) ReplayInOrder(ctx context.Context, events EventSource) error {
+	var replayed []OrchestrationEvent
+	for event, err := range events.Replay(ctx) {
+		if err != nil {
+			return fmt.Errorf("error replaying orchestration changes: %w", err)
+		}
+		replayed = append(replayed, event)
+	}
+	// Changes without a sequence keep their replay order
+	slices.SortStableFunc(replayed, func(a, b OrchestrationEvent) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+
+	recorded, skipped := 0, 0
+	for _, event := range replayed {
+		decoded, err := w.decode(event.Data, event.Header)
+		if err != nil {
+			skipped++
+			continue
+		}
+		entry := createEntry(decoded.Orchestration)
+		entry.Revision = event.Sequence
+		var existing *api.OrchestrationEntry
+		err = w.trxContext.Execute(withReplay(api.WithOrchestration(ctx, entry)), func(ctx context.Context) error {
+			var err error
+			existing, err = w.record(ctx, entry)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error replaying change %d of orchestration %s: %w", event.Sequence, entry.ID, err)
+		}
+		if isRecorded(entry, existing, w.clockSkewTolerance) {
+			recorded++
+		}
+	}
+	if skipped > 0 {
+		w.monitor.Warnf("Skipped %d orchestration changes that could not be decoded while replaying", skipped)
+	}
+	w.monitor.Infof("Replayed orchestration index, recorded %d of %d changes", recorded, len(replayed))
+	return nil
+}
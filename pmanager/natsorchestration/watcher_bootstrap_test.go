@@ -0,0 +1,125 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrap_OnlyLatestChangePerOrchestrationApplied(t *testing.T) {
+	ctx := context.Background()
+	event := func(id string, state api.OrchestrationState, sequence uint64) OrchestrationEvent {
+		data, err := json.Marshal(createWatcherOrchestration(id, "corr-"+id, state))
+		require.NoError(t, err)
+		return OrchestrationEvent{Data: data, Sequence: sequence}
+	}
+	index := &writeCountingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	err := watcher.Bootstrap(ctx, sliceEventSource{
+		event("orch-1", api.OrchestrationStateInitialized, 1),
+		event("orch-2", api.OrchestrationStateRunning, 2),
+		event("orch-1", api.OrchestrationStateRunning, 3),
+		event("orch-2", api.OrchestrationStateCompleted, 4),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, index.creates, "only the latest change of each orchestration must be applied")
+	assert.Equal(t, 0, index.updates)
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	assert.Equal(t, uint64(3), entry.Revision)
+	entry, err = index.FindByID(ctx, "orch-2")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+
+	// The live consumer delivers the bootstrapped change again
+	live := event("orch-1", api.OrchestrationStateRunning, 3)
+	msg := newDLQMessage("$KV.bucket.orch-1", 3, string(live.Data), nil)
+	watcher.onMessage(live.Data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, index.updates, "changes recorded while bootstrapping must be skipped")
+}
+
+func TestBootstrap_ReplayErrorReturned(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+
+	err := watcher.Bootstrap(context.Background(), failingEventSource{err: assert.AnError})
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+// writeCountingIndex counts the entries written to the index.
+type writeCountingIndex struct {
+	*memorystore.OrchestrationIndex
+	creates int
+	updates int
+}
+
+func (i *writeCountingIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	i.creates++
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *writeCountingIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	i.updates++
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+func TestReplayInOrder_IllegalTransitionApplied(t *testing.T) {
+	ctx := context.Background()
+	event := func(state api.OrchestrationState, sequence uint64) OrchestrationEvent {
+		data, err := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		require.NoError(t, err)
+		return OrchestrationEvent{Data: data, Sequence: sequence}
+	}
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.validateTransitions = true
+
+	events := sliceEventSource{
+		// Compensating orchestrations cannot be resumed, which validation rejects for live changes
+		event(api.OrchestrationStateRunning, 4),
+		event(api.OrchestrationStateInitialized, 1),
+		event(api.OrchestrationStateCompensating, 3),
+		event(api.OrchestrationStateRunning, 2),
+		{Data: []byte("not an orchestration"), Sequence: 5},
+	}
+	require.NoError(t, watcher.ReplayInOrder(ctx, events))
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	assert.Equal(t, uint64(4), entry.Revision)
+
+	// Validation applies again to live changes
+	data, err := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized))
+	require.NoError(t, err)
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls, "illegal transitions are dead-lettered")
+	entry, err = index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
@@ -0,0 +1,109 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DeliverPolicy determines where the orchestration index watcher starts reading the orchestration KV stream.
+type DeliverPolicy string
+
+const (
+	// DeliverNew only delivers changes made after the consumer is created. This is the default.
+	DeliverNew DeliverPolicy = "new"
+	// DeliverAll delivers the complete history of the KV stream, e.g. to rebuild the index.
+	DeliverAll DeliverPolicy = "all"
+	// DeliverLast starts with the last change recorded in the KV stream.
+	DeliverLast DeliverPolicy = "last"
+	// DeliverLastPerSubject starts with the latest state of every orchestration.
+	DeliverLastPerSubject DeliverPolicy = "lastPerSubject"
+	// DeliverByStartSequence starts at the stream sequence set in WatcherConfig.StartSequence.
+	DeliverByStartSequence DeliverPolicy = "sequence"
+
+	defaultWatcherDurable = "orchestration-index"
+)
+
+// WatcherConfig configures the JetStream consumer the OrchestrationIndexWatcher reads orchestration changes from.
+//
+// Note the deliver policy of an existing durable consumer cannot be changed. To switch policies, e.g. to rebuild the
+// index with DeliverAll, the consumer must be deleted first.
+type WatcherConfig struct {
+	// Durable names the consumer. Watchers configured with the same durable share its work queue: each change is
+	// delivered to one of them and the load is balanced across instances. Defaults to "orchestration-index".
+	Durable       string
+	DeliverPolicy DeliverPolicy
+	StartSequence uint64
+	// MaxWaiting is the maximum number of pending fetch requests of the consumer. Defaults to the server default.
+	MaxWaiting int
+}
+
+// ParseDeliverPolicy converts a configuration value to a DeliverPolicy. An empty value defaults to DeliverNew.
+func ParseDeliverPolicy(value string) (DeliverPolicy, error) {
+	switch strings.ToLower(value) {
+	case "", string(DeliverNew):
+		return DeliverNew, nil
+	case string(DeliverAll):
+		return DeliverAll, nil
+	case string(DeliverLast):
+		return DeliverLast, nil
+	case strings.ToLower(string(DeliverLastPerSubject)):
+		return DeliverLastPerSubject, nil
+	case string(DeliverByStartSequence):
+		return DeliverByStartSequence, nil
+	default:
+		return "", fmt.Errorf("invalid watcher deliver policy: %s", value)
+	}
+}
+
+// kvStreamName returns the name of the stream backing the given KV bucket.
+func kvStreamName(bucket string) string {
+	return "KV_" + bucket
+}
+
+// newWatcherConsumerConfig creates the durable consumer configuration for watching the given KV bucket.
+func newWatcherConsumerConfig(bucket string, config WatcherConfig) (jetstream.ConsumerConfig, error) {
+	durable := config.Durable
+	if durable == "" {
+		durable = defaultWatcherDurable
+	}
+	consumerConfig := jetstream.ConsumerConfig{
+		Durable:       durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: "$KV." + bucket + ".>",
+		MaxWaiting:    config.MaxWaiting,
+	}
+
+	switch config.DeliverPolicy {
+	case "", DeliverNew:
+		consumerConfig.DeliverPolicy = jetstream.DeliverNewPolicy
+	case DeliverAll:
+		consumerConfig.DeliverPolicy = jetstream.DeliverAllPolicy
+	case DeliverLast:
+		consumerConfig.DeliverPolicy = jetstream.DeliverLastPolicy
+	case DeliverLastPerSubject:
+		consumerConfig.DeliverPolicy = jetstream.DeliverLastPerSubjectPolicy
+	case DeliverByStartSequence:
+		if config.StartSequence == 0 {
+			return jetstream.ConsumerConfig{}, fmt.Errorf("watcher deliver policy %s requires a start sequence", config.DeliverPolicy)
+		}
+		consumerConfig.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		consumerConfig.OptStartSeq = config.StartSequence
+	default:
+		return jetstream.ConsumerConfig{}, fmt.Errorf("invalid watcher deliver policy: %s", config.DeliverPolicy)
+	}
+	return consumerConfig, nil
+}
@@ -0,0 +1,139 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Each deliver policy maps to the corresponding NATS consumer setting
+func TestNewWatcherConsumerConfig_DeliverPolicyMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   WatcherConfig
+		expected jetstream.DeliverPolicy
+		startSeq uint64
+	}{
+		{"default", WatcherConfig{}, jetstream.DeliverNewPolicy, 0},
+		{"new", WatcherConfig{DeliverPolicy: DeliverNew}, jetstream.DeliverNewPolicy, 0},
+		{"all", WatcherConfig{DeliverPolicy: DeliverAll}, jetstream.DeliverAllPolicy, 0},
+		{"last", WatcherConfig{DeliverPolicy: DeliverLast}, jetstream.DeliverLastPolicy, 0},
+		{"last per subject", WatcherConfig{DeliverPolicy: DeliverLastPerSubject}, jetstream.DeliverLastPerSubjectPolicy, 0},
+		{"start sequence", WatcherConfig{DeliverPolicy: DeliverByStartSequence, StartSequence: 42}, jetstream.DeliverByStartSequencePolicy, 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newWatcherConsumerConfig("test-bucket", tt.config)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg.DeliverPolicy)
+			assert.Equal(t, tt.startSeq, cfg.OptStartSeq)
+			assert.Equal(t, "$KV.test-bucket.>", cfg.FilterSubject)
+			assert.Equal(t, jetstream.AckExplicitPolicy, cfg.AckPolicy)
+			assert.Equal(t, defaultWatcherDurable, cfg.Durable)
+		})
+	}
+}
+
+func TestNewWatcherConsumerConfig_MaxWaiting(t *testing.T) {
+	cfg, err := newWatcherConsumerConfig("test-bucket", WatcherConfig{MaxWaiting: 16})
+
+	require.NoError(t, err)
+	assert.Equal(t, 16, cfg.MaxWaiting)
+}
+
+func TestProcessLoop_FetchBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := &changeRecordingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.fetchBatch = 2
+
+	consumer := &queueConsumer{}
+	consumer.push(
+		newPriorityMsg(t, "orch-1", api.OrchestrationStateRunning, 0),
+		newPriorityMsg(t, "orch-2", api.OrchestrationStateRunning, 0),
+		newPriorityMsg(t, "orch-3", api.OrchestrationStateRunning, 0),
+	)
+	go func() { _ = watcher.processLoop(ctx, consumer) }()
+
+	require.Eventually(t, func() bool { return len(index.changes()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"orch-1:1", "orch-2:1", "orch-3:1"}, index.changes())
+	cancel()
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+	require.NotEmpty(t, consumer.batches)
+	for _, batch := range consumer.batches {
+		assert.Equal(t, 2, batch)
+	}
+}
+
+// Sequence delivery requires a start sequence
+func TestNewWatcherConsumerConfig_SequenceWithoutStart(t *testing.T) {
+	_, err := newWatcherConsumerConfig("test-bucket", WatcherConfig{DeliverPolicy: DeliverByStartSequence})
+
+	assert.Error(t, err)
+}
+
+// Unknown policies are rejected
+func TestNewWatcherConsumerConfig_InvalidPolicy(t *testing.T) {
+	_, err := newWatcherConsumerConfig("test-bucket", WatcherConfig{DeliverPolicy: "bogus"})
+
+	assert.Error(t, err)
+}
+
+func TestParseDeliverPolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected DeliverPolicy
+	}{
+		{"", DeliverNew},
+		{"new", DeliverNew},
+		{"ALL", DeliverAll},
+		{"last", DeliverLast},
+		{"lastPerSubject", DeliverLastPerSubject},
+		{"sequence", DeliverByStartSequence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			policy, err := ParseDeliverPolicy(tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+
+	_, err := ParseDeliverPolicy("bogus")
+	assert.Error(t, err)
+}
+
+// Watchers sharing a durable name share the consumer
+func TestNewWatcherConsumerConfig_SharedDurable(t *testing.T) {
+	first, err := newWatcherConsumerConfig("test-bucket", WatcherConfig{Durable: "shared-index"})
+	require.NoError(t, err)
+	second, err := newWatcherConsumerConfig("test-bucket", WatcherConfig{Durable: "shared-index"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "shared-index", first.Durable)
+	assert.Equal(t, first, second, "instances must provision identical consumers to share the work queue")
+}
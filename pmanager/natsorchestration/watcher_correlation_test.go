@@ -0,0 +1,86 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_UniqueCorrelationPerType(t *testing.T) {
+	tests := []struct {
+		name    string
+		enforce bool
+		created []string
+	}{
+		{name: "enforced", enforce: true, created: []string{"orch-1", "orch-3"}},
+		{name: "not enforced", enforce: false, created: []string{"orch-1", "orch-2", "orch-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := memorystore.NewOrchestrationIndex()
+			watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+			watcher.enforceUniqueCorrelationPerType = tt.enforce
+
+			duplicate := createWatcherOrchestration("orch-2", "corr-1", api.OrchestrationStateRunning)
+			otherType := createWatcherOrchestration("orch-3", "corr-1", api.OrchestrationStateRunning)
+			otherType.OrchestrationType = "OtherType"
+			for _, orch := range []api.Orchestration{
+				createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning),
+				duplicate,
+				otherType,
+				// Changes of the existing orchestration are not duplicates
+				createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted),
+			} {
+				data, _ := json.Marshal(orch)
+				msg := NewMockMessage(data)
+				watcher.onMessage(data, msg)
+				require.Equal(t, 1, msg.AckCalls)
+				require.Equal(t, 0, msg.NakCalls)
+			}
+
+			var created []string
+			for _, id := range []string{"orch-1", "orch-2", "orch-3"} {
+				if exists, err := index.Exists(context.Background(), id); err == nil && exists {
+					created = append(created, id)
+				}
+			}
+			assert.Equal(t, tt.created, created)
+
+			state, _, err := index.FindStateByID(context.Background(), "orch-1")
+			require.NoError(t, err)
+			assert.Equal(t, api.OrchestrationStateCompleted, state)
+		})
+	}
+}
+
+func TestCheckUniqueCorrelation(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	ctx := context.Background()
+	_, err := index.Create(ctx, createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)))
+	require.NoError(t, err)
+
+	duplicate := createEntry(createWatcherOrchestration("orch-2", "corr-1", api.OrchestrationStateRunning))
+	assert.ErrorIs(t, api.CheckUniqueCorrelation(ctx, index, duplicate), types.ErrAlreadyExists)
+
+	existing := createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	assert.NoError(t, api.CheckUniqueCorrelation(ctx, index, existing))
+}
@@ -0,0 +1,144 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// debouncer coalesces changes submitted for the same key within a window so that only the latest is recorded. The
+// window starts with the first change of a key and is not extended by later changes, so that a continuous stream of
+// changes is still recorded once per window. Changes are recorded one at a time.
+type debouncer struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*debouncedChange
+	// recordMu serializes recording, changes of different keys may be due at the same time
+	recordMu sync.Mutex
+}
+
+type debouncedChange struct {
+	msg    MessageAck
+	order  changeOrder
+	record func()
+	timer  *time.Timer
+}
+
+// changeOrder orders changes of the same key, which may arrive out of order when they are redelivered.
+type changeOrder struct {
+	// revision is the stream sequence of the change, zero if unknown
+	revision uint64
+	// timestamp is the state timestamp of the change, used if the revision of either change is unknown
+	timestamp time.Time
+}
+
+// before returns true if the change is older than other. Changes that cannot be ordered are considered in arrival
+// order, i.e. the later arriving change is kept.
+func (o changeOrder) before(other changeOrder) bool {
+	if o.revision > 0 && other.revision > 0 {
+		return o.revision < other.revision
+	}
+	return !o.timestamp.After(other.timestamp)
+}
+
+func newDebouncer(window time.Duration) *debouncer {
+	return &debouncer{window: window, pending: make(map[string]*debouncedChange)}
+}
+
+// checkAckWait returns an error if the window is not shorter than the ack wait of the consumer, in which case deferred
+// messages would be redelivered before they are recorded.
+func (d *debouncer) checkAckWait(ackWait time.Duration) error {
+	if d.window >= ackWait {
+		return fmt.Errorf("debounce window %s must be shorter than the consumer ack wait %s", d.window, ackWait)
+	}
+	return nil
+}
+
+// submit schedules record to run for the key once the window elapses. If a change is already pending for the key, the
+// newer of both changes is kept and the message of the superseded change is returned, which is msg itself if the
+// pending change is newer.
+func (d *debouncer) submit(key string, msg MessageAck, order changeOrder, record func()) MessageAck {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if change, found := d.pending[key]; found {
+		if !change.order.before(order) {
+			return msg
+		}
+		superseded := change.msg
+		change.msg, change.order, change.record = msg, order, record
+		return superseded
+	}
+	change := &debouncedChange{msg: msg, order: order, record: record}
+	d.pending[key] = change
+	change.timer = time.AfterFunc(d.window, func() { d.fire(key, change) })
+	return nil
+}
+
+func (d *debouncer) fire(key string, change *debouncedChange) {
+	d.mu.Lock()
+	if d.pending[key] != change {
+		// Already flushed
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	record := change.record
+	d.mu.Unlock()
+
+	d.recordMu.Lock()
+	defer d.recordMu.Unlock()
+	record()
+}
+
+// flush records all pending changes without waiting for their window to elapse.
+func (d *debouncer) flush() {
+	d.mu.Lock()
+	records := make([]func(), 0, len(d.pending))
+	for key, change := range d.pending {
+		change.timer.Stop()
+		records = append(records, change.record)
+		delete(d.pending, key)
+	}
+	d.mu.Unlock()
+
+	d.recordMu.Lock()
+	defer d.recordMu.Unlock()
+	for _, record := range records {
+		record()
+	}
+}
+
+// debounce defers recording the decoded change until the debounce window of its orchestration elapses. A change
+// superseded by a newer change of the same orchestration is acknowledged without being recorded. Changes are ordered
+// by their stream sequence, falling back to the state timestamp for messages not read from a stream. The window is
+// checked against the ack wait of the consumer on startup, see debouncer.checkAckWait.
+func (w *OrchestrationIndexWatcher) debounce(data []byte, decoded DecodedMessage, msg MessageAck) {
+	order := changeOrder{timestamp: decoded.Orchestration.StateTimestamp}
+	if sequenced, ok := msg.(sequencedMessage); ok {
+		order.revision = sequenced.StreamSequence()
+	}
+	superseded := w.debouncer.submit(decoded.Orchestration.ID, msg, order, func() {
+		var orchestrationID string
+		defer w.recoverPanic(msg, &orchestrationID)
+		w.recordDecoded(data, decoded, msg, &orchestrationID)
+	})
+	if superseded == nil {
+		return
+	}
+	if err := w.ack(superseded); err != nil {
+		w.monitor.Infof("Failed to ack superseded message for orchestration %s: %v", decoded.Orchestration.ID, err)
+	}
+}
@@ -0,0 +1,157 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Debounce_RecordsLatestChange(t *testing.T) {
+	index := &updateCountingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	_, err := index.Create(context.Background(),
+		createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)))
+	require.NoError(t, err)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.debouncer = newDebouncer(50 * time.Millisecond)
+
+	var messages []*MockMessage
+	for _, state := range []api.OrchestrationState{
+		api.OrchestrationStateRunning,
+		api.OrchestrationStateCompensating,
+		api.OrchestrationStateCompleted,
+	} {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		msg := NewMockMessage(data)
+		messages = append(messages, msg)
+		watcher.onMessage(data, msg)
+	}
+
+	assert.Equal(t, 1, messages[0].AckCalls, "superseded changes are acked immediately")
+	assert.Equal(t, 1, messages[1].AckCalls)
+	assert.Equal(t, 0, index.updateCount(), "the latest change is deferred")
+
+	require.Eventually(t, func() bool { return index.updateCount() == 1 }, time.Second, time.Millisecond)
+	// Wait until recording has completed
+	watcher.debouncer.recordMu.Lock()
+	watcher.debouncer.recordMu.Unlock()
+	assert.Equal(t, 1, messages[2].AckCalls)
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, index.updateCount(), "superseded changes must not be recorded")
+}
+
+func TestOnMessage_Debounce_KeepsNewerRevision(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.debouncer = newDebouncer(time.Hour)
+
+	newer, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	newerMsg := newDLQMessage("", 5, string(newer), nil)
+	watcher.onMessage(newer, newerMsg)
+
+	// A redelivered older change arriving later must not replace the pending newer change
+	older, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	olderMsg := newDLQMessage("", 4, string(older), nil)
+	watcher.onMessage(older, olderMsg)
+
+	assert.Equal(t, 1, olderMsg.AckCalls, "the older change is acked without being recorded")
+	assert.Equal(t, 0, newerMsg.AckCalls)
+
+	watcher.finishDrain()
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+	assert.Equal(t, uint64(5), entry.Revision)
+	assert.Equal(t, 1, newerMsg.AckCalls)
+}
+
+func TestOnMessage_Debounce_KeepsNewerTimestamp(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.debouncer = newDebouncer(time.Hour)
+
+	newer := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	older := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	older.StateTimestamp = newer.StateTimestamp.Add(-time.Second)
+
+	newerData, _ := json.Marshal(newer)
+	newerMsg := NewMockMessage(newerData)
+	watcher.onMessage(newerData, newerMsg)
+	olderData, _ := json.Marshal(older)
+	olderMsg := NewMockMessage(olderData)
+	watcher.onMessage(olderData, olderMsg)
+
+	assert.Equal(t, 1, olderMsg.AckCalls, "changes without revision are ordered by their state timestamp")
+	watcher.finishDrain()
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+}
+
+func TestOnMessage_Debounce_FlushedOnDrain(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.debouncer = newDebouncer(time.Hour)
+
+	for _, id := range []string{"orch-1", "orch-2"} {
+		data, _ := json.Marshal(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateRunning))
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+	watcher.finishDrain()
+
+	for _, id := range []string{"orch-1", "orch-2"} {
+		entry, err := index.FindByID(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	}
+}
+
+// updateCountingIndex counts updates of entries.
+type updateCountingIndex struct {
+	*memorystore.OrchestrationIndex
+	mu      sync.Mutex
+	updates int
+}
+
+func (i *updateCountingIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	i.mu.Lock()
+	i.updates++
+	i.mu.Unlock()
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+func (i *updateCountingIndex) updateCount() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.updates
+}
+
+func TestDebouncer_CheckAckWait(t *testing.T) {
+	debouncer := newDebouncer(time.Second)
+
+	require.NoError(t, debouncer.checkAckWait(30*time.Second))
+	require.Error(t, debouncer.checkAckWait(time.Second))
+	require.Error(t, debouncer.checkAckWait(500*time.Millisecond))
+}
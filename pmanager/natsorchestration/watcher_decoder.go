@@ -0,0 +1,260 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	CloudEventsJSONContentType     = "application/cloudevents+json"
+	CloudEventsProtobufContentType = "application/cloudevents+protobuf"
+)
+
+// DecodedMessage is an orchestration change decoded from a message, together with the message headers. Decoders may
+// add headers derived from the message envelope, e.g. the CloudEvents id is mapped to the MsgIDHeader.
+type DecodedMessage struct {
+	Orchestration api.Orchestration
+	Header        nats.Header
+
+	// Source is the name of the source the message was received from, see Source. Set by the watcher, empty for the
+	// default source.
+	Source string
+}
+
+// MessageDecoder decodes the orchestration carried by a message. Decoding errors wrap errMalformedMessage.
+type MessageDecoder interface {
+	Decode(data []byte, header nats.Header) (DecodedMessage, error)
+}
+
+// JSONDecoder decodes messages whose payload is a JSON-serialized api.Orchestration. Fields unknown to this version
+// are ignored so that watchers tolerate changes published using a newer schema.
+type JSONDecoder struct {
+	// Strict rejects payloads with unknown fields as malformed.
+	Strict bool
+}
+
+func (d JSONDecoder) Decode(data []byte, header nats.Header) (DecodedMessage, error) {
+	orchestration, err := decodeOrchestration(data, d.Strict)
+	if err != nil {
+		return DecodedMessage{}, err
+	}
+	return DecodedMessage{Orchestration: orchestration, Header: header}, nil
+}
+
+// CloudEventFormat is the event format used to encode a CloudEvents envelope.
+type CloudEventFormat string
+
+const (
+	// CloudEventFormatNone only unwraps messages that declare a CloudEvents Content-Type header.
+	CloudEventFormatNone CloudEventFormat = ""
+	// CloudEventFormatJSON treats messages without a Content-Type header as JSON-encoded CloudEvents.
+	CloudEventFormatJSON CloudEventFormat = "json"
+	// CloudEventFormatProtobuf treats messages without a Content-Type header as Protobuf-encoded CloudEvents.
+	CloudEventFormatProtobuf CloudEventFormat = "protobuf"
+)
+
+// ParseCloudEventFormat converts a configuration value to a CloudEventFormat.
+func ParseCloudEventFormat(value string) (CloudEventFormat, error) {
+	switch strings.ToLower(value) {
+	case "", "none":
+		return CloudEventFormatNone, nil
+	case string(CloudEventFormatJSON):
+		return CloudEventFormatJSON, nil
+	case string(CloudEventFormatProtobuf):
+		return CloudEventFormatProtobuf, nil
+	default:
+		return "", fmt.Errorf("invalid CloudEvents format: %s", value)
+	}
+}
+
+// CloudEventsDecoder unwraps orchestrations published in a structured-mode CloudEvents envelope. The envelope format
+// is selected by the Content-Type header, falling back to DefaultFormat for messages without one. Messages that are
+// not CloudEvents are decoded directly as JSON.
+//
+// The CloudEvents id is mapped to the MsgIDHeader and a non-empty type overrides the orchestration type.
+type CloudEventsDecoder struct {
+	DefaultFormat CloudEventFormat
+
+	// Strict rejects orchestrations with unknown fields as malformed. Unknown envelope attributes are always ignored
+	// since they are CloudEvents extensions.
+	Strict bool
+}
+
+// cloudEvent holds the envelope attributes and data used by the decoder.
+type cloudEvent struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+func (d CloudEventsDecoder) Decode(data []byte, header nats.Header) (DecodedMessage, error) {
+	var event cloudEvent
+	var err error
+	switch d.format(header) {
+	case CloudEventFormatJSON:
+		event, err = decodeJSONCloudEvent(data)
+	case CloudEventFormatProtobuf:
+		event, err = decodeProtobufCloudEvent(data)
+	default:
+		return JSONDecoder{Strict: d.Strict}.Decode(data, header)
+	}
+	if err != nil {
+		return DecodedMessage{}, fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+
+	orchestration, err := decodeOrchestration(event.Data, d.Strict)
+	if err != nil {
+		return DecodedMessage{}, err
+	}
+	if event.Type != "" {
+		orchestration.OrchestrationType = model.OrchestrationType(event.Type)
+	}
+
+	mapped := nats.Header{}
+	for key, values := range header {
+		mapped[key] = values
+	}
+	MessageHeaders(mapped).SetMsgID(event.ID)
+	return DecodedMessage{Orchestration: orchestration, Header: mapped}, nil
+}
+
+func (d CloudEventsDecoder) format(header nats.Header) CloudEventFormat {
+	contentType := MessageHeaders(header).GetContentType()
+	if contentType == "" {
+		return d.DefaultFormat
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return CloudEventFormatNone
+	}
+	switch mediaType {
+	case CloudEventsJSONContentType:
+		return CloudEventFormatJSON
+	case CloudEventsProtobufContentType:
+		return CloudEventFormatProtobuf
+	default:
+		return CloudEventFormatNone
+	}
+}
+
+// decodeJSONCloudEvent decodes an event in the CloudEvents JSON format.
+func decodeJSONCloudEvent(data []byte) (cloudEvent, error) {
+	var envelope struct {
+		SpecVersion string          `json:"specversion"`
+		ID          string          `json:"id"`
+		Type        string          `json:"type"`
+		Data        json.RawMessage `json:"data"`
+		DataBase64  string          `json:"data_base64"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return cloudEvent{}, err
+	}
+	event := cloudEvent{ID: envelope.ID, Type: envelope.Type}
+	switch {
+	case envelope.DataBase64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(envelope.DataBase64)
+		if err != nil {
+			return cloudEvent{}, fmt.Errorf("invalid data_base64: %w", err)
+		}
+		event.Data = decoded
+	case len(envelope.Data) > 0 && envelope.Data[0] == '"':
+		// JSON string data carries the serialized orchestration
+		var text string
+		if err := json.Unmarshal(envelope.Data, &text); err != nil {
+			return cloudEvent{}, err
+		}
+		event.Data = []byte(text)
+	default:
+		event.Data = envelope.Data
+	}
+	return event, validateCloudEvent(event, envelope.SpecVersion)
+}
+
+// CloudEvent field numbers as defined by the CloudEvents Protobuf format (io.cloudevents.v1.CloudEvent).
+const (
+	cloudEventIDField          protowire.Number = 1
+	cloudEventSourceField      protowire.Number = 2
+	cloudEventSpecVersionField protowire.Number = 3
+	cloudEventTypeField        protowire.Number = 4
+	cloudEventAttributesField  protowire.Number = 5
+	cloudEventBinaryDataField  protowire.Number = 6
+	cloudEventTextDataField    protowire.Number = 7
+	cloudEventProtoDataField   protowire.Number = 8
+)
+
+// decodeProtobufCloudEvent decodes an event in the CloudEvents Protobuf format. Extension attributes are ignored and
+// google.protobuf.Any data is not supported since the orchestration is JSON.
+func decodeProtobufCloudEvent(data []byte) (cloudEvent, error) {
+	var event cloudEvent
+	var specVersion string
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return cloudEvent{}, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if wireType != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+			if n < 0 {
+				return cloudEvent{}, protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return cloudEvent{}, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch number {
+		case cloudEventIDField:
+			event.ID = string(value)
+		case cloudEventSpecVersionField:
+			specVersion = string(value)
+		case cloudEventTypeField:
+			event.Type = string(value)
+		case cloudEventBinaryDataField, cloudEventTextDataField:
+			event.Data = value
+		case cloudEventProtoDataField:
+			return cloudEvent{}, fmt.Errorf("protobuf event data is not supported")
+		case cloudEventSourceField, cloudEventAttributesField:
+			// not used
+		}
+	}
+	return event, validateCloudEvent(event, specVersion)
+}
+
+func validateCloudEvent(event cloudEvent, specVersion string) error {
+	if specVersion == "" {
+		return fmt.Errorf("missing CloudEvents specversion")
+	}
+	if event.ID == "" {
+		return fmt.Errorf("missing CloudEvents id")
+	}
+	if len(event.Data) == 0 {
+		return fmt.Errorf("missing CloudEvents data")
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestOnMessage_CloudEventsWrappedEntry(t *testing.T) {
+	index := createTestStore(t)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.decoder = CloudEventsDecoder{}
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data := protobufCloudEvent(t, "event-1", "provision", orch)
+	msg := NewMockMessage(data)
+
+	watcher.onHeaderMessage(data, nats.Header{ContentTypeHeader: []string{CloudEventsProtobufContentType}}, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "corr-1", entry.CorrelationID)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	assert.Equal(t, model.OrchestrationType("provision"), entry.OrchestrationType)
+}
+
+func TestCloudEventsDecoder_Decode(t *testing.T) {
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	plain, err := json.Marshal(orch)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		decoder       CloudEventsDecoder
+		data          []byte
+		header        nats.Header
+		expectedType  model.OrchestrationType
+		expectedMsgID string
+	}{
+		{
+			name:          "protobuf by content type",
+			data:          protobufCloudEvent(t, "event-1", "provision", orch),
+			header:        nats.Header{ContentTypeHeader: []string{CloudEventsProtobufContentType}},
+			expectedType:  "provision",
+			expectedMsgID: "event-1",
+		},
+		{
+			name:          "json by content type",
+			data:          jsonCloudEvent(t, map[string]any{"specversion": "1.0", "id": "event-2", "type": "deprovision", "data": orch}),
+			header:        nats.Header{ContentTypeHeader: []string{CloudEventsJSONContentType + "; charset=utf-8"}},
+			expectedType:  "deprovision",
+			expectedMsgID: "event-2",
+		},
+		{
+			name:          "json with base64 data",
+			data:          jsonCloudEvent(t, map[string]any{"specversion": "1.0", "id": "event-3", "data_base64": base64.StdEncoding.EncodeToString(plain)}),
+			header:        nats.Header{ContentTypeHeader: []string{CloudEventsJSONContentType}},
+			expectedType:  orch.OrchestrationType,
+			expectedMsgID: "event-3",
+		},
+		{
+			name:          "protobuf by configured default format",
+			decoder:       CloudEventsDecoder{DefaultFormat: CloudEventFormatProtobuf},
+			data:          protobufCloudEvent(t, "event-4", "", orch),
+			expectedType:  orch.OrchestrationType,
+			expectedMsgID: "event-4",
+		},
+		{
+			name:         "plain message decodes directly",
+			data:         plain,
+			expectedType: orch.OrchestrationType,
+		},
+		{
+			name:         "plain message with other content type decodes directly",
+			decoder:      CloudEventsDecoder{DefaultFormat: CloudEventFormatProtobuf},
+			data:         plain,
+			header:       nats.Header{ContentTypeHeader: []string{"application/json"}},
+			expectedType: orch.OrchestrationType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := tt.decoder.Decode(tt.data, tt.header)
+			require.NoError(t, err)
+			assert.Equal(t, "orch-1", decoded.Orchestration.ID)
+			assert.Equal(t, "corr-1", decoded.Orchestration.CorrelationID)
+			assert.Equal(t, tt.expectedType, decoded.Orchestration.OrchestrationType)
+			assert.Equal(t, tt.expectedMsgID, decoded.Header.Get(nats.MsgIdHdr))
+		})
+	}
+}
+
+func TestCloudEventsDecoder_Decode_Malformed(t *testing.T) {
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	protobufHeader := nats.Header{ContentTypeHeader: []string{CloudEventsProtobufContentType}}
+	jsonHeader := nats.Header{ContentTypeHeader: []string{CloudEventsJSONContentType}}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		header nats.Header
+	}{
+		{"truncated protobuf", protobufCloudEvent(t, "event-1", "provision", orch)[:10], protobufHeader},
+		{"protobuf without id", protobufCloudEvent(t, "", "provision", orch), protobufHeader},
+		{"protobuf data", protowire.AppendBytes(protowire.AppendTag(nil, cloudEventProtoDataField, protowire.BytesType), []byte{1}), protobufHeader},
+		{"json without data", jsonCloudEvent(t, map[string]any{"specversion": "1.0", "id": "event-1"}), jsonHeader},
+		{"json without specversion", jsonCloudEvent(t, map[string]any{"id": "event-1", "data": orch}), jsonHeader},
+		{"json invalid base64", jsonCloudEvent(t, map[string]any{"specversion": "1.0", "id": "event-1", "data_base64": "%%"}), jsonHeader},
+		{"json with malformed orchestration", jsonCloudEvent(t, map[string]any{"specversion": "1.0", "id": "event-1", "data": "{"}), jsonHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CloudEventsDecoder{}.Decode(tt.data, tt.header)
+			require.ErrorIs(t, err, errMalformedMessage)
+			assert.Equal(t, ActionDeadLetter, decideAction(nil, nil, err, 0))
+		})
+	}
+}
+
+func TestJSONDecoder_UnknownFields(t *testing.T) {
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, err := json.Marshal(orch)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	// Published by a newer version adding a field
+	fields["priorityClass"] = "gold"
+	extended, err := json.Marshal(fields)
+	require.NoError(t, err)
+
+	decoded, err := JSONDecoder{}.Decode(extended, nil)
+	require.NoError(t, err, "unknown fields are ignored by default")
+	assert.Equal(t, "orch-1", decoded.Orchestration.ID)
+
+	_, err = JSONDecoder{Strict: true}.Decode(extended, nil)
+	assert.ErrorIs(t, err, errMalformedMessage)
+	assert.ErrorContains(t, err, "priorityClass")
+
+	decoded, err = JSONDecoder{Strict: true}.Decode(data, nil)
+	require.NoError(t, err, "payloads without unknown fields are accepted in strict mode")
+	assert.Equal(t, "orch-1", decoded.Orchestration.ID)
+
+	_, err = JSONDecoder{Strict: true}.Decode(append(data, []byte(`{}`)...), nil)
+	assert.ErrorIs(t, err, errMalformedMessage)
+
+	wrapped, err := json.Marshal(map[string]any{"specversion": "1.0", "id": "event-1", "source": "test", "type": "provision",
+		"data": json.RawMessage(extended)})
+	require.NoError(t, err)
+	header := nats.Header{ContentTypeHeader: []string{CloudEventsJSONContentType}}
+	_, err = CloudEventsDecoder{}.Decode(wrapped, header)
+	assert.NoError(t, err)
+	_, err = CloudEventsDecoder{Strict: true}.Decode(wrapped, header)
+	assert.ErrorIs(t, err, errMalformedMessage)
+}
+
+func TestOnMessage_StrictDecode_UnknownFieldsMalformed(t *testing.T) {
+	index := createTestStore(t)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.decoder = JSONDecoder{Strict: true}
+	watcher.malformedPolicy = MalformedDrop
+
+	data := []byte(`{"id":"orch-1","correlationId":"corr-1","state":1,"priorityClass":"gold"}`)
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls, "malformed messages are dropped per the malformed policy")
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	watcher.decoder = JSONDecoder{}
+	msg = NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	_, err = index.FindByID(context.Background(), "orch-1")
+	assert.NoError(t, err, "unknown fields are ignored in lenient mode")
+}
+
+func TestParseCloudEventFormat(t *testing.T) {
+	for value, expected := range map[string]CloudEventFormat{
+		"":         CloudEventFormatNone,
+		"none":     CloudEventFormatNone,
+		"json":     CloudEventFormatJSON,
+		"Protobuf": CloudEventFormatProtobuf,
+	} {
+		format, err := ParseCloudEventFormat(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+	_, err := ParseCloudEventFormat("avro")
+	assert.Error(t, err)
+}
+
+// protobufCloudEvent encodes the orchestration as text data of a CloudEvents Protobuf event.
+func protobufCloudEvent(t *testing.T, id string, eventType string, orch api.Orchestration) []byte {
+	data, err := json.Marshal(orch)
+	require.NoError(t, err)
+
+	var event []byte
+	event = protowire.AppendTag(event, cloudEventIDField, protowire.BytesType)
+	event = protowire.AppendString(event, id)
+	event = protowire.AppendTag(event, cloudEventSourceField, protowire.BytesType)
+	event = protowire.AppendString(event, "/pmanager")
+	event = protowire.AppendTag(event, cloudEventSpecVersionField, protowire.BytesType)
+	event = protowire.AppendString(event, "1.0")
+	if eventType != "" {
+		event = protowire.AppendTag(event, cloudEventTypeField, protowire.BytesType)
+		event = protowire.AppendString(event, eventType)
+	}
+	event = protowire.AppendTag(event, cloudEventTextDataField, protowire.BytesType)
+	event = protowire.AppendBytes(event, data)
+	return event
+}
+
+func jsonCloudEvent(t *testing.T, envelope map[string]any) []byte {
+	data, err := json.Marshal(envelope)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,68 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+)
+
+// WatcherDependencies are the dependencies of an OrchestrationIndexWatcher created by NewWatcherWithDependencies.
+type WatcherDependencies struct {
+	Index      store.EntityStore[*api.OrchestrationEntry]
+	TrxContext store.TransactionContext
+	Monitor    system.LogMonitor
+
+	// Clock returns the current time. Defaults to time.Now.
+	Clock func() time.Time
+
+	// Metrics records the delivery attempts of acknowledged messages when set.
+	Metrics api.DeliveryMetrics
+
+	// Handlers receive the recorded entries when set. They are invoked once projections are run, see RunProjections.
+	Handlers *api.HandlerRegistry
+}
+
+// NewWatcherWithDependencies creates a watcher that is not connected to a consumer, e.g. to test code embedding the
+// watcher, see testsupport.NewTestWatcher. Changes are passed to ProcessMessage. All other settings have their zero
+// values: messages are not retried with a backoff, dead-lettered or forwarded. Production watchers are created by the
+// service assembly.
+func NewWatcherWithDependencies(deps WatcherDependencies) *OrchestrationIndexWatcher {
+	watcher := &OrchestrationIndexWatcher{
+		index:           deps.Index,
+		trxContext:      deps.TrxContext,
+		monitor:         deps.Monitor,
+		clock:           deps.Clock,
+		deliveryMetrics: deps.Metrics,
+	}
+	if watcher.trxContext == nil {
+		watcher.trxContext = &store.NoOpTransactionContext{}
+	}
+	if watcher.monitor == nil {
+		watcher.monitor = system.NoopMonitor{}
+	}
+	if deps.Handlers != nil {
+		watcher.RegisterProjector(deps.Handlers)
+	}
+	return watcher
+}
+
+// ProcessMessage records the orchestration change carried by the message and settles it as if it had been fetched from
+// the consumer.
+func (w *OrchestrationIndexWatcher) ProcessMessage(data []byte, header nats.Header, msg MessageAck) {
+	w.onHeaderMessage(data, header, msg)
+}
@@ -0,0 +1,213 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultMaxRedrives = 3
+	defaultRedriveWait = 500 * time.Millisecond
+	deadLetterDurable  = "cfm-dead-letter"
+)
+
+// deadLetterMessage is implemented by messages that can be copied to the DLQ.
+type deadLetterMessage interface {
+	Subject() string
+	Data() []byte
+	Headers() nats.Header
+	StreamSequence() uint64
+}
+
+// DeadLetterQueue publishes messages the watcher cannot process to the DLQ subject and redrives them into the main flow
+// once the cause is fixed.
+type DeadLetterQueue struct {
+	client   natsclient.MsgClient
+	consumer jetstream.Consumer
+	subject  string
+	monitor  system.LogMonitor
+
+	// MaxRedrives is the number of times a message is redriven. Messages exceeding it remain in the DLQ.
+	MaxRedrives int
+	// FetchWait is the time to wait for DLQ messages when redriving.
+	FetchWait time.Duration
+	// SampleRate is the fraction of unprocessable messages published to the DLQ, between 0 and 1. Messages that are not
+	// sampled are discarded and counted, see Discarded. Defaults to 1.
+	SampleRate float64
+
+	randomMu  sync.Mutex
+	random    *rand.Rand
+	discarded atomic.Int64
+}
+
+// NewDeadLetterQueue creates a DLQ publishing to the subject and redriving messages read from the consumer.
+func NewDeadLetterQueue(
+	client natsclient.MsgClient,
+	consumer jetstream.Consumer,
+	subject string,
+	monitor system.LogMonitor) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		client:      client,
+		consumer:    consumer,
+		subject:     subject,
+		monitor:     monitor,
+		MaxRedrives: defaultMaxRedrives,
+		FetchWait:   defaultRedriveWait,
+		SampleRate:  1,
+		random:      rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// Sample returns true if an unprocessable message is to be published to the DLQ according to SampleRate. Otherwise,
+// the message is counted as discarded.
+func (q *DeadLetterQueue) Sample() bool {
+	if q.SampleRate >= 1 {
+		return true
+	}
+	q.randomMu.Lock()
+	sampled := q.random.Float64() < q.SampleRate
+	q.randomMu.Unlock()
+	if !sampled {
+		q.discarded.Add(1)
+	}
+	return sampled
+}
+
+// Discarded returns the number of unprocessable messages that were not sampled for the DLQ.
+func (q *DeadLetterQueue) Discarded() int64 {
+	return q.discarded.Load()
+}
+
+// Publish copies the message to the DLQ, recording the cause and the original subject and sequence in headers.
+func (q *DeadLetterQueue) Publish(ctx context.Context, msg deadLetterMessage, cause error) error {
+	header := MessageHeaders{}
+	for key, values := range msg.Headers() {
+		header[key] = append([]string(nil), values...)
+	}
+	if cause != nil {
+		header.SetDeadLetterReason(cause.Error())
+	}
+	header.SetOriginalSubject(msg.Subject())
+	header.SetOriginalSequence(msg.StreamSequence())
+	if q.SampleRate < 1 {
+		header.SetSampleRate(q.SampleRate)
+	}
+
+	_, err := q.client.PublishMsg(ctx, &nats.Msg{Subject: q.subject, Data: msg.Data(), Header: nats.Header(header)})
+	return err
+}
+
+// RedriveDLQ republishes dead-lettered messages to their original subject, see api.DeadLetterQueue.
+//
+// Messages are republished with the expected last subject sequence set to the original sequence. If the subject has
+// changed since, e.g. because the orchestration progressed, the message is superseded and removed without being
+// redriven. Messages rejected by the filter or exceeding MaxRedrives are left in the DLQ. Running a redrive repeatedly
+// is safe: a message is only removed from the DLQ after it was republished or found to be superseded.
+func (q *DeadLetterQueue) RedriveDLQ(ctx context.Context, filter func(data []byte) bool, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, types.ErrInvalidInput
+	}
+	redriven := 0
+	for redriven < limit {
+		batch, err := q.consumer.Fetch(limit-redriven, jetstream.FetchMaxWait(q.FetchWait))
+		if err != nil {
+			return redriven, fmt.Errorf("error fetching dead-lettered messages: %w", err)
+		}
+		received := 0
+		for msg := range batch.Messages() {
+			received++
+			ok, err := q.redrive(ctx, msg, filter)
+			if err != nil {
+				// Left in the DLQ and redelivered once the ack wait expires
+				q.monitor.Warnf("Failed to redrive dead-lettered message: %v", err)
+				continue
+			}
+			if ok {
+				redriven++
+			}
+		}
+		if err := batch.Error(); err != nil {
+			return redriven, fmt.Errorf("error fetching dead-lettered messages: %w", err)
+		}
+		if received == 0 {
+			// Skipped messages are not redelivered until their ack wait expires, the DLQ is drained
+			break
+		}
+	}
+	return redriven, nil
+}
+
+// redrive republishes the message and removes it from the DLQ, returning true if it was republished.
+func (q *DeadLetterQueue) redrive(ctx context.Context, msg jetstream.Msg, filter func(data []byte) bool) (bool, error) {
+	if filter != nil && !filter(msg.Data()) {
+		return false, nil
+	}
+	header := MessageHeaders(msg.Headers())
+	subject := header.GetOriginalSubject()
+	if subject == "" {
+		return false, fmt.Errorf("dead-lettered message does not contain header %s", OriginalSubjectHeader)
+	}
+	count, err := header.GetRedriveCount()
+	if err != nil {
+		return false, fmt.Errorf("invalid %s header: %s", RedriveCountHeader, header.get(RedriveCountHeader))
+	}
+	if count >= q.MaxRedrives {
+		q.monitor.Infof("Dead-lettered message for %s exceeded %d redrives and remains in the DLQ", subject, q.MaxRedrives)
+		return false, nil
+	}
+
+	redriveHeader := MessageHeaders{}
+	for key, values := range header {
+		switch key {
+		case DeadLetterReasonHeader, OriginalSubjectHeader, OriginalSequenceHeader, SampleRateHeader:
+		default:
+			redriveHeader[key] = append([]string(nil), values...)
+		}
+	}
+	redriveHeader.SetRedriveCount(count + 1)
+	if sequence := header.get(OriginalSequenceHeader); sequence != "" {
+		redriveHeader.set(expectedSubjectSeqHdr, sequence)
+	}
+
+	_, err = q.client.PublishMsg(ctx, &nats.Msg{Subject: subject, Data: msg.Data(), Header: nats.Header(redriveHeader)})
+	superseded := isWrongLastSequence(err)
+	if err != nil && !superseded {
+		return false, fmt.Errorf("error republishing to %s: %w", subject, err)
+	}
+	if err := msg.Ack(); err != nil {
+		// The next redrive finds the message superseded
+		return false, fmt.Errorf("error acknowledging dead-lettered message: %w", err)
+	}
+	return !superseded, nil
+}
+
+func isWrongLastSequence(err error) bool {
+	var jsErr jetstream.JetStreamError
+	if errors.As(err, &jsErr) && jsErr.APIError() != nil {
+		return jsErr.APIError().ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence
+	}
+	return false
+}
@@ -0,0 +1,399 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testDLQSubject = "event.cfm-dead-letter"
+
+func TestDeadLetterQueue_Publish(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testDLQSubject &&
+			string(msg.Data) == "payload" &&
+			msg.Header.Get(DeadLetterReasonHeader) == "malformed" &&
+			msg.Header.Get(OriginalSubjectHeader) == "$KV.bucket.orch-1" &&
+			msg.Header.Get(OriginalSequenceHeader) == "42" &&
+			msg.Header.Get(RedriveCountHeader) == "1"
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	queue := NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	source := newDLQMessage("$KV.bucket.orch-1", 42, "payload", nats.Header{RedriveCountHeader: []string{"1"}})
+
+	err := queue.Publish(context.Background(), source, errors.New("malformed"))
+
+	require.NoError(t, err)
+	assert.Empty(t, source.Headers().Get(DeadLetterReasonHeader), "source headers must not be modified")
+}
+
+func TestOnMessage_DeadLetter_PublishedToDLQ(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testDLQSubject && msg.Header.Get(DeadLetterReasonHeader) != ""
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	watcher := createTestWatcher(nil, nil)
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, "{not json", nil)
+	watcher.onMessage(msg.Data(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestOnMessage_DeadLetter_PublishFails_Nak(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	watcher := createTestWatcher(nil, nil)
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, "{not json", nil)
+	watcher.onMessage(msg.Data(), msg)
+
+	assert.Equal(t, 0, msg.AckCalls, "message must not be lost")
+	assert.Equal(t, 1, msg.NakCalls)
+}
+
+func TestOnMessage_MalformedPolicy_DeadLetter(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testDLQSubject &&
+			string(msg.Data) == "{not json" &&
+			strings.Contains(msg.Header.Get(DeadLetterReasonHeader), errMalformedMessage.Error())
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	watcher := createTestWatcher(nil, nil)
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	watcher.malformedPolicy = MalformedDeadLetter
+
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, "{not json", nil)
+	watcher.onMessage(msg.Data(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestOnMessage_MalformedPolicy_Drop(t *testing.T) {
+	// No publish expected, the mock fails on unexpected calls
+	client := mocks.NewMockMsgClient(t)
+
+	watcher := createTestWatcher(nil, nil)
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	watcher.malformedPolicy = MalformedDrop
+
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, "{not json", nil)
+	watcher.onMessage(msg.Data(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestParseMalformedPolicy(t *testing.T) {
+	for value, expected := range map[string]MalformedPolicy{
+		"":           MalformedDeadLetter,
+		"deadLetter": MalformedDeadLetter,
+		"deadletter": MalformedDeadLetter,
+		"drop":       MalformedDrop,
+		"DROP":       MalformedDrop,
+	} {
+		policy, err := ParseMalformedPolicy(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, policy, value)
+	}
+
+	_, err := ParseMalformedPolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestDeadLetterQueue_Sample(t *testing.T) {
+	queue := NewDeadLetterQueue(mocks.NewMockMsgClient(t), nil, testDLQSubject, system.NoopMonitor{})
+	queue.SampleRate = 0.1
+	queue.random = rand.New(rand.NewPCG(1, 2))
+
+	const total = 10000
+	sampled := 0
+	for range total {
+		if queue.Sample() {
+			sampled++
+		}
+	}
+
+	assert.InDelta(t, total*queue.SampleRate, sampled, total*0.01)
+	assert.Equal(t, int64(total-sampled), queue.Discarded())
+}
+
+func TestDeadLetterQueue_Sample_AllByDefault(t *testing.T) {
+	queue := NewDeadLetterQueue(mocks.NewMockMsgClient(t), nil, testDLQSubject, system.NoopMonitor{})
+
+	for range 100 {
+		require.True(t, queue.Sample())
+	}
+	assert.Equal(t, int64(0), queue.Discarded())
+}
+
+func TestDeadLetterQueue_Publish_SampleRateHeader(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Header.Get(SampleRateHeader) == "0.25"
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	queue := NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	queue.SampleRate = 0.25
+
+	err := queue.Publish(context.Background(), newDLQMessage("$KV.bucket.orch-1", 1, "payload", nil), errors.New("malformed"))
+
+	require.NoError(t, err)
+}
+
+func TestOnMessage_DeadLetter_NotSampled_AckedAndCounted(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+
+	watcher := createTestWatcher(nil, nil)
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	watcher.deadLetters.SampleRate = 0
+
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, "{not json", nil)
+	watcher.onMessage(msg.Data(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, int64(1), watcher.deadLetters.Discarded())
+	client.AssertNotCalled(t, "PublishMsg", mock.Anything, mock.Anything)
+}
+
+func TestRedriveDLQ_RepublishesAndAcks(t *testing.T) {
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	first := newDeadLetteredMsg("$KV.bucket.orch-1", "3", string(data), "")
+	second := newDeadLetteredMsg("$KV.bucket.orch-2", "5", string(data), "1")
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == "$KV.bucket.orch-1" &&
+			msg.Header.Get(RedriveCountHeader) == "1" &&
+			msg.Header.Get(expectedSubjectSeqHdr) == "3" &&
+			msg.Header.Get(DeadLetterReasonHeader) == "" &&
+			msg.Header.Get(OriginalSubjectHeader) == ""
+	})).Return(&jetstream.PubAck{}, nil).Once()
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == "$KV.bucket.orch-2" && msg.Header.Get(RedriveCountHeader) == "2"
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	consumer := &queueConsumer{pending: []jetstream.Msg{first, second}}
+	queue := NewDeadLetterQueue(client, consumer, testDLQSubject, system.NoopMonitor{})
+
+	count, err := queue.RedriveDLQ(context.Background(), nil, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, first.acks)
+	assert.Equal(t, 1, second.acks)
+
+	// Redriving again is a no-op
+	count, err = queue.RedriveDLQ(context.Background(), nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestRedriveDLQ_FilterAndLimit(t *testing.T) {
+	skipped := newDeadLetteredMsg("$KV.bucket.orch-1", "1", "skip", "")
+	first := newDeadLetteredMsg("$KV.bucket.orch-2", "2", "keep", "")
+	second := newDeadLetteredMsg("$KV.bucket.orch-3", "3", "keep", "")
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == "$KV.bucket.orch-2"
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	consumer := &queueConsumer{pending: []jetstream.Msg{skipped, first, second}}
+	queue := NewDeadLetterQueue(client, consumer, testDLQSubject, system.NoopMonitor{})
+
+	count, err := queue.RedriveDLQ(context.Background(), func(data []byte) bool {
+		return string(data) == "keep"
+	}, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 0, skipped.acks, "filtered messages must remain in the DLQ")
+	assert.Equal(t, 1, first.acks)
+	assert.Equal(t, 0, second.acks)
+}
+
+func TestRedriveDLQ_MaxRedrivesExceeded_RemainsInDLQ(t *testing.T) {
+	msg := newDeadLetteredMsg("$KV.bucket.orch-1", "1", "payload", "3")
+
+	client := mocks.NewMockMsgClient(t)
+	consumer := &queueConsumer{pending: []jetstream.Msg{msg}}
+	queue := NewDeadLetterQueue(client, consumer, testDLQSubject, system.NoopMonitor{})
+
+	count, err := queue.RedriveDLQ(context.Background(), nil, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0, msg.acks)
+	client.AssertNotCalled(t, "PublishMsg", mock.Anything, mock.Anything)
+}
+
+func TestRedriveDLQ_Superseded_RemovedWithoutRedrive(t *testing.T) {
+	msg := newDeadLetteredMsg("$KV.bucket.orch-1", "1", "payload", "")
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).
+		Return(nil, &jetstream.APIError{ErrorCode: jetstream.JSErrCodeStreamWrongLastSequence}).Once()
+
+	consumer := &queueConsumer{pending: []jetstream.Msg{msg}}
+	queue := NewDeadLetterQueue(client, consumer, testDLQSubject, system.NoopMonitor{})
+
+	count, err := queue.RedriveDLQ(context.Background(), nil, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, msg.acks)
+}
+
+func TestRedriveDLQ_PublishFails_RemainsInDLQ(t *testing.T) {
+	msg := newDeadLetteredMsg("$KV.bucket.orch-1", "1", "payload", "")
+
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).Return(nil, errors.New("timeout")).Once()
+
+	consumer := &queueConsumer{pending: []jetstream.Msg{msg}}
+	queue := NewDeadLetterQueue(client, consumer, testDLQSubject, system.NoopMonitor{})
+
+	count, err := queue.RedriveDLQ(context.Background(), nil, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0, msg.acks)
+}
+
+func TestRedriveDLQ_InvalidLimit(t *testing.T) {
+	queue := NewDeadLetterQueue(mocks.NewMockMsgClient(t), &queueConsumer{}, testDLQSubject, system.NoopMonitor{})
+
+	_, err := queue.RedriveDLQ(context.Background(), nil, 0)
+
+	require.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+// dlqMessage is a watcher message that can be copied to the DLQ.
+type dlqMessage struct {
+	*MockMessage
+	subject  string
+	sequence uint64
+	header   nats.Header
+}
+
+func newDLQMessage(subject string, sequence uint64, data string, header nats.Header) *dlqMessage {
+	if header == nil {
+		header = nats.Header{}
+	}
+	return &dlqMessage{MockMessage: NewMockMessage([]byte(data)), subject: subject, sequence: sequence, header: header}
+}
+
+func (m *dlqMessage) Subject() string        { return m.subject }
+func (m *dlqMessage) Data() []byte           { return m.data }
+func (m *dlqMessage) Headers() nats.Header   { return m.header }
+func (m *dlqMessage) StreamSequence() uint64 { return m.sequence }
+
+// deadLetteredMsg is a message read from the DLQ stream.
+type deadLetteredMsg struct {
+	jetstream.Msg
+	data   []byte
+	header nats.Header
+	acks   int
+}
+
+func newDeadLetteredMsg(subject, sequence, data, redrives string) *deadLetteredMsg {
+	header := nats.Header{}
+	header.Set(DeadLetterReasonHeader, "malformed")
+	header.Set(OriginalSubjectHeader, subject)
+	header.Set(OriginalSequenceHeader, sequence)
+	if redrives != "" {
+		header.Set(RedriveCountHeader, redrives)
+	}
+	return &deadLetteredMsg{data: []byte(data), header: header}
+}
+
+func (m *deadLetteredMsg) Data() []byte         { return m.data }
+func (m *deadLetteredMsg) Headers() nats.Header { return m.header }
+func (m *deadLetteredMsg) Ack() error {
+	m.acks++
+	return nil
+}
+
+// queueConsumer delivers each pending message once. Unacknowledged messages are not redelivered, mirroring a consumer
+// whose ack wait has not expired.
+type queueConsumer struct {
+	jetstream.Consumer
+	mu      sync.Mutex
+	pending []jetstream.Msg
+	// batches records the batch size of each fetch
+	batches []int
+}
+
+func (c *queueConsumer) Fetch(batch int, _ ...jetstream.FetchOpt) (jetstream.MessageBatch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, batch)
+	n := min(batch, len(c.pending))
+	if n == 0 {
+		// Mimic the fetch wait so that processing loops do not spin
+		time.Sleep(time.Millisecond)
+	}
+	messages := make(chan jetstream.Msg, n)
+	for _, msg := range c.pending[:n] {
+		messages <- msg
+	}
+	close(messages)
+	c.pending = c.pending[n:]
+	return stubBatch{messages: messages}, nil
+}
+
+func (c *queueConsumer) FetchNoWait(batch int) (jetstream.MessageBatch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := min(batch, len(c.pending))
+	messages := make(chan jetstream.Msg, n)
+	for _, msg := range c.pending[:n] {
+		messages <- msg
+	}
+	close(messages)
+	c.pending = c.pending[n:]
+	return stubBatch{messages: messages}, nil
+}
+
+func (c *queueConsumer) push(messages ...jetstream.Msg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, messages...)
+}
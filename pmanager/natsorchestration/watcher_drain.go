@@ -0,0 +1,75 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDraining is reported by the health check once the watcher has been drained.
+var ErrDraining = errors.New("watcher draining")
+
+// subjectMessage is implemented by messages exposing the subject they were published to.
+type subjectMessage interface {
+	Subject() string
+}
+
+// Drain stops the watcher from fetching further messages. The message being processed completes and pending
+// acknowledgements are flushed before the process loop returns. Draining cannot be reverted, the watcher must be
+// restarted.
+func (w *OrchestrationIndexWatcher) Drain() {
+	if !w.draining.Swap(true) {
+		w.monitor.Infof("Draining orchestration index watcher")
+	}
+}
+
+// Draining returns true if the watcher has been drained.
+func (w *OrchestrationIndexWatcher) Draining() bool {
+	return w.draining.Load()
+}
+
+// isControlMessage returns true if the message was published to the control subject. Control messages are disabled
+// when no control subject is configured.
+func (w *OrchestrationIndexWatcher) isControlMessage(msg MessageAck) bool {
+	if w.controlSubject == "" {
+		return false
+	}
+	subjectMsg, ok := msg.(subjectMessage)
+	return ok && subjectMsg.Subject() == w.controlSubject
+}
+
+// onControlMessage acknowledges the control message and drains the watcher.
+func (w *OrchestrationIndexWatcher) onControlMessage(msg MessageAck) {
+	if err := w.ack(msg); err != nil {
+		w.monitor.Infof("Failed to ack control message: %v", err)
+	}
+	w.Drain()
+}
+
+// finishDrain completes deferred work, see flushDeferred.
+func (w *OrchestrationIndexWatcher) finishDrain() {
+	w.flushDeferred()
+	w.monitor.Infof("Orchestration index watcher drained")
+}
+
+// flushDeferred records debounced changes and flushes acknowledgements deferred by the batcher so they are not
+// redelivered to another instance.
+func (w *OrchestrationIndexWatcher) flushDeferred() {
+	if w.debouncer != nil {
+		w.debouncer.flush()
+	}
+	if w.acks != nil {
+		w.acks.Flush(context.Background())
+	}
+}
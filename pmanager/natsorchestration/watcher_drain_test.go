@@ -0,0 +1,109 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testControlSubject = "$KV.cfm-orchestrations.cfm-control"
+
+func TestOnMessage_ControlMessage_DrainsWithoutRecording(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.controlSubject = testControlSubject
+
+	msg := newDLQMessage(testControlSubject, 1, "drain", nil)
+	watcher.onHeaderMessage(msg.Data(), msg.Headers(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.True(t, watcher.Draining())
+	assert.ErrorIs(t, watcher.CheckHealth(context.Background()), ErrDraining)
+	mockStore.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOnMessage_ControlMessage_DisabledByDefault(t *testing.T) {
+	watcher := createTestWatcher(createTestStore(t), &store.NoOpTransactionContext{})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := newDLQMessage(testControlSubject, 1, string(data), nil)
+	watcher.onHeaderMessage(msg.Data(), msg.Headers(), msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.False(t, watcher.Draining())
+	_, err := watcher.index.FindByID(context.Background(), "orch-1")
+	assert.NoError(t, err, "message is recorded as an orchestration")
+}
+
+func TestProcessLoop_ControlMessage_StopsFetching(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	index := createTestStore(t)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.controlSubject = testControlSubject
+	watcher.acks = NewAckBatcher(10, time.Hour, watcher.monitor)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	before := &subjectMsg{subject: "$KV.cfm-orchestrations.orch-1", data: data}
+	control := &subjectMsg{subject: testControlSubject, data: []byte("drain")}
+	after := &subjectMsg{subject: "$KV.cfm-orchestrations.orch-2", data: data}
+	consumer := &queueConsumer{}
+	consumer.push(before, control, after)
+
+	err := watcher.processLoop(ctx, consumer)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, before.acks, "pending acks are flushed")
+	assert.Equal(t, 1, control.acks)
+	assert.Equal(t, 0, after.acks, "no messages are fetched after draining")
+	assert.Len(t, consumer.pending, 1)
+	assert.True(t, watcher.Draining())
+}
+
+// subjectMsg is a jetstream.Msg published to a subject.
+type subjectMsg struct {
+	jetstream.Msg
+	subject string
+	data    []byte
+	acks    int
+}
+
+func (m *subjectMsg) Subject() string      { return m.subject }
+func (m *subjectMsg) Data() []byte         { return m.data }
+func (m *subjectMsg) Headers() nats.Header { return nats.Header{} }
+func (m *subjectMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return nil, jetstream.ErrNotJSMessage
+}
+func (m *subjectMsg) Ack() error {
+	m.acks++
+	return nil
+}
+
+func (m *subjectMsg) DoubleAck(context.Context) error {
+	return m.Ack()
+}
@@ -0,0 +1,48 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+)
+
+// isExpired returns true if the message header carries an expiry that has passed. Messages with a malformed expiry
+// header are processed rather than lost.
+func (w *OrchestrationIndexWatcher) isExpired(header nats.Header) bool {
+	expiry, err := MessageHeaders(header).GetExpiry()
+	if err != nil {
+		w.monitor.Warnf("Ignoring malformed %s header %q: %v", ExpiryHeader, header.Get(ExpiryHeader), err)
+		return false
+	}
+	return !expiry.IsZero() && w.now().After(expiry)
+}
+
+// isExpiredChange returns true if the orchestration change carries an expiry that has passed. Changes written to the
+// orchestration bucket cannot carry headers, the expiry is stamped in the payload instead.
+func (w *OrchestrationIndexWatcher) isExpiredChange(orchestration *api.Orchestration) bool {
+	return !orchestration.Expiry.IsZero() && w.now().After(orchestration.Expiry)
+}
+
+// dropExpired acknowledges the expired message without processing it and counts it, see ExpiredMessages.
+func (w *OrchestrationIndexWatcher) dropExpired(msg MessageAck) {
+	w.expired.Add(1)
+	if err := w.ack(msg); err != nil {
+		w.monitor.Infof("Failed to ack expired message: %v", err)
+	}
+}
+
+// ExpiredMessages returns the number of messages dropped because their expiry had passed.
+func (w *OrchestrationIndexWatcher) ExpiredMessages() int64 {
+	return w.expired.Load()
+}
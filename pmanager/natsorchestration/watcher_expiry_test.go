@@ -0,0 +1,130 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Expiry_ExpiredMessageDropped(t *testing.T) {
+	// No store calls expected, the mock fails on unexpected calls
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+
+	header := nats.Header{}
+	MessageHeaders(header).SetExpiry(time.Now().Add(-time.Minute))
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onHeaderMessage(data, header, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, int64(1), watcher.ExpiredMessages())
+}
+
+func TestOnMessage_Expiry_ExpiredChangeDropped(t *testing.T) {
+	// No store calls expected, the mock fails on unexpected calls
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+
+	// Changes written to the orchestration bucket carry the expiry in the payload
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.Expiry = time.Now().Add(-time.Minute)
+	data, _ := json.Marshal(orchestration)
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, int64(1), watcher.ExpiredMessages())
+}
+
+func TestNatsOrchestrator_Execute_StampsExpiry(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	var stored api.Orchestration
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(0)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &stored))
+		}).
+		Return(uint64(1), nil).Once()
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).Return(&jetstream.PubAck{}, nil).Once()
+
+	orchestrator := NewNatsOrchestrator(client, system.NoopMonitor{})
+	orchestrator.ExpiryTTL = time.Hour
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	orchestration.Steps = []api.OrchestrationStep{{Activities: []api.Activity{{ID: "a1", Type: "provision"}}}}
+
+	start := time.Now()
+	require.NoError(t, orchestrator.Execute(context.Background(), &orchestration))
+	assert.WithinRange(t, stored.Expiry, start.Add(time.Hour), time.Now().Add(time.Hour))
+}
+
+func TestUpdateOrchestration_ResetsExpiry(t *testing.T) {
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.Expiry = time.Now().Add(time.Hour)
+
+	client := mocks.NewMockMsgClient(t)
+	var stored api.Orchestration
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(3)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &stored))
+		}).
+		Return(uint64(4), nil).Once()
+
+	_, _, err := UpdateOrchestration(context.Background(), orchestration, 3, client, func(o *api.Orchestration) {
+		o.SetState(api.OrchestrationStateCompleted)
+	})
+
+	require.NoError(t, err)
+	assert.True(t, stored.Expiry.IsZero(), "the expiry applies to the change that stamped it only")
+}
+
+func TestOnMessage_Expiry_UnexpiredMessageProcessed(t *testing.T) {
+	for name, value := range map[string]string{
+		"future expiry": time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano),
+		"no expiry":     "",
+		"malformed":     "tomorrow",
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+			watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+
+			mockStore.EXPECT().FindByID(mock.Anything, "orch-1").Return(nil, types.ErrNotFound).Once()
+			mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+
+			header := nats.Header{}
+			if value != "" {
+				header.Set(ExpiryHeader, value)
+			}
+			data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+			msg := NewMockMessage(data)
+			watcher.onHeaderMessage(data, header, msg)
+
+			assert.Equal(t, 1, msg.AckCalls)
+			assert.Equal(t, int64(0), watcher.ExpiredMessages())
+		})
+	}
+}
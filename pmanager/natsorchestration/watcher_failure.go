@@ -0,0 +1,54 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// remediationHints guide operators in resolving failures of the given reason.
+var remediationHints = map[api.FailureReason]string{
+	api.FailureReasonTimeout: "Recording the orchestration took longer than its processing timeout. Check the latency of " +
+		"the orchestration index store or raise watcher.processingTimeout or the ackWait of the orchestration type.",
+	api.FailureReasonPoolExhausted: "The store had no connections available. Raise the connection limit of the store " +
+		"or reduce the number of watcher workers.",
+	api.FailureReasonThrottled: "The store rejected requests because of a rate limit. Raise the limit or reduce the " +
+		"number of watcher workers.",
+	api.FailureReasonConflict: "The entry was modified concurrently on every attempt. Check for other writers of the " +
+		"orchestration index and retry the orchestration.",
+	api.FailureReasonStore: "The orchestration index store failed. Check its availability and logs, then retry the " +
+		"orchestration.",
+}
+
+// classifyFailure returns the reason and the remediation hint of the error the orchestration failed with.
+func (w *OrchestrationIndexWatcher) classifyFailure(err error) (api.FailureReason, string) {
+	var reason api.FailureReason
+	var retryAfter types.RetryAfterError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		reason = api.FailureReasonTimeout
+	case w.poolExhausted != nil && w.poolExhausted(err):
+		reason = api.FailureReasonPoolExhausted
+	case errors.As(err, &retryAfter):
+		reason = api.FailureReasonThrottled
+	case errors.Is(err, types.ErrConflict):
+		reason = api.FailureReasonConflict
+	default:
+		reason = api.FailureReasonStore
+	}
+	return reason, remediationHints[reason]
+}
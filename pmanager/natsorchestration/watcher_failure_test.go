@@ -0,0 +1,91 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An orchestration given up on after an exhausted connection pool records the reason and hint on the entry and its
+// state change
+func TestOnMessage_ErroredAfterMaxAttempts_RecordsFailureReason(t *testing.T) {
+	index := &flakyIndex{
+		OrchestrationIndex: memorystore.NewOrchestrationIndex(),
+		failures:           1,
+		err:                errors.New("pq: sorry, too many clients already"),
+	}
+	outbox := memorystore.NewOutboxStore()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.maxAttempts = 1
+	watcher.poolExhausted = MatchErrorMessages(DefaultPoolExhaustedMessages...)
+	watcher.messageOutbox = outbox
+	watcher.messageNaming = natsclient.DefaultNamingStrategy{}
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls, "the change must not be redelivered once the retries are exhausted")
+
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateErrored, entry.State)
+	assert.Equal(t, api.FailureReasonPoolExhausted, entry.FailureReason)
+	assert.Equal(t, remediationHints[api.FailureReasonPoolExhausted], entry.RemediationHint)
+
+	pending, err := outbox.FindPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	var event api.OrchestrationEntry
+	require.NoError(t, json.Unmarshal(pending[0].Payload, &event))
+	assert.Equal(t, api.OrchestrationStateErrored, event.State)
+	assert.Equal(t, api.FailureReasonPoolExhausted, event.FailureReason)
+	assert.Equal(t, entry.RemediationHint, event.RemediationHint)
+	assert.Equal(t, testTerminalSubject, pending[0].Subject)
+}
+
+func TestClassifyFailure(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.poolExhausted = MatchErrorMessages(DefaultPoolExhaustedMessages...)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected api.FailureReason
+	}{
+		{"timeout", fmt.Errorf("update failed: %w", context.DeadlineExceeded), api.FailureReasonTimeout},
+		{"pool exhausted", errors.New("FATAL: remaining connection slots are reserved"), api.FailureReasonPoolExhausted},
+		{"throttled", types.RetryableError{Message: "rate limited", Delay: time.Second}, api.FailureReasonThrottled},
+		{"version conflict", types.ErrVersionConflict, api.FailureReasonConflict},
+		{"other", errors.New("database unavailable"), api.FailureReasonStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, hint := watcher.classifyFailure(tt.err)
+			assert.Equal(t, tt.expected, reason)
+			assert.NotEmpty(t, hint)
+		})
+	}
+}
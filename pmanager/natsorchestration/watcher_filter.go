@@ -0,0 +1,71 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// matchesHeaderFilter returns true if the message carries all headers of the filter, e.g. environment=staging so that
+// a stream serves multiple environments. Since changes written to the orchestration bucket cannot carry custom headers,
+// a filter entry is also matched by the orchestration label of the same name. Header and label names are matched
+// case-insensitively since configuration keys are not case-preserving, values must match exactly. A header with
+// multiple values matches if any value matches.
+func (w *OrchestrationIndexWatcher) matchesHeaderFilter(header nats.Header, labels map[string]string) bool {
+	for name, expected := range w.headerFilter {
+		if !hasHeaderValue(header, name, expected) && !hasLabelValue(labels, name, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasLabelValue(labels map[string]string, name string, expected string) bool {
+	for key, value := range labels {
+		if strings.EqualFold(key, name) && value == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func hasHeaderValue(header nats.Header, name string, expected string) bool {
+	for key, values := range header {
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+		for _, value := range values {
+			if value == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dropFiltered acknowledges a message not matching the header filter without processing it and counts it, see
+// FilteredMessages.
+func (w *OrchestrationIndexWatcher) dropFiltered(msg MessageAck) {
+	w.filtered.Add(1)
+	if err := w.ack(msg); err != nil {
+		w.monitor.Infof("Failed to ack filtered message: %v", err)
+	}
+}
+
+// FilteredMessages returns the number of messages skipped because their headers and labels did not match the header
+// filter.
+func (w *OrchestrationIndexWatcher) FilteredMessages() int64 {
+	return w.filtered.Load()
+}
@@ -0,0 +1,99 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_HeaderFilter_OnlyMatchingMessagesRecorded(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	// Configuration keys are lowercased
+	watcher.headerFilter = map[string]string{"environment": "staging"}
+
+	for id, header := range map[string]nats.Header{
+		"orch-staging":     {"Environment": []string{"staging"}},
+		"orch-multivalued": {"environment": []string{"production", "staging"}},
+		"orch-production":  {"Environment": []string{"production"}},
+		"orch-case":        {"Environment": []string{"Staging"}},
+		"orch-missing":     {"Region": []string{"eu"}},
+		"orch-no-header":   nil,
+	} {
+		data, _ := json.Marshal(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateRunning))
+		msg := NewMockMessage(data)
+		watcher.onHeaderMessage(data, header, msg)
+		require.Equal(t, 1, msg.AckCalls, "filtered messages must be acked so that they are not redelivered")
+		require.Equal(t, 0, msg.NakCalls)
+	}
+
+	for _, id := range []string{"orch-staging", "orch-multivalued"} {
+		_, err := index.FindByID(context.Background(), id)
+		assert.NoError(t, err, "%s must be recorded", id)
+	}
+	for _, id := range []string{"orch-production", "orch-case", "orch-missing", "orch-no-header"} {
+		_, err := index.FindByID(context.Background(), id)
+		assert.ErrorIs(t, err, types.ErrNotFound, "%s must be filtered", id)
+	}
+	assert.Equal(t, int64(4), watcher.FilteredMessages())
+}
+
+func TestOnMessage_HeaderFilter_MatchesLabels(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.headerFilter = map[string]string{"environment": "staging"}
+
+	// Changes read from the orchestration bucket carry no custom headers, the filter is matched by labels instead
+	for id, labels := range map[string]map[string]string{
+		"orch-staging":    {"Environment": "staging"},
+		"orch-production": {"environment": "production"},
+		"orch-unlabeled":  nil,
+	} {
+		orchestration := createWatcherOrchestration(id, "corr-1", api.OrchestrationStateRunning)
+		orchestration.Labels = labels
+		data, _ := json.Marshal(orchestration)
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	_, err := index.FindByID(context.Background(), "orch-staging")
+	assert.NoError(t, err)
+	for _, id := range []string{"orch-production", "orch-unlabeled"} {
+		_, err := index.FindByID(context.Background(), id)
+		assert.ErrorIs(t, err, types.ErrNotFound, "%s must be filtered", id)
+	}
+	assert.Equal(t, int64(2), watcher.FilteredMessages())
+}
+
+func TestOnMessage_HeaderFilter_NoFilterProcessesAll(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onHeaderMessage(data, nats.Header{"Environment": []string{"production"}}, NewMockMessage(data))
+
+	_, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), watcher.FilteredMessages())
+}
@@ -0,0 +1,103 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+const (
+	defaultForwardBackoffInitial = time.Second
+	defaultForwardBackoffMax     = 30 * time.Second
+
+	// defaultStateOutboxCapacity is the number of entries a StateOutbox queues before Enqueue blocks.
+	defaultStateOutboxCapacity = 1000
+)
+
+// StateOutbox decouples forwarding recorded state changes from acknowledging the NATS message. Changes are queued in
+// memory and forwarded in order by Run, retrying failures with backoff. A failing forwarder therefore does not delay
+// acknowledgements until the outbox is full; Enqueue then blocks, so that a forwarder that is down for long slows the
+// watcher instead of growing the queue without bound. Changes still queued when the process exits are not forwarded.
+type StateOutbox struct {
+	forwarder api.StateForwarder
+	backoff   BackoffStrategy
+	monitor   system.LogMonitor
+
+	pending  chan *api.OrchestrationEntry
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStateOutbox creates an outbox forwarding to the given forwarder that queues up to capacity entries.
+func NewStateOutbox(forwarder api.StateForwarder, capacity int, monitor system.LogMonitor) *StateOutbox {
+	return &StateOutbox{
+		forwarder: forwarder,
+		backoff:   ExponentialBackoff{Initial: defaultForwardBackoffInitial, Max: defaultForwardBackoffMax},
+		monitor:   monitor,
+		pending:   make(chan *api.OrchestrationEntry, capacity),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Enqueue adds a copy of the entry to the outbox, so that the entry is not shared with the forwarder. If the outbox is
+// full, Enqueue blocks until Run forwarded the oldest entry. Entries enqueued after Run stopped are dropped.
+func (o *StateOutbox) Enqueue(entry *api.OrchestrationEntry) {
+	select {
+	case o.pending <- entry.Clone():
+	case <-o.stopped:
+		o.monitor.Warnf("State outbox stopped, not forwarding state of orchestration %s", entry.ID)
+	}
+}
+
+// Run forwards queued entries until the context is canceled. An entry is retried until it is forwarded so that later
+// changes to the same orchestration are not forwarded before it.
+func (o *StateOutbox) Run(ctx context.Context) {
+	defer o.stopOnce.Do(func() { close(o.stopped) })
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-o.pending:
+			if !o.forward(ctx, entry) {
+				return
+			}
+		}
+	}
+}
+
+// forward retries forwarding the entry until it succeeds, returning false if the context is canceled first.
+func (o *StateOutbox) forward(ctx context.Context, entry *api.OrchestrationEntry) bool {
+	for attempt := uint64(1); ; attempt++ {
+		err := o.forwarder.Forward(api.WithOrchestration(ctx, entry), entry)
+		if err == nil {
+			return true
+		}
+		delay := o.backoff.Delay(attempt)
+		o.monitor.Warnf("Failed to forward state of orchestration %s, retrying in %s: %v", entry.ID, delay, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isStateChange returns true if recording the entry changed the state held in the index.
+func isStateChange(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry, tolerance time.Duration) bool {
+	return existing == nil || (existing.State != entry.State && !isStale(entry, existing, tolerance))
+}
@@ -0,0 +1,193 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_StateForwarder_ForwardsEachStateChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := newRecordingForwarder(nil)
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.outbox = NewStateOutbox(forwarder, defaultStateOutboxCapacity, system.NoopMonitor{})
+	go watcher.outbox.Run(ctx)
+
+	for _, state := range []api.OrchestrationState{
+		api.OrchestrationStateRunning,
+		api.OrchestrationStateRunning, // redelivered, not a state change
+		api.OrchestrationStateCompleted,
+		api.OrchestrationStateRunning, // stale, the orchestration already completed
+	} {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	require.Eventually(t, func() bool { return len(forwarder.forwardedStates()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []api.OrchestrationState{api.OrchestrationStateRunning, api.OrchestrationStateCompleted},
+		forwarder.forwardedStates())
+}
+
+func TestOnMessage_StateForwarder_FailedUpdateNotForwarded(t *testing.T) {
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("database unavailable")}
+	forwarder := newRecordingForwarder(nil)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.outbox = NewStateOutbox(forwarder, defaultStateOutboxCapacity, system.NoopMonitor{})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Empty(t, watcher.outbox.pending)
+}
+
+func TestStateOutbox_ForwarderContextCarriesOrchestration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := newRecordingForwarder(nil)
+	outbox := NewStateOutbox(forwarder, defaultStateOutboxCapacity, system.NoopMonitor{})
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", CorrelationID: "corr-1", State: api.OrchestrationStateRunning})
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-2", CorrelationID: "corr-2", State: api.OrchestrationStateRunning})
+	go outbox.Run(ctx)
+
+	require.Eventually(t, func() bool { return len(forwarder.forwardedStates()) == 2 }, time.Second, 5*time.Millisecond)
+	forwarder.mu.Lock()
+	defer forwarder.mu.Unlock()
+	assert.Equal(t, []string{"corr-1", "corr-2"}, forwarder.contextIDs)
+}
+
+func TestStateOutbox_RetriesWithoutReordering(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := newRecordingForwarder([]error{errors.New("broker unavailable"), errors.New("broker unavailable")})
+	outbox := NewStateOutbox(forwarder, defaultStateOutboxCapacity, system.NoopMonitor{})
+	outbox.backoff = ExponentialBackoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning})
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateCompleted})
+	go outbox.Run(ctx)
+
+	require.Eventually(t, func() bool { return len(forwarder.forwardedStates()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []api.OrchestrationState{api.OrchestrationStateRunning, api.OrchestrationStateCompleted},
+		forwarder.forwardedStates())
+	assert.Equal(t, 4, forwarder.attemptCount())
+}
+
+func TestStateOutbox_FullBlocksEnqueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := newRecordingForwarder(nil)
+	outbox := NewStateOutbox(forwarder, 1, system.NoopMonitor{})
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning})
+
+	enqueued := make(chan struct{})
+	go func() {
+		outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateCompleted})
+		close(enqueued)
+	}()
+	select {
+	case <-enqueued:
+		t.Fatal("enqueue must block while the outbox is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	go outbox.Run(ctx)
+	require.Eventually(t, func() bool { return len(forwarder.forwardedStates()) == 2 }, time.Second, 5*time.Millisecond)
+	<-enqueued
+}
+
+func TestStateOutbox_StoppedDropsEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	outbox := NewStateOutbox(newRecordingForwarder(nil), 1, system.NoopMonitor{})
+	cancel()
+	outbox.Run(ctx)
+
+	// Neither call blocks although the outbox holds a single entry
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateRunning})
+	outbox.Enqueue(&api.OrchestrationEntry{ID: "orch-1", State: api.OrchestrationStateCompleted})
+}
+
+// recordingForwarder records forwarded entries, failing with the given errors first.
+type recordingForwarder struct {
+	mu        sync.Mutex
+	failures  []error
+	attempts  int
+	forwarded []*api.OrchestrationEntry
+	// contextIDs holds the correlation IDs carried by the context of each forwarded entry
+	contextIDs []string
+}
+
+func newRecordingForwarder(failures []error) *recordingForwarder {
+	return &recordingForwarder{failures: failures}
+}
+
+func (f *recordingForwarder) Forward(ctx context.Context, entry *api.OrchestrationEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return err
+	}
+	f.forwarded = append(f.forwarded, entry)
+	correlationID, _ := api.CorrelationIDFromContext(ctx)
+	f.contextIDs = append(f.contextIDs, correlationID)
+	return nil
+}
+
+func (f *recordingForwarder) forwardedStates() []api.OrchestrationState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	states := make([]api.OrchestrationState, 0, len(f.forwarded))
+	for _, entry := range f.forwarded {
+		states = append(states, entry.State)
+	}
+	return states
+}
+
+func (f *recordingForwarder) attemptCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts
+}
+
+// failingIndex fails all creates with err.
+type failingIndex struct {
+	*memorystore.OrchestrationIndex
+	err error
+}
+
+func (i *failingIndex) Create(context.Context, *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	return nil, i.err
+}
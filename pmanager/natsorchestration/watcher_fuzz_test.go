@@ -0,0 +1,70 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// FuzzOnMessage feeds arbitrary bytes to the watcher and verifies it never panics and settles every message with
+// exactly one Ack or Nak.
+func FuzzOnMessage(f *testing.F) {
+	valid, err := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add([]byte(""))
+	f.Add([]byte("null"))
+	f.Add([]byte("{}"))
+	f.Add([]byte(`{"id":"orch-1","state":99}`))
+	f.Add([]byte(`{"id":"orch-1","state":-1}`))
+	f.Add([]byte(`{"id":"orch-1","steps":[{"activities":[{"inputs":[null,1,{"source":[]}]}]}]}`))
+	f.Add([]byte(`{"id":"orch-1","steps":null,"completed":[],"processingData":"x"}`))
+	f.Add([]byte(`{"id":"orch-1","stateTimestamp":"not a time","deadline":0}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		watcher := createTestWatcher(&noopIndex{}, &store.NoOpTransactionContext{})
+		msg := NewMockMessage(data)
+
+		watcher.onMessage(data, msg)
+
+		if msg.AckCalls+msg.NakCalls != 1 {
+			t.Fatalf("expected exactly one Ack or Nak, got %d Ack and %d Nak", msg.AckCalls, msg.NakCalls)
+		}
+	})
+}
+
+// noopIndex is an orchestration index that records nothing.
+type noopIndex struct {
+	store.EntityStore[*api.OrchestrationEntry]
+}
+
+func (n *noopIndex) FindByID(context.Context, string) (*api.OrchestrationEntry, error) {
+	return nil, types.ErrNotFound
+}
+
+func (n *noopIndex) Create(_ context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	return entry, nil
+}
+
+func (n *noopIndex) Update(context.Context, *api.OrchestrationEntry) error {
+	return nil
+}
@@ -0,0 +1,72 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/nats-io/nats.go"
+)
+
+// progressMessage is implemented by messages whose redelivery can be postponed while they are being processed.
+type progressMessage interface {
+	InProgress(opts ...nats.AckOpt) error
+}
+
+func (a jetStreamMessageAck) InProgress(...nats.AckOpt) error {
+	return a.msg.InProgress()
+}
+
+// processingTimeoutFor returns the processing timeout of the orchestration type, which is the ack wait configured for
+// the type if any and processingTimeout otherwise.
+func (w *OrchestrationIndexWatcher) processingTimeoutFor(orchestrationType model.OrchestrationType) time.Duration {
+	if ackWait, found := w.typeAckWaits[orchestrationType]; found {
+		return ackWait
+	}
+	return w.processingTimeout
+}
+
+// startHeartbeat reports the message as in progress every heartbeatInterval so that the consumer does not redeliver it
+// while it is being processed. Heartbeats stop once the context is done, i.e. the processing timeout of the type
+// expired, or the returned function is called. Disabled when heartbeatInterval is not positive.
+func (w *OrchestrationIndexWatcher) startHeartbeat(ctx context.Context, msg MessageAck) func() {
+	progress, ok := msg.(progressMessage)
+	if !ok || w.heartbeatInterval <= 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(w.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := progress.InProgress(); err != nil {
+					w.monitor.Infof("Failed to report message in progress: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
@@ -0,0 +1,85 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressMockMessage counts the in-progress reports of a message.
+type progressMockMessage struct {
+	*MockMessage
+	inProgress atomic.Int32
+}
+
+func (m *progressMockMessage) InProgress(...nats.AckOpt) error {
+	m.inProgress.Add(1)
+	return nil
+}
+
+func TestRecordDecoded_HeartbeatTunedByType(t *testing.T) {
+	index := &blockingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.heartbeatInterval = 10 * time.Millisecond
+	watcher.typeAckWaits = map[model.OrchestrationType]time.Duration{
+		"cfm.long":  300 * time.Millisecond,
+		"cfm.short": 50 * time.Millisecond,
+	}
+
+	process := func(id string, orchestrationType model.OrchestrationType) *progressMockMessage {
+		orch := createWatcherOrchestration(id, "corr-"+id, api.OrchestrationStateRunning)
+		orch.OrchestrationType = orchestrationType
+		_, err := index.Create(context.Background(), createEntry(orch))
+		require.NoError(t, err)
+
+		// Updates block until the ack wait of the type expires
+		orch.State = api.OrchestrationStateCompleted
+		data, _ := json.Marshal(orch)
+		msg := &progressMockMessage{MockMessage: NewMockMessage(data)}
+		watcher.onMessage(data, msg)
+		assert.Equal(t, 1, msg.NakCalls, "timed out messages must be redelivered")
+		return msg
+	}
+
+	long := process("orch-long", "cfm.long")
+	short := process("orch-short", "cfm.short")
+
+	assert.Greater(t, long.inProgress.Load(), short.inProgress.Load())
+	assert.LessOrEqual(t, short.inProgress.Load(), int32(5), "heartbeats must stop once the ack wait expires")
+}
+
+func TestRecordDecoded_HeartbeatStopsWhenRecorded(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.heartbeatInterval = time.Millisecond
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &progressMockMessage{MockMessage: NewMockMessage(data)}
+	watcher.onMessage(data, msg)
+	reported := msg.inProgress.Load()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, reported, msg.inProgress.Load(), "no heartbeats must be sent once the message is settled")
+}
@@ -0,0 +1,133 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InFlightInfo describes a message being recorded by the watcher.
+type InFlightInfo struct {
+	OrchestrationID string
+	// Deliveries is the number of times the message was delivered, zero if the message does not expose it.
+	Deliveries uint64
+	// Elapsed is the time since processing of the message started.
+	Elapsed time.Duration
+}
+
+// InFlight returns the messages currently being recorded, ordered by start time, so that operators can spot hung
+// handlers.
+func (w *OrchestrationIndexWatcher) InFlight() []InFlightInfo {
+	return w.inFlight.list(w.now())
+}
+
+// CancelInFlight cancels the processing context of the messages of the orchestration being recorded. Canceled
+// messages are negatively acknowledged and redelivered once the store calls observing the context return. Returns false
+// if no message of the orchestration is in flight.
+func (w *OrchestrationIndexWatcher) CancelInFlight(id string) bool {
+	return w.inFlight.cancel(id)
+}
+
+// trackInFlight registers the message in the in-flight registry and returns a context canceled by CancelInFlight and a
+// function removing the message once it is settled.
+func (w *OrchestrationIndexWatcher) trackInFlight(
+	ctx context.Context,
+	orchestrationID string,
+	msg MessageAck) (context.Context, func()) {
+	var deliveries uint64
+	if counter, ok := msg.(deliveryCounter); ok {
+		deliveries = counter.NumDelivered()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	release := w.inFlight.add(orchestrationID, deliveries, w.now(), cancel)
+	return ctx, func() {
+		release()
+		cancel()
+	}
+}
+
+// inFlightRegistry tracks the messages being recorded. The zero value is ready to use.
+type inFlightRegistry struct {
+	mu       sync.Mutex
+	next     uint64
+	messages map[uint64]*inFlightMessage
+}
+
+type inFlightMessage struct {
+	orchestrationID string
+	deliveries      uint64
+	started         time.Time
+	cancel          context.CancelFunc
+}
+
+// add registers a message and returns a function removing it.
+func (r *inFlightRegistry) add(
+	orchestrationID string,
+	deliveries uint64,
+	started time.Time,
+	cancel context.CancelFunc) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.messages == nil {
+		r.messages = make(map[uint64]*inFlightMessage)
+	}
+	r.next++
+	key := r.next
+	r.messages[key] = &inFlightMessage{
+		orchestrationID: orchestrationID,
+		deliveries:      deliveries,
+		started:         started,
+		cancel:          cancel,
+	}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.messages, key)
+	}
+}
+
+func (r *inFlightRegistry) list(now time.Time) []InFlightInfo {
+	r.mu.Lock()
+	messages := make([]*inFlightMessage, 0, len(r.messages))
+	for _, message := range r.messages {
+		messages = append(messages, message)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(messages, func(i, j int) bool { return messages[i].started.Before(messages[j].started) })
+	infos := make([]InFlightInfo, 0, len(messages))
+	for _, message := range messages {
+		infos = append(infos, InFlightInfo{
+			OrchestrationID: message.orchestrationID,
+			Deliveries:      message.deliveries,
+			Elapsed:         now.Sub(message.started),
+		})
+	}
+	return infos
+}
+
+func (r *inFlightRegistry) cancel(orchestrationID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	canceled := false
+	for _, message := range r.messages {
+		if message.orchestrationID == orchestrationID {
+			message.cancel()
+			canceled = true
+		}
+	}
+	return canceled
+}
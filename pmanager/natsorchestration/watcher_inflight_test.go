@@ -0,0 +1,60 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelInFlight_HungUpdateNaked(t *testing.T) {
+	index := &blockingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	_, err := index.Create(context.Background(), createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)))
+	require.NoError(t, err)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 3}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.onMessage(data, msg)
+	}()
+
+	require.Eventually(t, func() bool { return len(watcher.InFlight()) == 1 }, time.Second, 5*time.Millisecond)
+	inFlight := watcher.InFlight()[0]
+	assert.Equal(t, "orch-1", inFlight.OrchestrationID)
+	assert.Equal(t, uint64(3), inFlight.Deliveries)
+	assert.GreaterOrEqual(t, inFlight.Elapsed, time.Duration(0))
+
+	assert.False(t, watcher.CancelInFlight("orch-2"))
+	assert.True(t, watcher.CancelInFlight("orch-1"))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processing did not stop after cancellation")
+	}
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.Empty(t, watcher.InFlight(), "settled messages must be removed")
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// LogKeySchema names the attributes of the structured logs emitted by the watcher so that they match the conventions
+// of the log aggregator. Empty keys default to the keys of DefaultLogKeySchema.
+type LogKeySchema struct {
+	OrchestrationID string `mapstructure:"orchestrationId"`
+	CorrelationID   string `mapstructure:"correlationId"`
+	State           string `mapstructure:"state"`
+	Error           string `mapstructure:"error"`
+}
+
+// DefaultLogKeySchema is the schema used unless configured otherwise.
+var DefaultLogKeySchema = LogKeySchema{
+	OrchestrationID: "orchestrationId",
+	CorrelationID:   "correlationId",
+	State:           "state",
+	Error:           "error",
+}
+
+// withDefaults returns the schema with empty keys replaced by the default keys.
+func (s LogKeySchema) withDefaults() LogKeySchema {
+	if s.OrchestrationID == "" {
+		s.OrchestrationID = DefaultLogKeySchema.OrchestrationID
+	}
+	if s.CorrelationID == "" {
+		s.CorrelationID = DefaultLogKeySchema.CorrelationID
+	}
+	if s.State == "" {
+		s.State = DefaultLogKeySchema.State
+	}
+	if s.Error == "" {
+		s.Error = DefaultLogKeySchema.Error
+	}
+	return s
+}
+
+// entryLogFields returns the log attributes of the entry and the error, which is omitted if nil.
+func (w *OrchestrationIndexWatcher) entryLogFields(entry *api.OrchestrationEntry, err error) []any {
+	keys := w.logKeys.withDefaults()
+	fields := []any{
+		keys.OrchestrationID, entry.ID,
+		keys.CorrelationID, entry.CorrelationID,
+		keys.State, entry.State,
+	}
+	if err != nil {
+		fields = append(fields, keys.Error, err.Error())
+	}
+	return fields
+}
+
+// idLogFields returns the log attributes of the orchestration and the error for messages whose entry is unknown. The
+// error is omitted if nil.
+func (w *OrchestrationIndexWatcher) idLogFields(orchestrationID string, err error) []any {
+	keys := w.logKeys.withDefaults()
+	fields := []any{keys.OrchestrationID, orchestrationID}
+	if err != nil {
+		fields = append(fields, keys.Error, err.Error())
+	}
+	return fields
+}
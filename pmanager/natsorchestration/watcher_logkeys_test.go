@@ -0,0 +1,95 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMonitor records the attributes of structured logs by message.
+type recordingMonitor struct {
+	system.NoopMonitor
+	mu     sync.Mutex
+	fields map[string]map[string]any
+}
+
+func (m *recordingMonitor) record(message string, keyValues []any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fields == nil {
+		m.fields = make(map[string]map[string]any)
+	}
+	attributes := make(map[string]any, len(keyValues)/2)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		attributes[keyValues[i].(string)] = keyValues[i+1]
+	}
+	m.fields[message] = attributes
+}
+
+func (m *recordingMonitor) Infow(message string, keyValues ...any) { m.record(message, keyValues) }
+
+func (m *recordingMonitor) Warnw(message string, keyValues ...any) { m.record(message, keyValues) }
+
+func (m *recordingMonitor) Severew(message string, keyValues ...any) { m.record(message, keyValues) }
+
+func TestOnMessage_LogKeySchema(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		schema   LogKeySchema
+		expected map[string]any
+	}{
+		{
+			name: "default",
+			expected: map[string]any{
+				"orchestrationId": "orch-1",
+				"correlationId":   "corr-1",
+				"state":           api.OrchestrationStateRunning,
+				"error":           "store unavailable",
+			},
+		},
+		{
+			name:   "custom",
+			schema: LogKeySchema{OrchestrationID: "orch.id", CorrelationID: "trace.correlation", Error: "err.message"},
+			expected: map[string]any{
+				"orch.id":           "orch-1",
+				"trace.correlation": "corr-1",
+				"state":             api.OrchestrationStateRunning,
+				"err.message":       "store unavailable",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			monitor := &recordingMonitor{}
+			watcher := createTestWatcher(&failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("store unavailable")},
+				&store.NoOpTransactionContext{})
+			watcher.monitor = monitor
+			watcher.logKeys = tc.schema
+
+			data, err := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+			require.NoError(t, err)
+			watcher.onMessage(data, NewMockMessage(data))
+
+			assert.Equal(t, tc.expected, monitor.fields["Failed to create orchestration entry"])
+		})
+	}
+}
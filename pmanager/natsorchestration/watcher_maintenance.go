@@ -0,0 +1,53 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"errors"
+	"time"
+)
+
+// defaultMaintenanceDelay is the redelivery delay of messages received in maintenance mode.
+const defaultMaintenanceDelay = time.Minute
+
+// ErrMaintenance is reported by the health check while the watcher is in maintenance mode.
+var ErrMaintenance = errors.New("maintenance mode enabled")
+
+// SetMaintenance pauses or resumes recording orchestration changes. In maintenance mode, messages are redelivered
+// after a delay without accessing the index, e.g. while the database is migrated.
+func (w *OrchestrationIndexWatcher) SetMaintenance(enabled bool) {
+	if w.maintenance.Swap(enabled) != enabled {
+		if enabled {
+			w.monitor.Infof("Orchestration index watcher entered maintenance mode")
+		} else {
+			w.monitor.Infof("Orchestration index watcher resumed processing")
+		}
+	}
+}
+
+// Maintenance returns true if the watcher is in maintenance mode.
+func (w *OrchestrationIndexWatcher) Maintenance() bool {
+	return w.maintenance.Load()
+}
+
+// deferMessage naks the message for redelivery once maintenance or the pause of its orchestration type is expected to
+// have ended.
+func (w *OrchestrationIndexWatcher) deferMessage(msg MessageAck) {
+	delay := w.maintenanceDelay
+	if delay <= 0 {
+		delay = defaultMaintenanceDelay
+	}
+	if err := msg.NakWithDelay(delay); err != nil {
+		w.monitor.Infof("Failed to nak deferred message: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Maintenance_NakWithoutStoreAccess(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.SetMaintenance(true)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	malformed := NewMockMessage([]byte("{not json"))
+	watcher.onMessage(malformed.data, malformed)
+
+	for _, m := range []*MockMessage{msg, malformed} {
+		assert.Equal(t, 0, m.AckCalls)
+		assert.Equal(t, 1, m.NakCalls)
+		assert.Equal(t, []time.Duration{defaultMaintenanceDelay}, m.NakDelays)
+	}
+	mockStore.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestOnMessage_Maintenance_Resumed(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.SetMaintenance(true)
+	watcher.SetMaintenance(false)
+
+	mockStore.EXPECT().FindByID(mock.Anything, "orch-1").Return(nil, types.ErrNotFound).Once()
+	mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestCheckHealth_Maintenance(t *testing.T) {
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	require.NoError(t, watcher.CheckHealth(context.Background()))
+
+	watcher.SetMaintenance(true)
+	assert.True(t, watcher.Maintenance())
+	assert.ErrorIs(t, watcher.CheckHealth(context.Background()), ErrMaintenance)
+
+	watcher.SetMaintenance(false)
+	assert.NoError(t, watcher.CheckHealth(context.Background()))
+}
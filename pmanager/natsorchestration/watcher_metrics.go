@@ -0,0 +1,105 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"sync"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// defaultDeliveryBuckets is the number of delivery attempts tracked individually by the DeliveryHistogram.
+const defaultDeliveryBuckets = 10
+
+// DeliveryHistogram is an api.DeliveryMetrics implementation counting messages per number of delivery attempts.
+// Messages needing more attempts than there are buckets are counted in the last bucket. It also implements
+// api.StateMetrics, tracking the mean time orchestrations spend in each state.
+type DeliveryHistogram struct {
+	mu     sync.RWMutex
+	counts []int64
+
+	// stateTotals and stateCounts accumulate the observed time spent per state, see MeanTimeInState.
+	stateTotals map[api.OrchestrationState]time.Duration
+	stateCounts map[api.OrchestrationState]int64
+}
+
+// NewDeliveryHistogram creates a histogram with one bucket per attempt up to the given number of buckets.
+func NewDeliveryHistogram(buckets int) *DeliveryHistogram {
+	return &DeliveryHistogram{counts: make([]int64, max(buckets, 1))}
+}
+
+func (h *DeliveryHistogram) RecordDeliveryAttempts(attempts uint64) {
+	bucket := min(max(attempts, 1), uint64(len(h.counts))) - 1
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[bucket]++
+}
+
+// Counts returns the number of messages per bucket. The count at index i is the number of messages acknowledged after
+// i+1 delivery attempts, the last bucket also includes all messages needing more attempts.
+func (h *DeliveryHistogram) Counts() []int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	counts := make([]int64, len(h.counts))
+	copy(counts, h.counts)
+	return counts
+}
+
+func (h *DeliveryHistogram) ObserveTimeInState(from api.OrchestrationState, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stateTotals == nil {
+		h.stateTotals = make(map[api.OrchestrationState]time.Duration)
+		h.stateCounts = make(map[api.OrchestrationState]int64)
+	}
+	h.stateTotals[from] += d
+	h.stateCounts[from]++
+}
+
+// MeanTimeInState returns the mean time orchestrations spent in the state before transitioning out of it, zero if no
+// transition out of the state has been observed.
+func (h *DeliveryHistogram) MeanTimeInState(state api.OrchestrationState) time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := h.stateCounts[state]
+	if count == 0 {
+		return 0
+	}
+	return h.stateTotals[state] / time.Duration(count)
+}
+
+// recordDelivery records the delivery attempts of a successfully processed message. Messages that do not report their
+// delivery count are recorded as first deliveries.
+func (w *OrchestrationIndexWatcher) recordDelivery(msg MessageAck) {
+	if w.deliveryMetrics == nil {
+		return
+	}
+	attempts := uint64(1)
+	if counter, ok := msg.(deliveryCounter); ok && counter.NumDelivered() > 0 {
+		attempts = counter.NumDelivered()
+	}
+	w.deliveryMetrics.RecordDeliveryAttempts(attempts)
+}
+
+// observeTimeInState records the time the orchestration spent in its previous state if the delivery metrics implement
+// api.StateMetrics. existing is the entry found in the index before the change. Nothing is observed for new
+// orchestrations, which have no previous state, or if the state did not change. Durations are not negative, even if
+// the clocks of publishers are skewed.
+func (w *OrchestrationIndexWatcher) observeTimeInState(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry) {
+	metrics, ok := w.deliveryMetrics.(api.StateMetrics)
+	if !ok || existing == nil || !isStateChange(entry, existing, w.clockSkewTolerance) {
+		return
+	}
+	metrics.ObserveTimeInState(existing.State, max(entry.StateTimestamp.Sub(existing.StateTimestamp), 0))
+}
@@ -0,0 +1,115 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_DeliveryMetrics_RecordsMetadataDeliveryCount(t *testing.T) {
+	histogram := NewDeliveryHistogram(5)
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.deliveryMetrics = histogram
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 3}
+	watcher.onMessage(data, msg)
+
+	require.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, []int64{0, 0, 1, 0, 0}, histogram.Counts())
+}
+
+func TestOnMessage_DeliveryMetrics_FirstDeliveryWithoutMetadata(t *testing.T) {
+	histogram := NewDeliveryHistogram(5)
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.deliveryMetrics = histogram
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	require.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, []int64{1, 0, 0, 0, 0}, histogram.Counts())
+}
+
+func TestOnMessage_DeliveryMetrics_FailedMessageNotRecorded(t *testing.T) {
+	histogram := NewDeliveryHistogram(5)
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("database unavailable")}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.deliveryMetrics = histogram
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 2}
+	watcher.onMessage(data, msg)
+
+	require.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, []int64{0, 0, 0, 0, 0}, histogram.Counts())
+}
+
+func TestOnMessage_StateMetrics_ObservesTimeInPreviousState(t *testing.T) {
+	histogram := NewDeliveryHistogram(5)
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.deliveryMetrics = histogram
+
+	initialized := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	running.StateTimestamp = initialized.StateTimestamp.Add(3 * time.Second)
+	completed := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	completed.StateTimestamp = running.StateTimestamp.Add(7 * time.Second)
+	record := func(orch api.Orchestration) {
+		data, _ := json.Marshal(orch)
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	record(initialized)
+	assert.Zero(t, histogram.MeanTimeInState(api.OrchestrationStateInitialized), "creating an entry is not a transition")
+
+	record(running)
+	record(running) // redelivered, not a transition
+	record(completed)
+
+	assert.Equal(t, 3*time.Second, histogram.MeanTimeInState(api.OrchestrationStateInitialized))
+	assert.Equal(t, 7*time.Second, histogram.MeanTimeInState(api.OrchestrationStateRunning))
+	assert.Zero(t, histogram.MeanTimeInState(api.OrchestrationStateCompleted))
+}
+
+func TestDeliveryHistogram_MeanTimeInState(t *testing.T) {
+	histogram := NewDeliveryHistogram(1)
+
+	histogram.ObserveTimeInState(api.OrchestrationStateRunning, time.Second)
+	histogram.ObserveTimeInState(api.OrchestrationStateRunning, 3*time.Second)
+
+	assert.Equal(t, 2*time.Second, histogram.MeanTimeInState(api.OrchestrationStateRunning))
+	assert.Zero(t, histogram.MeanTimeInState(api.OrchestrationStateInitialized))
+}
+
+func TestDeliveryHistogram_OverflowBucket(t *testing.T) {
+	histogram := NewDeliveryHistogram(3)
+
+	for _, attempts := range []uint64{0, 1, 3, 4, 100} {
+		histogram.RecordDeliveryAttempts(attempts)
+	}
+
+	assert.Equal(t, []int64{2, 0, 3}, histogram.Counts())
+}
@@ -0,0 +1,56 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// defaultMaxPanics is the number of consecutive panics for an orchestration after which its message is dead-lettered.
+const defaultMaxPanics = 3
+
+// recoverPanic recovers from a panic raised while processing the message so that the worker keeps running. The panic
+// is treated as a transient error and the message is redelivered. Once the messages of an orchestration panicked
+// maxPanics times in a row, the message is dead-lettered to break the crash loop. orchestrationID points to the ID of
+// the orchestration being processed, which is empty if the message was not decoded yet.
+func (w *OrchestrationIndexWatcher) recoverPanic(msg MessageAck, orchestrationID *string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	key := *orchestrationID
+	if key == "" {
+		if subjectMsg, ok := msg.(subjectMessage); ok {
+			key = subjectMsg.Subject()
+		}
+	}
+	count := w.panics.record(key)
+	w.monitor.Severew("Recovered from panic processing orchestration message",
+		w.logKeys.withDefaults().OrchestrationID, *orchestrationID,
+		"panic", fmt.Sprint(r),
+		"consecutivePanics", count,
+		"stack", string(debug.Stack()))
+
+	cause := fmt.Errorf("panic processing message: %v", r)
+	maxPanics := w.maxPanics
+	if maxPanics <= 0 {
+		maxPanics = defaultMaxPanics
+	}
+	if count >= maxPanics {
+		w.panics.reset(key)
+		w.settle(msg, *orchestrationID, ActionDeadLetter, cause)
+		return
+	}
+	w.settle(msg, *orchestrationID, ActionNak, cause)
+}
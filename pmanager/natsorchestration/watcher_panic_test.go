@@ -0,0 +1,111 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Panic_RecoveredAndNaked(t *testing.T) {
+	index := &panickingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), panics: 1}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	require.NotPanics(t, func() { watcher.onMessage(data, msg) })
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, 0, msg.AckCalls)
+
+	// The worker keeps processing the redelivered message
+	msg = NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	assert.Equal(t, 1, msg.AckCalls)
+	_, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+}
+
+func TestOnMessage_Panic_DeadLetteredAfterConsecutivePanics(t *testing.T) {
+	index := &panickingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), panics: 10}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.maxPanics = 3
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	for range 2 {
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.NakCalls)
+	}
+
+	// Without a dead letter queue the message is acked to stop redelivery
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, 1, msg.AckCalls)
+
+	// The count starts over for the next message
+	msg = NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	assert.Equal(t, 1, msg.NakCalls)
+}
+
+func TestOnMessage_Panic_CountResetAfterSuccess(t *testing.T) {
+	index := &panickingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), panics: 2}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.maxPanics = 3
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	for range 2 {
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+	watcher.onMessage(data, NewMockMessage(data))
+
+	index.panics = 2
+	data, _ = json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	for range 2 {
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		assert.Equal(t, 1, msg.NakCalls, "panics before the successful message must not count")
+	}
+}
+
+// panickingIndex panics on the given number of update calls before delegating.
+type panickingIndex struct {
+	*memorystore.OrchestrationIndex
+	panics int
+}
+
+func (i *panickingIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	i.maybePanic()
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *panickingIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	i.maybePanic()
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+func (i *panickingIndex) maybePanic() {
+	if i.panics > 0 {
+		i.panics--
+		panic("index corrupted")
+	}
+}
@@ -0,0 +1,56 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+)
+
+// ErrTypesPaused is reported by the health check while orchestration types are paused.
+var ErrTypesPaused = errors.New("orchestration types paused")
+
+// PauseType pauses recording changes of the orchestration type, e.g. during an incident affecting only its
+// orchestrations. Like in maintenance mode, messages of the type are redelivered after a delay without accessing the
+// index, while other types are processed.
+func (w *OrchestrationIndexWatcher) PauseType(orchestrationType string) {
+	if _, loaded := w.pausedTypes.LoadOrStore(model.OrchestrationType(orchestrationType), struct{}{}); !loaded {
+		w.monitor.Infof("Paused recording orchestrations of type %s", orchestrationType)
+	}
+}
+
+// ResumeType resumes recording changes of the orchestration type.
+func (w *OrchestrationIndexWatcher) ResumeType(orchestrationType string) {
+	if _, loaded := w.pausedTypes.LoadAndDelete(model.OrchestrationType(orchestrationType)); loaded {
+		w.monitor.Infof("Resumed recording orchestrations of type %s", orchestrationType)
+	}
+}
+
+// PausedTypes returns the paused orchestration types ordered by name.
+func (w *OrchestrationIndexWatcher) PausedTypes() []string {
+	var paused []string
+	w.pausedTypes.Range(func(key, _ any) bool {
+		paused = append(paused, string(key.(model.OrchestrationType)))
+		return true
+	})
+	slices.Sort(paused)
+	return paused
+}
+
+// isTypePaused returns true if changes of the orchestration type are paused.
+func (w *OrchestrationIndexWatcher) isTypePaused(orchestrationType model.OrchestrationType) bool {
+	_, paused := w.pausedTypes.Load(orchestrationType)
+	return paused
+}
@@ -0,0 +1,88 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_PausedType_NakWithoutStoreAccess(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.PauseType("cfm.paused")
+
+	mockStore.EXPECT().FindByID(mock.Anything, "orch-2").Return(nil, types.ErrNotFound).Once()
+	mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+
+	paused := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	paused.OrchestrationType = "cfm.paused"
+	pausedData, _ := json.Marshal(paused)
+	pausedMsg := NewMockMessage(pausedData)
+	watcher.onMessage(pausedData, pausedMsg)
+
+	active := createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)
+	active.OrchestrationType = "cfm.active"
+	activeData, _ := json.Marshal(active)
+	activeMsg := NewMockMessage(activeData)
+	watcher.onMessage(activeData, activeMsg)
+
+	assert.Equal(t, 0, pausedMsg.AckCalls)
+	assert.Equal(t, []time.Duration{defaultMaintenanceDelay}, pausedMsg.NakDelays)
+	mockStore.AssertNotCalled(t, "FindByID", mock.Anything, "orch-1")
+	assert.Equal(t, 1, activeMsg.AckCalls)
+	assert.Equal(t, 0, activeMsg.NakCalls)
+}
+
+func TestOnMessage_ResumedType_Recorded(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.PauseType("TestType")
+	watcher.ResumeType("TestType")
+
+	mockStore.EXPECT().FindByID(mock.Anything, "orch-1").Return(nil, types.ErrNotFound).Once()
+	mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+}
+
+func TestCheckHealth_PausedTypes(t *testing.T) {
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	watcher.PauseType("cfm.provision")
+	watcher.PauseType("cfm.deprovision")
+
+	assert.Equal(t, []string{"cfm.deprovision", "cfm.provision"}, watcher.PausedTypes())
+	err := watcher.CheckHealth(context.Background())
+	require.ErrorIs(t, err, ErrTypesPaused)
+	assert.Contains(t, err.Error(), "cfm.deprovision, cfm.provision")
+
+	watcher.ResumeType("cfm.provision")
+	watcher.ResumeType("cfm.deprovision")
+	assert.Empty(t, watcher.PausedTypes())
+	assert.NoError(t, watcher.CheckHealth(context.Background()))
+}
@@ -0,0 +1,164 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+)
+
+// errNotPending is returned when a confirmation does not match the pending state of the entry.
+var errNotPending = errors.New("no matching pending state to confirm")
+
+// SetPendingTimeout marks the change carried by the message as pending until confirmed or the timeout elapses.
+func SetPendingTimeout(header nats.Header, timeout time.Duration) {
+	MessageHeaders(header).SetPendingTimeout(timeout)
+}
+
+// SetConfirmPending marks the change carried by the message as the confirmation of the pending state.
+func SetConfirmPending(header nats.Header) {
+	MessageHeaders(header).SetConfirmPending()
+}
+
+type pendingKey struct{}
+
+// pendingDirective holds the pending handling requested by the message being recorded.
+type pendingDirective struct {
+	timeout time.Duration
+	confirm bool
+}
+
+// withPending returns a context carrying the pending directive of the message being recorded. Malformed timeouts
+// return errMalformedMessage.
+func withPending(ctx context.Context, header nats.Header) (context.Context, error) {
+	var directive pendingDirective
+	headers := MessageHeaders(header)
+	if value := header.Get(PendingTimeoutHeader); value != "" {
+		timeout, err := headers.GetPendingTimeout()
+		if err != nil || timeout <= 0 {
+			return ctx, fmt.Errorf("%w: invalid %s header %q", errMalformedMessage, PendingTimeoutHeader, value)
+		}
+		directive.timeout = timeout
+	}
+	directive.confirm = headers.GetConfirmPending()
+	if directive == (pendingDirective{}) {
+		return ctx, nil
+	}
+	if directive.timeout > 0 && directive.confirm {
+		return ctx, fmt.Errorf("%w: %s and %s are mutually exclusive", errMalformedMessage, PendingTimeoutHeader,
+			ConfirmPendingHeader)
+	}
+	return context.WithValue(ctx, pendingKey{}, directive), nil
+}
+
+// checkPendingCreate rejects pending changes and confirmations of orchestrations not recorded in the index.
+func checkPendingCreate(ctx context.Context, entry *api.OrchestrationEntry) error {
+	if _, ok := ctx.Value(pendingKey{}).(pendingDirective); ok {
+		return fmt.Errorf("%w: orchestration %s has no state to keep pending", types.ErrInvalidInput, entry.ID)
+	}
+	return nil
+}
+
+// resolvePending applies the pending directive of the message to the entry given the entry found in the index:
+//   - pending changes keep the current state and record the state of the change as pending;
+//   - confirmations promote the pending state or return errNotPending if it expired or does not match;
+//   - other changes keep an unexpired pending state until the state changes.
+func (w *OrchestrationIndexWatcher) resolvePending(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	current *api.OrchestrationEntry) error {
+	directive, _ := ctx.Value(pendingKey{}).(pendingDirective)
+	now := w.now()
+	switch {
+	case directive.timeout > 0:
+		pending := entry.State
+		entry.State, entry.StateTimestamp = current.State, current.StateTimestamp
+		entry.PendingState, entry.PendingExpiry = &pending, now.Add(directive.timeout)
+	case directive.confirm:
+		if !current.Pending(now) || *current.PendingState != entry.State {
+			return fmt.Errorf("%w: orchestration %s in state %d", errNotPending, entry.ID, entry.State)
+		}
+		entry.PendingState, entry.PendingExpiry = nil, time.Time{}
+	case entry.State == current.State && current.Pending(now):
+		entry.PendingState, entry.PendingExpiry = current.PendingState, current.PendingExpiry
+	}
+	return nil
+}
+
+// ExpirePending discards the pending states that were not confirmed before their timeout, leaving the entries in
+// their original state, and returns the number of expired pending states.
+func (w *OrchestrationIndexWatcher) ExpirePending(ctx context.Context) (int, error) {
+	now := w.now()
+	count := 0
+	err := w.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var expired []*api.OrchestrationEntry
+		predicate := query.NotIn("state", api.OrchestrationStateCompleted, api.OrchestrationStateErrored)
+		for entry, err := range w.index.FindByPredicate(ctx, predicate) {
+			if err != nil {
+				return err
+			}
+			if entry.PendingState != nil && !entry.Pending(now) {
+				expired = append(expired, entry)
+			}
+		}
+		for _, entry := range expired {
+			entry.PendingState, entry.PendingExpiry = nil, time.Time{}
+			if err := w.index.Update(ctx, entry); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire pending states: %w", err)
+	}
+	return count, nil
+}
+
+// RunPendingExpiry expires pending states at the given interval until the context is canceled. Runs are skipped while
+// another replica expires pending states.
+func (w *OrchestrationIndexWatcher) RunPendingExpiry(ctx context.Context, interval time.Duration) {
+	lock := jobLock{locker: w.index, trxContext: w.trxContext, name: pendingExpiryLock}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := lock.run(ctx, func(ctx context.Context) error {
+				_, err := w.ExpirePending(ctx)
+				return err
+			})
+			if err != nil {
+				w.monitor.Warnf("Error expiring pending orchestration states: %v", err)
+			}
+		}
+	}
+}
+
+func equalPendingState(entry *api.OrchestrationEntry, existing *api.OrchestrationEntry) bool {
+	if entry.PendingState == nil || existing.PendingState == nil {
+		return entry.PendingState == existing.PendingState
+	}
+	return *entry.PendingState == *existing.PendingState && entry.PendingExpiry.Equal(existing.PendingExpiry)
+}
@@ -0,0 +1,117 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_PendingState_ConfirmedBeforeTimeout(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	ctx := context.Background()
+
+	sendPendingChange(t, watcher, api.OrchestrationStateRunning, nats.Header{})
+
+	pending := nats.Header{}
+	SetPendingTimeout(pending, time.Minute)
+	sendPendingChange(t, watcher, api.OrchestrationStateCompleted, pending)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State, "the pending state must not be promoted before confirmation")
+	require.NotNil(t, entry.PendingState)
+	assert.Equal(t, api.OrchestrationStateCompleted, *entry.PendingState)
+
+	confirm := nats.Header{}
+	SetConfirmPending(confirm)
+	sendPendingChange(t, watcher, api.OrchestrationStateCompleted, confirm)
+
+	entry, err = index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+	assert.Nil(t, entry.PendingState)
+	assert.True(t, entry.PendingExpiry.IsZero())
+}
+
+func TestOnMessage_PendingState_TimeoutReverts(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	now := time.Now()
+	watcher.clock = func() time.Time { return now }
+	ctx := context.Background()
+
+	sendPendingChange(t, watcher, api.OrchestrationStateRunning, nats.Header{})
+
+	pending := nats.Header{}
+	SetPendingTimeout(pending, time.Minute)
+	sendPendingChange(t, watcher, api.OrchestrationStateCompleted, pending)
+
+	now = now.Add(2 * time.Minute)
+
+	// The confirmation arrives after the timeout and is not recorded
+	confirm := nats.Header{}
+	SetConfirmPending(confirm)
+	sendPendingChange(t, watcher, api.OrchestrationStateCompleted, confirm)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+
+	expired, err := watcher.ExpirePending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	entry, err = index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State, "the entry must keep its original state")
+	assert.Nil(t, entry.PendingState)
+
+	expired, err = watcher.ExpirePending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestOnMessage_PendingState_MalformedTimeoutNotRecorded(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onHeaderMessage(data, nats.Header{PendingTimeoutHeader: []string{"soon"}}, msg)
+
+	// Without a dead letter queue, dead-lettered messages are dropped
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.Error(t, err)
+}
+
+// sendPendingChange records a change of orch-1 to the given state and asserts that it is acknowledged.
+func sendPendingChange(t *testing.T, watcher *OrchestrationIndexWatcher, state api.OrchestrationState, header nats.Header) {
+	t.Helper()
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+	msg := NewMockMessage(data)
+	watcher.onHeaderMessage(data, header, msg)
+	require.Equal(t, 1, msg.AckCalls)
+}
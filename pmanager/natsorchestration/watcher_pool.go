@@ -0,0 +1,91 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// defaultPoolExhaustedPause is the time fetching is paused after the store connection pool was exhausted.
+const defaultPoolExhaustedPause = 5 * time.Second
+
+// DefaultPoolExhaustedMessages match the errors reported by Postgres when no connection slots are available.
+var DefaultPoolExhaustedMessages = []string{"too many clients", "remaining connection slots are reserved", "SQLSTATE 53300"}
+
+// ErrorClassifier returns true if the error belongs to the class of errors it recognizes.
+type ErrorClassifier func(err error) bool
+
+// MatchErrorMessages returns a classifier recognizing errors whose message contains any of the given substrings,
+// ignoring case.
+func MatchErrorMessages(messages ...string) ErrorClassifier {
+	return func(err error) bool {
+		if err == nil {
+			return false
+		}
+		msg := strings.ToLower(err.Error())
+		for _, message := range messages {
+			if message != "" && strings.Contains(msg, strings.ToLower(message)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// pauseOnPoolExhaustion pauses fetching if the store failed because its connection pool was exhausted. The message is
+// redelivered with a backoff like after any transient failure, while the pause keeps the watcher from adding load
+// until connections are released.
+func (w *OrchestrationIndexWatcher) pauseOnPoolExhaustion(err error) {
+	if err == nil || w.poolExhausted == nil || !w.poolExhausted(err) {
+		return
+	}
+	pause := w.poolPause
+	if pause <= 0 {
+		pause = defaultPoolExhaustedPause
+	}
+	until := time.Now().Add(pause).UnixNano()
+	for {
+		current := w.fetchPausedUntil.Load()
+		if current >= until || w.fetchPausedUntil.CompareAndSwap(current, until) {
+			break
+		}
+	}
+	w.monitor.Warnf("Store connection pool exhausted, pausing fetching for %s: %v", pause, err)
+}
+
+// FetchPausedUntil returns the time until which fetching is paused, the zero time if it is not paused.
+func (w *OrchestrationIndexWatcher) FetchPausedUntil() time.Time {
+	if until := w.fetchPausedUntil.Load(); until > 0 {
+		return time.Unix(0, until)
+	}
+	return time.Time{}
+}
+
+// waitFetchPause blocks until fetching is no longer paused or the context is canceled.
+func (w *OrchestrationIndexWatcher) waitFetchPause(ctx context.Context) error {
+	for {
+		remaining := time.Until(w.FetchPausedUntil())
+		if remaining <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
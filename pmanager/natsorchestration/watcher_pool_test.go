@@ -0,0 +1,76 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_PoolExhausted_NakedAndFetchingPaused(t *testing.T) {
+	poolErr := errors.New("failed to connect: FATAL: sorry, too many clients already (SQLSTATE 53300)")
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: poolErr}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+	watcher.poolExhausted = MatchErrorMessages(DefaultPoolExhaustedMessages...)
+	watcher.poolPause = 200 * time.Millisecond
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.Equal(t, []time.Duration{time.Second}, msg.NakDelays, "the message must be redelivered with a backoff")
+	assert.True(t, watcher.FetchPausedUntil().After(time.Now()), "fetching must be paused")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumer := &stubConsumer{onFetch: cancel}
+	start := time.Now()
+
+	err := watcher.processLoop(ctx, consumer)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, consumer.fetches)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "the fetch must wait for the pause to end")
+}
+
+func TestOnMessage_OtherStoreErrors_FetchingNotPaused(t *testing.T) {
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("connection reset")}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.poolExhausted = MatchErrorMessages(DefaultPoolExhaustedMessages...)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.True(t, watcher.FetchPausedUntil().IsZero())
+}
+
+func TestMatchErrorMessages(t *testing.T) {
+	classifier := MatchErrorMessages("Pool Exhausted", "")
+
+	assert.True(t, classifier(errors.New("connection pool exhausted")))
+	assert.False(t, classifier(errors.New("connection refused")))
+	assert.False(t, classifier(nil))
+}
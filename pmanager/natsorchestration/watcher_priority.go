@@ -0,0 +1,100 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// processFetched records the fetched messages. If a priority window is configured, the messages immediately available
+// are buffered up to the window size and processed highest priority first, see prioritize. Fetched changes sharing a
+// correlation ID are recorded together if the watcher is configured to, see processCorrelated.
+//
+// Streams deliver messages in order, so prioritization is limited to reordering the buffered window: a message is
+// only overtaken by more urgent messages fetched together with it. Larger windows reorder more but hold more messages
+// unacknowledged while they wait, which counts against the consumer ack wait.
+func (w *OrchestrationIndexWatcher) processFetched(
+	consumer jetstream.Consumer,
+	batch jetstream.MessageBatch,
+	source *sourceState) {
+	if w.priorityWindow <= 1 {
+		if w.correlatedBatches {
+			var fetched []jetstream.Msg
+			for message := range batch.Messages() {
+				fetched = append(fetched, message)
+			}
+			w.processCorrelated(fetched, source)
+			return
+		}
+		for message := range batch.Messages() {
+			w.onSourceMessage(source.receive(), message.Data(), message.Headers(), jetStreamMessageAck{msg: message})
+		}
+		return
+	}
+	window := make([]jetstream.Msg, 0, w.priorityWindow)
+	for message := range batch.Messages() {
+		window = append(window, message)
+	}
+	if len(window) > 0 && len(window) < w.priorityWindow {
+		// Fill the window without waiting so that prioritization does not delay processing
+		if fill, err := consumer.FetchNoWait(w.priorityWindow - len(window)); err == nil {
+			for message := range fill.Messages() {
+				window = append(window, message)
+			}
+		}
+	}
+	if w.correlatedBatches {
+		w.processCorrelated(prioritize(window), source)
+		return
+	}
+	for _, message := range prioritize(window) {
+		w.onSourceMessage(source.receive(), message.Data(), message.Headers(), jetStreamMessageAck{msg: message})
+	}
+}
+
+// prioritize orders messages highest priority first. Changes of an orchestration are kept in order: a message is
+// moved ahead together with the earlier messages on its subject, which inherit its priority.
+func prioritize(messages []jetstream.Msg) []jetstream.Msg {
+	priorities := make([]int, len(messages))
+	subjectPriorities := make(map[string]int, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		priority := messagePriority(messages[i].Headers())
+		subject := messages[i].Subject()
+		if later, found := subjectPriorities[subject]; found && later > priority {
+			priority = later
+		}
+		subjectPriorities[subject] = priority
+		priorities[i] = priority
+	}
+
+	order := make([]int, len(messages))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(priorities[b], priorities[a])
+	})
+	prioritized := make([]jetstream.Msg, len(messages))
+	for i, index := range order {
+		prioritized[i] = messages[index]
+	}
+	return prioritized
+}
+
+func messagePriority(header nats.Header) int {
+	return MessageHeaders(header).GetPriority()
+}
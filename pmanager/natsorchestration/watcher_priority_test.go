@@ -0,0 +1,136 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessLoop_PriorityWindow_HigherPriorityProcessedFirst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := &changeRecordingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.priorityWindow = 10
+
+	consumer := &queueConsumer{}
+	consumer.push(
+		newPriorityMsg(t, "orch-1", api.OrchestrationStateRunning, 0),
+		newPriorityMsg(t, "orch-2", api.OrchestrationStateRunning, 1),
+		newPriorityMsg(t, "orch-3", api.OrchestrationStateRunning, 5),
+	)
+	go func() { _ = watcher.processLoop(ctx, consumer) }()
+
+	require.Eventually(t, func() bool { return len(index.changes()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"orch-3:1", "orch-2:1", "orch-1:1"}, index.changes())
+}
+
+func TestProcessLoop_PriorityWindow_PreservesOrchestrationOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := &changeRecordingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.priorityWindow = 10
+
+	consumer := &queueConsumer{}
+	consumer.push(
+		newPriorityMsg(t, "orch-1", api.OrchestrationStateRunning, 0),
+		newPriorityMsg(t, "orch-2", api.OrchestrationStateRunning, 5),
+		// The urgent change moves the earlier change of its orchestration ahead with it
+		newPriorityMsg(t, "orch-1", api.OrchestrationStateCompleted, 9),
+	)
+	go func() { _ = watcher.processLoop(ctx, consumer) }()
+
+	require.Eventually(t, func() bool { return len(index.changes()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"orch-1:1", "orch-1:2", "orch-2:1"}, index.changes())
+}
+
+func TestProcessLoop_PriorityWindowDisabled_FetchOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := &changeRecordingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	consumer := &queueConsumer{}
+	consumer.push(
+		newPriorityMsg(t, "orch-1", api.OrchestrationStateRunning, 0),
+		newPriorityMsg(t, "orch-2", api.OrchestrationStateRunning, 5),
+	)
+	go func() { _ = watcher.processLoop(ctx, consumer) }()
+
+	require.Eventually(t, func() bool { return len(index.changes()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"orch-1:1", "orch-2:1"}, index.changes())
+}
+
+// priorityMsg is an orchestration change carrying a priority header.
+type priorityMsg struct {
+	subjectMsg
+	header nats.Header
+}
+
+func newPriorityMsg(t *testing.T, id string, state api.OrchestrationState, priority int) jetstream.Msg {
+	orchestration := createWatcherOrchestration(id, "corr-"+id, state)
+	data, err := json.Marshal(orchestration)
+	require.NoError(t, err)
+	header := nats.Header{}
+	header.Set(PriorityHeader, strconv.Itoa(priority))
+	return &priorityMsg{subjectMsg: subjectMsg{subject: "$KV.cfm-orchestrations." + id, data: data}, header: header}
+}
+
+func (m *priorityMsg) Headers() nats.Header { return m.header }
+
+// changeRecordingIndex records created and updated entries as "<id>:<state>" in the order they are written.
+type changeRecordingIndex struct {
+	*memorystore.OrchestrationIndex
+	mu      sync.Mutex
+	written []string
+}
+
+func (i *changeRecordingIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	i.record(entry)
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *changeRecordingIndex) Update(ctx context.Context, entry *api.OrchestrationEntry) error {
+	i.record(entry)
+	return i.OrchestrationIndex.Update(ctx, entry)
+}
+
+func (i *changeRecordingIndex) record(entry *api.OrchestrationEntry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.written = append(i.written, fmt.Sprintf("%s:%d", entry.ID, entry.State))
+}
+
+func (i *changeRecordingIndex) changes() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]string(nil), i.written...)
+}
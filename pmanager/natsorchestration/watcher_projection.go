@@ -0,0 +1,88 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"sync"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// RegisterProjector adds a projector invoked with each entry recorded in the index after the transaction commits.
+// Projections are queued and retried with backoff independently of each other, so a failing projector neither delays
+// acknowledgements nor other projectors. Projectors must be registered before the watcher starts processing; their
+// queues are run by RunProjections.
+func (w *OrchestrationIndexWatcher) RegisterProjector(projector api.Projector) {
+	w.projections = append(w.projections, NewStateOutbox(projectorForwarder{projector: projector}, defaultStateOutboxCapacity, w.monitor))
+}
+
+// RunProjections applies queued entries to the registered projectors until the context is canceled.
+func (w *OrchestrationIndexWatcher) RunProjections(ctx context.Context) {
+	for _, projection := range w.projections {
+		go projection.Run(ctx)
+	}
+}
+
+// project queues the recorded entry for all registered projectors.
+func (w *OrchestrationIndexWatcher) project(entry *api.OrchestrationEntry) {
+	for _, projection := range w.projections {
+		projection.Enqueue(entry)
+	}
+}
+
+// projectorForwarder adapts a projector to the StateOutbox.
+type projectorForwarder struct {
+	projector api.Projector
+}
+
+func (f projectorForwarder) Forward(ctx context.Context, entry *api.OrchestrationEntry) error {
+	return f.projector.Project(ctx, entry)
+}
+
+// CountProjection is an example api.Projector counting orchestrations per group, e.g. per customer. An orchestration
+// is counted once, in the group of the first entry projected for it.
+type CountProjection struct {
+	group func(entry *api.OrchestrationEntry) string
+
+	mu      sync.RWMutex
+	counted map[string]struct{}
+	counts  map[string]int
+}
+
+// NewCountProjection creates a projection counting orchestrations in the group returned by the given function.
+func NewCountProjection(group func(entry *api.OrchestrationEntry) string) *CountProjection {
+	return &CountProjection{
+		group:   group,
+		counted: make(map[string]struct{}),
+		counts:  make(map[string]int),
+	}
+}
+
+func (p *CountProjection) Project(_ context.Context, entry *api.OrchestrationEntry) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, found := p.counted[entry.ID]; found {
+		return nil
+	}
+	p.counted[entry.ID] = struct{}{}
+	p.counts[p.group(entry)]++
+	return nil
+}
+
+// Count returns the number of orchestrations in the group.
+func (p *CountProjection) Count(group string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.counts[group]
+}
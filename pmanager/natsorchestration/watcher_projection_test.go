@@ -0,0 +1,174 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Projector_InvokedForEachRecordedEntry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := &flakyIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	projector := newRecordingForwarder(nil)
+	watcher.RegisterProjector(projectorFunc(projector.Forward))
+	watcher.RunProjections(ctx)
+
+	running, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	completed, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	other, _ := json.Marshal(createWatcherOrchestration("orch-2", "corr-1", api.OrchestrationStateRunning))
+	for _, change := range []struct {
+		data     []byte
+		failures int
+	}{
+		{running, 0},
+		{other, 1},   // fails, not projected
+		{running, 0}, // redelivered, not recorded
+		{completed, 0},
+		{other, 0},
+	} {
+		index.failures = change.failures
+		watcher.onMessage(change.data, NewMockMessage(change.data))
+	}
+
+	require.Eventually(t, func() bool { return len(projector.forwardedStates()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []api.OrchestrationState{
+		api.OrchestrationStateRunning, api.OrchestrationStateCompleted, api.OrchestrationStateRunning,
+	}, projector.forwardedStates())
+}
+
+func TestOnMessage_Projector_FailureDoesNotBlockAck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	failing := newRecordingForwarder([]error{errors.New("read model unavailable")})
+	counts := NewCountProjection(func(entry *api.OrchestrationEntry) string { return entry.CorrelationID })
+	watcher.RegisterProjector(projectorFunc(failing.Forward))
+	watcher.RegisterProjector(counts)
+	watcher.projections[0].backoff = ExponentialBackoff{Initial: time.Millisecond}
+	watcher.RunProjections(ctx)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	require.Eventually(t, func() bool { return len(failing.forwardedStates()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, failing.attemptCount(), "failed projections are retried")
+	require.Eventually(t, func() bool { return counts.Count("corr-1") == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestCountProjection_CountsOrchestrationsOnce(t *testing.T) {
+	counts := NewCountProjection(func(entry *api.OrchestrationEntry) string { return entry.Labels["customer"] })
+	ctx := context.Background()
+
+	for _, entry := range []*api.OrchestrationEntry{
+		{ID: "orch-1", State: api.OrchestrationStateRunning, Labels: map[string]string{"customer": "acme"}},
+		{ID: "orch-1", State: api.OrchestrationStateCompleted, Labels: map[string]string{"customer": "acme"}},
+		{ID: "orch-2", State: api.OrchestrationStateRunning, Labels: map[string]string{"customer": "acme"}},
+		{ID: "orch-3", State: api.OrchestrationStateRunning, Labels: map[string]string{"customer": "globex"}},
+	} {
+		require.NoError(t, counts.Project(ctx, entry))
+	}
+
+	assert.Equal(t, 2, counts.Count("acme"))
+	assert.Equal(t, 1, counts.Count("globex"))
+	assert.Equal(t, 0, counts.Count("initech"))
+}
+
+// projectorFunc adapts a function to api.Projector.
+type projectorFunc func(ctx context.Context, entry *api.OrchestrationEntry) error
+
+func (f projectorFunc) Project(ctx context.Context, entry *api.OrchestrationEntry) error {
+	return f(ctx, entry)
+}
+
+func TestOnMessage_HandlerRegistry_DispatchesByMode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stable := newRecordingForwarder(nil)
+	experimental := newRecordingForwarder(nil)
+	handlers := api.NewHandlerRegistry()
+	handlers.Register("test", "", handlerFunc(stable.Forward))
+	handlers.Register("test", "experimental", handlerFunc(experimental.Forward))
+
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.RegisterProjector(handlers)
+	watcher.RunProjections(ctx)
+
+	orch1 := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orch1.OrchestrationType = "test"
+	orch2 := createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)
+	orch2.OrchestrationType = "test"
+	orch2.Mode = "experimental"
+	for _, orch := range []api.Orchestration{orch1, orch2} {
+		data, _ := json.Marshal(orch)
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+
+	require.Eventually(t, func() bool {
+		return len(stable.forwardedStates()) == 1 && len(experimental.forwardedStates()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "orch-1", stable.forwarded[0].ID)
+	assert.Equal(t, "orch-2", experimental.forwarded[0].ID)
+	assert.Equal(t, "experimental", experimental.forwarded[0].Mode)
+}
+
+// handlerFunc adapts a function to api.EntryHandler.
+type handlerFunc func(ctx context.Context, entry *api.OrchestrationEntry) error
+
+func (f handlerFunc) Handle(ctx context.Context, entry *api.OrchestrationEntry) error {
+	return f(ctx, entry)
+}
+
+func TestOnMessage_Projector_ReceivesCopy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	mutated := make(chan struct{})
+	watcher.RegisterProjector(projectorFunc(func(_ context.Context, entry *api.OrchestrationEntry) error {
+		entry.Labels["region"] = "us"
+		close(mutated)
+		return nil
+	}))
+	events, unsubscribe := watcher.Subscribe(nil)
+	defer unsubscribe()
+	watcher.RunProjections(ctx)
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orch.Labels = map[string]string{"region": "eu"}
+	data, _ := json.Marshal(orch)
+	watcher.onMessage(data, NewMockMessage(data))
+
+	<-mutated
+	entry := (<-events).Entry
+	assert.Equal(t, "eu", entry.Labels["region"], "mutations of a projector must not be visible to subscribers")
+	stored, err := watcher.index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "eu", stored.Labels["region"])
+}
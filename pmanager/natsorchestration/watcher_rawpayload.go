@@ -0,0 +1,39 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"slices"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// saveRawPayload records the raw message that produced the entry revision for audit. It is called in the transaction
+// updating the index so that payloads are only recorded for committed changes. Nothing is recorded if the change was
+// not written to the index.
+func (w *OrchestrationIndexWatcher) saveRawPayload(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	existing *api.OrchestrationEntry,
+	data []byte) error {
+	if w.rawPayloads == nil || !isRecorded(entry, existing, w.clockSkewTolerance) {
+		return nil
+	}
+	return w.rawPayloads.Save(ctx, &api.RawPayload{
+		ID:               entry.ID,
+		Revision:         entry.Revision,
+		Payload:          slices.Clone(data),
+		CreatedTimestamp: w.now(),
+	})
+}
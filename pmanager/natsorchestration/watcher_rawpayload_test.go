@@ -0,0 +1,74 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_RawPayload_RetrievableAfterUpdate(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	payloads := memorystore.NewRawPayloadStore()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.rawPayloads = payloads
+	ctx := context.Background()
+
+	running, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(running, newDLQMessage("$KV.cfm-orchestrations.orch-1", 4, string(running), nil))
+
+	// Fields unknown to the entry are kept in the raw payload
+	var completed map[string]any
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	require.NoError(t, json.Unmarshal(data, &completed))
+	completed["auditNote"] = "approved"
+	data, _ = json.Marshal(completed)
+	msg := newDLQMessage("$KV.cfm-orchestrations.orch-1", 7, string(data), nil)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), entry.Revision)
+	payload, err := payloads.Find(ctx, "orch-1", entry.Revision)
+	require.NoError(t, err)
+	assert.Equal(t, data, payload.Payload)
+
+	payload, err = payloads.Find(ctx, "orch-1", 4)
+	require.NoError(t, err)
+	assert.Equal(t, running, payload.Payload, "the payload creating the entry is kept")
+}
+
+func TestOnMessage_RawPayload_NotRecordedForSkippedChanges(t *testing.T) {
+	payloads := memorystore.NewRawPayloadStore()
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.rawPayloads = payloads
+	ctx := context.Background()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, newDLQMessage("$KV.cfm-orchestrations.orch-1", 1, string(data), nil))
+	require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 1, Payload: []byte("audited")}))
+	// Redelivered
+	watcher.onMessage(data, newDLQMessage("$KV.cfm-orchestrations.orch-1", 1, string(data), nil))
+
+	payload, err := payloads.Find(ctx, "orch-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("audited"), payload.Payload, "skipped changes must not replace recorded payloads")
+}
@@ -0,0 +1,97 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ErrConsumerDeleted is reported when the consumer the watcher reads from no longer exists and cannot be recreated.
+var ErrConsumerDeleted = errors.New("orchestration index consumer deleted")
+
+// ConsumerProvisioner creates the consumer the watcher reads from, e.g. after it was deleted by an operator.
+type ConsumerProvisioner func(ctx context.Context) (jetstream.Consumer, error)
+
+// watcherHealth records why the watcher stopped processing orchestration changes.
+type watcherHealth struct {
+	mu      sync.RWMutex
+	failure error
+}
+
+func (h *watcherHealth) fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failure = err
+}
+
+func (h *watcherHealth) err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.failure
+}
+
+// CheckHealth returns an error if the watcher stopped processing orchestration changes, is in maintenance mode, has
+// been drained or pauses orchestration types. The error lists the paused types.
+func (w *OrchestrationIndexWatcher) CheckHealth(context.Context) error {
+	if err := w.health.err(); err != nil {
+		return fmt.Errorf("orchestration index watcher stopped: %w", err)
+	}
+	if w.maintenance.Load() {
+		return fmt.Errorf("orchestration index watcher paused: %w", ErrMaintenance)
+	}
+	if w.draining.Load() {
+		return fmt.Errorf("orchestration index watcher stopped: %w", ErrDraining)
+	}
+	if paused := w.PausedTypes(); len(paused) > 0 {
+		return fmt.Errorf("%w: %s", ErrTypesPaused, strings.Join(paused, ", "))
+	}
+	return nil
+}
+
+// recoverConsumer recreates a deleted consumer if a provisioner is configured. Otherwise, ErrConsumerDeleted is
+// returned and the watcher must be restarted once the consumer is provisioned again.
+func (w *OrchestrationIndexWatcher) recoverConsumer(
+	ctx context.Context,
+	provision ConsumerProvisioner) (jetstream.Consumer, error) {
+	if provision == nil {
+		return nil, ErrConsumerDeleted
+	}
+	consumer, err := provision(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error recreating consumer: %w", ErrConsumerDeleted, err)
+	}
+	w.monitor.Warnf("Orchestration index consumer was deleted and has been recreated")
+	return consumer, nil
+}
+
+// isConsumerDeleted returns true if the fetch error was caused by the consumer no longer existing.
+func isConsumerDeleted(ctx context.Context, consumer jetstream.Consumer, err error) bool {
+	if errors.Is(err, jetstream.ErrConsumerDeleted) ||
+		errors.Is(err, jetstream.ErrConsumerNotFound) ||
+		errors.Is(err, jetstream.ErrConsumerDoesNotExist) {
+		return true
+	}
+	if !errors.Is(err, nats.ErrNoResponders) {
+		return false
+	}
+	// Pull requests for a missing consumer are not answered; confirm the consumer is gone by looking it up
+	_, infoErr := consumer.Info(ctx)
+	return errors.Is(infoErr, jetstream.ErrConsumerNotFound)
+}
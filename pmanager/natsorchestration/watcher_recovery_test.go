@@ -0,0 +1,156 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessLoop_ConsumerDeleted_Recreated(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deleted := &stubConsumer{batchErr: jetstream.ErrConsumerDeleted}
+	recreated := &stubConsumer{onFetch: cancel}
+	provisions := 0
+
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	watcher.provisionConsumer = func(context.Context) (jetstream.Consumer, error) {
+		provisions++
+		return recreated, nil
+	}
+
+	err := watcher.processLoop(ctx, deleted)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, provisions)
+	assert.Equal(t, 1, recreated.fetches)
+	assert.NoError(t, watcher.CheckHealth(context.Background()))
+}
+
+func TestProcessLoop_ConsumerNotFound_NoProvisioner_Unhealthy(t *testing.T) {
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+
+	err := watcher.processLoop(context.Background(), &stubConsumer{fetchErr: jetstream.ErrConsumerNotFound})
+
+	require.ErrorIs(t, err, ErrConsumerDeleted)
+	health := watcher.CheckHealth(context.Background())
+	require.Error(t, health)
+	assert.ErrorIs(t, health, ErrConsumerDeleted)
+}
+
+func TestProcessLoop_NoResponders_ConsumerMissing_Recreated(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	missing := &stubConsumer{fetchErr: nats.ErrNoResponders, infoErr: jetstream.ErrConsumerNotFound}
+	recreated := &stubConsumer{onFetch: cancel}
+
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	watcher.provisionConsumer = func(context.Context) (jetstream.Consumer, error) {
+		return recreated, nil
+	}
+
+	err := watcher.processLoop(ctx, missing)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, recreated.fetches)
+}
+
+func TestProcessLoop_NoResponders_ConsumerExists_ReturnsError(t *testing.T) {
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	watcher.provisionConsumer = func(context.Context) (jetstream.Consumer, error) {
+		t.Fatal("consumer must not be recreated")
+		return nil, nil
+	}
+
+	err := watcher.processLoop(context.Background(), &stubConsumer{fetchErr: nats.ErrNoResponders})
+
+	require.ErrorIs(t, err, nats.ErrNoResponders)
+	assert.ErrorIs(t, watcher.CheckHealth(context.Background()), nats.ErrNoResponders)
+}
+
+func TestProcessLoop_RecreateFails_Unhealthy(t *testing.T) {
+	provisionErr := errors.New("stream not found")
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+	watcher.provisionConsumer = func(context.Context) (jetstream.Consumer, error) {
+		return nil, provisionErr
+	}
+
+	err := watcher.processLoop(context.Background(), &stubConsumer{batchErr: jetstream.ErrConsumerDeleted})
+
+	require.ErrorIs(t, err, ErrConsumerDeleted)
+	assert.ErrorIs(t, err, provisionErr)
+	assert.ErrorIs(t, watcher.CheckHealth(context.Background()), ErrConsumerDeleted)
+}
+
+func TestProcessLoop_Canceled_Healthy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := createTestWatcher(nil, &store.NoOpTransactionContext{})
+
+	err := watcher.processLoop(ctx, &stubConsumer{onFetch: cancel})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, watcher.CheckHealth(context.Background()))
+}
+
+// stubConsumer returns empty batches, optionally failing the fetch or terminating the batch with an error.
+type stubConsumer struct {
+	jetstream.Consumer
+	fetchErr error
+	batchErr error
+	infoErr  error
+	onFetch  func()
+	fetches  int
+}
+
+func (c *stubConsumer) Fetch(int, ...jetstream.FetchOpt) (jetstream.MessageBatch, error) {
+	c.fetches++
+	if c.onFetch != nil {
+		c.onFetch()
+	}
+	if c.fetchErr != nil {
+		return nil, c.fetchErr
+	}
+	messages := make(chan jetstream.Msg)
+	close(messages)
+	return stubBatch{messages: messages, err: c.batchErr}, nil
+}
+
+func (c *stubConsumer) Info(context.Context) (*jetstream.ConsumerInfo, error) {
+	if c.infoErr != nil {
+		return nil, c.infoErr
+	}
+	return &jetstream.ConsumerInfo{}, nil
+}
+
+type stubBatch struct {
+	messages chan jetstream.Msg
+	err      error
+}
+
+func (b stubBatch) Messages() <-chan jetstream.Msg {
+	return b.messages
+}
+
+func (b stubBatch) Error() error {
+	return b.err
+}
@@ -0,0 +1,63 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// RunN fetches and processes up to n messages from the consumer and returns the number of messages processed, e.g. for
+// scheduled jobs that must terminate. Messages are processed as by the process loop, except that they are not
+// prioritized, and at most n messages are fetched. RunN returns early once no messages are available or the watcher is
+// drained.
+//
+// If ctx is canceled, the messages already fetched are processed before ctx.Err() is returned. Debounced changes and
+// deferred acknowledgements are flushed before RunN returns.
+func (w *OrchestrationIndexWatcher) RunN(ctx context.Context, n int) (processed int, err error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: the number of messages must be positive", types.ErrInvalidInput)
+	}
+	if w.consumer == nil {
+		return 0, errors.New("orchestration index watcher is not connected to a consumer")
+	}
+	defer w.flushDeferred()
+
+	for processed < n && !w.draining.Load() {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		if err := w.waitFetchPause(ctx); err != nil {
+			return processed, err
+		}
+		batch, err := w.consumer.Fetch(min(max(w.fetchBatch, 1), n-processed), jetstream.FetchMaxWait(time.Second))
+		if err != nil {
+			return processed, err
+		}
+		fetched := 0
+		for message := range batch.Messages() {
+			w.onSourceMessage("", message.Data(), message.Headers(), jetStreamMessageAck{msg: message})
+			fetched++
+		}
+		processed += fetched
+		if fetched == 0 {
+			return processed, batch.Error()
+		}
+	}
+	return processed, nil
+}
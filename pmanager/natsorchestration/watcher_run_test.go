@@ -0,0 +1,92 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunN_StopsAfterNMessages(t *testing.T) {
+	index := createTestStore(t)
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.fetchBatch = 2
+	consumer := &queueConsumer{}
+	messages := pushOrchestrations(consumer, 5)
+	watcher.consumer = consumer
+
+	processed, err := watcher.RunN(context.Background(), 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, []int{2, 1}, consumer.batches, "no more than n messages are fetched")
+	assert.Len(t, consumer.pending, 2)
+	for i, msg := range messages {
+		_, err := index.FindByID(context.Background(), fmt.Sprintf("orch-%d", i))
+		if i < 3 {
+			assert.Equal(t, 1, msg.acks)
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, 0, msg.acks)
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestRunN_ReturnsWhenNoMessagesAvailable(t *testing.T) {
+	watcher := createTestWatcher(createTestStore(t), &store.NoOpTransactionContext{})
+	consumer := &queueConsumer{}
+	pushOrchestrations(consumer, 2)
+	watcher.consumer = consumer
+
+	processed, err := watcher.RunN(context.Background(), 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	assert.Empty(t, consumer.pending)
+}
+
+func TestRunN_Canceled(t *testing.T) {
+	watcher := createTestWatcher(createTestStore(t), &store.NoOpTransactionContext{})
+	consumer := &queueConsumer{}
+	pushOrchestrations(consumer, 2)
+	watcher.consumer = consumer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	processed, err := watcher.RunN(ctx, 2)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, processed)
+	assert.Len(t, consumer.pending, 2)
+}
+
+// pushOrchestrations queues messages carrying n running orchestrations named orch-0 to orch-<n-1>.
+func pushOrchestrations(consumer *queueConsumer, n int) []*subjectMsg {
+	messages := make([]*subjectMsg, 0, n)
+	for i := range n {
+		id := fmt.Sprintf("orch-%d", i)
+		data, _ := json.Marshal(createWatcherOrchestration(id, "corr-1", api.OrchestrationStateRunning))
+		msg := &subjectMsg{subject: "$KV.cfm-orchestrations." + id, data: data}
+		messages = append(messages, msg)
+		consumer.push(msg)
+	}
+	return messages
+}
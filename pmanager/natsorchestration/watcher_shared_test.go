@@ -0,0 +1,109 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/natsfixtures"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestrationIndexWatcher_SharedDurable_ProcessesEachChangeOnce(t *testing.T) {
+	const bucket = "cfm-shared-watcher-bucket"
+	const changes = 50
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	nt, err := natsfixtures.SetupNatsContainer(ctx, bucket)
+	require.NoError(t, err)
+	defer natsfixtures.TeardownNatsContainer(ctx, nt)
+
+	second, err := natsclient.NewNatsClient(nt.URI, bucket)
+	require.NoError(t, err)
+	defer second.Close()
+
+	consumerConfig, err := newWatcherConsumerConfig(bucket, WatcherConfig{Durable: "shared-index", DeliverPolicy: DeliverAll})
+	require.NoError(t, err)
+
+	// Each watcher records into its own index so duplicate deliveries show up in both
+	indexes := make([]*recordingIndex, 0, 2)
+	for _, client := range []*natsclient.NatsClient{nt.Client, second} {
+		consumer, err := client.JetStream.CreateOrUpdateConsumer(ctx, kvStreamName(bucket), consumerConfig)
+		require.NoError(t, err)
+
+		index := &recordingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+		indexes = append(indexes, index)
+		watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+		go func() {
+			_ = watcher.processLoop(ctx, consumer)
+		}()
+	}
+
+	for i := range changes {
+		id := fmt.Sprintf("orch-%d", i)
+		data, err := json.Marshal(createWatcherOrchestration(id, "corr-"+id, api.OrchestrationStateRunning))
+		require.NoError(t, err)
+		_, err = nt.Client.KVStore.Put(ctx, id, data)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return len(indexes[0].createdIDs())+len(indexes[1].createdIDs()) >= changes
+	}, 20*time.Second, 50*time.Millisecond)
+	// Give redeliveries a chance to show up
+	time.Sleep(500 * time.Millisecond)
+
+	processed := make(map[string]int)
+	for _, index := range indexes {
+		assert.NotEmpty(t, index.createdIDs(), "the load must be shared across watchers")
+		for _, id := range index.createdIDs() {
+			processed[id]++
+		}
+	}
+	assert.Len(t, processed, changes)
+	for id, count := range processed {
+		assert.Equal(t, 1, count, "change %s must be processed exactly once", id)
+	}
+}
+
+// recordingIndex records the IDs of created entries.
+type recordingIndex struct {
+	*memorystore.OrchestrationIndex
+	mu      sync.Mutex
+	created []string
+}
+
+func (i *recordingIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	i.mu.Lock()
+	i.created = append(i.created, entry.ID)
+	i.mu.Unlock()
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *recordingIndex) createdIDs() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]string(nil), i.created...)
+}
@@ -0,0 +1,43 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// SLOBreachHook is invoked with a recorded entry whose change was recorded later after publishing than the processing
+// SLO allows, e.g. to raise an alert. latency is the time from publishing the change to recording it.
+type SLOBreachHook func(entry *api.OrchestrationEntry, latency time.Duration)
+
+// publishTimer is implemented by messages exposing the time they were published.
+type publishTimer interface {
+	PublishTime() time.Time
+}
+
+// checkSLO invokes the SLO breach hook if the processing latency of the recorded entry exceeds the SLO threshold.
+// Messages that do not report their publish time are not checked.
+func (w *OrchestrationIndexWatcher) checkSLO(msg MessageAck, entry *api.OrchestrationEntry) {
+	if w.onSLOBreach == nil || w.sloThreshold <= 0 {
+		return
+	}
+	timer, ok := msg.(publishTimer)
+	if !ok || timer.PublishTime().IsZero() {
+		return
+	}
+	if latency := w.now().Sub(timer.PublishTime()); latency > w.sloThreshold {
+		w.onSLOBreach(entry, latency)
+	}
+}
@@ -0,0 +1,69 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_SLOBreach_HookInvokedForDelayedMessage(t *testing.T) {
+	var breached []*api.OrchestrationEntry
+	var latencies []time.Duration
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.sloThreshold = time.Second
+	watcher.onSLOBreach = func(entry *api.OrchestrationEntry, latency time.Duration) {
+		breached = append(breached, entry)
+		latencies = append(latencies, latency)
+	}
+
+	delayed, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := &publishedMessage{MockMessage: NewMockMessage(delayed), published: time.Now().Add(-5 * time.Second)}
+	watcher.onMessage(delayed, msg)
+
+	timely, _ := json.Marshal(createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning))
+	watcher.onMessage(timely, &publishedMessage{MockMessage: NewMockMessage(timely), published: time.Now()})
+
+	require.Equal(t, 1, msg.AckCalls)
+	require.Len(t, breached, 1)
+	assert.Equal(t, "orch-1", breached[0].ID)
+	assert.GreaterOrEqual(t, latencies[0], 5*time.Second)
+}
+
+func TestOnMessage_SLOBreach_DisabledWithoutThreshold(t *testing.T) {
+	invoked := false
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.onSLOBreach = func(*api.OrchestrationEntry, time.Duration) { invoked = true }
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, &publishedMessage{MockMessage: NewMockMessage(data), published: time.Now().Add(-time.Hour)})
+
+	assert.False(t, invoked, "the hook must not be invoked without a threshold")
+}
+
+// publishedMessage reports the time it was published.
+type publishedMessage struct {
+	*MockMessage
+	published time.Time
+}
+
+func (m *publishedMessage) PublishTime() time.Time {
+	return m.published
+}
@@ -0,0 +1,178 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SourceLabel is the label set on entries recorded from a named source to the source name, e.g. the cluster the change
+// was received from. Projectors and forwarders can use it to route entries.
+const SourceLabel = "source"
+
+// Source is a consumer of the orchestration bucket in a NATS account or cluster, see RunSources.
+type Source struct {
+	// Name identifies the source in labels, status and logs.
+	Name string
+
+	// Consumer delivers the orchestration changes of the source.
+	Consumer jetstream.Consumer
+
+	// Provision recreates the consumer if it is deleted. When nil, consuming the source stops instead.
+	Provision ConsumerProvisioner
+}
+
+// SourceStatus reports how the changes of a source are consumed.
+type SourceStatus struct {
+	// Received is the number of messages received from the source.
+	Received int64
+
+	// Failure is the error that stopped consuming the source, nil while it is consumed.
+	Failure error
+}
+
+// sourceConfig configures a source connected by the assembly.
+type sourceConfig struct {
+	Name string `mapstructure:"name"`
+	URI  string `mapstructure:"uri"`
+}
+
+// validateSources checks that configured sources are named uniquely and have a URI.
+func validateSources(configs []sourceConfig) error {
+	names := make(map[string]struct{}, len(configs))
+	for _, config := range configs {
+		if config.Name == "" {
+			return fmt.Errorf("invalid %s: source name is required", watcherSourcesKey)
+		}
+		if config.URI == "" {
+			return fmt.Errorf("invalid %s: source %s requires a URI", watcherSourcesKey, config.Name)
+		}
+		if _, found := names[config.Name]; found {
+			return fmt.Errorf("invalid %s: duplicate source %s", watcherSourcesKey, config.Name)
+		}
+		names[config.Name] = struct{}{}
+	}
+	return nil
+}
+
+// sourceRegistry tracks the status of the sources consumed by the watcher.
+type sourceRegistry struct {
+	mu      sync.RWMutex
+	sources map[string]*sourceState
+}
+
+type sourceState struct {
+	name     string
+	received atomic.Int64
+	failure  error
+}
+
+// receive counts a message received from the source and returns the source name. The default source is nil.
+func (s *sourceState) receive() string {
+	if s == nil {
+		return ""
+	}
+	s.received.Add(1)
+	return s.name
+}
+
+func (r *sourceRegistry) add(name string) *sourceState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sources == nil {
+		r.sources = make(map[string]*sourceState)
+	}
+	state, found := r.sources[name]
+	if !found {
+		state = &sourceState{name: name}
+		r.sources[name] = state
+	}
+	return state
+}
+
+// fail records the failure of the source and returns true if all sources have failed.
+func (r *sourceRegistry) fail(state *sourceState, err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.failure = err
+	for _, other := range r.sources {
+		if other.failure == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *sourceRegistry) status() map[string]SourceStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	status := make(map[string]SourceStatus, len(r.sources))
+	for name, state := range r.sources {
+		status[name] = SourceStatus{Received: state.received.Load(), Failure: state.failure}
+	}
+	return status
+}
+
+// Sources returns the status of the sources started by RunSources, keyed by source name.
+func (w *OrchestrationIndexWatcher) Sources() map[string]SourceStatus {
+	return w.sources.status()
+}
+
+// FailSource records a source that could not be connected, so that it is reported by Sources.
+func (w *OrchestrationIndexWatcher) FailSource(name string, err error) {
+	w.sources.fail(w.sources.add(name), err)
+}
+
+// RunSources consumes the orchestration changes of each source in a separate loop until the context is canceled. The
+// changes of all sources are recorded in the same index, labeled with the source name, see SourceLabel. A source
+// failing does not stop the others; the watcher is reported unhealthy once all sources have failed.
+func (w *OrchestrationIndexWatcher) RunSources(ctx context.Context, sources []Source) {
+	states := make([]*sourceState, len(sources))
+	for i, source := range sources {
+		states[i] = w.sources.add(source.Name)
+	}
+	for i, source := range sources {
+		go func() {
+			err := w.consume(ctx, source, states[i])
+			if err == nil || errors.Is(err, context.Canceled) {
+				return
+			}
+			w.monitor.Warnf("Error processing orchestration changes from source %s: %v", source.Name, err)
+			if allFailed := w.sources.fail(states[i], err); allFailed && ctx.Err() == nil {
+				w.health.fail(fmt.Errorf("all sources failed, last %s: %w", source.Name, err))
+			}
+		}()
+	}
+}
+
+// labelSource sets the source label of entries recorded from a named source. The labels are copied as they are shared
+// with the decoded orchestration.
+func labelSource(entry *api.OrchestrationEntry, source string) {
+	if source == "" {
+		return
+	}
+	labels := maps.Clone(entry.Labels)
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[SourceLabel] = source
+	entry.Labels = labels
+}
@@ -0,0 +1,113 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSources_FailedSourceDoesNotStopOthers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	west := &queueConsumer{}
+	east := &stubConsumer{fetchErr: nats.ErrConnectionClosed}
+
+	watcher.RunSources(ctx, []Source{{Name: "west", Consumer: west}, {Name: "east", Consumer: east}})
+
+	require.Eventually(t, func() bool { return watcher.Sources()["east"].Failure != nil }, time.Second, time.Millisecond)
+	west.push(newPriorityMsg(t, "orch-1", api.OrchestrationStateRunning, 0))
+	require.Eventually(t, func() bool {
+		_, err := index.FindByID(ctx, "orch-1")
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "west", entry.Labels[SourceLabel])
+
+	status := watcher.Sources()
+	assert.ErrorIs(t, status["east"].Failure, nats.ErrConnectionClosed)
+	assert.NoError(t, status["west"].Failure)
+	assert.Equal(t, int64(1), status["west"].Received)
+	assert.NoError(t, watcher.CheckHealth(ctx), "the watcher is healthy while a source is consumed")
+}
+
+func TestRunSources_UnhealthyOnceAllSourcesFailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+
+	watcher.RunSources(ctx, []Source{
+		{Name: "west", Consumer: &stubConsumer{fetchErr: nats.ErrConnectionClosed}},
+		{Name: "east", Consumer: &stubConsumer{fetchErr: nats.ErrConnectionClosed}},
+	})
+
+	require.Eventually(t, func() bool { return watcher.CheckHealth(ctx) != nil }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, watcher.CheckHealth(ctx), nats.ErrConnectionClosed)
+}
+
+func TestRunSources_CanceledSourcesNotFailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	consumer := &queueConsumer{}
+
+	watcher.RunSources(ctx, []Source{{Name: "west", Consumer: consumer}})
+	require.Eventually(t, func() bool {
+		consumer.mu.Lock()
+		defer consumer.mu.Unlock()
+		return len(consumer.batches) > 0
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	assert.NoError(t, watcher.Sources()["west"].Failure)
+	assert.NoError(t, watcher.CheckHealth(context.Background()))
+}
+
+func TestOnSourceMessage_LabelsCopied(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orch.Labels = map[string]string{"region": "eu"}
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onSourceMessage("west", data, nil, msg)
+
+	require.Equal(t, 1, msg.AckCalls)
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "eu", SourceLabel: "west"}, entry.Labels)
+	assert.Equal(t, map[string]string{"region": "eu"}, orch.Labels)
+}
+
+func TestValidateSources(t *testing.T) {
+	assert.NoError(t, validateSources([]sourceConfig{{Name: "west", URI: "nats://west"}, {Name: "east", URI: "nats://east"}}))
+	assert.Error(t, validateSources([]sourceConfig{{URI: "nats://west"}}))
+	assert.Error(t, validateSources([]sourceConfig{{Name: "west"}}))
+	assert.Error(t, validateSources([]sourceConfig{{Name: "west", URI: "nats://west"}, {Name: "west", URI: "nats://east"}}))
+}
@@ -0,0 +1,201 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_StateReader_TerminalEntrySkippedWithoutLoading(t *testing.T) {
+	index := &countingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	completed := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	_, err := index.Create(context.Background(), createEntry(completed))
+	require.NoError(t, err)
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	assert.Equal(t, 0, index.findByIDCalls, "terminal entries must not be loaded")
+
+	state, _, err := index.FindStateByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, state)
+}
+
+func TestOnMessage_StateReader_NewEntryCreatedWithoutLoading(t *testing.T) {
+	index := &countingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, index.findByIDCalls, "new entries must not be loaded")
+
+	state, _, err := index.FindStateByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, state)
+}
+
+func TestOnMessage_StateReader_RunningEntryUpdated(t *testing.T) {
+	index := &countingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	_, err := index.Create(context.Background(), createEntry(running))
+	require.NoError(t, err)
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 1, index.findByIDCalls, "non-terminal entries are loaded to detect duplicates")
+
+	state, version, err := index.FindStateByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, state)
+	assert.Equal(t, int64(1), version)
+}
+
+func TestOnMessage_ClockSkew_ChangesWithinToleranceRecorded(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.clockSkewTolerance = time.Second
+
+	initialized := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	// Published after the first change by a publisher whose clock lags behind
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	running.StateTimestamp = initialized.StateTimestamp.Add(-500 * time.Millisecond)
+
+	for _, orch := range []api.Orchestration{initialized, running} {
+		data, _ := json.Marshal(orch)
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State, "the change within the skew window must not be dropped")
+	assert.Equal(t, int64(1), entry.Version)
+}
+
+func TestOnMessage_ClockSkew_RevisionPreferred(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	// The newer revision carries a timestamp far behind the recorded one
+	compensating := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompensating)
+	compensating.StateTimestamp = running.StateTimestamp.Add(-time.Minute)
+	// A redelivered older revision is stale although its timestamp is newer
+	initialized := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	initialized.StateTimestamp = running.StateTimestamp.Add(time.Minute)
+
+	for i, orch := range []api.Orchestration{running, compensating, initialized} {
+		data, _ := json.Marshal(orch)
+		msg := newDLQMessage("$KV.bucket.orch-1", []uint64{2, 3, 1}[i], string(data), nil)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	entry, err := index.FindByID(context.Background(), "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompensating, entry.State)
+	assert.Equal(t, uint64(3), entry.Revision)
+}
+
+// countingIndex records how often entries are fully loaded.
+type countingIndex struct {
+	*memorystore.OrchestrationIndex
+	findByIDCalls int
+}
+
+func (i *countingIndex) FindByID(ctx context.Context, id string) (*api.OrchestrationEntry, error) {
+	i.findByIDCalls++
+	return i.OrchestrationIndex.FindByID(ctx, id)
+}
+
+func TestOnMessage_CheckpointPreservedOnUpdate(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	ctx := context.Background()
+
+	running := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(running)
+	watcher.onMessage(data, NewMockMessage(data))
+
+	require.NoError(t, api.SaveCheckpoint(ctx, index, "orch-1", map[string]string{"step": "deploy"}))
+
+	completed := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted)
+	data, _ = json.Marshal(completed)
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls)
+
+	entry, err := index.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+	assert.JSONEq(t, `{"step":"deploy"}`, string(entry.Checkpoint))
+}
+
+func TestOnMessage_StoreContextCarriesOrchestration(t *testing.T) {
+	index := &contextIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+
+	orch := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(orch)
+	msg := NewMockMessage(data)
+
+	watcher.onMessage(data, msg)
+
+	require.Equal(t, 1, msg.AckCalls)
+	require.NotNil(t, index.created)
+	assert.Equal(t, "orch-1", index.created.ID)
+	assert.Equal(t, "corr-1", index.correlationID)
+}
+
+// contextIndex records the orchestration carried by the context of the Create call.
+type contextIndex struct {
+	*memorystore.OrchestrationIndex
+	created       *api.OrchestrationEntry
+	correlationID string
+}
+
+func (i *contextIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	i.created, _ = api.OrchestrationFromContext(ctx)
+	i.correlationID, _ = api.CorrelationIDFromContext(ctx)
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
@@ -0,0 +1,122 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultStormWindow is the number of settled messages the redelivery rate is computed over.
+const defaultStormWindow = 100
+
+// RedeliveryStormHook is invoked when the share of redelivered messages among the recently settled messages reaches
+// the storm threshold, e.g. to raise an alert on a downstream outage causing mass redeliveries rather than isolated
+// failures. rate is the share of redelivered messages in the window, between 0 and 1.
+type RedeliveryStormHook func(rate float64)
+
+// RedeliveryStorms returns the number of redelivery storms detected since the watcher started.
+func (w *OrchestrationIndexWatcher) RedeliveryStorms() int64 {
+	if w.redeliveries == nil {
+		return 0
+	}
+	w.redeliveries.mu.Lock()
+	defer w.redeliveries.mu.Unlock()
+	return w.redeliveries.storms
+}
+
+// observeRedelivery tracks the rolling redelivery rate and signals the start of a redelivery storm. Messages that do
+// not track their delivery count are not observed.
+func (w *OrchestrationIndexWatcher) observeRedelivery(msg MessageAck) {
+	if w.redeliveries == nil {
+		return
+	}
+	counter, ok := msg.(deliveryCounter)
+	if !ok {
+		return
+	}
+	rate, started := w.redeliveries.observe(counter.NumDelivered() > 1)
+	if !started {
+		return
+	}
+	w.monitor.Warnf("Redelivery storm detected: %.0f%% of the last %d messages were redelivered",
+		rate*100, len(w.redeliveries.window))
+	if w.onRedeliveryStorm != nil {
+		w.onRedeliveryStorm(rate)
+	}
+}
+
+// widenDelay multiplies the redelivery delay by the storm backoff factor during a redelivery storm so that a failing
+// downstream system is given time to recover.
+func (w *OrchestrationIndexWatcher) widenDelay(delay time.Duration) time.Duration {
+	if w.redeliveries == nil || w.stormBackoffFactor <= 1 || !w.redeliveries.active() {
+		return delay
+	}
+	return delay * time.Duration(w.stormBackoffFactor)
+}
+
+// redeliveryTracker computes the share of redelivered messages among the last settled messages. A storm starts once the
+// window is full and the rate reaches the threshold, and ends once it drops below.
+type redeliveryTracker struct {
+	mu          sync.Mutex
+	window      []bool
+	next        int
+	observed    int
+	redelivered int
+	threshold   float64
+	storming    bool
+	storms      int64
+}
+
+func newRedeliveryTracker(window int, threshold float64) *redeliveryTracker {
+	return &redeliveryTracker{window: make([]bool, window), threshold: threshold}
+}
+
+// observe records whether a message was redelivered and returns the rate and whether a storm started.
+func (t *redeliveryTracker) observe(redelivered bool) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.observed == len(t.window) {
+		if t.window[t.next] {
+			t.redelivered--
+		}
+	} else {
+		t.observed++
+	}
+	t.window[t.next] = redelivered
+	if redelivered {
+		t.redelivered++
+	}
+	t.next = (t.next + 1) % len(t.window)
+
+	if t.observed < len(t.window) {
+		return 0, false
+	}
+	rate := float64(t.redelivered) / float64(len(t.window))
+	if rate < t.threshold {
+		t.storming = false
+		return rate, false
+	}
+	if t.storming {
+		return rate, false
+	}
+	t.storming = true
+	t.storms++
+	return rate, true
+}
+
+func (t *redeliveryTracker) active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.storming
+}
@@ -0,0 +1,89 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_RedeliveryStorm_HookFiresOnce(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.redeliveries = newRedeliveryTracker(10, 0.5)
+	var rates []float64
+	watcher.onRedeliveryStorm = func(rate float64) { rates = append(rates, rate) }
+
+	// Isolated redeliveries do not signal a storm
+	for i := range 10 {
+		delivered := uint64(1)
+		if i%5 == 4 {
+			delivered = 2
+		}
+		sendDelivered(t, watcher, fmt.Sprintf("orch-%d", i), delivered)
+	}
+	assert.Empty(t, rates)
+
+	for i := 10; i < 30; i++ {
+		sendDelivered(t, watcher, fmt.Sprintf("orch-%d", i), 5)
+	}
+	require.Len(t, rates, 1, "the hook must fire once per storm")
+	assert.Equal(t, 0.5, rates[0])
+	assert.Equal(t, int64(1), watcher.RedeliveryStorms())
+
+	// The storm ends once the rate drops and a new storm is signaled again
+	for i := 30; i < 40; i++ {
+		sendDelivered(t, watcher, fmt.Sprintf("orch-%d", i), 1)
+	}
+	for i := 40; i < 45; i++ {
+		sendDelivered(t, watcher, fmt.Sprintf("orch-%d", i), 3)
+	}
+	assert.Len(t, rates, 2)
+	assert.Equal(t, int64(2), watcher.RedeliveryStorms())
+}
+
+func TestOnMessage_RedeliveryStorm_WidensBackoff(t *testing.T) {
+	index := &failingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex(), err: errors.New("database unavailable")}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute}
+	watcher.redeliveries = newRedeliveryTracker(2, 1)
+	watcher.stormBackoffFactor = 4
+
+	delays := make([]time.Duration, 0, 3)
+	for i := range 3 {
+		data, _ := json.Marshal(createWatcherOrchestration(fmt.Sprintf("orch-%d", i), "corr-1", api.OrchestrationStateRunning))
+		msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: 2}
+		watcher.onMessage(data, msg)
+		require.Len(t, msg.NakDelays, 1)
+		delays = append(delays, msg.NakDelays[0])
+	}
+
+	assert.Equal(t, []time.Duration{2 * time.Second, 8 * time.Second, 8 * time.Second}, delays)
+}
+
+// sendDelivered records a change of the orchestration carried by a message delivered the given number of times.
+func sendDelivered(t *testing.T, watcher *OrchestrationIndexWatcher, id string, delivered uint64) {
+	t.Helper()
+	data, _ := json.Marshal(createWatcherOrchestration(id, "corr-"+id, api.OrchestrationStateRunning))
+	msg := &deliveredMockMessage{MockMessage: NewMockMessage(data), delivered: delivered}
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls)
+}
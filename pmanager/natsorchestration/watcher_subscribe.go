@@ -0,0 +1,129 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// subscriberBufferSize is the number of entries buffered per subscriber before entries are dropped.
+const subscriberBufferSize = 64
+
+// ChangeEvent is an entry created or updated by the watcher. Seq is assigned by the watcher in the order the changes
+// are recorded, starting at 1 and incremented by one per change, so that subscribers can detect dropped changes as
+// gaps.
+type ChangeEvent struct {
+	Seq   int64
+	Entry *api.OrchestrationEntry
+}
+
+// entryFeed fans out recorded entries to subscribers. The zero value is ready to use.
+type entryFeed struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	dropped     atomic.Int64
+
+	// publishMu orders publishing so that subscribers receive changes in sequence.
+	publishMu sync.Mutex
+	seq       int64
+}
+
+type subscriber struct {
+	events chan ChangeEvent
+	// signals receives a coalesced signal per matching entry instead of events if set, see NotifyEntryChange.
+	signals chan struct{}
+	once    sync.Once
+
+	// filter selects the entries delivered to the subscriber, all entries if nil.
+	filter func(*api.OrchestrationEntry) bool
+}
+
+// Subscribe returns a channel receiving each entry created or updated by the watcher that matches the filter, or all
+// entries if the filter is nil. The filter is invoked when an entry is recorded and must not block. Each subscriber
+// receives its own copy of the entries. Entries are dropped if the subscriber falls behind by more than the buffer
+// size, see DroppedEntries, which subscribers observe as a gap in the sequence of the events. Since the sequence is
+// shared by all subscribers, filtered subscribers also observe gaps for the entries not matching their filter.
+// unsubscribe closes the channel and may be called more than once.
+func (w *OrchestrationIndexWatcher) Subscribe(filter func(*api.OrchestrationEntry) bool) (<-chan ChangeEvent, func()) {
+	return w.feed.subscribe(filter)
+}
+
+// NotifyEntryChange returns a channel signaled when the watcher records the entry of the orchestration. Signals are
+// not dropped but coalesced while not received. stop closes the channel and may be called more than once.
+func (w *OrchestrationIndexWatcher) NotifyEntryChange(id string) (<-chan struct{}, func()) {
+	return w.feed.notify(func(entry *api.OrchestrationEntry) bool { return entry.ID == id })
+}
+
+// DroppedEntries returns the number of entries not delivered to slow subscribers.
+func (w *OrchestrationIndexWatcher) DroppedEntries() int64 {
+	return w.feed.dropped.Load()
+}
+
+func (f *entryFeed) subscribe(filter func(*api.OrchestrationEntry) bool) (<-chan ChangeEvent, func()) {
+	s := &subscriber{events: make(chan ChangeEvent, subscriberBufferSize), filter: filter}
+	return s.events, f.add(s, func() { close(s.events) })
+}
+
+func (f *entryFeed) notify(filter func(*api.OrchestrationEntry) bool) (<-chan struct{}, func()) {
+	s := &subscriber{signals: make(chan struct{}, 1), filter: filter}
+	return s.signals, f.add(s, func() { close(s.signals) })
+}
+
+// add registers the subscriber, returning the function removing it. closeChannel closes the channel of the subscriber
+// once removed.
+func (f *entryFeed) add(s *subscriber, closeChannel func()) func() {
+	f.mu.Lock()
+	if f.subscribers == nil {
+		f.subscribers = make(map[*subscriber]struct{})
+	}
+	f.subscribers[s] = struct{}{}
+	f.mu.Unlock()
+
+	return func() {
+		s.once.Do(func() {
+			f.mu.Lock()
+			delete(f.subscribers, s)
+			f.mu.Unlock()
+			closeChannel()
+		})
+	}
+}
+
+// publish assigns the next sequence number to the entry and delivers it to all matching subscribers without blocking.
+func (f *entryFeed) publish(entry *api.OrchestrationEntry) {
+	f.publishMu.Lock()
+	defer f.publishMu.Unlock()
+	f.seq++
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for s := range f.subscribers {
+		if s.filter != nil && !s.filter(entry) {
+			continue
+		}
+		if s.signals != nil {
+			select {
+			case s.signals <- struct{}{}:
+			default: // a signal is pending
+			}
+			continue
+		}
+		select {
+		case s.events <- ChangeEvent{Seq: f.seq, Entry: entry.Clone()}:
+		default:
+			f.dropped.Add(1)
+		}
+	}
+}
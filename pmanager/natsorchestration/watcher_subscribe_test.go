@@ -0,0 +1,179 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe_ReceivesCreatedAndUpdatedEntries(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	events, unsubscribe := watcher.Subscribe(nil)
+	defer unsubscribe()
+
+	for _, state := range []api.OrchestrationState{
+		api.OrchestrationStateRunning,
+		api.OrchestrationStateCompleted,
+		api.OrchestrationStateCompleted, // redelivered, not recorded
+	} {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls)
+	}
+
+	require.Len(t, events, 2)
+	created := <-events
+	assert.Equal(t, "orch-1", created.Entry.ID)
+	assert.Equal(t, api.OrchestrationStateRunning, created.Entry.State)
+	updated := <-events
+	assert.Equal(t, "orch-1", updated.Entry.ID)
+	assert.Equal(t, api.OrchestrationStateCompleted, updated.Entry.State)
+	assert.Equal(t, []int64{1, 2}, []int64{created.Seq, updated.Seq})
+}
+
+func TestSubscribe_FanOut(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	first, unsubscribeFirst := watcher.Subscribe(nil)
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := watcher.Subscribe(nil)
+	defer unsubscribeSecond()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, NewMockMessage(data))
+
+	require.Len(t, first, 1)
+	require.Len(t, second, 1)
+	firstEvent, secondEvent := <-first, <-second
+	assert.Equal(t, "orch-1", firstEvent.Entry.ID)
+	assert.Equal(t, "orch-1", secondEvent.Entry.ID)
+	assert.Equal(t, firstEvent.Seq, secondEvent.Seq, "subscribers share the sequence of the watcher")
+}
+
+func TestSubscribe_FilteredSubscriberReceivesMatchingEntries(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	terminal, unsubscribeTerminal := watcher.Subscribe(func(entry *api.OrchestrationEntry) bool {
+		return isTerminal(entry.State)
+	})
+	defer unsubscribeTerminal()
+	all, unsubscribeAll := watcher.Subscribe(nil)
+	defer unsubscribeAll()
+
+	for _, id := range []string{"orch-1", "orch-2"} {
+		for _, state := range []api.OrchestrationState{api.OrchestrationStateRunning, api.OrchestrationStateCompleted} {
+			data, _ := json.Marshal(createWatcherOrchestration(id, "corr-1", state))
+			msg := NewMockMessage(data)
+			watcher.onMessage(data, msg)
+			require.Equal(t, 1, msg.AckCalls)
+		}
+	}
+
+	require.Len(t, all, 4)
+	require.Len(t, terminal, 2)
+	first, second := <-terminal, <-terminal
+	assert.Equal(t, []string{"orch-1", "orch-2"}, []string{first.Entry.ID, second.Entry.ID})
+	assert.Equal(t, api.OrchestrationStateCompleted, first.Entry.State)
+	assert.Equal(t, api.OrchestrationStateCompleted, second.Entry.State)
+	assert.Equal(t, []int64{2, 4}, []int64{first.Seq, second.Seq}, "entries not matching the filter are observed as gaps")
+	assert.Equal(t, int64(0), watcher.DroppedEntries())
+}
+
+func TestSubscribe_SlowSubscriberDropsEntries(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	events, unsubscribe := watcher.Subscribe(nil)
+	defer unsubscribe()
+
+	for i := range subscriberBufferSize + 3 {
+		data, _ := json.Marshal(createWatcherOrchestration(fmt.Sprintf("orch-%d", i), "corr-1", api.OrchestrationStateRunning))
+		msg := NewMockMessage(data)
+		watcher.onMessage(data, msg)
+		require.Equal(t, 1, msg.AckCalls, "processing must not block on slow subscribers")
+	}
+
+	assert.Len(t, events, subscriberBufferSize)
+	assert.Equal(t, int64(3), watcher.DroppedEntries())
+}
+
+func TestSubscribe_DroppedEntriesObservableAsGap(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	events, unsubscribe := watcher.Subscribe(nil)
+	defer unsubscribe()
+
+	record := func(i int) {
+		data, _ := json.Marshal(createWatcherOrchestration(fmt.Sprintf("orch-%d", i), "corr-1", api.OrchestrationStateRunning))
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+	// Overflow the buffer, then record another change once the subscriber caught up
+	for i := range subscriberBufferSize + 2 {
+		record(i)
+	}
+	var seqs []int64
+	for range subscriberBufferSize {
+		seqs = append(seqs, (<-events).Seq)
+	}
+	record(subscriberBufferSize + 2)
+	seqs = append(seqs, (<-events).Seq)
+
+	for i := 1; i < subscriberBufferSize; i++ {
+		require.Equal(t, seqs[i-1]+1, seqs[i], "sequence numbers must increase without gaps while nothing is dropped")
+	}
+	last, previous := seqs[subscriberBufferSize], seqs[subscriberBufferSize-1]
+	assert.Greater(t, last, previous)
+	assert.Equal(t, watcher.DroppedEntries(), last-previous-1, "the gap must match the dropped entries")
+}
+
+func TestSubscribe_Unsubscribe(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	events, unsubscribe := watcher.Subscribe(nil)
+
+	unsubscribe()
+	unsubscribe()
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	watcher.onMessage(data, NewMockMessage(data))
+
+	_, open := <-events
+	assert.False(t, open)
+	assert.Equal(t, int64(0), watcher.DroppedEntries())
+}
+
+func TestNotifyEntryChange_CoalescesSignalsForEntry(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	changes, stop := watcher.NotifyEntryChange("orch-1")
+
+	for _, orchestration := range []api.Orchestration{
+		createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning),
+		createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning),
+		createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted),
+	} {
+		data, _ := json.Marshal(orchestration)
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+
+	require.Len(t, changes, 1)
+	<-changes
+	assert.Zero(t, watcher.DroppedEntries())
+
+	stop()
+	stop()
+	_, open := <-changes
+	assert.False(t, open)
+}
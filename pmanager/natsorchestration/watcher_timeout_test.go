@@ -0,0 +1,88 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_ProcessingTimeout_BlockedUpdateNaked(t *testing.T) {
+	index := &blockingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.processingTimeout = 50 * time.Millisecond
+
+	_, err := index.Create(context.Background(), createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)))
+	require.NoError(t, err)
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateCompleted))
+	msg := NewMockMessage(data)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.onMessage(data, msg)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processing did not stop after the timeout")
+	}
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.True(t, index.findByIDDeadline, "the processing context must reach FindByID")
+}
+
+func TestOnMessage_ProcessingTimeout_ContextReachesCreate(t *testing.T) {
+	index := &blockingIndex{OrchestrationIndex: memorystore.NewOrchestrationIndex()}
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.processingTimeout = time.Minute
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.True(t, index.createDeadline, "the processing context must reach Create")
+}
+
+// blockingIndex blocks updates until their context is done and records whether lookups and creates receive a context
+// with a deadline.
+type blockingIndex struct {
+	*memorystore.OrchestrationIndex
+	findByIDDeadline bool
+	createDeadline   bool
+}
+
+func (i *blockingIndex) FindByID(ctx context.Context, id string) (*api.OrchestrationEntry, error) {
+	_, i.findByIDDeadline = ctx.Deadline()
+	return i.OrchestrationIndex.FindByID(ctx, id)
+}
+
+func (i *blockingIndex) Create(ctx context.Context, entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	_, i.createDeadline = ctx.Deadline()
+	return i.OrchestrationIndex.Create(ctx, entry)
+}
+
+func (i *blockingIndex) Update(ctx context.Context, _ *api.OrchestrationEntry) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,49 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+type replayKey struct{}
+
+// withReplay returns a context marking the changes recorded with it as replayed, which are not subject to transition
+// validation, see ReplayInOrder.
+func withReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+// checkTransition returns an error wrapping types.ErrInvalidInput if transitions are validated and the lifecycle does
+// not allow the change of the existing entry to the state of the entry, see api.CanTransition. Replayed changes are
+// not validated.
+func (w *OrchestrationIndexWatcher) checkTransition(
+	ctx context.Context,
+	entry *api.OrchestrationEntry,
+	existing *api.OrchestrationEntry) error {
+	if !w.validateTransitions || entry.State == existing.State {
+		return nil
+	}
+	if replayed, _ := ctx.Value(replayKey{}).(bool); replayed {
+		return nil
+	}
+	if !api.CanTransition(existing.State, entry.State) {
+		return fmt.Errorf("%w: orchestration %s cannot transition from state %d to %d", types.ErrInvalidInput,
+			entry.ID, existing.State, entry.State)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// RegisterValidator adds a validator enforcing business rules on the decoded entries of the orchestration type, e.g.
+// required processing data. Entries failing validation are settled like malformed messages according to the
+// MalformedPolicy and not recorded. Entries of types without validators are not validated. Validators must be
+// registered before the watcher starts processing.
+func (w *OrchestrationIndexWatcher) RegisterValidator(orchestrationType string, fn func(*api.OrchestrationEntry) error) {
+	if w.validators == nil {
+		w.validators = make(map[string][]func(*api.OrchestrationEntry) error)
+	}
+	w.validators[orchestrationType] = append(w.validators[orchestrationType], fn)
+}
+
+// validate checks the size of the entry and runs the validators registered for its type. Validation errors wrap
+// errMalformedMessage.
+func (w *OrchestrationIndexWatcher) validate(entry *api.OrchestrationEntry) error {
+	if err := w.checkEntrySize(entry); err != nil {
+		return fmt.Errorf("%w: %w", errMalformedMessage, err)
+	}
+	for _, validator := range w.validators[string(entry.OrchestrationType)] {
+		if err := validator(entry); err != nil {
+			return fmt.Errorf("%w: orchestration %s failed validation: %w", errMalformedMessage, entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// ValidateEntry runs the schema and type-specific validation of the watcher on the serialized orchestration without
+// recording it, see api.EntryValidator. Unknown fields are rejected if the decoder of the watcher is strict.
+func (w *OrchestrationIndexWatcher) ValidateEntry(_ context.Context, data []byte) []string {
+	orchestration, err := unmarshalGuarded(data, w.strictDecoding())
+	if err != nil {
+		return []string{err.Error()}
+	}
+	problems := orchestrationProblems(orchestration)
+	entry := createEntry(orchestration)
+	if err := w.checkEntrySize(entry); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, validator := range w.validators[string(entry.OrchestrationType)] {
+		if err := validator(entry); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if problems == nil {
+		return []string{}
+	}
+	return problems
+}
+
+// checkEntrySize returns an error if the serialized entry exceeds maxEntryBytes, e.g. because of a huge checkpoint or
+// labels map.
+func (w *OrchestrationIndexWatcher) checkEntrySize(entry *api.OrchestrationEntry) error {
+	if w.maxEntryBytes <= 0 {
+		return nil
+	}
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize orchestration %s: %w", entry.ID, err)
+	}
+	if len(serialized) > w.maxEntryBytes {
+		return fmt.Errorf("orchestration %s entry size of %d bytes exceeds the limit of %d bytes", entry.ID,
+			len(serialized), w.maxEntryBytes)
+	}
+	return nil
+}
+
+// strictDecoding returns true if the decoder of the watcher rejects unknown fields.
+func (w *OrchestrationIndexWatcher) strictDecoding() bool {
+	switch decoder := w.decoder.(type) {
+	case JSONDecoder:
+		return decoder.Strict
+	case CloudEventsDecoder:
+		return decoder.Strict
+	default:
+		return false
+	}
+}
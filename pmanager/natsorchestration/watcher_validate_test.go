@@ -0,0 +1,175 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_Validator_RejectedEntryNotPersisted(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testDLQSubject &&
+			strings.Contains(msg.Header.Get(DeadLetterReasonHeader), "target is required")
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	watcher.RegisterValidator("TestType", func(entry *api.OrchestrationEntry) error {
+		if entry.Labels["target"] == "" {
+			return errors.New("target is required")
+		}
+		return nil
+	})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := newDLQMessage("$KV.bucket.orch-1", 7, string(data), nil)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.ErrorIs(t, err, types.ErrNotFound, "rejected entries must not be persisted")
+}
+
+func TestOnMessage_OversizeEntryNotPersisted(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	client.EXPECT().PublishMsg(mock.Anything, mock.MatchedBy(func(msg *nats.Msg) bool {
+		return msg.Subject == testDLQSubject &&
+			strings.Contains(msg.Header.Get(DeadLetterReasonHeader), "exceeds the limit of 1024 bytes")
+	})).Return(&jetstream.PubAck{}, nil).Once()
+
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.deadLetters = NewDeadLetterQueue(client, nil, testDLQSubject, system.NoopMonitor{})
+	watcher.maxEntryBytes = 1024
+
+	small := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	data, _ := json.Marshal(small)
+	msg := newDLQMessage("$KV.bucket.orch-1", 6, string(data), nil)
+	watcher.onMessage(data, msg)
+	require.Equal(t, 1, msg.AckCalls)
+
+	oversize := createWatcherOrchestration("orch-2", "corr-2", api.OrchestrationStateRunning)
+	oversize.Labels = map[string]string{"blob": strings.Repeat("x", 2048)}
+	data, _ = json.Marshal(oversize)
+	msg = newDLQMessage("$KV.bucket.orch-2", 7, string(data), nil)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 1, msg.AckCalls)
+	assert.Equal(t, 0, msg.NakCalls)
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.NoError(t, err)
+	_, err = index.FindByID(context.Background(), "orch-2")
+	assert.ErrorIs(t, err, types.ErrNotFound, "oversize entries must not be persisted")
+}
+
+func TestOnMessage_Validator_UnregisteredTypesSkipped(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.RegisterValidator("cfm.orchestration.vpa.deploy", func(*api.OrchestrationEntry) error {
+		return errors.New("target is required")
+	})
+
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	require.Equal(t, 1, msg.AckCalls)
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.NoError(t, err)
+}
+
+func TestValidateEntry(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.RegisterValidator("TestType", func(entry *api.OrchestrationEntry) error {
+		if entry.Labels["target"] == "" {
+			return errors.New("target is required")
+		}
+		return nil
+	})
+	marshal := func(modify func(*api.Orchestration)) []byte {
+		orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+		orchestration.Labels = map[string]string{"target": "cluster-1"}
+		modify(&orchestration)
+		data, err := json.Marshal(orchestration)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{
+			name:     "valid",
+			data:     marshal(func(*api.Orchestration) {}),
+			expected: []string{},
+		},
+		{
+			name:     "invalid state",
+			data:     marshal(func(o *api.Orchestration) { o.State = 42 }),
+			expected: []string{"invalid orchestration state 42"},
+		},
+		{
+			name:     "missing id",
+			data:     marshal(func(o *api.Orchestration) { o.ID = "" }),
+			expected: []string{"orchestration id is missing"},
+		},
+		{
+			name:     "type-specific rule",
+			data:     marshal(func(o *api.Orchestration) { o.Labels = nil }),
+			expected: []string{"target is required"},
+		},
+		{
+			name: "all problems reported",
+			data: marshal(func(o *api.Orchestration) { o.ID, o.State, o.Labels = "", 42, nil }),
+			expected: []string{"orchestration id is missing", "invalid orchestration state 42",
+				"target is required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, watcher.ValidateEntry(context.Background(), tt.data))
+		})
+	}
+}
+
+func TestValidateEntry_Malformed(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.decoder = JSONDecoder{Strict: true}
+
+	problems := watcher.ValidateEntry(context.Background(), []byte(`{"id":"orch-1","unknown":true}`))
+
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "unknown")
+	assert.Len(t, watcher.ValidateEntry(context.Background(), []byte("{not json")), 1)
+}
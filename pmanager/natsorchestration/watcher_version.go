@@ -0,0 +1,54 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// defaultVersionDeferDelay is the redelivery delay of messages requiring a newer watcher version.
+	defaultVersionDeferDelay = 30 * time.Second
+)
+
+// requiresNewerVersion returns true if the message header requires a higher version than the watcher reports.
+// Messages with a malformed version header are processed, since no watcher could determine they are supported.
+func (w *OrchestrationIndexWatcher) requiresNewerVersion(header nats.Header) bool {
+	required, err := MessageHeaders(header).GetMinConsumerVersion()
+	if err != nil {
+		w.monitor.Warnf("Ignoring malformed %s header %q: %v", MinConsumerVersionHeader,
+			header.Get(MinConsumerVersionHeader), err)
+		return false
+	}
+	return required > w.version
+}
+
+// requiresNewerVersionPayload returns true if the orchestration requires a higher version than the watcher reports.
+// Changes written to the orchestration bucket cannot carry headers, the version is stamped in the payload instead.
+func (w *OrchestrationIndexWatcher) requiresNewerVersionPayload(orchestration *api.Orchestration) bool {
+	return orchestration.MinConsumerVersion > w.version
+}
+
+// deferToNewerVersion naks the message so that it is redelivered, possibly to a watcher of a newer version.
+func (w *OrchestrationIndexWatcher) deferToNewerVersion(msg MessageAck) {
+	delay := w.versionDeferDelay
+	if delay <= 0 {
+		delay = defaultVersionDeferDelay
+	}
+	if err := msg.NakWithDelay(delay); err != nil {
+		w.monitor.Infof("Failed to nak message requiring a newer watcher version: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/mocks"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnMessage_MinConsumerVersion_NewerMessageDeferred(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.version = 1
+
+	header := nats.Header{}
+	MessageHeaders(header).SetMinConsumerVersion(2)
+	data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+	msg := NewMockMessage(data)
+	watcher.onHeaderMessage(data, header, msg)
+
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.Equal(t, 1, msg.NakCalls)
+	assert.Equal(t, []time.Duration{defaultVersionDeferDelay}, msg.NakDelays)
+	mockStore.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOnMessage_MinConsumerVersion_NewerPayloadDeferred(t *testing.T) {
+	mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+	watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+	watcher.version = 1
+
+	// Changes written to the orchestration bucket carry the version in the payload
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)
+	orchestration.MinConsumerVersion = 2
+	data, _ := json.Marshal(orchestration)
+	msg := NewMockMessage(data)
+	watcher.onMessage(data, msg)
+
+	assert.Equal(t, 0, msg.AckCalls)
+	assert.Equal(t, []time.Duration{defaultVersionDeferDelay}, msg.NakDelays)
+	mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestNatsOrchestrator_Execute_StampsMinConsumerVersion(t *testing.T) {
+	client := mocks.NewMockMsgClient(t)
+	var stored api.Orchestration
+	client.EXPECT().Update(mock.Anything, "orch-1", mock.Anything, uint64(0)).
+		Run(func(_ context.Context, _ string, value []byte, _ uint64) {
+			require.NoError(t, json.Unmarshal(value, &stored))
+		}).
+		Return(uint64(1), nil).Once()
+	client.EXPECT().PublishMsg(mock.Anything, mock.Anything).Return(&jetstream.PubAck{}, nil).Once()
+
+	orchestrator := NewNatsOrchestrator(client, system.NoopMonitor{})
+	orchestrator.MinConsumerVersion = 3
+	orchestration := createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateInitialized)
+	orchestration.Steps = []api.OrchestrationStep{{Activities: []api.Activity{{ID: "a1", Type: "provision"}}}}
+
+	require.NoError(t, orchestrator.Execute(context.Background(), &orchestration))
+	assert.Equal(t, 3, stored.MinConsumerVersion)
+}
+
+func TestOnMessage_MinConsumerVersion_SupportedMessageProcessed(t *testing.T) {
+	for name, value := range map[string]string{
+		"same version":  "2",
+		"older version": "1",
+		"no version":    "",
+		"malformed":     "v3",
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockStore := mocks.NewMockEntityStore[*api.OrchestrationEntry](t)
+			watcher := createTestWatcher(mockStore, &store.NoOpTransactionContext{})
+			watcher.version = 2
+
+			mockStore.EXPECT().FindByID(mock.Anything, "orch-1").Return(nil, types.ErrNotFound).Once()
+			mockStore.EXPECT().Create(mock.Anything, mock.Anything).Return(&api.OrchestrationEntry{}, nil).Once()
+
+			header := nats.Header{}
+			if value != "" {
+				header.Set(MinConsumerVersionHeader, value)
+			}
+			data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning))
+			msg := NewMockMessage(data)
+			watcher.onHeaderMessage(data, header, msg)
+
+			assert.Equal(t, 1, msg.AckCalls)
+			assert.Equal(t, 0, msg.NakCalls)
+		})
+	}
+}
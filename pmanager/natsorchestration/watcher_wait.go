@@ -0,0 +1,95 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// defaultWaitPollInterval is the interval at which WaitForState re-reads the index in case a change was dropped from
+// the feed.
+const defaultWaitPollInterval = time.Second
+
+// WaitForState blocks until the orchestration reaches the target state or a terminal state and returns its entry, e.g.
+// for tests or CLIs that need synchronous results. Callers must check the state of the returned entry since it may have
+// completed in a different terminal state. The orchestration need not exist when called. An error is returned when ctx
+// is done before the state is reached.
+//
+// Changes are received from the feed, see Subscribe. The index is read initially and at regular intervals so that
+// changes dropped from the feed or recorded by other watchers are not missed.
+func (w *OrchestrationIndexWatcher) WaitForState(
+	ctx context.Context,
+	id string,
+	target api.OrchestrationState) (*api.OrchestrationEntry, error) {
+	// Subscribe before reading the index so that no change is missed in between
+	events, unsubscribe := w.Subscribe(func(entry *api.OrchestrationEntry) bool { return entry.ID == id })
+	defer unsubscribe()
+
+	pollInterval := w.waitPollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultWaitPollInterval
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		entry, err := w.findEntry(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if reachedState(entry, target) {
+			return entry, nil
+		}
+	receive:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("orchestration %s did not reach state %d: %w", id, target, ctx.Err())
+			case event := <-events:
+				if reachedState(event.Entry, target) {
+					return event.Entry, nil
+				}
+			case <-ticker.C:
+				break receive
+			}
+		}
+	}
+}
+
+// findEntry returns the entry recorded in the index or nil if none is recorded.
+func (w *OrchestrationIndexWatcher) findEntry(ctx context.Context, id string) (*api.OrchestrationEntry, error) {
+	var entry *api.OrchestrationEntry
+	err := w.trxContext.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		entry, err = w.index.FindByID(ctx, id)
+		return err
+	})
+	if errors.Is(err, types.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading orchestration %s: %w", id, err)
+	}
+	return entry, nil
+}
+
+// reachedState returns true if the entry is in the target or a terminal state.
+func reachedState(entry *api.OrchestrationEntry, target api.OrchestrationState) bool {
+	return entry != nil && (entry.State == target || isTerminal(entry.State))
+}
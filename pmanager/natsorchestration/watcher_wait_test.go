@@ -0,0 +1,114 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package natsorchestration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForState_ReturnsWhenTargetReached(t *testing.T) {
+	watcher := createTestWatcher(memorystore.NewOrchestrationIndex(), &store.NoOpTransactionContext{})
+	watcher.waitPollInterval = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make(chan *api.OrchestrationEntry, 1)
+	go func() {
+		entry, err := watcher.WaitForState(ctx, "orch-1", api.OrchestrationStateRunning)
+		assert.NoError(t, err)
+		result <- entry
+	}()
+
+	// Wait until the waiter is subscribed so that the change is received from the feed
+	require.Eventually(t, func() bool { return subscriberCount(&watcher.feed) == 1 }, time.Second, time.Millisecond)
+	for _, state := range []api.OrchestrationState{api.OrchestrationStateInitialized, api.OrchestrationStateRunning} {
+		data, _ := json.Marshal(createWatcherOrchestration("orch-1", "corr-1", state))
+		watcher.onMessage(data, NewMockMessage(data))
+	}
+
+	select {
+	case entry := <-result:
+		require.NotNil(t, entry)
+		assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+	case <-time.After(time.Second):
+		t.Fatal("WaitForState did not return after the target state was reached")
+	}
+}
+
+func TestWaitForState_AlreadyReached(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	_, err := index.Create(context.Background(),
+		createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateErrored)))
+	require.NoError(t, err)
+
+	entry, err := watcher.WaitForState(context.Background(), "orch-1", api.OrchestrationStateCompleted)
+
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateErrored, entry.State, "terminal states end the wait")
+}
+
+func TestWaitForState_PollsIndex(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.waitPollInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		// Recorded by another watcher, not published to the feed
+		time.Sleep(20 * time.Millisecond)
+		_, _ = index.Create(context.Background(),
+			createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)))
+	}()
+
+	entry, err := watcher.WaitForState(ctx, "orch-1", api.OrchestrationStateRunning)
+
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
+
+func TestWaitForState_Timeout(t *testing.T) {
+	index := memorystore.NewOrchestrationIndex()
+	watcher := createTestWatcher(index, &store.NoOpTransactionContext{})
+	watcher.waitPollInterval = 5 * time.Millisecond
+	_, err := index.Create(context.Background(),
+		createEntry(createWatcherOrchestration("orch-1", "corr-1", api.OrchestrationStateRunning)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	entry, err := watcher.WaitForState(ctx, "orch-1", api.OrchestrationStateCompleted)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, entry)
+	assert.Equal(t, 0, subscriberCount(&watcher.feed), "the waiter must unsubscribe")
+}
+
+// subscriberCount returns the number of subscribers of the feed.
+func subscriberCount(f *entryFeed) int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.subscribers)
+}
@@ -191,3 +191,7 @@ func (m *MockProvisionManager) QueryOrchestrations(ctx context.Context, predicat
 func (m *MockProvisionManager) CountOrchestrations(ctx context.Context, predicate query.Predicate) (int64, error) {
 	panic("not implemented")
 }
+
+func (m *MockProvisionManager) GetOrchestrationEntry(ctx context.Context, id string) (*api.OrchestrationEntry, error) {
+	panic("not implemented")
+}
@@ -0,0 +1,477 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// OrchestrationPrefix is the key prefix of orchestration entries.
+	OrchestrationPrefix = "orch"
+
+	stateSegment = "state"
+	lockSegment  = "lock"
+	idsSegment   = "ids"
+)
+
+// StateFunc returns the state of an entity used to maintain the per-state index sets.
+type StateFunc[T store.EntityType] func(entity T) string
+
+// RedisEntityStore is a store.EntityStore keeping entities as JSON at <prefix>:<id>. The IDs of all entities are kept
+// in the set <prefix>:ids and the IDs of entities in a given state in the set <prefix>:state:<state>. Writes use
+// WATCH/MULTI so that concurrent modifications of an entity fail with types.ErrVersionConflict.
+//
+// Only FindByID, Exists, GetAllCount and FindByState are served by keys and sets. Redis cannot evaluate predicates,
+// so predicate queries, counts, deletes and paginated reads load every entity and filter them in memory. Their cost
+// grows with the size of the store and they are not suited for large data sets.
+type RedisEntityStore[T store.EntityType] struct {
+	client  *redis.Client
+	prefix  string
+	state   StateFunc[T]
+	matcher query.FieldMatcher
+}
+
+func NewRedisEntityStore[T store.EntityType](client *redis.Client, prefix string, state StateFunc[T]) *RedisEntityStore[T] {
+	return &RedisEntityStore[T]{
+		client:  client,
+		prefix:  prefix,
+		state:   state,
+		matcher: &query.DefaultFieldMatcher{},
+	}
+}
+
+// NewOrchestrationStore creates a store for orchestration entries indexed by their state.
+func NewOrchestrationStore(client *redis.Client) *RedisEntityStore[*api.OrchestrationEntry] {
+	return NewRedisEntityStore(client, OrchestrationPrefix, func(entry *api.OrchestrationEntry) string {
+		return strconv.FormatUint(uint64(entry.State), 10)
+	})
+}
+
+func (s *RedisEntityStore[T]) FindByID(ctx context.Context, id string) (T, error) {
+	data, err := s.client.Get(ctx, s.entityKey(id)).Bytes()
+	if err != nil {
+		var zero T
+		return zero, mapError(err)
+	}
+	return unmarshalEntity[T](data)
+}
+
+func (s *RedisEntityStore[T]) Exists(ctx context.Context, id string) (bool, error) {
+	count, err := s.client.Exists(ctx, s.entityKey(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *RedisEntityStore[T]) Create(ctx context.Context, entity T) (T, error) {
+	var zero T
+	id := entity.GetID()
+	if id == "" || strings.Contains(id, ":") || id == idsSegment {
+		return zero, types.ErrInvalidInput
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return zero, fmt.Errorf("error marshalling entity: %w", err)
+	}
+
+	key := s.entityKey(id)
+	err = s.client.Watch(ctx, func(tx *redis.Tx) error {
+		count, err := tx.Exists(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return types.ErrConflict
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			pipe.SAdd(ctx, s.idsKey(), id)
+			pipe.SAdd(ctx, s.stateKey(s.state(entity)), id)
+			return nil
+		})
+		return err
+	}, key)
+	if err != nil {
+		return zero, mapError(err)
+	}
+	return entity, nil
+}
+
+// Update replaces the stored entity and increments its version. Returns types.ErrVersionConflict if the stored entity
+// has a different version or is modified concurrently.
+func (s *RedisEntityStore[T]) Update(ctx context.Context, entity T) error {
+	id := entity.GetID()
+	if id == "" {
+		return types.ErrInvalidInput
+	}
+
+	key := s.entityKey(id)
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			return err
+		}
+		existing, err := unmarshalEntity[T](data)
+		if err != nil {
+			return err
+		}
+		if existing.GetVersion() != entity.GetVersion() {
+			return types.ErrVersionConflict
+		}
+
+		updated, err := copyEntity(entity)
+		if err != nil {
+			return err
+		}
+		updated.IncrementVersion()
+		data, err = json.Marshal(updated)
+		if err != nil {
+			return fmt.Errorf("error marshalling entity: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			if oldState, newState := s.state(existing), s.state(updated); oldState != newState {
+				pipe.SRem(ctx, s.stateKey(oldState), id)
+				pipe.SAdd(ctx, s.stateKey(newState), id)
+			}
+			return nil
+		})
+		return err
+	}, key)
+	if err != nil {
+		return mapError(err)
+	}
+	entity.IncrementVersion()
+	return nil
+}
+
+func (s *RedisEntityStore[T]) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return types.ErrInvalidInput
+	}
+
+	key := s.entityKey(id)
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			return err
+		}
+		existing, err := unmarshalEntity[T](data)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.SRem(ctx, s.idsKey(), id)
+			pipe.SRem(ctx, s.stateKey(s.state(existing)), id)
+			return nil
+		})
+		return err
+	}, key)
+	return mapError(err)
+}
+
+// FindByState returns the entities in the given state ordered by ID.
+func (s *RedisEntityStore[T]) FindByState(ctx context.Context, state string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		entities, err := s.load(ctx, s.stateKey(state))
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for _, entity := range entities {
+			if !yield(entity, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *RedisEntityStore[T]) GetAll(ctx context.Context) iter.Seq2[T, error] {
+	return s.GetAllPaginated(ctx, store.DefaultPaginationOptions())
+}
+
+func (s *RedisEntityStore[T]) GetAllCount(ctx context.Context) (int64, error) {
+	return s.client.SCard(ctx, s.idsKey()).Result()
+}
+
+func (s *RedisEntityStore[T]) GetAllPaginated(ctx context.Context, opts store.PaginationOptions) iter.Seq2[T, error] {
+	return s.paginateEntities(ctx, nil, opts)
+}
+
+func (s *RedisEntityStore[T]) FindByPredicate(ctx context.Context, predicate query.Predicate) iter.Seq2[T, error] {
+	return s.paginateEntities(ctx, predicate, store.PaginationOptions{})
+}
+
+func (s *RedisEntityStore[T]) FindByPredicatePaginated(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts store.PaginationOptions) iter.Seq2[T, error] {
+
+	return s.paginateEntities(ctx, predicate, opts)
+}
+
+// paginateEntities yields the entities matching the predicate (or all if predicate is nil) ordered by ID with the
+// offset and limit applied.
+func (s *RedisEntityStore[T]) paginateEntities(
+	ctx context.Context,
+	predicate query.Predicate,
+	opts store.PaginationOptions) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+		filtered, err := s.filter(ctx, predicate)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+
+		start := max(opts.Offset, 0)
+		length := int64(len(filtered))
+		if start >= length {
+			return
+		}
+		end := length
+		if opts.Limit > 0 {
+			end = min(start+opts.Limit, length)
+		}
+		for _, entity := range filtered[start:end] {
+			if !yield(entity, nil) {
+				return
+			}
+		}
+	}
+}
+
+// FindFirstByPredicate returns the first entity matching the predicate or types.ErrNotFound if none found
+func (s *RedisEntityStore[T]) FindFirstByPredicate(ctx context.Context, predicate query.Predicate) (T, error) {
+	var zero T
+	filtered, err := s.filter(ctx, predicate)
+	if err != nil {
+		return zero, err
+	}
+	if len(filtered) == 0 {
+		return zero, types.ErrNotFound
+	}
+	return filtered[0], nil
+}
+
+func (s *RedisEntityStore[T]) CountByPredicate(ctx context.Context, predicate query.Predicate) (int64, error) {
+	filtered, err := s.filter(ctx, predicate)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(filtered)), nil
+}
+
+func (s *RedisEntityStore[T]) DeleteByPredicate(ctx context.Context, predicate query.Predicate) error {
+	filtered, err := s.filter(ctx, predicate)
+	if err != nil {
+		return err
+	}
+	for _, entity := range filtered {
+		// Entities deleted concurrently are skipped
+		if err := s.Delete(ctx, entity.GetID()); err != nil && !errors.Is(err, types.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// TryLock acquires the named lock unless it is held and has not expired. The lock is released only by its holder.
+func (s *RedisEntityStore[T]) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, func(), error) {
+	if name == "" || ttl <= 0 {
+		return false, func() {}, types.ErrInvalidInput
+	}
+
+	key := s.lockKey(name)
+	token := uuid.NewString()
+	acquired, err := s.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !acquired {
+		return false, func() {}, err
+	}
+
+	release := func() {
+		// The lock may have expired and been acquired by another holder
+		ctx := context.WithoutCancel(ctx)
+		_ = s.client.Watch(ctx, func(tx *redis.Tx) error {
+			held, err := tx.Get(ctx, key).Result()
+			if err != nil || held != token {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, key)
+				return nil
+			})
+			return err
+		}, key)
+	}
+	return true, release, nil
+}
+
+// Export writes all entities to w as JSON Lines ordered by ID.
+func (s *RedisEntityStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return store.ExportJSONLines(ctx, w, s.PageByID)
+}
+
+// Import creates the entities read from r, failing with types.ErrConflict if an entity already exists.
+func (s *RedisEntityStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return store.ImportJSONLines(ctx, r, func(ctx context.Context, entity T) error {
+		_, err := s.Create(ctx, entity)
+		return err
+	})
+}
+
+// PageByID returns up to limit entities ordered by ID, starting after afterID.
+func (s *RedisEntityStore[T]) PageByID(ctx context.Context, afterID string, limit int) ([]T, error) {
+	ids, err := s.members(ctx, s.idsKey())
+	if err != nil {
+		return nil, err
+	}
+	ids = slices.DeleteFunc(ids, func(id string) bool { return id <= afterID })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return s.fetch(ctx, ids)
+}
+
+// filter returns the entities matching the predicate (or all if predicate is nil) ordered by ID. It reads all IDs
+// and fetches every entity before matching in memory, see RedisEntityStore.
+func (s *RedisEntityStore[T]) filter(ctx context.Context, predicate query.Predicate) ([]T, error) {
+	entities, err := s.load(ctx, s.idsKey())
+	if err != nil {
+		return nil, err
+	}
+	if predicate == nil {
+		return entities, nil
+	}
+	return slices.DeleteFunc(entities, func(entity T) bool {
+		return !predicate.Matches(entity, s.matcher)
+	}), nil
+}
+
+// load returns the entities whose IDs are members of the set at key ordered by ID.
+func (s *RedisEntityStore[T]) load(ctx context.Context, key string) ([]T, error) {
+	ids, err := s.members(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.fetch(ctx, ids)
+}
+
+// members returns the sorted members of the set at key.
+func (s *RedisEntityStore[T]) members(ctx context.Context, key string) ([]string, error) {
+	ids, err := s.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// fetch returns the entities with the given IDs in order, skipping entities deleted since the IDs were read.
+func (s *RedisEntityStore[T]) fetch(ctx context.Context, ids []string) ([]T, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.entityKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]T, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		entity, err := unmarshalEntity[T]([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+func (s *RedisEntityStore[T]) entityKey(id string) string {
+	return s.prefix + ":" + id
+}
+
+func (s *RedisEntityStore[T]) stateKey(state string) string {
+	return s.prefix + ":" + stateSegment + ":" + state
+}
+
+func (s *RedisEntityStore[T]) lockKey(name string) string {
+	return s.prefix + ":" + lockSegment + ":" + name
+}
+
+func (s *RedisEntityStore[T]) idsKey() string {
+	return s.prefix + ":" + idsSegment
+}
+
+// mapError converts a missing key to types.ErrNotFound and a failed transaction to types.ErrVersionConflict.
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return types.ErrNotFound
+	case errors.Is(err, redis.TxFailedErr):
+		return types.ErrVersionConflict
+	default:
+		return err
+	}
+}
+
+func unmarshalEntity[T store.EntityType](data []byte) (T, error) {
+	var entity T
+	if err := json.Unmarshal(data, &entity); err != nil {
+		var zero T
+		return zero, fmt.Errorf("error unmarshalling entity: %w", err)
+	}
+	return entity, nil
+}
+
+// copyEntity creates a copy of a pointer entity so that a failed update leaves the caller's entity unchanged.
+func copyEntity[T store.EntityType](entity T) (T, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return unmarshalEntity[T](data)
+}
@@ -0,0 +1,228 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package redisstore
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/metaform/connector-fabric-manager/common/query"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) (*RedisEntityStore[*api.OrchestrationEntry], *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewOrchestrationStore(client), server
+}
+
+func newEntry(id string, state api.OrchestrationState) *api.OrchestrationEntry {
+	return &api.OrchestrationEntry{
+		ID:             id,
+		CorrelationID:  "corr-" + id,
+		State:          state,
+		StateTimestamp: time.Now().UTC(),
+	}
+}
+
+func stateOf(state api.OrchestrationState) string {
+	return strconv.FormatUint(uint64(state), 10)
+}
+
+func collectIDs(t *testing.T, seq func(func(*api.OrchestrationEntry, error) bool)) []string {
+	var ids []string
+	for entry, err := range seq {
+		require.NoError(t, err)
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestRedisEntityStore_CRUD(t *testing.T) {
+	estore, server := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.Create(ctx, newEntry("orch-1", api.OrchestrationStateRunning))
+	require.NoError(t, err)
+	assert.True(t, server.Exists("orch:orch-1"))
+
+	exists, err := estore.Exists(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	entry, err := estore.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "corr-orch-1", entry.CorrelationID)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+
+	entry.State = api.OrchestrationStateCompleted
+	require.NoError(t, estore.Update(ctx, entry))
+	assert.Equal(t, int64(1), entry.Version)
+
+	entry, err = estore.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, entry.State)
+	assert.Equal(t, int64(1), entry.Version)
+
+	count, err := estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, estore.Delete(ctx, "orch-1"))
+	_, err = estore.FindByID(ctx, "orch-1")
+	require.ErrorIs(t, err, types.ErrNotFound)
+	assert.Empty(t, collectIDs(t, estore.FindByState(ctx, stateOf(api.OrchestrationStateCompleted))))
+
+	count, err = estore.GetAllCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestRedisEntityStore_Errors(t *testing.T) {
+	estore, _ := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.FindByID(ctx, "missing")
+	require.ErrorIs(t, err, types.ErrNotFound)
+	require.ErrorIs(t, estore.Update(ctx, newEntry("missing", api.OrchestrationStateRunning)), types.ErrNotFound)
+	require.ErrorIs(t, estore.Delete(ctx, "missing"), types.ErrNotFound)
+
+	_, err = estore.Create(ctx, newEntry("", api.OrchestrationStateRunning))
+	require.ErrorIs(t, err, types.ErrInvalidInput)
+	_, err = estore.Create(ctx, newEntry("state:1", api.OrchestrationStateRunning))
+	require.ErrorIs(t, err, types.ErrInvalidInput)
+
+	_, err = estore.Create(ctx, newEntry("orch-1", api.OrchestrationStateRunning))
+	require.NoError(t, err)
+	_, err = estore.Create(ctx, newEntry("orch-1", api.OrchestrationStateRunning))
+	require.ErrorIs(t, err, types.ErrConflict)
+}
+
+func TestRedisEntityStore_Update_VersionConflict(t *testing.T) {
+	estore, _ := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := estore.Create(ctx, newEntry("orch-1", api.OrchestrationStateRunning))
+	require.NoError(t, err)
+
+	first, err := estore.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	second, err := estore.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+
+	first.State = api.OrchestrationStateCompleted
+	require.NoError(t, estore.Update(ctx, first))
+
+	second.State = api.OrchestrationStateErrored
+	err = estore.Update(ctx, second)
+	require.ErrorIs(t, err, types.ErrVersionConflict)
+	require.ErrorIs(t, err, types.ErrConflict)
+	assert.Equal(t, int64(0), second.Version, "a failed update must not change the version")
+
+	stored, err := estore.FindByID(ctx, "orch-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, stored.State)
+}
+
+func TestRedisEntityStore_FindByState(t *testing.T) {
+	estore, server := newTestStore(t)
+	ctx := context.Background()
+
+	for _, entry := range []*api.OrchestrationEntry{
+		newEntry("orch-3", api.OrchestrationStateRunning),
+		newEntry("orch-1", api.OrchestrationStateRunning),
+		newEntry("orch-2", api.OrchestrationStateCompleted),
+	} {
+		_, err := estore.Create(ctx, entry)
+		require.NoError(t, err)
+	}
+
+	running := stateOf(api.OrchestrationStateRunning)
+	completed := stateOf(api.OrchestrationStateCompleted)
+	assert.Equal(t, []string{"orch-1", "orch-3"}, collectIDs(t, estore.FindByState(ctx, running)))
+	assert.Equal(t, []string{"orch-2"}, collectIDs(t, estore.FindByState(ctx, completed)))
+	assert.Empty(t, collectIDs(t, estore.FindByState(ctx, stateOf(api.OrchestrationStateErrored))))
+
+	members, err := server.Members("orch:state:" + running)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"orch-1", "orch-3"}, members)
+
+	// A state change moves the entry between the index sets
+	entry, err := estore.FindByID(ctx, "orch-3")
+	require.NoError(t, err)
+	entry.State = api.OrchestrationStateCompleted
+	require.NoError(t, estore.Update(ctx, entry))
+
+	assert.Equal(t, []string{"orch-1"}, collectIDs(t, estore.FindByState(ctx, running)))
+	assert.Equal(t, []string{"orch-2", "orch-3"}, collectIDs(t, estore.FindByState(ctx, completed)))
+}
+
+func TestRedisEntityStore_Predicates(t *testing.T) {
+	estore, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"orch-1", "orch-2", "orch-3"} {
+		_, err := estore.Create(ctx, newEntry(id, api.OrchestrationStateRunning))
+		require.NoError(t, err)
+	}
+
+	predicate := query.Eq("CorrelationID", "corr-orch-2")
+	found, err := estore.FindFirstByPredicate(ctx, predicate)
+	require.NoError(t, err)
+	assert.Equal(t, "orch-2", found.ID)
+
+	count, err := estore.CountByPredicate(ctx, predicate)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.Equal(t, []string{"orch-2", "orch-3"}, collectIDs(t, estore.GetAllPaginated(ctx, store.PaginationOptions{Offset: 1, Limit: 2})))
+
+	require.NoError(t, estore.DeleteByPredicate(ctx, predicate))
+	assert.Equal(t, []string{"orch-1", "orch-3"}, collectIDs(t, estore.GetAll(ctx)))
+}
+
+func TestRedisEntityStore_TryLock(t *testing.T) {
+	estore, server := newTestStore(t)
+	ctx := context.Background()
+
+	acquired, release, err := estore.TryLock(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, _, err = estore.TryLock(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	release()
+	acquired, _, err = estore.TryLock(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// An expired lock can be acquired by another holder
+	server.FastForward(2 * time.Minute)
+	acquired, _, err = estore.TryLock(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -30,7 +30,8 @@ const (
 
 type PostgresServiceAssembly struct {
 	system.DefaultServiceAssembly
-	db *sql.DB
+	Config StoreConfig
+	db     *sql.DB
 }
 
 func (a *PostgresServiceAssembly) Name() string {
@@ -38,12 +39,24 @@ func (a *PostgresServiceAssembly) Name() string {
 }
 
 func (a *PostgresServiceAssembly) Provides() []system.ServiceType {
-	return []system.ServiceType{api.DefinitionStoreKey, api.OrchestrationIndexKey, store.TransactionContextKey}
+	return []system.ServiceType{
+		api.DefinitionStoreKey,
+		api.OrchestrationIndexKey,
+		api.OutboxStoreKey,
+		api.RawPayloadStoreKey,
+		store.TransactionContextKey,
+		store.TransactionMetricsKey}
 }
 
 func (a *PostgresServiceAssembly) Init(context *system.InitContext) error {
 	context.Registry.Register(api.DefinitionStoreKey, newPostgresDefinitionStore())
-	context.Registry.Register(api.OrchestrationIndexKey, newOrchestrationEntryStore())
+	if a.Config.partitioned() {
+		context.Registry.Register(api.OrchestrationIndexKey, newPartitionedOrchestrationEntryStore(a.Config.PartitionKey))
+	} else {
+		context.Registry.Register(api.OrchestrationIndexKey, newOrchestrationEntryStore())
+	}
+	context.Registry.Register(api.OutboxStoreKey, newOutboxStore())
+	context.Registry.Register(api.RawPayloadStoreKey, newRawPayloadStore())
 
 	if !context.Config.IsSet(dsnKey) {
 		return fmt.Errorf("missing Postgres DSN configuration: %s", dsnKey)
@@ -56,10 +69,14 @@ func (a *PostgresServiceAssembly) Init(context *system.InitContext) error {
 	}
 	a.db = db
 
-	txContext := sqlstore.NewDBTransactionContext(db)
+	metrics := &store.TransactionCounter{}
+	txContext := store.NewMeteredTransactionContext(sqlstore.NewDBTransactionContext(db), metrics)
 	context.Registry.Register(store.TransactionContextKey, txContext)
+	context.Registry.Register(store.TransactionMetricsKey, metrics)
 
-	createTables(db)
+	if err := createTables(db, a.Config); err != nil {
+		return fmt.Errorf("error creating Postgres tables: %w", err)
+	}
 
 	return nil
 }
@@ -71,7 +88,7 @@ func (a *PostgresServiceAssembly) Finalize() error {
 	return nil
 }
 
-func createTables(db *sql.DB) error {
+func createTables(db *sql.DB, config StoreConfig) error {
 	err := createActivityDefinitionsTable(db)
 	if err != nil {
 		return err
@@ -83,7 +100,32 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
-	err = createOrchestrationEntriesTable(db)
+	if err = checkOrchestrationEntriesTable(db, config.partitioned()); err != nil {
+		return err
+	}
+	if config.partitioned() {
+		err = createPartitionedOrchestrationEntriesTable(db)
+	} else {
+		err = createOrchestrationEntriesTable(db)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = createOutboxMessagesTable(db)
+
+	if err != nil {
+		return err
+	}
+
+	err = createRawPayloadsTable(db)
+
+	if err != nil {
+		return err
+	}
+
+	err = sqlstore.CreateLocksTable(db)
 
 	if err != nil {
 		return err
@@ -91,4 +133,3 @@ func createTables(db *sql.DB) error {
 
 	return nil
 }
-
@@ -13,33 +13,182 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/query"
 	"github.com/metaform/connector-fabric-manager/common/sqlstore"
-	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/types"
 	"github.com/metaform/connector-fabric-manager/pmanager/api"
 )
 
-func newOrchestrationEntryStore() store.EntityStore[*api.OrchestrationEntry] {
-	columnNames := []string{"id", "version", "correlation_id", "state", "state_timestamp", "created_timestamp", "orchestration_type"}
+// orchestrationEntryStore is a Postgres api.OrchestrationIndex.
+type orchestrationEntryStore struct {
+	*sqlstore.PostgresEntityStore[*api.OrchestrationEntry]
+}
+
+// uniqueViolation is the Postgres error code of unique constraint violations.
+const uniqueViolation = "23505"
+
+// Create stores the entry. Creating an existing entry returns types.ErrConflict without aborting the transaction, so
+// that the index watcher can apply its change to the concurrently created entry within the same transaction. The insert
+// runs in a savepoint that is rolled back on conflict.
+func (s *orchestrationEntryStore) Create(
+	ctx context.Context,
+	entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT create_entry"); err != nil {
+		return nil, fmt.Errorf("failed to create orchestration entry %s: %w", entry.ID, err)
+	}
+	created, err := s.PostgresEntityStore.Create(ctx, entry)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT create_entry"); err != nil {
+			return nil, fmt.Errorf("failed to create orchestration entry %s: %w", entry.ID, err)
+		}
+		return nil, types.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT create_entry"); err != nil {
+		return nil, fmt.Errorf("failed to create orchestration entry %s: %w", entry.ID, err)
+	}
+	return created, nil
+}
+
+func (s *orchestrationEntryStore) List(
+	ctx context.Context,
+	predicate query.Predicate,
+	cursor string,
+	limit int) ([]*api.OrchestrationEntry, string, error) {
+	return s.ListByCursor(ctx, predicate, cursor, limit, "state_timestamp", api.OrchestrationEntryCursor)
+}
+
+func (s *orchestrationEntryStore) FindByTimeRange(
+	ctx context.Context,
+	field api.TimeField,
+	from time.Time,
+	to time.Time,
+	limit int) ([]*api.OrchestrationEntry, error) {
+	predicate, keyFn, err := api.TimeRangeQuery(field, from, to)
+	if err != nil {
+		return nil, err
+	}
+	column := "state_timestamp"
+	if field == api.TimeFieldCreated {
+		column = "created_timestamp"
+	}
+	entries, _, err := s.ListByCursor(ctx, predicate, "", limit, column, keyFn)
+	return entries, err
+}
+
+// FindByLabel uses JSONB containment so that the query is served by the GIN index on the labels column.
+func (s *orchestrationEntryStore) FindByLabel(
+	ctx context.Context,
+	key string,
+	value string,
+	limit int) ([]*api.OrchestrationEntry, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%w: label key must not be empty", types.ErrInvalidInput)
+	}
+	label, err := json.Marshal(map[string]string{key: value})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize label %s: %w", key, err)
+	}
+	predicate := query.Contains("labels", string(label))
+	entries, _, err := s.ListByCursor(ctx, predicate, "", limit, "state_timestamp", api.OrchestrationEntryCursor)
+	return entries, err
+}
+
+// FindStateByID selects only the state and version columns of the entry.
+func (s *orchestrationEntryStore) FindStateByID(ctx context.Context, id string) (api.OrchestrationState, int64, error) {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	row := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT state, version FROM %s WHERE id = $1", cfmOrchestrationEntriesTable),
+		id,
+	)
+
+	var state api.OrchestrationState
+	var version int64
+	if err := row.Scan(&state, &version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, types.ErrNotFound
+		}
+		return 0, 0, fmt.Errorf("failed to query orchestration entry state: %w", err)
+	}
+	return state, version, nil
+}
+
+// ClaimNext locks the oldest claimable row, skipping rows locked by concurrent claims, and claims it in a single
+// statement.
+func (s *orchestrationEntryStore) ClaimNext(
+	ctx context.Context,
+	state api.OrchestrationState,
+	workerID string,
+	lease time.Duration) (*api.OrchestrationEntry, error) {
+	if err := api.ValidateClaim(workerID, lease); err != nil {
+		return nil, err
+	}
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	now := time.Now()
+	row := tx.QueryRowContext(ctx,
+		fmt.Sprintf(`UPDATE %[1]s SET claimed_by = $1, lease_expiry = $2, version = version + 1
+			WHERE id = (
+				SELECT id FROM %[1]s
+				WHERE "state" = $3 AND (claimed_by = '' OR lease_expiry IS NULL OR lease_expiry <= $4)
+				ORDER BY state_timestamp, id
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED)
+			RETURNING id`, cfmOrchestrationEntriesTable),
+		workerID, now.Add(lease), state, now,
+	)
+
+	var id string
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to claim orchestration entry: %w", err)
+	}
+	return s.FindByID(ctx, id)
+}
+
+func newOrchestrationEntryStore() api.OrchestrationIndex {
+	return createOrchestrationEntryStore(orchestrationEntryColumns(), orchestrationEntryToRecord)
+}
+
+func orchestrationEntryColumns() []string {
+	return []string{"id", "version", "correlation_id", "state", "state_timestamp", "created_timestamp", "orchestration_type", "deadline", "checkpoint", "labels", "revision", "mode", "claimed_by", "lease_expiry", "retry_policy", "pending_state", "pending_expiry", "failure_reason", "remediation_hint"}
+}
+
+func createOrchestrationEntryStore(
+	columnNames []string,
+	toRecord func(*api.OrchestrationEntry) (*sqlstore.DatabaseRecord, error)) *orchestrationEntryStore {
 	builder := sqlstore.NewPostgresJSONBBuilder().
 		WithFieldMappings(map[string]string{"correlationId": "correlation_id",
 			"stateTimestamp":    "state_timestamp",
 			"createdTimestamp":  "created_timestamp",
-			"orchestrationType": "orchestration_type"})
+			"orchestrationType": "orchestration_type",
+			"deadline":          "deadline",
+			"checkpoint":        "checkpoint"}).
+		WithJSONBFieldTypes(map[string]sqlstore.JSONBFieldType{"labels": sqlstore.JSONBFieldTypeScalar})
 
 	estore := sqlstore.NewPostgresEntityStore[*api.OrchestrationEntry](
 		cfmOrchestrationEntriesTable,
 		columnNames,
 		recordToOrchestrationEntry,
-		orchestrationEntryToRecord,
+		toRecord,
 		builder,
 	)
 
-	return estore
+	return &orchestrationEntryStore{PostgresEntityStore: estore}
 }
 
 func recordToOrchestrationEntry(tx *sql.Tx, record *sqlstore.DatabaseRecord) (*api.OrchestrationEntry, error) {
@@ -86,6 +235,64 @@ func recordToOrchestrationEntry(tx *sql.Tx, record *sqlstore.DatabaseRecord) (*a
 		return nil, fmt.Errorf("invalid orchestration entry type reading record")
 	}
 
+	// deadline is optional and NULL when not set
+	if deadline, ok := record.Values["deadline"].(time.Time); ok {
+		profile.Deadline = deadline
+	}
+
+	// checkpoint is optional and NULL when not set
+	if checkpoint, ok := record.Values["checkpoint"].([]byte); ok && checkpoint != nil {
+		profile.Checkpoint = json.RawMessage(checkpoint)
+	}
+
+	// labels are optional and NULL when not set
+	if labels, ok := record.Values["labels"].([]byte); ok && labels != nil {
+		if err := json.Unmarshal(labels, &profile.Labels); err != nil {
+			return nil, fmt.Errorf("invalid orchestration entry labels reading record: %w", err)
+		}
+	}
+
+	if revision, ok := record.Values["revision"].(int64); ok {
+		profile.Revision = uint64(revision)
+	}
+
+	if mode, ok := record.Values["mode"].(string); ok {
+		profile.Mode = mode
+	}
+
+	if claimedBy, ok := record.Values["claimed_by"].(string); ok {
+		profile.ClaimedBy = claimedBy
+	}
+
+	// lease_expiry is NULL when the entry has not been claimed
+	if leaseExpiry, ok := record.Values["lease_expiry"].(time.Time); ok {
+		profile.LeaseExpiry = leaseExpiry
+	}
+
+	// retry_policy is optional and NULL when the defaults apply
+	if policy, ok := record.Values["retry_policy"].([]byte); ok && policy != nil {
+		if err := json.Unmarshal(policy, &profile.RetryPolicy); err != nil {
+			return nil, fmt.Errorf("invalid orchestration entry retry_policy reading record: %w", err)
+		}
+	}
+
+	// pending_state and pending_expiry are NULL when no state awaits confirmation
+	if pending, ok := record.Values["pending_state"].(int64); ok {
+		state := api.OrchestrationState(pending)
+		profile.PendingState = &state
+	}
+	if pendingExpiry, ok := record.Values["pending_expiry"].(time.Time); ok {
+		profile.PendingExpiry = pendingExpiry
+	}
+
+	if reason, ok := record.Values["failure_reason"].(string); ok {
+		profile.FailureReason = api.FailureReason(reason)
+	}
+
+	if hint, ok := record.Values["remediation_hint"].(string); ok {
+		profile.RemediationHint = hint
+	}
+
 	return profile, nil
 
 }
@@ -102,6 +309,51 @@ func orchestrationEntryToRecord(profile *api.OrchestrationEntry) (*sqlstore.Data
 	record.Values["state_timestamp"] = profile.StateTimestamp
 	record.Values["created_timestamp"] = profile.CreatedTimestamp
 	record.Values["orchestration_type"] = profile.OrchestrationType
+	if profile.Deadline.IsZero() {
+		record.Values["deadline"] = nil
+	} else {
+		record.Values["deadline"] = profile.Deadline
+	}
+	if len(profile.Checkpoint) == 0 {
+		record.Values["checkpoint"] = nil
+	} else {
+		record.Values["checkpoint"] = []byte(profile.Checkpoint)
+	}
+	if len(profile.Labels) == 0 {
+		record.Values["labels"] = nil
+	} else {
+		labels, err := json.Marshal(profile.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize orchestration entry labels: %w", err)
+		}
+		record.Values["labels"] = labels
+	}
+	record.Values["revision"] = int64(profile.Revision)
+	record.Values["mode"] = profile.Mode
+	record.Values["claimed_by"] = profile.ClaimedBy
+	if profile.LeaseExpiry.IsZero() {
+		record.Values["lease_expiry"] = nil
+	} else {
+		record.Values["lease_expiry"] = profile.LeaseExpiry
+	}
+	if profile.RetryPolicy == nil {
+		record.Values["retry_policy"] = nil
+	} else {
+		policy, err := json.Marshal(profile.RetryPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize orchestration entry retry policy: %w", err)
+		}
+		record.Values["retry_policy"] = policy
+	}
+	if profile.PendingState == nil {
+		record.Values["pending_state"] = nil
+		record.Values["pending_expiry"] = nil
+	} else {
+		record.Values["pending_state"] = *profile.PendingState
+		record.Values["pending_expiry"] = profile.PendingExpiry
+	}
+	record.Values["failure_reason"] = string(profile.FailureReason)
+	record.Values["remediation_hint"] = profile.RemediationHint
 
 	return record, nil
 }
@@ -15,6 +15,7 @@ package sqlstore
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
@@ -101,6 +102,41 @@ func TestNewOrchestrationEntryStore_FindByID_NotFound(t *testing.T) {
 	assert.ErrorAs(t, types.ErrNotFound, &err)
 }
 
+// TestNewOrchestrationEntryStore_FindStateByID tests selecting the state and version of an entry
+func TestNewOrchestrationEntryStore_FindStateByID(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	_, err := testDB.Exec(
+		"INSERT INTO orchestration_entries (id, version, correlation_id, state, state_timestamp, created_timestamp, orchestration_type) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		"orch-state-1",
+		3,
+		"correlation-state-1",
+		api.OrchestrationStateErrored,
+		time.Now(),
+		time.Now(),
+		"provision",
+	)
+	require.NoError(t, err)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	state, version, err := estore.FindStateByID(txCtx, "orch-state-1")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateErrored, state)
+	assert.Equal(t, int64(3), version)
+
+	_, _, err = estore.FindStateByID(txCtx, "non-existent")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
 // TestNewOrchestrationEntryStore_Create tests creating a new orchestration entry
 func TestNewOrchestrationEntryStore_Create(t *testing.T) {
 	setupOrchestrationEntryTable(t, testDB)
@@ -132,6 +168,53 @@ func TestNewOrchestrationEntryStore_Create(t *testing.T) {
 	assert.Equal(t, "correlation-new-123", created.CorrelationID)
 }
 
+// TestNewOrchestrationEntryStore_Create_Conflict tests that a concurrently created entry can be updated within the same
+// transaction after its creation conflicted, as done by the index watcher
+func TestNewOrchestrationEntryStore_Create_Conflict(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+	newEntry := func(state api.OrchestrationState) *api.OrchestrationEntry {
+		return &api.OrchestrationEntry{
+			ID:                "orch-conflict",
+			CorrelationID:     "corr-conflict",
+			State:             state,
+			StateTimestamp:    time.Now().UTC(),
+			CreatedTimestamp:  time.Now().UTC(),
+			OrchestrationType: "provision",
+		}
+	}
+	trxContext := sqlstore.NewDBTransactionContext(testDB)
+	require.NoError(t, trxContext.Execute(ctx, func(ctx context.Context) error {
+		_, err := estore.Create(ctx, newEntry(api.OrchestrationStateInitialized))
+		return err
+	}))
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	_, err = estore.Create(txCtx, newEntry(api.OrchestrationStateRunning))
+	require.ErrorIs(t, err, types.ErrConflict)
+
+	existing, err := estore.FindByID(txCtx, "orch-conflict")
+	require.NoError(t, err)
+	existing.State = api.OrchestrationStateRunning
+	require.NoError(t, estore.Update(txCtx, existing))
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, trxContext.Execute(ctx, func(ctx context.Context) error {
+		found, err := estore.FindByID(ctx, "orch-conflict")
+		require.NoError(t, err)
+		assert.Equal(t, api.OrchestrationStateRunning, found.State)
+		return nil
+	}))
+}
+
 // TestNewOrchestrationEntryStore_SearchByStatePredicate tests filtering by state
 func TestNewOrchestrationEntryStore_SearchByStatePredicate(t *testing.T) {
 	setupOrchestrationEntryTable(t, testDB)
@@ -379,6 +462,399 @@ func TestNewOrchestrationEntryStore_SearchByStateAndCorrelationIDPredicate(t *te
 	assert.Equal(t, 1, count)
 }
 
+// TestNewOrchestrationEntryStore_ListByCursor tests keyset pagination returns every entry exactly once
+func TestNewOrchestrationEntryStore_ListByCursor(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	// Several entries share a timestamp so ordering must fall back to the ID
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	const total = 25
+	for i := 0; i < total; i++ {
+		_, err := testDB.Exec(
+			"INSERT INTO orchestration_entries (id, version, correlation_id, state, state_timestamp, created_timestamp, orchestration_type) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			fmt.Sprintf("orch-cursor-%02d", i),
+			1,
+			fmt.Sprintf("corr-cursor-%02d", i),
+			api.OrchestrationStateRunning,
+			base.Add(time.Duration(i/3)*time.Second),
+			base,
+			"provision",
+		)
+		require.NoError(t, err)
+	}
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	seen := make(map[string]struct{})
+	cursor := ""
+	for {
+		entries, next, err := estore.List(txCtx, nil, cursor, 4)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			_, duplicate := seen[entry.ID]
+			require.False(t, duplicate, "duplicate entry %s", entry.ID)
+			seen[entry.ID] = struct{}{}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, total, len(seen))
+
+	_, _, err = estore.List(txCtx, nil, "not-a-cursor", 4)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+// TestNewOrchestrationEntryStore_FindByTimeRange tests searching entries by created and state timestamps
+func TestNewOrchestrationEntryStore_FindByTimeRange(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		_, err := testDB.Exec(
+			"INSERT INTO orchestration_entries (id, version, correlation_id, state, state_timestamp, created_timestamp, orchestration_type) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			fmt.Sprintf("orch-range-%d", i),
+			1,
+			fmt.Sprintf("corr-range-%d", i),
+			api.OrchestrationStateRunning,
+			base.Add(time.Duration(10-i)*time.Hour),
+			base.Add(time.Duration(i)*time.Hour),
+			"provision",
+		)
+		require.NoError(t, err)
+	}
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	entries, err := estore.FindByTimeRange(txCtx, api.TimeFieldCreated, base.Add(time.Hour), base.Add(4*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "orch-range-1", entries[0].ID)
+	assert.Equal(t, "orch-range-3", entries[2].ID)
+
+	entries, err = estore.FindByTimeRange(txCtx, api.TimeFieldState, base.Add(7*time.Hour), base.Add(11*time.Hour), 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "orch-range-3", entries[0].ID)
+	assert.Equal(t, "orch-range-2", entries[1].ID)
+
+	_, err = estore.FindByTimeRange(txCtx, api.TimeFieldState, base, base, 10)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+func TestNewOrchestrationEntryStore_Checkpoint(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	_, err = estore.Create(txCtx, &api.OrchestrationEntry{
+		ID:                "orch-checkpoint",
+		CorrelationID:     "corr-checkpoint",
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    time.Now(),
+		CreatedTimestamp:  time.Now(),
+		OrchestrationType: "provision",
+		Checkpoint:        []byte(`{"step":1}`),
+	})
+	require.NoError(t, err)
+
+	entry, err := estore.FindByID(txCtx, "orch-checkpoint")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"step":1}`, string(entry.Checkpoint))
+
+	require.NoError(t, api.SaveCheckpoint(txCtx, estore, "orch-checkpoint", map[string]int{"step": 2}))
+
+	var checkpoint map[string]int
+	found, err := api.LoadCheckpoint(txCtx, estore, "orch-checkpoint", &checkpoint)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 2, checkpoint["step"])
+}
+
+func TestNewOrchestrationEntryStore_FindByLabel(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	regions := []string{"eu", "us", "eu", "eu"}
+	for i, region := range regions {
+		_, err = estore.Create(txCtx, &api.OrchestrationEntry{
+			ID:                fmt.Sprintf("orch-label-%d", i),
+			CorrelationID:     fmt.Sprintf("corr-label-%d", i),
+			State:             api.OrchestrationStateRunning,
+			StateTimestamp:    base.Add(time.Duration(i) * time.Hour),
+			CreatedTimestamp:  base,
+			OrchestrationType: "provision",
+			Labels:            map[string]string{"region": region, "cfm.io/tier": "gold"},
+		})
+		require.NoError(t, err)
+	}
+	_, err = estore.Create(txCtx, &api.OrchestrationEntry{
+		ID:                "orch-unlabeled",
+		CorrelationID:     "corr-unlabeled",
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    base,
+		CreatedTimestamp:  base,
+		OrchestrationType: "provision",
+	})
+	require.NoError(t, err)
+
+	entries, err := estore.FindByLabel(txCtx, "region", "eu", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "orch-label-0", entries[0].ID)
+	assert.Equal(t, "orch-label-3", entries[2].ID)
+	assert.Equal(t, "eu", entries[0].Labels["region"])
+
+	entries, err = estore.FindByLabel(txCtx, "cfm.io/tier", "gold", 2)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Labels are updatable
+	entry, err := estore.FindByID(txCtx, "orch-label-1")
+	require.NoError(t, err)
+	entry.Labels["region"] = "eu"
+	require.NoError(t, estore.Update(txCtx, entry))
+
+	entries, err = estore.FindByLabel(txCtx, "region", "eu", 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+
+	_, err = estore.FindByLabel(txCtx, "", "eu", 10)
+	assert.ErrorIs(t, err, types.ErrInvalidInput)
+}
+
+// TestNewOrchestrationEntryStore_ClaimNext tests that concurrent transactions claim different entries
+func TestNewOrchestrationEntryStore_ClaimNext(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setupTx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	setupCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, setupTx)
+	for i := range 2 {
+		_, err = estore.Create(setupCtx, &api.OrchestrationEntry{
+			ID:                fmt.Sprintf("orch-claim-%d", i),
+			CorrelationID:     fmt.Sprintf("corr-claim-%d", i),
+			State:             api.OrchestrationStateRunning,
+			StateTimestamp:    base.Add(time.Duration(i) * time.Hour),
+			CreatedTimestamp:  base,
+			OrchestrationType: "provision",
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, setupTx.Commit())
+
+	// The row claimed by the first transaction stays locked until it commits and is skipped by the second
+	tx1, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx1.Rollback()
+	tx2, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx2.Rollback()
+
+	first, err := estore.ClaimNext(context.WithValue(ctx, sqlstore.SQLTransactionKey, tx1), api.OrchestrationStateRunning, "worker-1", time.Minute)
+	require.NoError(t, err)
+	second, err := estore.ClaimNext(context.WithValue(ctx, sqlstore.SQLTransactionKey, tx2), api.OrchestrationStateRunning, "worker-2", time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, "orch-claim-0", first.ID)
+	assert.Equal(t, "worker-1", first.ClaimedBy)
+	assert.Equal(t, "orch-claim-1", second.ID)
+	assert.Equal(t, "worker-2", second.ClaimedBy)
+	assert.False(t, second.LeaseExpiry.IsZero())
+	require.NoError(t, tx1.Commit())
+	require.NoError(t, tx2.Commit())
+
+	tx3, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx3.Rollback()
+	_, err = estore.ClaimNext(context.WithValue(ctx, sqlstore.SQLTransactionKey, tx3), api.OrchestrationStateRunning, "worker-3", time.Minute)
+	assert.ErrorIs(t, err, types.ErrNotFound, "claimed entries must not be claimed again before their lease expires")
+}
+
+func TestNewOrchestrationEntryStore_Revision(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	entry := &api.OrchestrationEntry{
+		ID:                "orch-revision",
+		CorrelationID:     "corr-revision",
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    time.Now().UTC(),
+		CreatedTimestamp:  time.Now().UTC(),
+		OrchestrationType: "provision",
+		Revision:          7,
+	}
+	_, err = estore.Create(txCtx, entry)
+	require.NoError(t, err)
+
+	found, err := estore.FindByID(txCtx, "orch-revision")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), found.Revision)
+
+	found.Revision = 9
+	require.NoError(t, estore.Update(txCtx, found))
+	found, err = estore.FindByID(txCtx, "orch-revision")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), found.Revision)
+}
+
+func TestNewOrchestrationEntryStore_RetryPolicy(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	policy := &api.RetryPolicy{MaxAttempts: 2, BackoffBase: 250 * time.Millisecond}
+	for _, entry := range []*api.OrchestrationEntry{
+		{ID: "orch-policy", RetryPolicy: policy},
+		{ID: "orch-default"},
+	} {
+		entry.CorrelationID = "corr-policy"
+		entry.State = api.OrchestrationStateRunning
+		entry.StateTimestamp = time.Now().UTC()
+		entry.CreatedTimestamp = time.Now().UTC()
+		entry.OrchestrationType = "provision"
+		_, err = estore.Create(txCtx, entry)
+		require.NoError(t, err)
+	}
+
+	found, err := estore.FindByID(txCtx, "orch-policy")
+	require.NoError(t, err)
+	assert.Equal(t, policy, found.RetryPolicy)
+
+	found, err = estore.FindByID(txCtx, "orch-default")
+	require.NoError(t, err)
+	assert.Nil(t, found.RetryPolicy)
+}
+
+func TestNewOrchestrationEntryStore_PendingState(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	pending := api.OrchestrationStateCompleted
+	expiry := time.Now().UTC().Add(time.Minute).Truncate(time.Microsecond)
+	for _, entry := range []*api.OrchestrationEntry{
+		{ID: "orch-pending", PendingState: &pending, PendingExpiry: expiry},
+		{ID: "orch-settled"},
+	} {
+		entry.CorrelationID = "corr-pending"
+		entry.State = api.OrchestrationStateRunning
+		entry.StateTimestamp = time.Now().UTC()
+		entry.CreatedTimestamp = time.Now().UTC()
+		entry.OrchestrationType = "provision"
+		_, err = estore.Create(txCtx, entry)
+		require.NoError(t, err)
+	}
+
+	found, err := estore.FindByID(txCtx, "orch-pending")
+	require.NoError(t, err)
+	require.NotNil(t, found.PendingState)
+	assert.Equal(t, api.OrchestrationStateCompleted, *found.PendingState)
+	assert.True(t, expiry.Equal(found.PendingExpiry))
+
+	found, err = estore.FindByID(txCtx, "orch-settled")
+	require.NoError(t, err)
+	assert.Nil(t, found.PendingState)
+	assert.True(t, found.PendingExpiry.IsZero())
+}
+
+func TestNewOrchestrationEntryStore_FailureReason(t *testing.T) {
+	setupOrchestrationEntryTable(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newOrchestrationEntryStore()
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	_, err = estore.Create(txCtx, &api.OrchestrationEntry{
+		ID:                "orch-failed",
+		CorrelationID:     "corr-failed",
+		State:             api.OrchestrationStateErrored,
+		StateTimestamp:    time.Now().UTC(),
+		CreatedTimestamp:  time.Now().UTC(),
+		OrchestrationType: "provision",
+		FailureReason:     api.FailureReasonTimeout,
+		RemediationHint:   "Raise the processing timeout",
+	})
+	require.NoError(t, err)
+
+	found, err := estore.FindByID(txCtx, "orch-failed")
+	require.NoError(t, err)
+	assert.Equal(t, api.FailureReasonTimeout, found.FailureReason)
+	assert.Equal(t, "Raise the processing timeout", found.RemediationHint)
+}
+
 func setupOrchestrationEntryTable(t *testing.T, db *sql.DB) {
 	err := createOrchestrationEntriesTable(db)
 	require.NoError(t, err)
@@ -0,0 +1,95 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// outboxStore is a Postgres api.OutboxStore. Messages are written with the transaction in the context, so they are
+// only visible to the relay once the transaction commits.
+type outboxStore struct{}
+
+func newOutboxStore() api.OutboxStore {
+	return &outboxStore{}
+}
+
+func (s *outboxStore) Add(ctx context.Context, message *api.OutboxMessage) error {
+	if message.ID == "" {
+		return types.ErrInvalidInput
+	}
+	created := message.CreatedTimestamp
+	if created.IsZero() {
+		created = time.Now()
+	}
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	_, err := tx.ExecContext(ctx,
+		fmt.Sprintf("INSERT INTO %s (id, subject, payload, created_timestamp) VALUES ($1, $2, $3, $4)", cfmOutboxMessagesTable),
+		message.ID, message.Subject, message.Payload, created,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+	return nil
+}
+
+func (s *outboxStore) FindPending(ctx context.Context, limit int) ([]*api.OutboxMessage, error) {
+	if limit <= 0 {
+		return nil, types.ErrInvalidInput
+	}
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	rows, err := tx.QueryContext(ctx,
+		fmt.Sprintf("SELECT id, subject, payload, created_timestamp FROM %s ORDER BY seq LIMIT $1", cfmOutboxMessagesTable),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*api.OutboxMessage, 0, limit)
+	for rows.Next() {
+		message := &api.OutboxMessage{}
+		if err := rows.Scan(&message.ID, &message.Subject, &message.Payload, &message.CreatedTimestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return messages, nil
+}
+
+func (s *outboxStore) MarkSent(ctx context.Context, id string) error {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	result, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", cfmOutboxMessagesTable), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message sent: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message sent: %w", err)
+	}
+	if affected == 0 {
+		return types.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxStore_RolledBackTransactionLeavesNoMessage(t *testing.T) {
+	setupOutboxTable(t, testDB)
+	defer cleanupOutboxTestData(t, testDB)
+
+	outbox := newOutboxStore()
+	trxContext := sqlstore.NewDBTransactionContext(testDB)
+	ctx := context.Background()
+
+	rollback := errors.New("update failed")
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		require.NoError(t, outbox.Add(ctx, &api.OutboxMessage{ID: "msg-1", Subject: "event.test", Payload: []byte("data")}))
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+
+	var count int
+	require.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM outbox_messages").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestOutboxStore_PendingUntilSent(t *testing.T) {
+	setupOutboxTable(t, testDB)
+	defer cleanupOutboxTestData(t, testDB)
+
+	outbox := newOutboxStore()
+	trxContext := sqlstore.NewDBTransactionContext(testDB)
+	ctx := context.Background()
+
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		for _, id := range []string{"msg-1", "msg-2", "msg-3"} {
+			if err := outbox.Add(ctx, &api.OutboxMessage{ID: id, Subject: "event.test", Payload: []byte(id), CreatedTimestamp: time.Now()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = trxContext.Execute(ctx, func(ctx context.Context) error {
+		pending, err := outbox.FindPending(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, "msg-1", pending[0].ID)
+		assert.Equal(t, "event.test", pending[0].Subject)
+		assert.Equal(t, []byte("msg-1"), pending[0].Payload)
+		assert.Equal(t, "msg-2", pending[1].ID)
+
+		require.NoError(t, outbox.MarkSent(ctx, "msg-1"))
+		assert.ErrorIs(t, outbox.MarkSent(ctx, "msg-1"), types.ErrNotFound, "sent messages are deleted")
+		assert.ErrorIs(t, outbox.MarkSent(ctx, "unknown"), types.ErrNotFound)
+
+		pending, err = outbox.FindPending(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, "msg-2", pending[0].ID)
+		assert.Equal(t, "msg-3", pending[1].ID)
+
+		_, err = outbox.FindPending(ctx, 0)
+		assert.ErrorIs(t, err, types.ErrInvalidInput)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func setupOutboxTable(t *testing.T, db *sql.DB) {
+	err := createOutboxMessagesTable(db)
+	require.NoError(t, err)
+}
+
+func cleanupOutboxTestData(t *testing.T, db *sql.DB) {
+	_, err := db.Exec("DROP TABLE IF EXISTS outbox_messages CASCADE")
+	require.NoError(t, err)
+}
@@ -0,0 +1,144 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+const (
+	partitionKeyColumn = "partition_key"
+
+	// maxPartitionNameLength bounds the key-derived part of partition table names so that names stay within the 63
+	// byte Postgres identifier limit.
+	maxPartitionNameLength = 32
+)
+
+// PartitionKeyFunc determines the partition an orchestration entry is stored in, for example the month it was created
+// in. The key is computed once when the entry is created, so it must be derived from fields that do not change over the
+// lifetime of an entry, such as its ID, type or creation timestamp.
+type PartitionKeyFunc func(entry *api.OrchestrationEntry) string
+
+// StoreConfig configures the Postgres stores.
+type StoreConfig struct {
+	// PartitionKey routes orchestration entries to partitions of the orchestration entries table. Entries with the same
+	// key are stored in the same partition. When nil, all entries are stored in a single, unpartitioned table.
+	//
+	// Partitioning is applied when the table is created. An existing table is not converted, the store fails to start
+	// if its partitioning does not match.
+	PartitionKey PartitionKeyFunc
+}
+
+func (c StoreConfig) partitioned() bool {
+	return c.PartitionKey != nil
+}
+
+// partitionTableName returns the table holding the entries with the given partition key. Keys are reduced to valid
+// identifiers and suffixed with their hash so that distinct keys never share a table.
+func partitionTableName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(key) {
+		if b.Len() == maxPartitionNameLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%s_%s_%08x", cfmOrchestrationEntriesTable, b.String(), h.Sum32())
+}
+
+// partitionedOrchestrationEntryStore writes entries to the partition selected by the partition key function, creating
+// missing partitions on demand. Postgres routes rows written to the parent table to their partition, so reads operate
+// on the parent table unchanged. Updates do not write the partition key, entries stay in the partition they were
+// created in.
+type partitionedOrchestrationEntryStore struct {
+	*orchestrationEntryStore
+	creator      *orchestrationEntryStore
+	partitionKey PartitionKeyFunc
+}
+
+// Create stores the entry in its partition. As the primary key of the partitioned table includes the partition key,
+// Postgres does not enforce unique IDs across partitions. Create therefore serializes creations of the same ID and
+// checks that the ID is not stored in any partition.
+func (s *partitionedOrchestrationEntryStore) Create(
+	ctx context.Context,
+	entry *api.OrchestrationEntry) (*api.OrchestrationEntry, error) {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", entry.ID); err != nil {
+		return nil, fmt.Errorf("failed to lock orchestration entry %s: %w", entry.ID, err)
+	}
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)", cfmOrchestrationEntriesTable),
+		entry.ID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up orchestration entry %s: %w", entry.ID, err)
+	}
+	if exists {
+		return nil, types.ErrConflict
+	}
+	if err := s.ensurePartition(ctx, s.partitionKey(entry)); err != nil {
+		return nil, err
+	}
+	return s.creator.Create(ctx, entry)
+}
+
+// ensurePartition creates the partition for the key in the current transaction if it does not exist yet.
+func (s *partitionedOrchestrationEntryStore) ensurePartition(ctx context.Context, key string) error {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	table := partitionTableName(key)
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up partition %s: %w", table, err)
+	}
+	if exists {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES IN (%s)",
+		pq.QuoteIdentifier(table), cfmOrchestrationEntriesTable, pq.QuoteLiteral(key)))
+	if err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", table, err)
+	}
+	return nil
+}
+
+func newPartitionedOrchestrationEntryStore(partitionKey PartitionKeyFunc) api.OrchestrationIndex {
+	toRecord := func(entry *api.OrchestrationEntry) (*sqlstore.DatabaseRecord, error) {
+		record, err := orchestrationEntryToRecord(entry)
+		if err != nil {
+			return nil, err
+		}
+		record.Values[partitionKeyColumn] = partitionKey(entry)
+		return record, nil
+	}
+	return &partitionedOrchestrationEntryStore{
+		orchestrationEntryStore: createOrchestrationEntryStore(orchestrationEntryColumns(), orchestrationEntryToRecord),
+		creator:                 createOrchestrationEntryStore(append(orchestrationEntryColumns(), partitionKeyColumn), toRecord),
+		partitionKey:            partitionKey,
+	}
+}
@@ -0,0 +1,138 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/model"
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedOrchestrationEntryStore_RoutesByPartitionKey(t *testing.T) {
+	cleanupOrchestrationEntryTestData(t, testDB)
+	require.NoError(t, createPartitionedOrchestrationEntriesTable(testDB))
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newPartitionedOrchestrationEntryStore(func(entry *api.OrchestrationEntry) string {
+		return string(entry.OrchestrationType)
+	})
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	for id, orchestrationType := range map[string]string{"orch-1": "provision", "orch-2": "deprovision", "orch-3": "provision"} {
+		_, err = estore.Create(txCtx, newPartitionTestEntry(id, orchestrationType))
+		require.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, []string{"orch-1", "orch-3"}, partitionIDs(t, tx, "provision"))
+	assert.ElementsMatch(t, []string{"orch-2"}, partitionIDs(t, tx, "deprovision"))
+
+	// Reads are served from the parent table across partitions
+	found, err := estore.FindByID(txCtx, "orch-2")
+	require.NoError(t, err)
+	assert.Equal(t, "deprovision", string(found.OrchestrationType))
+
+	// Updates are applied in the partition of the entry
+	found.State = api.OrchestrationStateCompleted
+	require.NoError(t, estore.Update(txCtx, found))
+	updated, err := estore.FindByID(txCtx, "orch-2")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateCompleted, updated.State)
+	assert.ElementsMatch(t, []string{"orch-2"}, partitionIDs(t, tx, "deprovision"))
+}
+
+func TestPartitionedOrchestrationEntryStore_Create_ConflictAcrossPartitions(t *testing.T) {
+	cleanupOrchestrationEntryTestData(t, testDB)
+	require.NoError(t, createPartitionedOrchestrationEntriesTable(testDB))
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+
+	estore := newPartitionedOrchestrationEntryStore(func(entry *api.OrchestrationEntry) string {
+		return string(entry.OrchestrationType)
+	})
+	ctx := context.Background()
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	txCtx := context.WithValue(ctx, sqlstore.SQLTransactionKey, tx)
+
+	_, err = estore.Create(txCtx, newPartitionTestEntry("orch-1", "provision"))
+	require.NoError(t, err)
+
+	_, err = estore.Create(txCtx, newPartitionTestEntry("orch-1", "deprovision"))
+	require.ErrorIs(t, err, types.ErrConflict)
+	assert.ElementsMatch(t, []string{"orch-1"}, partitionIDs(t, tx, "provision"))
+}
+
+func TestPartitionTableName(t *testing.T) {
+	assert.Regexp(t, `^orchestration_entries_tenant_a_[0-9a-f]{8}$`, partitionTableName("Tenant-A"))
+	assert.Regexp(t, `^orchestration_entries__[0-9a-f]{8}$`, partitionTableName(""))
+	assert.NotEqual(t, partitionTableName("tenant-a"), partitionTableName("tenant_a"), "sanitized keys must not share a partition")
+	assert.LessOrEqual(t, len(partitionTableName(string(make([]byte, 200)))), 63)
+}
+
+func TestCreateTables_PartitioningMismatch(t *testing.T) {
+	cleanupOrchestrationEntryTestData(t, testDB)
+	defer cleanupOrchestrationEntryTestData(t, testDB)
+	partitioned := StoreConfig{PartitionKey: func(entry *api.OrchestrationEntry) string {
+		return string(entry.OrchestrationType)
+	}}
+
+	require.NoError(t, createTables(testDB, StoreConfig{}))
+	assert.ErrorContains(t, createTables(testDB, partitioned), "not partitioned")
+
+	cleanupOrchestrationEntryTestData(t, testDB)
+	require.NoError(t, createTables(testDB, partitioned))
+	assert.ErrorContains(t, createTables(testDB, StoreConfig{}), "is partitioned")
+}
+
+func newPartitionTestEntry(id string, orchestrationType string) *api.OrchestrationEntry {
+	now := time.Now()
+	return &api.OrchestrationEntry{
+		ID:                id,
+		Version:           1,
+		CorrelationID:     "corr-" + id,
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    now,
+		CreatedTimestamp:  now,
+		OrchestrationType: model.OrchestrationType(orchestrationType),
+	}
+}
+
+// partitionIDs returns the IDs of the entries stored in the partition for the given key.
+func partitionIDs(t *testing.T, tx *sql.Tx, key string) []string {
+	rows, err := tx.Query("SELECT id FROM " + partitionTableName(key))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	return ids
+}
@@ -0,0 +1,70 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+)
+
+// rawPayloadStore is a Postgres api.RawPayloadStore. Payloads are written with the transaction in the context, so
+// they are only recorded if the change they produced commits.
+type rawPayloadStore struct{}
+
+func newRawPayloadStore() api.RawPayloadStore {
+	return &rawPayloadStore{}
+}
+
+func (s *rawPayloadStore) Save(ctx context.Context, payload *api.RawPayload) error {
+	if payload.ID == "" {
+		return types.ErrInvalidInput
+	}
+	created := payload.CreatedTimestamp
+	if created.IsZero() {
+		created = time.Now()
+	}
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	_, err := tx.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (id, revision, payload, created_timestamp) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id, revision) DO UPDATE SET payload = EXCLUDED.payload, created_timestamp = EXCLUDED.created_timestamp`,
+			cfmRawPayloadsTable),
+		payload.ID, payload.Revision, payload.Payload, created,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save raw payload: %w", err)
+	}
+	return nil
+}
+
+func (s *rawPayloadStore) Find(ctx context.Context, id string, revision uint64) (*api.RawPayload, error) {
+	tx := ctx.Value(sqlstore.SQLTransactionKey).(*sql.Tx)
+	row := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT id, revision, payload, created_timestamp FROM %s WHERE id = $1 AND revision = $2", cfmRawPayloadsTable),
+		id, revision,
+	)
+	payload := &api.RawPayload{}
+	if err := row.Scan(&payload.ID, &payload.Revision, &payload.Payload, &payload.CreatedTimestamp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find raw payload: %w", err)
+	}
+	return payload, nil
+}
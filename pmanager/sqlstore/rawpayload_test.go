@@ -0,0 +1,83 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/sqlstore"
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawPayloadStore_SaveAndFind(t *testing.T) {
+	setupRawPayloadsTable(t, testDB)
+	defer cleanupRawPayloadsTestData(t, testDB)
+
+	payloads := newRawPayloadStore()
+	trxContext := sqlstore.NewDBTransactionContext(testDB)
+	ctx := context.Background()
+
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 0, Payload: []byte("created")}))
+		require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 1, Payload: []byte("updated")}))
+		return payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Revision: 1, Payload: []byte("redelivered")})
+	})
+	require.NoError(t, err)
+
+	err = trxContext.Execute(ctx, func(ctx context.Context) error {
+		payload, err := payloads.Find(ctx, "orch-1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("redelivered"), payload.Payload)
+
+		_, err = payloads.Find(ctx, "orch-1", 2)
+		assert.ErrorIs(t, err, types.ErrNotFound)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRawPayloadStore_RolledBackTransactionLeavesNoPayload(t *testing.T) {
+	setupRawPayloadsTable(t, testDB)
+	defer cleanupRawPayloadsTestData(t, testDB)
+
+	payloads := newRawPayloadStore()
+	trxContext := sqlstore.NewDBTransactionContext(testDB)
+	ctx := context.Background()
+
+	rollback := errors.New("update failed")
+	err := trxContext.Execute(ctx, func(ctx context.Context) error {
+		require.NoError(t, payloads.Save(ctx, &api.RawPayload{ID: "orch-1", Payload: []byte("data")}))
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+
+	var count int
+	require.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM raw_payloads").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func setupRawPayloadsTable(t *testing.T, db *sql.DB) {
+	err := createRawPayloadsTable(db)
+	require.NoError(t, err)
+}
+
+func cleanupRawPayloadsTestData(t *testing.T, db *sql.DB) {
+	_, err := db.Exec("DROP TABLE IF EXISTS raw_payloads CASCADE")
+	require.NoError(t, err)
+}
@@ -14,6 +14,7 @@ package sqlstore
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
@@ -21,21 +22,104 @@ const (
 	cfmOrchestrationEntriesTable     = "orchestration_entries"
 	cfmOrchestrationDefinitionsTable = "orchestration_definitions"
 	cfmActivityDefinitionsTable      = "activity_definitions"
+	cfmOutboxMessagesTable           = "outbox_messages"
+	cfmRawPayloadsTable              = "raw_payloads"
 )
 
 // Note fields are quoted to avoid some IDEs (Goland) reformatting them to uppercase
 
 func createOrchestrationEntriesTable(db *sql.DB) error {
 	_, err := db.Exec(fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s (
+		CREATE TABLE IF NOT EXISTS %[1]s (
 			id VARCHAR(255) PRIMARY KEY,
 			version BIGINT NOT NULL,
 			correlation_id VARCHAR(255) NOT NULL ,
 			"state" INTEGER,
 			state_timestamp TIMESTAMP NOT NULL ,
 			created_timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			orchestration_type VARCHAR(255)
-		)
+			orchestration_type VARCHAR(255),
+			deadline TIMESTAMP,
+			checkpoint JSONB,
+			labels JSONB,
+			revision BIGINT NOT NULL DEFAULT 0,
+			mode VARCHAR(255) NOT NULL DEFAULT '',
+			claimed_by VARCHAR(255) NOT NULL DEFAULT '',
+			lease_expiry TIMESTAMP,
+			retry_policy JSONB,
+			pending_state INTEGER,
+			pending_expiry TIMESTAMP,
+			failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+			remediation_hint TEXT NOT NULL DEFAULT ''
+		);
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS deadline TIMESTAMP;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS labels JSONB;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS mode VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS lease_expiry TIMESTAMP;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS retry_policy JSONB;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS pending_state INTEGER;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS pending_expiry TIMESTAMP;
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS remediation_hint TEXT NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_state_timestamp_id ON %[1]s(state_timestamp, id);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_labels ON %[1]s USING GIN (labels)
+	`, cfmOrchestrationEntriesTable))
+	return err
+}
+
+// checkOrchestrationEntriesTable returns an error if the orchestration entries table exists and its partitioning does
+// not match the configuration. Tables are created if they do not exist, but never converted.
+func checkOrchestrationEntriesTable(db *sql.DB, partitioned bool) error {
+	var kind sql.NullString
+	err := db.QueryRow("SELECT relkind::text FROM pg_class WHERE oid = to_regclass($1)", cfmOrchestrationEntriesTable).Scan(&kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up table %s: %w", cfmOrchestrationEntriesTable, err)
+	}
+	switch {
+	case partitioned && kind.String != "p":
+		return fmt.Errorf("table %s is not partitioned, migrate it to a partitioned table or disable partitioning",
+			cfmOrchestrationEntriesTable)
+	case !partitioned && kind.String == "p":
+		return fmt.Errorf("table %s is partitioned, configure the partition key it was created with", cfmOrchestrationEntriesTable)
+	}
+	return nil
+}
+
+// createPartitionedOrchestrationEntriesTable creates the orchestration entries table partitioned by the partition key
+// column. Partitions are created on demand by the store. Postgres requires the primary key of a partitioned table to
+// contain the partition key, so the store checks that entry IDs are unique across partitions on creation.
+func createPartitionedOrchestrationEntriesTable(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			id VARCHAR(255) NOT NULL,
+			version BIGINT NOT NULL,
+			correlation_id VARCHAR(255) NOT NULL ,
+			"state" INTEGER,
+			state_timestamp TIMESTAMP NOT NULL ,
+			created_timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			orchestration_type VARCHAR(255),
+			deadline TIMESTAMP,
+			checkpoint JSONB,
+			labels JSONB,
+			revision BIGINT NOT NULL DEFAULT 0,
+			mode VARCHAR(255) NOT NULL DEFAULT '',
+			claimed_by VARCHAR(255) NOT NULL DEFAULT '',
+			lease_expiry TIMESTAMP,
+			retry_policy JSONB,
+			pending_state INTEGER,
+			pending_expiry TIMESTAMP,
+			failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+			remediation_hint TEXT NOT NULL DEFAULT '',
+			partition_key VARCHAR(255) NOT NULL DEFAULT '',
+			PRIMARY KEY (id, partition_key)
+		) PARTITION BY LIST (partition_key);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_state_timestamp_id ON %[1]s(state_timestamp, id);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_labels ON %[1]s USING GIN (labels)
 	`, cfmOrchestrationEntriesTable))
 	return err
 }
@@ -69,3 +153,29 @@ func createActivityDefinitionsTable(db *sql.DB) error {
 	`, cfmActivityDefinitionsTable))
 	return err
 }
+
+func createOutboxMessagesTable(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			seq BIGSERIAL PRIMARY KEY,
+			id VARCHAR(255) NOT NULL UNIQUE,
+			subject VARCHAR(255) NOT NULL,
+			payload BYTEA,
+			created_timestamp TIMESTAMP NOT NULL
+		)
+	`, cfmOutboxMessagesTable))
+	return err
+}
+
+func createRawPayloadsTable(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(255) NOT NULL,
+			revision BIGINT NOT NULL,
+			payload BYTEA,
+			created_timestamp TIMESTAMP NOT NULL,
+			PRIMARY KEY (id, revision)
+		)
+	`, cfmRawPayloadsTable))
+	return err
+}
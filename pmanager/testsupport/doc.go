@@ -0,0 +1,20 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+// Package testsupport provides helpers for testing code built on the orchestration index.
+//
+// NewTestWatcher creates an orchestration index watcher with replaceable dependencies that processes messages passed
+// to it directly. The integration test harness runs the orchestration index against an embedded NATS JetStream server
+// and is only compiled with the integration build tag:
+//
+//	go test -tags integration ./...
+package testsupport
@@ -0,0 +1,141 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build integration
+
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/metaform/connector-fabric-manager/pmanager/natsorchestration"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	DefaultBucket = "cfm-orchestrations"
+	DefaultStream = "cfm-stream"
+
+	startupTimeout = 10 * time.Second
+	waitTimeout    = 5 * time.Second
+	pollInterval   = 10 * time.Millisecond
+)
+
+// Harness runs the NATS orchestration assembly, including the orchestration index watcher, against an embedded NATS
+// JetStream server using the in-memory index. Tests publish orchestration changes and assert on the index.
+type Harness struct {
+	Server *server.Server
+	Client *natsclient.NatsClient
+	Index  api.OrchestrationIndex
+	Bucket string
+
+	assembler *system.ServiceAssembler
+}
+
+// NewHarness starts an embedded server and assembles the runtime. Configuration values, e.g. watcher settings, are
+// applied before assembly. The harness is shut down when the test completes.
+func NewHarness(t *testing.T, config map[string]any) *Harness {
+	t.Helper()
+
+	natsServer, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go natsServer.Start()
+	if !natsServer.ReadyForConnections(startupTimeout) {
+		natsServer.Shutdown()
+		t.Fatal("embedded NATS server not ready")
+	}
+
+	vConfig := viper.New()
+	for key, value := range config {
+		vConfig.Set(key, value)
+	}
+
+	assembler := system.NewServiceAssembler(system.NoopMonitor{}, vConfig, system.DebugMode)
+	assembler.Register(&store.NoOpTrxAssembly{})
+	assembler.Register(&memorystore.MemoryStoreServiceAssembly{})
+	assembler.Register(natsorchestration.NewOrchestratorServiceAssembly(natsServer.ClientURL(), DefaultBucket, DefaultStream))
+
+	if err := assembler.Assemble(); err != nil {
+		natsServer.Shutdown()
+		t.Fatalf("failed to assemble runtime: %v", err)
+	}
+
+	h := &Harness{
+		Server:    natsServer,
+		Client:    assembler.Resolve(natsclient.NatsClientKey).(*natsclient.NatsClient),
+		Index:     assembler.Resolve(api.OrchestrationIndexKey).(api.OrchestrationIndex),
+		Bucket:    DefaultBucket,
+		assembler: assembler,
+	}
+	t.Cleanup(h.shutdown)
+	return h
+}
+
+// PutOrchestration writes the orchestration to the KV bucket and returns the revision.
+func (h *Harness) PutOrchestration(t *testing.T, orchestration api.Orchestration) uint64 {
+	t.Helper()
+	data, err := json.Marshal(orchestration)
+	require.NoError(t, err)
+	revision, err := h.Client.KVStore.Put(context.Background(), orchestration.ID, data)
+	require.NoError(t, err)
+	return revision
+}
+
+// PublishMsg publishes a raw message to the KV subject of the given key, e.g. to send headers or malformed payloads.
+func (h *Harness) PublishMsg(t *testing.T, key string, data []byte, header nats.Header) {
+	t.Helper()
+	_, err := h.Client.JetStream.PublishMsg(context.Background(), &nats.Msg{
+		Subject: "$KV." + h.Bucket + "." + key,
+		Data:    data,
+		Header:  header,
+	})
+	require.NoError(t, err)
+}
+
+// WaitForEntry waits until the index contains an entry with the given ID satisfying the condition and returns it.
+func (h *Harness) WaitForEntry(t *testing.T, id string, condition func(entry *api.OrchestrationEntry) bool) *api.OrchestrationEntry {
+	t.Helper()
+	var found *api.OrchestrationEntry
+	require.Eventually(t, func() bool {
+		entry, err := h.Index.FindByID(context.Background(), id)
+		if err != nil || !condition(entry) {
+			return false
+		}
+		found = entry
+		return true
+	}, waitTimeout, pollInterval, "orchestration entry %s did not reach the expected condition", id)
+	return found
+}
+
+func (h *Harness) shutdown() {
+	_ = h.assembler.Shutdown()
+	h.Server.Shutdown()
+	h.Server.WaitForShutdown()
+}
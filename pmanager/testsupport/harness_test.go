@@ -0,0 +1,56 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build integration
+
+package testsupport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarness_CreateThenUpdate(t *testing.T) {
+	h := NewHarness(t, nil)
+
+	created := time.Now().Add(-time.Minute)
+	orchestration := api.Orchestration{
+		ID:                "orch-1",
+		CorrelationID:     "corr-1",
+		State:             api.OrchestrationStateRunning,
+		StateTimestamp:    created,
+		CreatedTimestamp:  created,
+		OrchestrationType: "provision",
+		ProcessingData:    map[string]any{},
+		OutputData:        map[string]any{},
+		Completed:         map[string]struct{}{},
+	}
+	h.PutOrchestration(t, orchestration)
+
+	entry := h.WaitForEntry(t, "orch-1", func(entry *api.OrchestrationEntry) bool {
+		return entry.State == api.OrchestrationStateRunning
+	})
+	assert.Equal(t, "corr-1", entry.CorrelationID)
+	assert.Equal(t, "provision", entry.OrchestrationType.String())
+
+	orchestration.SetState(api.OrchestrationStateCompleted)
+	h.PutOrchestration(t, orchestration)
+
+	entry = h.WaitForEntry(t, "orch-1", func(entry *api.OrchestrationEntry) bool {
+		return entry.State == api.OrchestrationStateCompleted
+	})
+	assert.True(t, orchestration.StateTimestamp.Equal(entry.StateTimestamp))
+	assert.True(t, created.Equal(entry.CreatedTimestamp))
+}
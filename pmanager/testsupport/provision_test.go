@@ -0,0 +1,79 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+//go:build integration
+
+package testsupport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metaform/connector-fabric-manager/common/natsclient"
+	"github.com/metaform/connector-fabric-manager/pmanager/natsorchestration"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisioner_Idempotent(t *testing.T) {
+	// The assembly provisions the resources on startup
+	h := NewHarness(t, map[string]any{"provision.enabled": true})
+	ctx := context.Background()
+
+	provisioner := natsorchestration.NewProvisioner(
+		h.Client.JetStream,
+		natsclient.DefaultNamingStrategy{Stream: DefaultStream},
+		h.Bucket,
+		indexConsumerConfig(h.Bucket))
+	require.NoError(t, provisioner.Provision(ctx))
+	require.NoError(t, provisioner.Provision(ctx))
+
+	stream, err := h.Client.JetStream.Stream(ctx, DefaultStream)
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, jetstream.WorkQueuePolicy, info.Config.Retention)
+	assert.Equal(t, 1, info.State.Consumers, "the DLQ consumer is created once")
+
+	index, err := h.Client.JetStream.Stream(ctx, "KV_"+h.Bucket)
+	require.NoError(t, err)
+	indexInfo, err := index.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexInfo.State.Consumers, "the index consumer is created once")
+}
+
+func TestProvisioner_IncompatibleConsumer(t *testing.T) {
+	h := NewHarness(t, map[string]any{"provision.enabled": true})
+
+	config := indexConsumerConfig(h.Bucket)
+	config.AckPolicy = jetstream.AckAllPolicy
+	provisioner := natsorchestration.NewProvisioner(
+		h.Client.JetStream,
+		natsclient.DefaultNamingStrategy{Stream: DefaultStream},
+		h.Bucket,
+		config)
+
+	err := provisioner.Provision(context.Background())
+
+	assert.ErrorContains(t, err, "incompatible consumer")
+}
+
+// indexConsumerConfig returns the configuration of the index consumer provisioned by the assembly by default.
+func indexConsumerConfig(bucket string) jetstream.ConsumerConfig {
+	return jetstream.ConsumerConfig{
+		Durable:       "orchestration-index",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: "$KV." + bucket + ".>",
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+	}
+}
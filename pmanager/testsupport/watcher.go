@@ -0,0 +1,83 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package testsupport
+
+import (
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/store"
+	"github.com/metaform/connector-fabric-manager/common/system"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/metaform/connector-fabric-manager/pmanager/natsorchestration"
+)
+
+// TestWatcherOption overrides a dependency of the watcher created by NewTestWatcher.
+type TestWatcherOption func(*natsorchestration.WatcherDependencies)
+
+// WithIndex sets the index the watcher records changes in. Defaults to an in-memory index.
+func WithIndex(index store.EntityStore[*api.OrchestrationEntry]) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.Index = index
+	}
+}
+
+// WithTransactionContext sets the transaction context changes are recorded in. Defaults to a no-op context.
+func WithTransactionContext(trxContext store.TransactionContext) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.TrxContext = trxContext
+	}
+}
+
+// WithClock sets the clock of the watcher, e.g. to test message expiry. Defaults to time.Now.
+func WithClock(clock func() time.Time) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.Clock = clock
+	}
+}
+
+// WithMonitor sets the logger of the watcher. Defaults to a no-op monitor.
+func WithMonitor(monitor system.LogMonitor) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.Monitor = monitor
+	}
+}
+
+// WithMetrics sets the delivery metrics recorded by the watcher.
+func WithMetrics(metrics api.DeliveryMetrics) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.Metrics = metrics
+	}
+}
+
+// WithHandlers sets the handlers invoked with the recorded entries. Handlers are invoked once the projections of the
+// watcher are run, see natsorchestration.OrchestrationIndexWatcher.RunProjections.
+func WithHandlers(handlers *api.HandlerRegistry) TestWatcherOption {
+	return func(deps *natsorchestration.WatcherDependencies) {
+		deps.Handlers = handlers
+	}
+}
+
+// NewTestWatcher creates an orchestration index watcher for tests. The watcher is not connected to NATS; messages are
+// passed to natsorchestration.OrchestrationIndexWatcher.ProcessMessage.
+func NewTestWatcher(opts ...TestWatcherOption) *natsorchestration.OrchestrationIndexWatcher {
+	deps := natsorchestration.WatcherDependencies{
+		Index:      memorystore.NewOrchestrationIndex(),
+		TrxContext: &store.NoOpTransactionContext{},
+		Monitor:    system.NoopMonitor{},
+	}
+	for _, opt := range opts {
+		opt(&deps)
+	}
+	return natsorchestration.NewWatcherWithDependencies(deps)
+}
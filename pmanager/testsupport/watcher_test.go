@@ -0,0 +1,81 @@
+//  Copyright (c) 2025 Metaform Systems, Inc
+//
+//  This program and the accompanying materials are made available under the
+//  terms of the Apache License, Version 2.0 which is available at
+//  https://www.apache.org/licenses/LICENSE-2.0
+//
+//  SPDX-License-Identifier: Apache-2.0
+//
+//  Contributors:
+//       Metaform Systems, Inc. - initial API and implementation
+//
+
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/metaform/connector-fabric-manager/common/types"
+	"github.com/metaform/connector-fabric-manager/pmanager/api"
+	"github.com/metaform/connector-fabric-manager/pmanager/memorystore"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestWatcher_CustomClock(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	index := memorystore.NewOrchestrationIndex()
+	watcher := NewTestWatcher(WithIndex(index), WithClock(func() time.Time { return now }))
+
+	publish := func(id string, expiry time.Time) *ackRecorder {
+		data, err := json.Marshal(api.Orchestration{
+			ID:             id,
+			CorrelationID:  "corr-1",
+			State:          api.OrchestrationStateRunning,
+			StateTimestamp: now,
+			Expiry:         expiry,
+		})
+		require.NoError(t, err)
+		msg := &ackRecorder{}
+		watcher.ProcessMessage(data, nil, msg)
+		return msg
+	}
+
+	// Expiry is evaluated against the watcher clock, not the wall clock
+	expired := publish("orch-1", now.Add(-time.Minute))
+	current := publish("orch-2", now.Add(time.Minute))
+
+	assert.Equal(t, 1, expired.acks)
+	assert.Equal(t, 1, current.acks)
+	assert.Equal(t, int64(1), watcher.ExpiredMessages())
+	_, err := index.FindByID(context.Background(), "orch-1")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+	entry, err := index.FindByID(context.Background(), "orch-2")
+	require.NoError(t, err)
+	assert.Equal(t, api.OrchestrationStateRunning, entry.State)
+}
+
+// ackRecorder counts how a message is settled.
+type ackRecorder struct {
+	acks int
+	naks int
+}
+
+func (m *ackRecorder) Ack(...nats.AckOpt) error {
+	m.acks++
+	return nil
+}
+
+func (m *ackRecorder) Nak(...nats.AckOpt) error {
+	m.naks++
+	return nil
+}
+
+func (m *ackRecorder) NakWithDelay(time.Duration, ...nats.AckOpt) error {
+	m.naks++
+	return nil
+}
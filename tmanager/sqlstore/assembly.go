@@ -102,6 +102,12 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	err = sqlstore.CreateLocksTable(db)
+
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 